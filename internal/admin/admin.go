@@ -139,6 +139,18 @@ func (a *Admin) SetOASBackend(backend adminext.OASBackend) {
 	a.ssi.SetOASBackend(backend)
 }
 
+// SetCachePurger sets the cache purger for the admin component. This allows the admin API to purge
+// cached responses.
+func (a *Admin) SetCachePurger(purger adminext.CachePurger) {
+	a.ssi.SetCachePurger(purger)
+}
+
+// SetTrafficSplitter sets the traffic splitter for the admin component. This allows the admin API
+// to adjust the traffic splits of backends at runtime.
+func (a *Admin) SetTrafficSplitter(splitter adminext.TrafficSplitter) {
+	a.ssi.SetTrafficSplitter(splitter)
+}
+
 // RegisterAPIProvider registers an API provider with the admin API. All adminext.APIProvider implementations must
 // be registered using this method in order for their routes to be served by the admin API.
 func (a *Admin) RegisterAPIProvider(apiProvider adminext.APIProvider) error {
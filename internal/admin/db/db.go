@@ -3,7 +3,9 @@ package admindb
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"slices"
 	"time"
 
@@ -89,8 +91,12 @@ const (
 	selectDebugSessionCalls         = "SELECT id, started_at, stopped_at, url, method, status_code FROM admin_debug_session_calls WHERE session_id = @session_id ORDER BY stopped_at DESC;"
 	selectDebugSessionCall          = "SELECT id, started_at, stopped_at, url, method, status_code FROM admin_debug_session_calls WHERE id = @id ORDER BY stopped_at DESC;"
 
-	insertDebugSessionFlowTransition  = "INSERT INTO admin_debug_session_call_flow_transitions (call_id, component, direction, started_at, stopped_at, result, failure_cause) VALUES(@call_id, @component, @direction, @started_at, @stopped_at, @result, @failure_cause);"
-	selectDebugSessionFlowTransitions = "SELECT component, direction, started_at, stopped_at, result, failure_cause FROM admin_debug_session_call_flow_transitions WHERE call_id = @call_id ORDER BY started_at ASC;"
+	insertDebugSessionFlowTransition  = "INSERT INTO admin_debug_session_call_flow_transitions (call_id, component, direction, started_at, stopped_at, result, failure_cause, attributes) VALUES(@call_id, @component, @direction, @started_at, @stopped_at, @result, @failure_cause, @attributes);"
+	selectDebugSessionFlowTransitions = "SELECT component, direction, started_at, stopped_at, result, failure_cause, attributes FROM admin_debug_session_call_flow_transitions WHERE call_id = @call_id ORDER BY started_at ASC;"
+
+	selectTransitionAttributesColumn = "SELECT name FROM pragma_table_info('admin_debug_session_call_flow_transitions') WHERE name = 'attributes';"
+	addTransitionAttributes          = "ALTER TABLE admin_debug_session_call_flow_transitions ADD COLUMN attributes TEXT;"
+	addTransitionAttributesPostgres  = "ALTER TABLE admin_debug_session_call_flow_transitions ADD COLUMN IF NOT EXISTS attributes TEXT;"
 
 	SessionExpiry        = 15 * time.Minute
 	SessionRefreshExpiry = 60 * time.Minute
@@ -113,7 +119,32 @@ func ApplySchemas(sqlClient db.SQLClient) error {
 	if _, err := sqlClient.Exec(timeoutCtx, string(schema)); err != nil {
 		return err
 	}
-	return nil
+	return migrate(timeoutCtx, sqlClient)
+}
+
+// migrate adds the columns added to existing tables since they were created, which CREATE TABLE
+// IF NOT EXISTS leaves as they are.
+func migrate(ctx context.Context, sqlClient db.SQLClient) error {
+	if sqlClient.Dialect() == db.PostgresDialect {
+		_, err := sqlClient.Exec(ctx, addTransitionAttributesPostgres)
+		return err
+	}
+
+	rows, err := sqlClient.Query(ctx, selectTransitionAttributesColumn)
+	if err != nil {
+		return err
+	}
+	exists := rows.Next()
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = sqlClient.Exec(ctx, addTransitionAttributes)
+	return err
 }
 
 func ListDebugSessions(
@@ -352,6 +383,15 @@ func CreateDebugSessionCall(
 			stoppedAt = transition.StoppedAt.UTC().Format(time.RFC3339Nano)
 		}
 
+		var attributes *string
+		if transition.Attributes != nil {
+			encoded, err := json.Marshal(transition.Attributes)
+			if err != nil {
+				return 0, fmt.Errorf("encode flow transition attributes: %w", err)
+			}
+			attributes = new(string(encoded))
+		}
+
 		if _, err := client.Exec(
 			ctx,
 			insertDebugSessionFlowTransition,
@@ -362,6 +402,7 @@ func CreateDebugSessionCall(
 			sql.Named("direction", transition.Direction),
 			sql.Named("result", transition.Result.Outcome),
 			sql.Named("failure_cause", transition.Result.Cause),
+			sql.Named("attributes", attributes),
 		); err != nil {
 			zerologr.Error(err, "Failed to insert debug session flow transition")
 			return 0, err
@@ -506,6 +547,7 @@ func ListDebugSessionFlowTransitions(
 			transition adminapi.FlowTransition
 			startedAt  db.TimeString
 			stoppedAt  db.TimeString
+			attributes sql.NullString
 		)
 		if err := rows.Scan(
 			&transition.Component,
@@ -514,11 +556,19 @@ func ListDebugSessionFlowTransitions(
 			&stoppedAt,
 			&transition.Result.Outcome,
 			&transition.Result.Cause,
+			&attributes,
 		); err != nil {
 			zerologr.Error(err, "Failed to scan debug session flow transition row")
 			return nil, err
 		}
 
+		if attributes.Valid {
+			if err := json.Unmarshal([]byte(attributes.String), &transition.Attributes); err != nil {
+				zerologr.Error(err, "Failed to decode debug session flow transition attributes")
+				return nil, err
+			}
+		}
+
 		transition.StartedAt = startedAt.Time
 		transition.StoppedAt = stoppedAt.Time
 		transitions = append(transitions, transition)
@@ -1167,6 +1217,8 @@ func BootstrapPermissions(client db.SQLClient) error {
 		{5, "admin-user-mgmt-admin"},
 		{6, "admin-user-mgmt-viewer"},
 		{7, "debugger"},
+		{8, "cache-admin"},
+		{9, "traffic-admin"},
 	}
 
 	for _, p := range perms {
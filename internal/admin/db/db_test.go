@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/trebent/kerberos/internal/db"
+	"github.com/trebent/kerberos/internal/db/sqlite"
 	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
 )
 
@@ -310,6 +312,16 @@ func TestDebugSessionCalls(t *testing.T) {
 			StartedAt:  time.Now().UTC().Truncate(time.Microsecond),
 			StoppedAt:  time.Now().UTC().Add(1 * time.Second).Truncate(time.Microsecond),
 			StatusCode: http.StatusOK,
+			FlowTransitions: []adminapi.FlowTransition{
+				{
+					Component:  "forwarder",
+					Direction:  "inbound",
+					StartedAt:  time.Now().UTC().Truncate(time.Microsecond),
+					StoppedAt:  time.Now().UTC().Truncate(time.Microsecond),
+					Result:     adminapi.FlowTransitionResult{Outcome: "success"},
+					Attributes: &map[string]string{"target": "localhost:8080"},
+				},
+			},
 		})
 		if err != nil {
 			t.Fatalf("Failed to create debug session call: %v", err)
@@ -324,6 +336,15 @@ func TestDebugSessionCalls(t *testing.T) {
 			t.Fatalf("Expected call ID %d, got %d", callID, call.Id)
 		}
 
+		if len(call.FlowTransitions) != 1 {
+			t.Fatalf("Expected 1 flow transition, got %d", len(call.FlowTransitions))
+		}
+
+		attributes := call.FlowTransitions[0].Attributes
+		if attributes == nil || (*attributes)["target"] != "localhost:8080" {
+			t.Fatalf("Expected transition attribute target 'localhost:8080', got %v", attributes)
+		}
+
 		if call.Method != http.MethodGet {
 			t.Fatalf("Expected method 'GET', got '%s'", call.Method)
 		}
@@ -859,3 +880,39 @@ func TestDBGroupBindings(t *testing.T) {
 		}
 	})
 }
+
+// --- Migrations ---
+
+// TestApplySchemasMigratesTransitions applies the schema to a database created before debug
+// transitions had attributes.
+func TestApplySchemasMigratesTransitions(t *testing.T) {
+	client := sqlite.New(&sqlite.Opts{DSN: filepath.Join(t.TempDir(), "baseline.db")})
+	if _, err := client.Exec(context.Background(), `
+CREATE TABLE admin_debug_session_call_flow_transitions (
+  call_id INTEGER NOT NULL,
+  component VARCHAR(100) NOT NULL,
+  direction VARCHAR(10) NOT NULL,
+  started_at TEXT NOT NULL,
+  stopped_at TEXT NOT NULL,
+  result VARCHAR(20) NOT NULL,
+  failure_cause VARCHAR(512)
+);`); err != nil {
+		t.Fatalf("Failed to create baseline table: %v", err)
+	}
+
+	// Applying the schema again must not fail on the added column.
+	for range 2 {
+		if err := ApplySchemas(client); err != nil {
+			t.Fatalf("ApplySchemas() error: %v", err)
+		}
+	}
+
+	rows, err := client.Query(
+		context.Background(),
+		"SELECT attributes FROM admin_debug_session_call_flow_transitions;",
+	)
+	if err != nil {
+		t.Fatalf("Expected the attributes column to be added, got %v", err)
+	}
+	_ = rows.Close()
+}
@@ -81,6 +81,7 @@ CREATE TABLE IF NOT EXISTS admin_debug_session_call_flow_transitions (
   stopped_at TIMESTAMPTZ NOT NULL,
   result VARCHAR(20),
   failure_cause VARCHAR(512),
+  attributes TEXT,
   FOREIGN KEY(call_id) REFERENCES admin_debug_session_calls(id) ON DELETE CASCADE ON UPDATE CASCADE
 );
 
@@ -118,8 +118,9 @@ func (r *realCall) AddTransition(
 	startTime time.Time, endTime time.Time,
 	result composerdebug.CallResult,
 	failureCause string,
+	attributes ...composerdebug.Attribute,
 ) {
-	r.apiCall.FlowTransitions = append(r.apiCall.FlowTransitions, adminapi.FlowTransition{
+	transition := adminapi.FlowTransition{
 		Component: component,
 		Direction: adminapi.FlowTransitionDirection(direction),
 		StartedAt: startTime,
@@ -128,7 +129,17 @@ func (r *realCall) AddTransition(
 			Outcome: adminapi.FlowTransitionResultOutcome(result),
 			Cause:   new(failureCause),
 		},
-	})
+	}
+
+	if len(attributes) > 0 {
+		attributeMap := make(map[string]string, len(attributes))
+		for _, attribute := range attributes {
+			attributeMap[attribute.Key] = attribute.Value
+		}
+		transition.Attributes = &attributeMap
+	}
+
+	r.apiCall.FlowTransitions = append(r.apiCall.FlowTransitions, transition)
 }
 
 // SetMethod implements [debug.DebuggedCall].
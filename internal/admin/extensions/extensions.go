@@ -1,6 +1,7 @@
 package adminext
 
 import (
+	"context"
 	"net/http"
 
 	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
@@ -22,6 +23,30 @@ type (
 	// DummyOASBackend is a no-op OAS backend that always returns not found. This is used by default
 	// when admin is instantiated without an OAS backend, to avoid nil checks.
 	DummyOASBackend struct{}
+	// CachePurger implementors provide a way for the admin API to purge cached responses.
+	CachePurger interface {
+		// Purge removes the cached responses of the backend with a path starting with the path
+		// prefix, an empty backend matches all backends. It returns the number of purged responses.
+		Purge(ctx context.Context, backend, pathPrefix string) (int, error)
+	}
+	// DummyCachePurger is a no-op cache purger that never purges anything. This is used by default
+	// when admin is instantiated without a cache, to avoid nil checks.
+	DummyCachePurger struct{}
+	// TrafficSplitter implementors provide a way for the admin API to adjust the traffic splits of
+	// backends at runtime.
+	TrafficSplitter interface {
+		// Splits returns the current traffic splits.
+		Splits() []adminapi.TrafficSplit
+		// SetSplit replaces the traffic split of a backend. It returns an *apierror.Error with status
+		// 404 if the backend is unknown, or with status 400 if the split is invalid.
+		SetSplit(split adminapi.TrafficSplit) error
+		// DeleteSplit removes the traffic split of a backend, it returns apierror.ErrNotFound if the
+		// backend has none.
+		DeleteSplit(backend string) error
+	}
+	// DummyTrafficSplitter is a traffic splitter without any backends. This is used by default when
+	// admin is instantiated without a traffic splitter, to avoid nil checks.
+	DummyTrafficSplitter struct{}
 
 	// APIProvider is implemented by any extension that wants to expose additional admin API endpoints.
 	APIProvider interface {
@@ -34,8 +59,28 @@ type (
 	}
 )
 
-var _ OASBackend = (*DummyOASBackend)(nil)
+var (
+	_ OASBackend      = (*DummyOASBackend)(nil)
+	_ CachePurger     = (*DummyCachePurger)(nil)
+	_ TrafficSplitter = (*DummyTrafficSplitter)(nil)
+)
 
 func (d *DummyOASBackend) GetOAS(_ string) ([]byte, error) {
 	return nil, apierror.ErrNotFound
 }
+
+func (d *DummyCachePurger) Purge(_ context.Context, _, _ string) (int, error) {
+	return 0, nil
+}
+
+func (d *DummyTrafficSplitter) Splits() []adminapi.TrafficSplit {
+	return []adminapi.TrafficSplit{}
+}
+
+func (d *DummyTrafficSplitter) SetSplit(_ adminapi.TrafficSplit) error {
+	return apierror.ErrNotFound
+}
+
+func (d *DummyTrafficSplitter) DeleteSplit(_ string) error {
+	return apierror.ErrNotFound
+}
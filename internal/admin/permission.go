@@ -17,6 +17,8 @@ const (
 	PermissionIDAdminUserMgmtAdmin  = int64(5)
 	PermissionIDAdminUserMgmtViewer = int64(6)
 	PermissionIDDebugger            = int64(7)
+	PermissionIDCacheAdmin          = int64(8)
+	PermissionIDTrafficAdmin        = int64(9)
 
 	// Permission names.
 
@@ -27,6 +29,8 @@ const (
 	PermissionNameAdminUserMgmtAdmin  = "admin-user-mgmt-admin"
 	PermissionNameAdminUserMgmtViewer = "admin-user-mgmt-viewer"
 	PermissionNameDebugger            = "debugger"
+	PermissionNameCacheAdmin          = "cache-admin"
+	PermissionNameTrafficAdmin        = "traffic-admin"
 )
 
 // ContextSessionValid reports whether the context contains an admin session.
@@ -105,3 +109,13 @@ func ContextIsAdminUserMgmtViewer(ctx context.Context) bool {
 func ContextIsDebugger(ctx context.Context) bool {
 	return ContextHasPermission(ctx, PermissionIDDebugger)
 }
+
+// ContextIsCacheAdmin reports whether the calling admin user has the cacheadmin permission.
+func ContextIsCacheAdmin(ctx context.Context) bool {
+	return ContextHasPermission(ctx, PermissionIDCacheAdmin)
+}
+
+// ContextIsTrafficAdmin reports whether the calling admin user has the trafficadmin permission.
+func ContextIsTrafficAdmin(ctx context.Context) bool {
+	return ContextHasPermission(ctx, PermissionIDTrafficAdmin)
+}
@@ -3,6 +3,7 @@ package admin
 import (
 	"bytes"
 	"context"
+	"errors"
 	"net/http"
 
 	admindb "github.com/trebent/kerberos/internal/admin/db"
@@ -10,6 +11,7 @@ import (
 	"github.com/trebent/kerberos/internal/config"
 	"github.com/trebent/kerberos/internal/db"
 	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
 	"github.com/trebent/zerologr"
 )
 
@@ -23,6 +25,10 @@ type (
 		SetFlowFetcher(adminext.FlowFetcher)
 		// SetOASBackend sets the OAS backend for the SSI, allowing it to serve OAS data to the admin API.
 		SetOASBackend(adminext.OASBackend)
+		// SetCachePurger sets the cache purger for the SSI, allowing it to purge cached responses.
+		SetCachePurger(adminext.CachePurger)
+		// SetTrafficSplitter sets the traffic splitter for the SSI, allowing it to adjust traffic splits.
+		SetTrafficSplitter(adminext.TrafficSplitter)
 	}
 	ssiOpts struct {
 		SQLClient db.SQLClient
@@ -39,6 +45,8 @@ type (
 
 		flowFetcher adminext.FlowFetcher
 		oasBackend  adminext.OASBackend
+		cachePurger adminext.CachePurger
+		splitter    adminext.TrafficSplitter
 
 		*debugger
 
@@ -62,10 +70,12 @@ func makeGenAPIError(msg string) adminapi.APIErrorResponse {
 
 func newSSI(opts *ssiOpts) (withExtensions, error) {
 	i := &impl{
-		sqlClient:  opts.SQLClient,
-		oasBackend: &adminext.DummyOASBackend{},
-		debugger:   opts.Debugger,
-		cookieCfg:  opts.CookieCfg,
+		sqlClient:   opts.SQLClient,
+		oasBackend:  &adminext.DummyOASBackend{},
+		cachePurger: &adminext.DummyCachePurger{},
+		splitter:    &adminext.DummyTrafficSplitter{},
+		debugger:    opts.Debugger,
+		cookieCfg:   opts.CookieCfg,
 	}
 
 	if err := admindb.BootstrapSuperuser(
@@ -89,6 +99,14 @@ func (i *impl) SetOASBackend(ob adminext.OASBackend) {
 	i.oasBackend = ob
 }
 
+func (i *impl) SetCachePurger(cp adminext.CachePurger) {
+	i.cachePurger = cp
+}
+
+func (i *impl) SetTrafficSplitter(ts adminext.TrafficSplitter) {
+	i.splitter = ts
+}
+
 // GetFlow implements [adminapi.StrictServerInterface].
 func (i *impl) GetFlow(
 	ctx context.Context,
@@ -121,6 +139,92 @@ func (i *impl) GetBackendOAS(
 	}, nil
 }
 
+// PurgeCache implements [adminapi.StrictServerInterface].
+func (i *impl) PurgeCache(
+	ctx context.Context,
+	request adminapi.PurgeCacheRequestObject,
+) (adminapi.PurgeCacheResponseObject, error) {
+	if !ContextIsCacheAdmin(ctx) {
+		return adminapi.PurgeCache403JSONResponse(apiErrForbidden), nil
+	}
+
+	backend, pathPrefix := "", ""
+	if request.Params.Backend != nil {
+		backend = *request.Params.Backend
+	}
+	if request.Params.PathPrefix != nil {
+		pathPrefix = *request.Params.PathPrefix
+	}
+
+	purged, err := i.cachePurger.Purge(ctx, backend, pathPrefix)
+	if err != nil {
+		zerologr.Error(err, "Failed to purge cached responses")
+		return adminapi.PurgeCache500JSONResponse(apiErrInternal), nil
+	}
+
+	return adminapi.PurgeCache200JSONResponse{Purged: purged}, nil
+}
+
+// ListTrafficSplits implements [adminapi.StrictServerInterface].
+func (i *impl) ListTrafficSplits(
+	ctx context.Context,
+	_ adminapi.ListTrafficSplitsRequestObject,
+) (adminapi.ListTrafficSplitsResponseObject, error) {
+	if !ContextIsTrafficAdmin(ctx) {
+		return adminapi.ListTrafficSplits403JSONResponse(apiErrForbidden), nil
+	}
+
+	return adminapi.ListTrafficSplits200JSONResponse(i.splitter.Splits()), nil
+}
+
+// SetTrafficSplit implements [adminapi.StrictServerInterface].
+func (i *impl) SetTrafficSplit(
+	ctx context.Context,
+	request adminapi.SetTrafficSplitRequestObject,
+) (adminapi.SetTrafficSplitResponseObject, error) {
+	if !ContextIsTrafficAdmin(ctx) {
+		return adminapi.SetTrafficSplit403JSONResponse(apiErrForbidden), nil
+	}
+
+	split := adminapi.TrafficSplit{Backend: request.Backend, Variants: request.Body.Variants}
+	err := i.splitter.SetSplit(split)
+
+	var apiErr *apierror.Error
+	switch {
+	case err == nil:
+		zerologr.Info("Set traffic split", "backend", request.Backend)
+		return adminapi.SetTrafficSplit200JSONResponse(split), nil
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		return adminapi.SetTrafficSplit404JSONResponse(apiErrNotFound), nil
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest:
+		return adminapi.SetTrafficSplit400JSONResponse{Errors: apiErr.Errors}, nil
+	default:
+		zerologr.Error(err, "Failed to set traffic split")
+		return adminapi.SetTrafficSplit500JSONResponse(apiErrInternal), nil
+	}
+}
+
+// DeleteTrafficSplit implements [adminapi.StrictServerInterface].
+func (i *impl) DeleteTrafficSplit(
+	ctx context.Context,
+	request adminapi.DeleteTrafficSplitRequestObject,
+) (adminapi.DeleteTrafficSplitResponseObject, error) {
+	if !ContextIsTrafficAdmin(ctx) {
+		return adminapi.DeleteTrafficSplit403JSONResponse(apiErrForbidden), nil
+	}
+
+	if err := i.splitter.DeleteSplit(request.Backend); err != nil {
+		if errors.Is(err, apierror.ErrNotFound) {
+			return adminapi.DeleteTrafficSplit404JSONResponse(apiErrNotFound), nil
+		}
+		zerologr.Error(err, "Failed to delete traffic split")
+		return adminapi.DeleteTrafficSplit500JSONResponse(apiErrInternal), nil
+	}
+
+	zerologr.Info("Deleted traffic split", "backend", request.Backend)
+	return adminapi.DeleteTrafficSplit204Response{}, nil
+}
+
 // GetPermissions implements [adminapi.StrictServerInterface].
 func (i *impl) GetPermissions(
 	ctx context.Context,
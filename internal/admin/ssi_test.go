@@ -45,6 +45,76 @@ func TestAdminSSIDummyOASBackend(t *testing.T) {
 	}
 }
 
+func TestAdminSSIPurgeCache(t *testing.T) {
+	ssi, err := newSSI(&ssiOpts{
+		SQLClient:    testClient,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+	})
+	if err != nil {
+		t.Fatalf("expected newSSI to succeed, got error: %v", err)
+	}
+
+	resp, err := ssi.PurgeCache(t.Context(), adminapi.PurgeCacheRequestObject{})
+	if err != nil {
+		t.Fatalf("expected PurgeCache to succeed, got error: %v", err)
+	}
+	if _, ok := resp.(adminapi.PurgeCache403JSONResponse); !ok {
+		t.Fatalf("expected a 403 response without the cache-admin permission, got %T", resp)
+	}
+
+	ctx := context.WithValue(t.Context(), adminContextPermissions, []int64{PermissionIDCacheAdmin})
+	resp, err = ssi.PurgeCache(ctx, adminapi.PurgeCacheRequestObject{})
+	if err != nil {
+		t.Fatalf("expected PurgeCache to succeed, got error: %v", err)
+	}
+	if purge, ok := resp.(adminapi.PurgeCache200JSONResponse); !ok || purge.Purged != 0 {
+		t.Fatalf("expected the dummy cache purger to purge nothing, got %+v", resp)
+	}
+}
+
+func TestAdminSSITrafficSplits(t *testing.T) {
+	ssi, err := newSSI(&ssiOpts{
+		SQLClient:    testClient,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+	})
+	if err != nil {
+		t.Fatalf("expected newSSI to succeed, got error: %v", err)
+	}
+
+	request := adminapi.SetTrafficSplitRequestObject{
+		Backend: "backend",
+		Body: &adminapi.SetTrafficSplitJSONRequestBody{
+			Variants: []adminapi.TrafficSplitVariant{{Backend: "canary", Weight: 10}},
+		},
+	}
+	resp, err := ssi.SetTrafficSplit(t.Context(), request)
+	if err != nil {
+		t.Fatalf("expected SetTrafficSplit to succeed, got error: %v", err)
+	}
+	if _, ok := resp.(adminapi.SetTrafficSplit403JSONResponse); !ok {
+		t.Fatalf("expected a 403 response without the traffic-admin permission, got %T", resp)
+	}
+
+	ctx := context.WithValue(t.Context(), adminContextPermissions, []int64{PermissionIDTrafficAdmin})
+	resp, err = ssi.SetTrafficSplit(ctx, request)
+	if err != nil {
+		t.Fatalf("expected SetTrafficSplit to succeed, got error: %v", err)
+	}
+	if _, ok := resp.(adminapi.SetTrafficSplit404JSONResponse); !ok {
+		t.Fatalf("expected the dummy traffic splitter to know no backends, got %T", resp)
+	}
+
+	list, err := ssi.ListTrafficSplits(ctx, adminapi.ListTrafficSplitsRequestObject{})
+	if err != nil {
+		t.Fatalf("expected ListTrafficSplits to succeed, got error: %v", err)
+	}
+	if splits, ok := list.(adminapi.ListTrafficSplits200JSONResponse); !ok || len(splits) != 0 {
+		t.Fatalf("expected the dummy traffic splitter to have no splits, got %+v", list)
+	}
+}
+
 func TestAdminSSISuperuserBootstrap(t *testing.T) {
 	_, err := newSSI(&ssiOpts{
 		SQLClient:    testClient,
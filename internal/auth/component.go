@@ -1,10 +1,13 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -12,6 +15,10 @@ import (
 	adminext "github.com/trebent/kerberos/internal/admin/extensions"
 	"github.com/trebent/kerberos/internal/auth/method"
 	"github.com/trebent/kerberos/internal/auth/method/basic"
+	"github.com/trebent/kerberos/internal/auth/method/hmac"
+	"github.com/trebent/kerberos/internal/auth/method/jwt"
+	"github.com/trebent/kerberos/internal/auth/method/mtls"
+	"github.com/trebent/kerberos/internal/auth/method/oidc"
 	"github.com/trebent/kerberos/internal/composer"
 	"github.com/trebent/kerberos/internal/composer/custom"
 	"github.com/trebent/kerberos/internal/composer/debug"
@@ -19,6 +26,7 @@ import (
 	"github.com/trebent/kerberos/internal/db"
 	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
 	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/kerberos/internal/response"
 	"github.com/trebent/zerologr"
 )
 
@@ -27,6 +35,10 @@ type (
 		composer.FlowComponent
 		custom.Ordered
 		adminext.APIProvider
+
+		// StartKeyReload reloads the token verification keys of the auth methods periodically
+		// until ctx is done.
+		StartKeyReload(ctx context.Context)
 	}
 	Opts struct {
 		// Auth configuration.
@@ -43,11 +55,21 @@ type (
 
 		cfg   *config.AuthConfig
 		basic basic.Basic
+		jwt   jwt.JWT
+		oidc  oidc.OIDC
+		mtls  mtls.MTLS
+		hmac  hmac.HMAC
 		db    db.SQLClient
 	}
 )
 
-const methodBasic = "basic"
+const (
+	methodBasic = "basic"
+	methodJWT   = "jwt"
+	methodOIDC  = "oidc"
+	methodMTLS  = "mtls"
+	methodHMAC  = "hmac"
+)
 
 var (
 	_ Authorizer = (*authorizer)(nil)
@@ -67,19 +89,78 @@ func NewComponent(opts *Opts) (Authorizer, error) {
 		zerologr.Info("Basic authentication enabled")
 		// If basic auth, create the method.
 		b, err := basic.New(&basic.Opts{
+			SQLClient:    opts.SQLClient,
+			OASDir:       opts.OASDir,
+			AuthZConfig:  makeAuthZMap(opts.Cfg.Scheme.Mappings),
+			Quotas:       opts.Cfg.Methods.Basic.Quotas,
+			APIKeyHeader: opts.Cfg.Methods.Basic.APIKeyHeader,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create basic auth method: %w", err)
+		}
+		authorizer.basic = b
+	}
+
+	if opts.Cfg.Methods.JWT != nil {
+		zerologr.Info("JWT authentication enabled")
+		j, err := jwt.New(&jwt.Opts{
+			Cfg:         opts.Cfg.Methods.JWT,
+			AuthZConfig: makeAuthZMap(opts.Cfg.Scheme.Mappings),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create JWT auth method: %w", err)
+		}
+		authorizer.jwt = j
+	}
+
+	if opts.Cfg.Methods.OIDC != nil {
+		zerologr.Info("OIDC authentication enabled")
+		o, err := oidc.New(&oidc.Opts{
+			Cfg:         opts.Cfg.Methods.OIDC,
+			AuthZConfig: makeAuthZMap(opts.Cfg.Scheme.Mappings),
 			SQLClient:   opts.SQLClient,
 			OASDir:      opts.OASDir,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OIDC auth method: %w", err)
+		}
+		authorizer.oidc = o
+	}
+
+	if opts.Cfg.Methods.MTLS != nil {
+		zerologr.Info("mTLS authentication enabled")
+		m, err := mtls.New(&mtls.Opts{
+			Cfg:         opts.Cfg.Methods.MTLS,
 			AuthZConfig: makeAuthZMap(opts.Cfg.Scheme.Mappings),
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create basic auth method: %w", err)
+			return nil, fmt.Errorf("failed to create mTLS auth method: %w", err)
 		}
-		authorizer.basic = b
+		authorizer.mtls = m
+	}
+
+	if opts.Cfg.Methods.HMAC != nil {
+		zerologr.Info("HMAC authentication enabled")
+		h, err := hmac.New(&hmac.Opts{
+			Cfg:         opts.Cfg.Methods.HMAC,
+			AuthZConfig: makeAuthZMap(opts.Cfg.Scheme.Mappings),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HMAC auth method: %w", err)
+		}
+		authorizer.hmac = h
 	}
 
 	return authorizer, nil
 }
 
+// StartKeyReload implements [Authorizer].
+func (a *authorizer) StartKeyReload(ctx context.Context) {
+	if a.jwt != nil {
+		a.jwt.StartKeyReload(ctx)
+	}
+}
+
 func (a *authorizer) Order() int {
 	return a.cfg.Order
 }
@@ -97,7 +178,84 @@ func (a *authorizer) GetMeta() []adminapi.FlowMeta {
 				if a.cfg.Methods.Basic == nil {
 					return nil
 				}
-				return &adminapi.FlowMetaDataAuthMethodBasic{}
+				if len(a.cfg.Methods.Basic.Quotas) == 0 {
+					return &adminapi.FlowMetaDataAuthMethodBasic{}
+				}
+
+				quotas := make([]adminapi.FlowMetaDataAuthQuota, 0, len(a.cfg.Methods.Basic.Quotas))
+				for _, q := range a.cfg.Methods.Basic.Quotas {
+					quotas = append(quotas, adminapi.FlowMetaDataAuthQuota{
+						Organisation:     optional(q.Organisation),
+						Backend:          optional(q.Backend),
+						RequestsPerDay:   optional(q.RequestsPerDay),
+						RequestsPerMonth: optional(q.RequestsPerMonth),
+					})
+				}
+				return &adminapi.FlowMetaDataAuthMethodBasic{Quotas: &quotas}
+			}(),
+			Jwt: func() *adminapi.FlowMetaDataAuthMethodJWT {
+				if a.cfg.Methods.JWT == nil {
+					return nil
+				}
+				return &adminapi.FlowMetaDataAuthMethodJWT{
+					Issuer:     a.cfg.Methods.JWT.Issuer,
+					Audiences:  a.cfg.Methods.JWT.Audiences,
+					Algorithms: a.cfg.Methods.JWT.Algorithms,
+				}
+			}(),
+			Oidc: func() *adminapi.FlowMetaDataAuthMethodOIDC {
+				if a.cfg.Methods.OIDC == nil {
+					return nil
+				}
+				return &adminapi.FlowMetaDataAuthMethodOIDC{
+					Issuer:   a.cfg.Methods.OIDC.Issuer,
+					ClientId: a.cfg.Methods.OIDC.ClientID,
+					Scopes:   a.cfg.Methods.OIDC.Scopes,
+				}
+			}(),
+			Mtls: func() *adminapi.FlowMetaDataAuthMethodMTLS {
+				if a.cfg.Methods.MTLS == nil {
+					return nil
+				}
+
+				identities := make(
+					[]adminapi.FlowMetaDataAuthMTLSIdentity, 0, len(a.cfg.Methods.MTLS.Identities),
+				)
+				for _, identity := range a.cfg.Methods.MTLS.Identities {
+					var groups *[]string
+					if len(identity.Groups) > 0 {
+						groups = &identity.Groups
+					}
+					identities = append(identities, adminapi.FlowMetaDataAuthMTLSIdentity{
+						Subject:      optional(identity.Subject),
+						Dns:          optional(identity.DNS),
+						Uri:          optional(identity.URI),
+						User:         optional(identity.User),
+						Organisation: optional(identity.Organisation),
+						Groups:       groups,
+					})
+				}
+				return &adminapi.FlowMetaDataAuthMethodMTLS{Identities: identities}
+			}(),
+			Hmac: func() *adminapi.FlowMetaDataAuthMethodHMAC {
+				if a.cfg.Methods.HMAC == nil {
+					return nil
+				}
+
+				organisations := make([]string, 0, len(a.cfg.Methods.HMAC.Organisations))
+				for _, org := range a.cfg.Methods.HMAC.Organisations {
+					organisations = append(organisations, org.Organisation)
+				}
+				var signedHeaders *[]string
+				if len(a.cfg.Methods.HMAC.SignedHeaders) > 0 {
+					signedHeaders = &a.cfg.Methods.HMAC.SignedHeaders
+				}
+				return &adminapi.FlowMetaDataAuthMethodHMAC{
+					Header:        a.cfg.Methods.HMAC.Header,
+					SignedHeaders: signedHeaders,
+					MaxClockSkew:  a.cfg.Methods.HMAC.MaxClockSkewMs,
+					Organisations: organisations,
+				}
 			}(),
 			// Future auth methods would be added here.
 		},
@@ -177,7 +335,12 @@ func (a *authorizer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if err := m.Authenticated(req); err != nil {
 		zerologr.Error(err, "User tried to perform an authenticated action while unauthenticated")
 		apierror.ErrorHandler(w, req, apierror.ErrUnauthorized)
-		transitionFailure(debugCall, debugStart, http.StatusText(http.StatusUnauthorized))
+		cause := http.StatusText(http.StatusUnauthorized)
+		var unauthenticated *method.UnauthenticatedError
+		if errors.As(err, &unauthenticated) {
+			cause += ": " + unauthenticated.Cause
+		}
+		transitionFailure(debugCall, debugStart, cause)
 		return
 	}
 
@@ -188,6 +351,14 @@ func (a *authorizer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	metered, isMetered := m.(method.Metered)
+	if isMetered {
+		if err := metered.Admit(req); err != nil {
+			transitionFailure(debugCall, debugStart, a.rejectQuota(w, req, err))
+			return
+		}
+	}
+
 	debugCall.AddTransition(
 		"authorizer",
 		debug.CallDirectionInbound,
@@ -197,10 +368,53 @@ func (a *authorizer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		"",
 	)
 
+	if isMetered {
+		a.serveMetered(w, req, metered)
+		return
+	}
+
 	// Forward the request now that it's been auth'd.
 	a.next.ServeHTTP(w, req)
 }
 
+// rejectQuota responds to a request that was not admitted by the quotas of its organisation,
+// returning the cause of the rejection.
+func (a *authorizer) rejectQuota(w http.ResponseWriter, req *http.Request, err error) string {
+	var exceeded *method.QuotaExceededError
+	if !errors.As(err, &exceeded) {
+		zerologr.Error(err, "Failed to check quotas")
+		apierror.ErrorHandler(w, req, apierror.ErrISE)
+		return apierror.ErrISE.Error()
+	}
+
+	zerologr.Info("Organisation quota exceeded", "org", req.Header.Get("X-Krb-Org"))
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(exceeded.RetryAfter.Seconds()))))
+	apierror.ErrorHandler(w, req, apierror.ErrTooManyRequests)
+	return exceeded.Error()
+}
+
+// serveMetered forwards an auth'd request and records its usage once it has been served.
+func (a *authorizer) serveMetered(w http.ResponseWriter, req *http.Request, metered method.Metered) {
+	var body *response.BodyWrapper
+	if req.Body != nil && req.Body != http.NoBody {
+		//nolint:errcheck // NewBodyWrapper always returns a *BodyWrapper.
+		body = response.NewBodyWrapper(req.Body).(*response.BodyWrapper)
+		req.Body = body
+	}
+	// Components between observability and the authorizer may have wrapped the response writer, so
+	// the response is counted here.
+	//nolint:errcheck // NewResponseWrapper always returns a *Wrapper.
+	wrapped := response.NewResponseWrapper(w).(*response.Wrapper)
+
+	a.next.ServeHTTP(wrapped, req)
+
+	var requestBytes int64
+	if body != nil {
+		requestBytes = body.NumBytes()
+	}
+	metered.Record(req, requestBytes, wrapped.NumBytes())
+}
+
 func (a *authorizer) RegisterRoutes(
 	mux *http.ServeMux,
 	middleware ...strictnethttp.StrictHTTPMiddlewareFunc,
@@ -211,31 +425,54 @@ func (a *authorizer) RegisterRoutes(
 		}
 	}
 
+	if a.oidc != nil {
+		if err := a.oidc.RegisterRoutes(mux, middleware...); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // findMethod attempts to find the method which protects the input backend, if any.
 func (a *authorizer) findMethod(backend string, req *http.Request) (method.Method, error) {
 	for _, mapping := range a.cfg.Scheme.Mappings {
-		if mapping.Backend == backend {
-			switch mapping.Method {
-			case methodBasic:
-				zerologr.V(20).Info("Using basic authentication for backend: " + backend)
-				for _, exemption := range mapping.Exempt {
-					match, err := path.Match(exemption, req.URL.Path)
-					if err != nil {
-						return nil, err
-					}
+		if mapping.Backend != backend {
+			continue
+		}
 
-					if match {
-						return nil, fmt.Errorf("%w: %s", errExempted, req.URL.Path)
-					}
-				}
-				return a.basic, nil
-			default:
-				return nil, errUnrecognizedMethod
+		var m method.Method
+		switch mapping.Method {
+		case methodBasic:
+			zerologr.V(20).Info("Using basic authentication for backend: " + backend)
+			m = a.basic
+		case methodJWT:
+			zerologr.V(20).Info("Using JWT authentication for backend: " + backend)
+			m = a.jwt
+		case methodOIDC:
+			zerologr.V(20).Info("Using OIDC authentication for backend: " + backend)
+			m = a.oidc
+		case methodMTLS:
+			zerologr.V(20).Info("Using mTLS authentication for backend: " + backend)
+			m = a.mtls
+		case methodHMAC:
+			zerologr.V(20).Info("Using HMAC authentication for backend: " + backend)
+			m = a.hmac
+		default:
+			return nil, errUnrecognizedMethod
+		}
+
+		for _, exemption := range mapping.Exempt {
+			match, err := path.Match(exemption, req.URL.Path)
+			if err != nil {
+				return nil, err
+			}
+
+			if match {
+				return nil, fmt.Errorf("%w: %s", errExempted, req.URL.Path)
 			}
 		}
+		return m, nil
 	}
 
 	return nil, errNoMethod
@@ -249,6 +486,15 @@ func makeAuthZMap(mappings []*config.AuthMapping) map[string]*config.AuthZ {
 	return m
 }
 
+// optional returns nil for the zero value and a pointer to v otherwise.
+func optional[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
+
 func transitionFailure(debugCall debug.DebuggedCall, debugStart time.Time, errMsg string) {
 	debugCall.AddTransition(
 		"authorizer",
@@ -0,0 +1,72 @@
+package basic
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	// apiKeyPrefix starts every API key, telling them apart from other bearer tokens.
+	apiKeyPrefix = "krb_"
+	// apiKeyUser prefixes the ID of the API key in the X-Krb-User header of requests it
+	// authenticated.
+	apiKeyUser = "apikey-"
+
+	apiKeyIDBytes     = 6
+	apiKeySecretBytes = 32
+)
+
+// newAPIKey returns a new API key and its prefix. The prefix identifies the key and may be shown,
+// the remainder is secret.
+func newAPIKey() (string, string) {
+	id := make([]byte, apiKeyIDBytes)
+	secret := make([]byte, apiKeySecretBytes)
+	_, _ = rand.Read(id)
+	_, _ = rand.Read(secret)
+
+	prefix := apiKeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix
+}
+
+// splitAPIKey returns the prefix of the API key, or false if the key is malformed.
+func splitAPIKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(id) != hex.EncodedLen(apiKeyIDBytes) || secret == "" {
+		return "", false
+	}
+
+	return apiKeyPrefix + id, true
+}
+
+// hashAPIKey hashes an API key for storage. Unlike passwords, keys are random and long enough that
+// they need neither a salt nor a slow hash, which would otherwise be paid by every request.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyFromRequest returns the API key of the request, read from the header if configured, or
+// from a bearer token that is an API key. Returns "" if the request has no API key.
+func apiKeyFromRequest(req *http.Request, header string) string {
+	if header != "" {
+		if key := req.Header.Get(header); key != "" {
+			return key
+		}
+	}
+
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if ok && strings.HasPrefix(token, apiKeyPrefix) {
+		return token
+	}
+
+	return ""
+}
@@ -2,13 +2,14 @@ package basic
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
-	"path"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "embed"
@@ -29,6 +30,7 @@ import (
 type (
 	Basic interface {
 		method.Method
+		method.Metered
 
 		// RegisterRoutes is overridden to pass the auth config, and since it's the authorizer
 		// that needs to satisfy the admin extension that does not matter.
@@ -39,14 +41,20 @@ type (
 		) error
 	}
 	basic struct {
-		config    map[string]*config.AuthZ
-		sqlClient db.SQLClient
-		oasDir    string
+		config       map[string]*config.AuthZ
+		quotas       []*config.AuthQuota
+		apiKeyHeader string
+		sqlClient    db.SQLClient
+		oasDir       string
 	}
 	Opts struct {
 		AuthZConfig map[string]*config.AuthZ
-		SQLClient   db.SQLClient
-		OASDir      string
+		// Quotas is optional, organisations are only limited when set.
+		Quotas []*config.AuthQuota
+		// APIKeyHeader is optional, API keys are always accepted as bearer tokens.
+		APIKeyHeader string
+		SQLClient    db.SQLClient
+		OASDir       string
 	}
 )
 
@@ -81,9 +89,11 @@ func New(opts *Opts) (Basic, error) {
 	}
 
 	b := &basic{
-		sqlClient: opts.SQLClient,
-		oasDir:    opts.OASDir,
-		config:    opts.AuthZConfig,
+		sqlClient:    opts.SQLClient,
+		oasDir:       opts.OASDir,
+		config:       opts.AuthZConfig,
+		quotas:       opts.Quotas,
+		apiKeyHeader: opts.APIKeyHeader,
 	}
 
 	return b, nil
@@ -92,6 +102,10 @@ func New(opts *Opts) (Basic, error) {
 func (a *basic) Authenticated(req *http.Request) error {
 	zerologr.V(50).Info("Authenticating request " + req.URL.Path)
 
+	if key := apiKeyFromRequest(req, a.apiKeyHeader); key != "" {
+		return a.authenticateAPIKey(req, key)
+	}
+
 	if len(req.Cookies()) == 0 {
 		zerologr.V(20).Info("No cookies found, denying access")
 		return apierror.ErrUnauthorized
@@ -152,49 +166,20 @@ func (a *basic) Authorized(req *http.Request) error {
 		return nil
 	}
 
-	var groupsToValidate []string
-	// Check if path override is present for the backend:
-	for p, pathGroups := range authZ.Paths {
-		match, err := path.Match(p, req.URL.Path)
-		if err != nil {
-			return err
-		}
-
-		if match {
-			groupsToValidate = pathGroups
-			break
-		}
+	groupsToValidate, err := method.RequiredGroups(authZ, req.URL.Path)
+	if err != nil {
+		return err
 	}
 
 	// Return nil if neither global groups are configured, nor any path override exists.
-	if len(groupsToValidate) == 0 && len(authZ.Groups) == 0 {
+	if len(groupsToValidate) == 0 {
 		zerologr.V(50).Info("No authorization group mapping defined for backend " + backend)
 		return nil
 	}
 
-	// Set validation groups depending on if global or path override.
-	if len(groupsToValidate) == 0 {
-		zerologr.V(50).Info("Validating global group mapping for " + backend)
-		groupsToValidate = authZ.Groups
-	} else {
-		zerologr.V(50).Info("Validating path group mapping for " + backend)
-	}
-
-	// Fetch the user's groups.
-	orgID, err := strconv.ParseInt(req.Header.Get("X-Krb-Org"), 10, 64)
-	if err != nil {
-		zerologr.Error(err, "Failed to parse org ID header")
-		return apierror.ErrISE
-	}
-	userID, err := strconv.ParseInt(req.Header.Get("X-Krb-User"), 10, 64)
-	if err != nil {
-		zerologr.Error(err, "Failed to parse user ID header")
-		return apierror.ErrISE
-	}
-
-	userGroups, err := dbGetUserGroups(req.Context(), a.sqlClient, orgID, userID)
+	userGroups, err := a.requestGroups(req)
 	if err != nil {
-		return apierror.ErrISE
+		return err
 	}
 
 	for _, g := range userGroups {
@@ -211,6 +196,73 @@ func (a *basic) Authorized(req *http.Request) error {
 	return apierror.ErrForbidden
 }
 
+// authenticateAPIKey authenticates a request with an API key, setting X-Krb-User to the key.
+func (a *basic) authenticateAPIKey(req *http.Request, key string) error {
+	prefix, ok := splitAPIKey(key)
+	if !ok {
+		zerologr.V(20).Info("Malformed API key, denying access")
+		return apierror.ErrUnauthorized
+	}
+
+	apiKey, err := dbGetAPIKey(req.Context(), a.sqlClient, prefix)
+	if errors.Is(err, errNoAPIKey) {
+		zerologr.Error(apierror.ErrUnauthorized, "Failed to find a matching API key")
+		return apierror.ErrUnauthorized
+	}
+	if err != nil {
+		return apierror.ErrISE
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(apiKey.HashedKey)) != 1 {
+		zerologr.Error(apierror.ErrUnauthorized, "API key mismatch")
+		return apierror.ErrUnauthorized
+	}
+
+	if apiKey.Expires > 0 && time.Now().UnixMilli() > apiKey.Expires {
+		zerologr.Error(apierror.ErrUnauthorized, "API key expired")
+		return apierror.ErrUnauthorized
+	}
+
+	req.Header.Set("X-Krb-Org", strconv.FormatInt(apiKey.OrgID, 10))
+	req.Header.Set("X-Krb-User", apiKeyUser+strconv.FormatInt(apiKey.ID, 10))
+
+	return nil
+}
+
+// requestGroups returns the groups of the user or API key that authenticated the request.
+func (a *basic) requestGroups(req *http.Request) ([]authbasicapi.Group, error) {
+	orgID, err := strconv.ParseInt(req.Header.Get("X-Krb-Org"), 10, 64)
+	if err != nil {
+		zerologr.Error(err, "Failed to parse org ID header")
+		return nil, apierror.ErrISE
+	}
+
+	var groups []authbasicapi.Group
+	if keyID, ok := strings.CutPrefix(req.Header.Get("X-Krb-User"), apiKeyUser); ok {
+		apiKeyID, err := strconv.ParseInt(keyID, 10, 64)
+		if err != nil {
+			zerologr.Error(err, "Failed to parse API key ID header")
+			return nil, apierror.ErrISE
+		}
+		groups, err = dbGetAPIKeyGroups(req.Context(), a.sqlClient, orgID, apiKeyID)
+		if err != nil {
+			return nil, apierror.ErrISE
+		}
+		return groups, nil
+	}
+
+	userID, err := strconv.ParseInt(req.Header.Get("X-Krb-User"), 10, 64)
+	if err != nil {
+		zerologr.Error(err, "Failed to parse user ID header")
+		return nil, apierror.ErrISE
+	}
+	groups, err = dbGetUserGroups(req.Context(), a.sqlClient, orgID, userID)
+	if err != nil {
+		return nil, apierror.ErrISE
+	}
+	return groups, nil
+}
+
 // RegisterRoutes registers the API routes for the basic auth method.
 func (a *basic) RegisterRoutes(
 	mux *http.ServeMux,
@@ -227,7 +279,7 @@ func (a *basic) RegisterRoutes(
 		return fmt.Errorf("failed to load basic authentication OAS: %w", err)
 	}
 
-	ssi := newSSI(a.sqlClient, cfg.Methods.Basic.API.Cookies)
+	ssi := newSSI(a.sqlClient, cfg.Methods.Basic.API.Cookies, a.quotas)
 	authMiddleware := make([]authbasicapi.StrictMiddlewareFunc, len(middleware)+1)
 	authMiddleware[0] = AuthMiddleware(ssi)
 
@@ -2,13 +2,17 @@ package basic
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/trebent/kerberos/internal/composer"
 	"github.com/trebent/kerberos/internal/config"
 	authbasicapi "github.com/trebent/kerberos/internal/oapi/auth/basic"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
 )
 
 func TestAuthorizer_Authenticated(t *testing.T) {
@@ -116,3 +120,129 @@ func TestAuthorizer_AuthorizedGroup(t *testing.T) {
 		t.Fatal("Expected an error when user is not authorized")
 	}
 }
+
+func TestAuthorizer_APIKey(t *testing.T) {
+	groupName := uniqueName(t, "apikey-group")
+	basic, err := New(&Opts{
+		AuthZConfig: map[string]*config.AuthZ{
+			"backend": {
+				Groups: []string{groupName},
+			},
+		},
+		APIKeyHeader: "X-Api-Key",
+		SQLClient:    testClient,
+		OASDir:       "something",
+	})
+	if err != nil {
+		t.Fatal("Expected no error when creating authorizer")
+	}
+
+	orgID, _ := mustCreateOrg(t, uniqueName(t, "apikey-test-org"))
+	groupID := mustCreateGroup(t, orgID, groupName)
+	ssi := newSSI(testClient, &config.Cookies{}, nil)
+
+	createKey := func(t *testing.T, groups []int64) authbasicapi.CreateAPIKey201JSONResponse {
+		t.Helper()
+		resp, err := ssi.CreateAPIKey(t.Context(), authbasicapi.CreateAPIKeyRequestObject{
+			OrgID: orgID,
+			Body:  &authbasicapi.CreateAPIKeyJSONRequestBody{Name: uniqueName(t, "key"), Groups: &groups},
+		})
+		if err != nil {
+			t.Fatalf("CreateAPIKey error: %v", err)
+		}
+		created, ok := resp.(authbasicapi.CreateAPIKey201JSONResponse)
+		if !ok {
+			t.Fatalf("expected CreateAPIKey201JSONResponse, got %T", resp)
+		}
+		return created
+	}
+	newRequest := func(t *testing.T) *http.Request {
+		t.Helper()
+		req, err := http.NewRequest("GET", "/api/v1/some/path", nil)
+		if err != nil {
+			t.Fatal("Expected no error when creating request")
+		}
+		return req.WithContext(
+			context.WithValue(req.Context(), composer.BackendContextKey, "backend"),
+		)
+	}
+
+	member := createKey(t, []int64{groupID})
+	if !strings.HasPrefix(member.Key, member.Prefix+"_") {
+		t.Fatalf("Expected key %q to start with its prefix %q", member.Key, member.Prefix)
+	}
+
+	t.Run("bearer token", func(t *testing.T) {
+		req := newRequest(t)
+		req.Header.Set("Authorization", "Bearer "+member.Key)
+		req.Header.Set("X-Krb-User", "spoofed")
+		if err := basic.Authenticated(req); err != nil {
+			t.Fatalf("Expected no error for a valid API key, got %v", err)
+		}
+		if req.Header.Get("X-Krb-Org") != strconv.FormatInt(orgID, 10) {
+			t.Fatalf("Unexpected X-Krb-Org %q", req.Header.Get("X-Krb-Org"))
+		}
+		if req.Header.Get("X-Krb-User") != apiKeyUser+strconv.FormatInt(member.Id, 10) {
+			t.Fatalf("Unexpected X-Krb-User %q", req.Header.Get("X-Krb-User"))
+		}
+		if err := basic.Authorized(req); err != nil {
+			t.Fatalf("Expected the API key to be authorized, got %v", err)
+		}
+		if req.Header.Get("X-Krb-Groups") != groupName {
+			t.Fatalf("Unexpected X-Krb-Groups %q", req.Header.Get("X-Krb-Groups"))
+		}
+	})
+
+	t.Run("header", func(t *testing.T) {
+		req := newRequest(t)
+		req.Header.Set("X-Api-Key", member.Key)
+		if err := basic.Authenticated(req); err != nil {
+			t.Fatalf("Expected no error for a valid API key, got %v", err)
+		}
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		req := newRequest(t)
+		req.Header.Set("Authorization", "Bearer "+createKey(t, nil).Key)
+		if err := basic.Authenticated(req); err != nil {
+			t.Fatalf("Expected no error for a valid API key, got %v", err)
+		}
+		if err := basic.Authorized(req); !errors.Is(err, apierror.ErrForbidden) {
+			t.Fatalf("Expected forbidden, got %v", err)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		revoked := createKey(t, nil)
+		if _, err := ssi.DeleteAPIKey(t.Context(), authbasicapi.DeleteAPIKeyRequestObject{
+			OrgID:    orgID,
+			ApiKeyID: revoked.Id,
+		}); err != nil {
+			t.Fatalf("DeleteAPIKey error: %v", err)
+		}
+
+		expired := createKey(t, nil)
+		if _, err := testClient.Exec(
+			t.Context(),
+			"UPDATE api_keys SET expires = 1 WHERE id = @id;",
+			sql.NamedArg{Name: "id", Value: expired.Id},
+		); err != nil {
+			t.Fatalf("Failed to expire API key: %v", err)
+		}
+
+		for name, key := range map[string]string{
+			"wrong secret": member.Prefix + "_wrong",
+			"malformed":    apiKeyPrefix + "malformed",
+			"revoked":      revoked.Key,
+			"expired":      expired.Key,
+		} {
+			t.Run(name, func(t *testing.T) {
+				req := newRequest(t)
+				req.Header.Set("Authorization", "Bearer "+key)
+				if err := basic.Authenticated(req); !errors.Is(err, apierror.ErrUnauthorized) {
+					t.Fatalf("Expected unauthorized, got %v", err)
+				}
+			})
+		}
+	})
+}
@@ -57,6 +57,24 @@ const (
 	selectSessionByRefresh = "SELECT s.session_id, s.refresh_id, s.user_id, s.organisation_id, u.administrator, s.expires FROM sessions s INNER JOIN users u ON s.user_id = u.id WHERE refresh_id = @refreshID AND s.organisation_id = @orgID;"
 	deleteUserSession      = "DELETE FROM sessions WHERE organisation_id = @orgID AND user_id = @userID AND session_id = @sessionID;"
 
+	// Usage.
+	upsertUsage        = "INSERT INTO organisation_usage (organisation_id, backend, day, requests, request_bytes, response_bytes) VALUES(@orgID, @backend, @day, 1, @requestBytes, @responseBytes) ON CONFLICT (organisation_id, backend, day) DO UPDATE SET requests = organisation_usage.requests + 1, request_bytes = organisation_usage.request_bytes + excluded.request_bytes, response_bytes = organisation_usage.response_bytes + excluded.response_bytes;"
+	selectUsage        = "SELECT backend, day, requests, request_bytes, response_bytes FROM organisation_usage WHERE organisation_id = @orgID AND day >= @from AND day <= @to ORDER BY day, backend;"
+	selectBackendUsage = "SELECT backend, day, requests, request_bytes, response_bytes FROM organisation_usage WHERE organisation_id = @orgID AND backend = @backend AND day >= @from AND day <= @to ORDER BY day, backend;"
+
+	// Quotas.
+	upsertQuotaReservation = "INSERT INTO organisation_quotas (organisation_id, backend, day, day_requests, month, month_requests) VALUES(@orgID, @backend, @day, 1, @month, 1) ON CONFLICT (organisation_id, backend) DO UPDATE SET day_requests = CASE WHEN organisation_quotas.day = excluded.day THEN organisation_quotas.day_requests + 1 ELSE 1 END, day = excluded.day, month_requests = CASE WHEN organisation_quotas.month = excluded.month THEN organisation_quotas.month_requests + 1 ELSE 1 END, month = excluded.month WHERE (@perDay = 0 OR organisation_quotas.day <> excluded.day OR organisation_quotas.day_requests < @perDay) AND (@perMonth = 0 OR organisation_quotas.month <> excluded.month OR organisation_quotas.month_requests < @perMonth) RETURNING day_requests;"
+	selectQuota            = "SELECT day, day_requests, month, month_requests FROM organisation_quotas WHERE organisation_id = @orgID AND backend = @backend;"
+
+	// API keys.
+	insertAPIKey          = "INSERT INTO api_keys (name, prefix, hashed_key, organisation_id, expires, created) VALUES(@name, @prefix, @hashedKey, @orgID, @expires, @created);"
+	insertAPIKeyReturning = "INSERT INTO api_keys (name, prefix, hashed_key, organisation_id, expires, created) VALUES(@name, @prefix, @hashedKey, @orgID, @expires, @created) RETURNING id"
+	insertAPIKeyGroup     = "INSERT INTO api_key_groups (api_key_id, group_id) SELECT CAST(@apiKeyID AS INTEGER), id FROM groups WHERE id = @groupID AND organisation_id = @orgID;"
+	selectAPIKey          = "SELECT id, organisation_id, hashed_key, expires FROM api_keys WHERE prefix = @prefix;"
+	selectAPIKeys         = "SELECT id, name, prefix, expires, created FROM api_keys WHERE organisation_id = @orgID;"
+	selectAPIKeyGroups    = "SELECT id, name FROM groups WHERE id IN (SELECT group_id FROM api_key_groups WHERE api_key_id = @apiKeyID) AND organisation_id = @orgID;"
+	deleteAPIKey          = "DELETE FROM api_keys WHERE id = @apiKeyID AND organisation_id = @orgID;"
+
 	// Named arg keys.
 	argSession        = "session"
 	argOrgID          = "orgID"
@@ -66,6 +84,11 @@ const (
 	argHashedPassword = "hashedPassword"
 	argIsAdmin        = "isAdmin"
 	argGroupID        = "groupID"
+	argBackend        = "backend"
+	argDay            = "day"
+	argFrom           = "from"
+	argTo             = "to"
+	argAPIKeyID       = "apiKeyID"
 
 	sessionExpiry        = 15 * time.Minute
 	sessionRefreshExpiry = 15 * time.Minute
@@ -76,6 +99,7 @@ var (
 	errNoUser    = errors.New("no user found")
 	errNoGroup   = errors.New("no group found")
 	errNoOrg     = errors.New("no organisation found")
+	errNoAPIKey  = errors.New("no API key found")
 )
 
 // --- Package-level helpers (shared by impl and basic) ---
@@ -723,6 +747,288 @@ func dbListGroupBindings(
 	return bindings, nil
 }
 
+// --- API keys ---
+
+// dbGetAPIKey returns the API key with the prefix.
+// Returns (nil, errNoAPIKey) when no matching API key exists.
+func dbGetAPIKey(ctx context.Context, client db.SQLClient, prefix string) (*models.APIKey, error) {
+	rows, err := client.Query(ctx, selectAPIKey, sql.NamedArg{Name: "prefix", Value: prefix})
+	if err != nil {
+		zerologr.Error(err, "Failed to query API key")
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			zerologr.Error(err, "Failed to iterate API key rows")
+			return nil, err
+		}
+		return nil, errNoAPIKey
+	}
+
+	k := &models.APIKey{Prefix: prefix}
+	if err := rows.Scan(&k.ID, &k.OrgID, &k.HashedKey, &k.Expires); err != nil {
+		zerologr.Error(err, "Failed to scan API key row")
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// dbListAPIKeys returns the API keys of an organisation, without their groups.
+func dbListAPIKeys(
+	ctx context.Context,
+	client db.SQLClient,
+	orgID int64,
+) ([]authbasicapi.APIKey, error) {
+	rows, err := client.Query(ctx, selectAPIKeys, sql.NamedArg{Name: argOrgID, Value: orgID})
+	if err != nil {
+		zerologr.Error(err, "Failed to query API keys")
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]authbasicapi.APIKey, 0)
+	for rows.Next() {
+		var (
+			k                authbasicapi.APIKey
+			expires, created int64
+		)
+		if err := rows.Scan(&k.Id, &k.Name, &k.Prefix, &expires, &created); err != nil {
+			zerologr.Error(err, "Failed to scan API key row")
+			return nil, err
+		}
+		k.Created = time.UnixMilli(created).UTC()
+		if expires > 0 {
+			e := time.UnixMilli(expires).UTC()
+			k.Expires = &e
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		zerologr.Error(err, "Failed to iterate API key rows")
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// dbGetAPIKeyGroups returns the groups an API key is a member of.
+func dbGetAPIKeyGroups(
+	ctx context.Context,
+	client db.SQLClient,
+	orgID, apiKeyID int64,
+) ([]authbasicapi.Group, error) {
+	rows, err := client.Query(
+		ctx,
+		selectAPIKeyGroups,
+		sql.NamedArg{Name: argOrgID, Value: orgID},
+		sql.NamedArg{Name: argAPIKeyID, Value: apiKeyID},
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to query API key groups")
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]authbasicapi.Group, 0)
+	for rows.Next() {
+		var g authbasicapi.Group
+		if err := rows.Scan(&g.Id, &g.Name); err != nil {
+			zerologr.Error(err, "Failed to scan API key group row")
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		zerologr.Error(err, "Failed to iterate API key group rows")
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// dbDeleteAPIKey deletes an API key of an organisation.
+// Returns errNoAPIKey when no matching API key exists.
+func dbDeleteAPIKey(ctx context.Context, client db.SQLClient, orgID, apiKeyID int64) error {
+	res, err := client.Exec(
+		ctx,
+		deleteAPIKey,
+		sql.NamedArg{Name: argOrgID, Value: orgID},
+		sql.NamedArg{Name: argAPIKeyID, Value: apiKeyID},
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to delete API key")
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errNoAPIKey
+	}
+	return nil
+}
+
+// --- Usage ---
+
+// dbRecordUsage adds a request and its bytes to the usage of the organisation and backend on the
+// input day.
+func dbRecordUsage(
+	ctx context.Context,
+	client db.SQLClient,
+	orgID int64,
+	backend, day string,
+	requestBytes, responseBytes int64,
+) error {
+	_, err := client.Exec(
+		ctx,
+		upsertUsage,
+		sql.NamedArg{Name: argOrgID, Value: orgID},
+		sql.NamedArg{Name: argBackend, Value: backend},
+		sql.NamedArg{Name: argDay, Value: day},
+		sql.NamedArg{Name: "requestBytes", Value: requestBytes},
+		sql.NamedArg{Name: "responseBytes", Value: responseBytes},
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to record usage")
+	}
+	return err
+}
+
+// dbListUsage returns the daily usage of an organisation between the from and to days, inclusive.
+// An empty backend lists the usage of all backends.
+func dbListUsage(
+	ctx context.Context,
+	client db.SQLClient,
+	orgID int64,
+	backend, from, to string,
+) ([]authbasicapi.UsageRecord, error) {
+	query := selectUsage
+	args := []any{
+		sql.NamedArg{Name: argOrgID, Value: orgID},
+		sql.NamedArg{Name: argFrom, Value: from},
+		sql.NamedArg{Name: argTo, Value: to},
+	}
+	if backend != "" {
+		query = selectBackendUsage
+		args = append(args, sql.NamedArg{Name: argBackend, Value: backend})
+	}
+
+	rows, err := client.Query(ctx, query, args...)
+	if err != nil {
+		zerologr.Error(err, "Failed to query usage")
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]authbasicapi.UsageRecord, 0)
+	for rows.Next() {
+		var (
+			r   authbasicapi.UsageRecord
+			day string
+		)
+		if err := rows.Scan(&r.Backend, &day, &r.Requests, &r.RequestBytes, &r.ResponseBytes); err != nil {
+			zerologr.Error(err, "Failed to scan usage row")
+			return nil, err
+		}
+		if r.Day.Time, err = time.Parse(time.DateOnly, day); err != nil {
+			zerologr.Error(err, "Failed to parse usage day")
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		zerologr.Error(err, "Failed to iterate usage rows")
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// --- Quotas ---
+
+// dbReserveQuota counts a request against the quota of the organisation and backend, an empty
+// backend being the quota of all backends. The count is only increased while it is below the daily
+// and monthly limits, zero meaning no limit, so that concurrent requests cannot exceed them. It
+// returns false when the quota is used up.
+func dbReserveQuota(
+	ctx context.Context,
+	client db.Queryer,
+	orgID int64,
+	backend, day, month string,
+	perDay, perMonth int64,
+) (bool, error) {
+	rows, err := client.Query(
+		ctx,
+		upsertQuotaReservation,
+		sql.NamedArg{Name: argOrgID, Value: orgID},
+		sql.NamedArg{Name: argBackend, Value: backend},
+		sql.NamedArg{Name: argDay, Value: day},
+		sql.NamedArg{Name: "month", Value: month},
+		sql.NamedArg{Name: "perDay", Value: perDay},
+		sql.NamedArg{Name: "perMonth", Value: perMonth},
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to reserve quota")
+		return false, err
+	}
+	defer rows.Close()
+
+	reserved := rows.Next()
+	if err := rows.Err(); err != nil {
+		zerologr.Error(err, "Failed to iterate quota reservation rows")
+		return false, err
+	}
+
+	return reserved, nil
+}
+
+// dbGetQuota returns the number of requests counted against the quota of the organisation and
+// backend on the input day and month.
+func dbGetQuota(
+	ctx context.Context,
+	client db.Queryer,
+	orgID int64,
+	backend, day, month string,
+) (int64, int64, error) {
+	rows, err := client.Query(
+		ctx,
+		selectQuota,
+		sql.NamedArg{Name: argOrgID, Value: orgID},
+		sql.NamedArg{Name: argBackend, Value: backend},
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to query quota")
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		today, thisMonth           int64
+		countedDay, countedMonth   string
+		dayRequests, monthRequests int64
+	)
+	if rows.Next() {
+		if err := rows.Scan(&countedDay, &dayRequests, &countedMonth, &monthRequests); err != nil {
+			zerologr.Error(err, "Failed to scan quota row")
+			return 0, 0, err
+		}
+		// Counts of past days and months are reset by the next reservation.
+		if countedDay == day {
+			today = dayRequests
+		}
+		if countedMonth == month {
+			thisMonth = monthRequests
+		}
+	}
+	if err := rows.Err(); err != nil {
+		zerologr.Error(err, "Failed to iterate quota rows")
+		return 0, 0, err
+	}
+
+	return today, thisMonth, nil
+}
+
 // --- Transaction helpers ---
 
 // dbCreateOrganisation atomically creates an organisation and its initial admin user.
@@ -804,6 +1110,71 @@ func dbCreateOrganisation(
 	return orgID, adminUserID, adminUsername, adminPassword, nil
 }
 
+// dbCreateAPIKey atomically creates an API key and adds it to the groups, returning its ID.
+// Returns errNoGroup if a group is not part of the organisation of the key.
+func dbCreateAPIKey(
+	ctx context.Context,
+	client db.SQLClient,
+	k *models.APIKey,
+	groupIDs []int64,
+) (int64, error) {
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		zerologr.Error(err, "Failed to start transaction")
+		return 0, err
+	}
+	//nolint:errcheck // intentional: no-op if already committed
+	defer tx.Rollback()
+
+	args := []any{
+		sql.NamedArg{Name: argName, Value: k.Name},
+		sql.NamedArg{Name: "prefix", Value: k.Prefix},
+		sql.NamedArg{Name: "hashedKey", Value: k.HashedKey},
+		sql.NamedArg{Name: argOrgID, Value: k.OrgID},
+		sql.NamedArg{Name: "expires", Value: k.Expires},
+		sql.NamedArg{Name: "created", Value: k.Created},
+	}
+
+	var id int64
+	if client.Dialect() == db.PostgresDialect {
+		id, err = postgres.InsertReturningID(ctx, tx, insertAPIKeyReturning, args...)
+	} else {
+		var res sql.Result
+		res, err = tx.Exec(ctx, insertAPIKey, args...)
+		if err == nil {
+			id, _ = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		zerologr.Error(err, "Failed to insert API key")
+		return 0, err
+	}
+
+	for _, groupID := range groupIDs {
+		res, err := tx.Exec(
+			ctx,
+			insertAPIKeyGroup,
+			sql.NamedArg{Name: argAPIKeyID, Value: id},
+			sql.NamedArg{Name: argGroupID, Value: groupID},
+			sql.NamedArg{Name: argOrgID, Value: k.OrgID},
+		)
+		if err != nil {
+			zerologr.Error(err, "Failed to insert API key group")
+			return 0, err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return 0, fmt.Errorf("%w: %d", errNoGroup, groupID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		zerologr.Error(err, "Failed to commit API key creation transaction")
+		return 0, err
+	}
+
+	return id, nil
+}
+
 // dbUpdateUserGroupBindings atomically updates a user's group memberships to match desiredGroups.
 func dbUpdateUserGroupBindings(
 	ctx context.Context,
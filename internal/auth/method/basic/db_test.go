@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	models "github.com/trebent/kerberos/internal/auth/method/basic/model"
+	"github.com/trebent/kerberos/internal/db"
 	authbasicapi "github.com/trebent/kerberos/internal/oapi/auth/basic"
 )
 
@@ -613,6 +615,235 @@ func TestDBSessions(t *testing.T) {
 	})
 }
 
+// --- API keys ---
+
+func TestDBAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	orgID, _ := mustCreateOrg(t, uniqueName(t, "org-apikeys"))
+	groupID := mustCreateGroup(t, orgID, uniqueName(t, "group-apikeys"))
+	otherOrgID, _ := mustCreateOrg(t, uniqueName(t, "org-apikeys-other"))
+	otherGroupID := mustCreateGroup(t, otherOrgID, uniqueName(t, "group-apikeys-other"))
+
+	mustCreateAPIKey := func(t *testing.T, name string, groupIDs ...int64) (int64, string) {
+		t.Helper()
+		_, prefix := newAPIKey()
+		id, err := dbCreateAPIKey(ctx, testClient, &models.APIKey{
+			OrgID:     orgID,
+			Name:      name,
+			Prefix:    prefix,
+			HashedKey: "hashed",
+			Created:   time.Now().UnixMilli(),
+		}, groupIDs)
+		if err != nil {
+			t.Fatalf("dbCreateAPIKey error: %v", err)
+		}
+		return id, prefix
+	}
+
+	t.Run("create and get", func(t *testing.T) {
+		id, prefix := mustCreateAPIKey(t, uniqueName(t, "key"), groupID)
+
+		k, err := dbGetAPIKey(ctx, testClient, prefix)
+		if err != nil {
+			t.Fatalf("dbGetAPIKey error: %v", err)
+		}
+		if k.ID != id || k.OrgID != orgID || k.HashedKey != "hashed" || k.Expires != 0 {
+			t.Fatalf("unexpected API key %+v", k)
+		}
+
+		groups, err := dbGetAPIKeyGroups(ctx, testClient, orgID, id)
+		if err != nil {
+			t.Fatalf("dbGetAPIKeyGroups error: %v", err)
+		}
+		if len(groups) != 1 || groups[0].Id != groupID {
+			t.Fatalf("expected group %d, got %v", groupID, groups)
+		}
+	})
+
+	t.Run("create with group of other org", func(t *testing.T) {
+		name := uniqueName(t, "key-other-group")
+		_, prefix := newAPIKey()
+		_, err := dbCreateAPIKey(ctx, testClient, &models.APIKey{
+			OrgID:     orgID,
+			Name:      name,
+			Prefix:    prefix,
+			HashedKey: "hashed",
+		}, []int64{otherGroupID})
+		if !errors.Is(err, errNoGroup) {
+			t.Fatalf("expected errNoGroup, got %v", err)
+		}
+
+		// The key must not have been created.
+		if _, err := dbGetAPIKey(ctx, testClient, prefix); !errors.Is(err, errNoAPIKey) {
+			t.Fatalf("expected errNoAPIKey, got %v", err)
+		}
+	})
+
+	t.Run("create duplicate", func(t *testing.T) {
+		name := uniqueName(t, "key-dup")
+		mustCreateAPIKey(t, name)
+		_, prefix := newAPIKey()
+		_, err := dbCreateAPIKey(ctx, testClient, &models.APIKey{
+			OrgID:     orgID,
+			Name:      name,
+			Prefix:    prefix,
+			HashedKey: "hashed",
+		}, nil)
+		if !errors.Is(err, db.ErrUnique) {
+			t.Fatalf("expected db.ErrUnique, got %v", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		listOrgID, _ := mustCreateOrg(t, uniqueName(t, "org-apikeys-list"))
+		for _, name := range []string{"key-1", "key-2"} {
+			_, prefix := newAPIKey()
+			if _, err := dbCreateAPIKey(ctx, testClient, &models.APIKey{
+				OrgID:     listOrgID,
+				Name:      name,
+				Prefix:    prefix,
+				HashedKey: "hashed",
+				Expires:   time.Now().Add(time.Hour).UnixMilli(),
+			}, nil); err != nil {
+				t.Fatalf("dbCreateAPIKey error: %v", err)
+			}
+		}
+
+		keys, err := dbListAPIKeys(ctx, testClient, listOrgID)
+		if err != nil {
+			t.Fatalf("dbListAPIKeys error: %v", err)
+		}
+		if len(keys) != 2 {
+			t.Fatalf("expected 2 API keys, got %d", len(keys))
+		}
+		if keys[0].Expires == nil {
+			t.Fatal("expected an expiry")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		id, prefix := mustCreateAPIKey(t, uniqueName(t, "key-del"), groupID)
+
+		if err := dbDeleteAPIKey(ctx, testClient, otherOrgID, id); !errors.Is(err, errNoAPIKey) {
+			t.Fatalf("expected errNoAPIKey deleting in other org, got %v", err)
+		}
+		if err := dbDeleteAPIKey(ctx, testClient, orgID, id); err != nil {
+			t.Fatalf("dbDeleteAPIKey error: %v", err)
+		}
+		if _, err := dbGetAPIKey(ctx, testClient, prefix); !errors.Is(err, errNoAPIKey) {
+			t.Fatalf("expected errNoAPIKey after delete, got %v", err)
+		}
+		if err := dbDeleteAPIKey(ctx, testClient, orgID, id); !errors.Is(err, errNoAPIKey) {
+			t.Fatalf("expected errNoAPIKey deleting twice, got %v", err)
+		}
+	})
+}
+
+// --- Usage ---
+
+func TestDBUsage(t *testing.T) {
+	ctx := context.Background()
+	orgID, _ := mustCreateOrg(t, uniqueName(t, "org-usage"))
+
+	for _, r := range []struct {
+		backend string
+		day     string
+	}{
+		{"orders", "2026-01-31"},
+		{"orders", "2026-02-01"},
+		{"orders", "2026-02-01"},
+		{"users", "2026-02-01"},
+	} {
+		if err := dbRecordUsage(ctx, testClient, orgID, r.backend, r.day, 1, 2); err != nil {
+			t.Fatalf("dbRecordUsage error: %v", err)
+		}
+	}
+
+	t.Run("list", func(t *testing.T) {
+		records, err := dbListUsage(ctx, testClient, orgID, "", "2026-02-01", "2026-02-28")
+		if err != nil {
+			t.Fatalf("dbListUsage error: %v", err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("expected 2 records, got %d", len(records))
+		}
+		if records[0].Backend != "orders" ||
+			records[0].Requests != 2 ||
+			records[0].RequestBytes != 2 ||
+			records[0].ResponseBytes != 4 ||
+			records[0].Day.Format(time.DateOnly) != "2026-02-01" {
+			t.Errorf("unexpected record: %+v", records[0])
+		}
+	})
+
+	t.Run("list backend", func(t *testing.T) {
+		records, err := dbListUsage(ctx, testClient, orgID, "users", "2026-01-01", "2026-02-28")
+		if err != nil {
+			t.Fatalf("dbListUsage error: %v", err)
+		}
+		if len(records) != 1 || records[0].Backend != "users" {
+			t.Errorf("unexpected records: %+v", records)
+		}
+	})
+}
+
+// --- Quotas ---
+
+func TestDBQuota(t *testing.T) {
+	ctx := context.Background()
+	orgID, _ := mustCreateOrg(t, uniqueName(t, "org-quota"))
+
+	reserve := func(day string, perDay, perMonth int64) bool {
+		t.Helper()
+		reserved, err := dbReserveQuota(
+			ctx, testClient, orgID, "orders", day, day[:7], perDay, perMonth,
+		)
+		if err != nil {
+			t.Fatalf("dbReserveQuota error: %v", err)
+		}
+		return reserved
+	}
+	expectQuota := func(day string, expectedToday, expectedMonth int64) {
+		t.Helper()
+		today, thisMonth, err := dbGetQuota(ctx, testClient, orgID, "orders", day, day[:7])
+		if err != nil {
+			t.Fatalf("dbGetQuota error: %v", err)
+		}
+		if today != expectedToday || thisMonth != expectedMonth {
+			t.Errorf(
+				"expected %d/%d requests, got %d/%d", expectedToday, expectedMonth, today, thisMonth,
+			)
+		}
+	}
+
+	expectQuota("2026-01-31", 0, 0)
+
+	// The daily limit.
+	if !reserve("2026-01-31", 2, 0) || !reserve("2026-01-31", 2, 0) {
+		t.Fatal("expected the requests to be reserved")
+	}
+	if reserve("2026-01-31", 2, 0) {
+		t.Error("expected the daily limit to be reached")
+	}
+	expectQuota("2026-01-31", 2, 2)
+
+	// A new day and month reset the counts.
+	if !reserve("2026-02-01", 2, 3) || !reserve("2026-02-02", 2, 3) || !reserve("2026-02-02", 2, 3) {
+		t.Fatal("expected the requests to be reserved")
+	}
+	if reserve("2026-02-03", 2, 3) {
+		t.Error("expected the monthly limit to be reached")
+	}
+	expectQuota("2026-02-02", 2, 3)
+	expectQuota("2026-02-03", 0, 3)
+
+	// Other backends have their own counts.
+	reserved, err := dbReserveQuota(ctx, testClient, orgID, "", "2026-02-03", "2026-02", 1, 0)
+	if err != nil || !reserved {
+		t.Errorf("expected the request to be reserved, got %v", err)
+	}
+}
+
 // --- Cascade Deletes ---
 
 // TestDBCascadeDeleteOrg verifies that deleting an organisation cascades to
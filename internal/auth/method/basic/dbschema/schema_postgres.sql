@@ -52,3 +52,50 @@ CREATE TABLE IF NOT EXISTS sessions (
   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
   FOREIGN KEY(organisation_id) REFERENCES organisations(id) ON DELETE CASCADE ON UPDATE CASCADE
 );
+
+CREATE TABLE IF NOT EXISTS organisation_usage (
+  organisation_id INTEGER,
+  backend VARCHAR(100) NOT NULL,
+  day VARCHAR(10) NOT NULL,
+  requests BIGINT NOT NULL DEFAULT 0,
+  request_bytes BIGINT NOT NULL DEFAULT 0,
+  response_bytes BIGINT NOT NULL DEFAULT 0,
+  FOREIGN KEY(organisation_id) REFERENCES organisations(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS organisation_usage_day ON organisation_usage(organisation_id, backend, day);
+
+CREATE TABLE IF NOT EXISTS organisation_quotas (
+  organisation_id INTEGER,
+  backend VARCHAR(100) NOT NULL,
+  day VARCHAR(10) NOT NULL,
+  day_requests BIGINT NOT NULL DEFAULT 0,
+  month VARCHAR(7) NOT NULL,
+  month_requests BIGINT NOT NULL DEFAULT 0,
+  FOREIGN KEY(organisation_id) REFERENCES organisations(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS organisation_quotas_backend ON organisation_quotas(organisation_id, backend);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  prefix VARCHAR(20) NOT NULL,
+  hashed_key VARCHAR(64) NOT NULL,
+  organisation_id INTEGER,
+  expires BIGINT NOT NULL DEFAULT 0,
+  created BIGINT NOT NULL,
+  FOREIGN KEY(organisation_id) REFERENCES organisations(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_key_name ON api_keys(organisation_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS api_key_prefix ON api_keys(prefix);
+
+CREATE TABLE IF NOT EXISTS api_key_groups (
+  api_key_id INTEGER,
+  group_id INTEGER,
+  FOREIGN KEY(api_key_id) REFERENCES api_keys(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  FOREIGN KEY(group_id) REFERENCES groups(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_key_group ON api_key_groups(api_key_id, group_id);
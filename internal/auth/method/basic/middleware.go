@@ -181,6 +181,21 @@ func AuthMiddleware(ssi authbasicapi.StrictServerInterface) authbasicapi.StrictM
 				validation = make([]error, 2)
 				validation[0] = orgValidator(session.OrgID, r)
 				validation[1] = administratorValidator(session.Administrator)
+			case
+				"GetUsage",
+				"GetQuotaUsage":
+				zerologr.V(20).Info("Validating auth for usage paths")
+				validation = make([]error, 2)
+				validation[0] = orgValidator(session.OrgID, r)
+				validation[1] = administratorValidator(session.Administrator)
+			case
+				"CreateAPIKey",
+				"ListAPIKeys",
+				"DeleteAPIKey":
+				zerologr.V(20).Info("Validating auth for API key paths")
+				validation = make([]error, 2)
+				validation[0] = orgValidator(session.OrgID, r)
+				validation[1] = administratorValidator(session.Administrator)
 			default:
 				validation = make([]error, 1)
 				validation[0] = apierror.New(
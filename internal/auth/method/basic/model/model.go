@@ -31,6 +31,18 @@ type (
 		Name    string
 	}
 
+	// APIKey holds the fields of an API key of an organisation.
+	APIKey struct {
+		ID        int64
+		OrgID     int64
+		Name      string
+		Prefix    string
+		HashedKey string
+		// Expires is 0 for keys that do not expire.
+		Expires int64
+		Created int64
+	}
+
 	Organisation struct {
 		ID   int64
 		Name string
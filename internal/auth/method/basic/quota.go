@@ -0,0 +1,172 @@
+package basic
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/trebent/kerberos/internal/auth/method"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/kerberos/internal/db"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/zerologr"
+)
+
+// monthLayout formats the month quotas are counted in.
+const monthLayout = "2006-01"
+
+// Admit implements [method.Metered]. The request is counted against every quota applying to it,
+// or against none of them if one is used up.
+func (a *basic) Admit(req *http.Request) error {
+	if len(a.quotas) == 0 {
+		return nil
+	}
+
+	orgID, err := strconv.ParseInt(req.Header.Get("X-Krb-Org"), 10, 64)
+	if err != nil {
+		zerologr.Error(err, "Failed to parse org ID header")
+		return apierror.ErrISE
+	}
+	//nolint:errcheck // bigger problems if this is missing
+	backend := req.Context().Value(composer.BackendContextKey).(string)
+
+	org, err := dbGetOrg(req.Context(), a.sqlClient, orgID)
+	if err != nil {
+		return apierror.ErrISE
+	}
+
+	quotas := slices.DeleteFunc(orgQuotas(a.quotas, org.Name), func(q *config.AuthQuota) bool {
+		return q.Backend != "" && q.Backend != backend
+	})
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	tx, err := a.sqlClient.Begin(req.Context())
+	if err != nil {
+		zerologr.Error(err, "Failed to begin transaction")
+		return apierror.ErrISE
+	}
+	//nolint:errcheck // Rollback is a no-op once committed, and reservations are undone otherwise.
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for _, q := range quotas {
+		reserved, err := dbReserveQuota(
+			req.Context(),
+			tx,
+			orgID,
+			q.Backend,
+			now.Format(time.DateOnly),
+			now.Format(monthLayout),
+			q.RequestsPerDay,
+			q.RequestsPerMonth,
+		)
+		if err != nil {
+			return apierror.ErrISE
+		}
+		if !reserved {
+			return quotaExceeded(req.Context(), tx, orgID, q, now)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		zerologr.Error(err, "Failed to commit quota reservations")
+		return apierror.ErrISE
+	}
+
+	return nil
+}
+
+// quotaExceeded returns the error of a quota that is used up, retrying once the exceeded period
+// ends.
+func quotaExceeded(
+	ctx context.Context,
+	client db.Queryer,
+	orgID int64,
+	q *config.AuthQuota,
+	now time.Time,
+) error {
+	today, _, err := quotaUsage(ctx, client, orgID, q, now)
+	if err != nil {
+		return apierror.ErrISE
+	}
+
+	if q.RequestsPerDay > 0 && today >= q.RequestsPerDay {
+		zerologr.V(20).Info("Daily quota exceeded", "org", orgID, "backend", q.Backend)
+		return &method.QuotaExceededError{RetryAfter: nextDay(now).Sub(now)}
+	}
+
+	zerologr.V(20).Info("Monthly quota exceeded", "org", orgID, "backend", q.Backend)
+	return &method.QuotaExceededError{RetryAfter: nextMonth(now).Sub(now)}
+}
+
+// Record implements [method.Metered]. Admit has already counted the request against the quotas of
+// the organisation, Record settles it by adding the request and its bytes to the usage.
+func (a *basic) Record(req *http.Request, requestBytes, responseBytes int64) {
+	orgID, err := strconv.ParseInt(req.Header.Get("X-Krb-Org"), 10, 64)
+	if err != nil {
+		zerologr.Error(err, "Failed to parse org ID header")
+		return
+	}
+	//nolint:errcheck // bigger problems if this is missing
+	backend := req.Context().Value(composer.BackendContextKey).(string)
+
+	// The request has been served, usage is recorded even if the client has gone away.
+	_ = dbRecordUsage(
+		context.WithoutCancel(req.Context()),
+		a.sqlClient,
+		orgID,
+		backend,
+		time.Now().UTC().Format(time.DateOnly),
+		requestBytes,
+		responseBytes,
+	)
+}
+
+// orgQuotas returns the quotas applying to the named organisation, at most one per backend. A
+// quota of the organisation replaces the quota without organisation for the same backend.
+func orgQuotas(quotas []*config.AuthQuota, orgName string) []*config.AuthQuota {
+	selected := make([]*config.AuthQuota, 0, len(quotas))
+	index := make(map[string]int, len(quotas))
+	for _, q := range quotas {
+		if q.Organisation != "" && q.Organisation != orgName {
+			continue
+		}
+
+		i, ok := index[q.Backend]
+		switch {
+		case !ok:
+			index[q.Backend] = len(selected)
+			selected = append(selected, q)
+		case q.Organisation != "":
+			selected[i] = q
+		}
+	}
+
+	return selected
+}
+
+// quotaUsage returns the number of requests counted against the quota during the current UTC day
+// and month.
+func quotaUsage(
+	ctx context.Context,
+	client db.Queryer,
+	orgID int64,
+	q *config.AuthQuota,
+	now time.Time,
+) (int64, int64, error) {
+	day := now.Format(time.DateOnly)
+	return dbGetQuota(ctx, client, orgID, q.Backend, day, now.Format(monthLayout))
+}
+
+func nextDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+func nextMonth(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
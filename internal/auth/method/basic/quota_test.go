@@ -0,0 +1,150 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/trebent/kerberos/internal/auth/method"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	authbasicapi "github.com/trebent/kerberos/internal/oapi/auth/basic"
+)
+
+func TestOrgQuotas(t *testing.T) {
+	quotas := []*config.AuthQuota{
+		{RequestsPerDay: 10},
+		{Backend: "orders", RequestsPerDay: 5},
+		{Organisation: "acme", RequestsPerDay: 100},
+		{Organisation: "other", Backend: "orders", RequestsPerDay: 50},
+	}
+
+	acme := orgQuotas(quotas, "acme")
+	if len(acme) != 2 || acme[0] != quotas[2] || acme[1] != quotas[1] {
+		t.Errorf("Unexpected quotas for acme: %+v", acme)
+	}
+
+	other := orgQuotas(quotas, "other")
+	if len(other) != 2 || other[0] != quotas[0] || other[1] != quotas[3] {
+		t.Errorf("Unexpected quotas for other: %+v", other)
+	}
+}
+
+func TestQuotaAdmit(t *testing.T) {
+	orgName := uniqueName(t, "quota-org")
+	basic, err := New(&Opts{
+		AuthZConfig: map[string]*config.AuthZ{},
+		Quotas: []*config.AuthQuota{
+			{Organisation: orgName, Backend: "backend", RequestsPerDay: 2},
+		},
+		SQLClient: testClient,
+		OASDir:    "something",
+	})
+	if err != nil {
+		t.Fatal("Expected no error when creating authorizer")
+	}
+
+	orgID, _ := mustCreateOrg(t, orgName)
+	newRequest := func(backend string) *http.Request {
+		req, err := http.NewRequest("GET", "/api/v1/some/path", nil)
+		if err != nil {
+			t.Fatal("Expected no error when creating request")
+		}
+		req.Header.Set("X-Krb-Org", strconv.Itoa(int(orgID)))
+		return req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, backend))
+	}
+
+	for range 2 {
+		req := newRequest("backend")
+		if err := basic.Admit(req); err != nil {
+			t.Fatalf("Expected the request to be admitted, got %v", err)
+		}
+		basic.Record(req, 10, 100)
+	}
+
+	var exceeded *method.QuotaExceededError
+	if err := basic.Admit(newRequest("backend")); !errors.As(err, &exceeded) {
+		t.Fatalf("Expected the quota to be exceeded, got %v", err)
+	}
+	if exceeded.RetryAfter <= 0 || exceeded.RetryAfter > 24*time.Hour {
+		t.Errorf("Unexpected retry after %s", exceeded.RetryAfter)
+	}
+
+	// Other backends are not counted by the quota.
+	if err := basic.Admit(newRequest("other")); err != nil {
+		t.Fatalf("Expected the request to another backend to be admitted, got %v", err)
+	}
+
+	day := time.Now().UTC().Format(time.DateOnly)
+	usage, err := dbListUsage(t.Context(), testClient, orgID, "", day, day)
+	if err != nil {
+		t.Fatalf("dbListUsage error: %v", err)
+	}
+	if len(usage) != 1 {
+		t.Fatalf("Expected 1 usage record, got %d", len(usage))
+	}
+	expected := authbasicapi.UsageRecord{
+		Backend:       "backend",
+		Requests:      2,
+		RequestBytes:  20,
+		ResponseBytes: 200,
+	}
+	expected.Day.Time, _ = time.Parse(time.DateOnly, day)
+	if usage[0] != expected {
+		t.Errorf("Expected usage %+v, got %+v", expected, usage[0])
+	}
+}
+
+func TestQuotaAdmitConcurrent(t *testing.T) {
+	orgName := uniqueName(t, "quota-concurrent-org")
+	basic, err := New(&Opts{
+		AuthZConfig: map[string]*config.AuthZ{},
+		Quotas: []*config.AuthQuota{
+			{Organisation: orgName, Backend: "backend", RequestsPerDay: 10},
+			{Organisation: orgName, RequestsPerDay: 3},
+		},
+		SQLClient: testClient,
+		OASDir:    "something",
+	})
+	if err != nil {
+		t.Fatal("Expected no error when creating authorizer")
+	}
+
+	orgID, _ := mustCreateOrg(t, orgName)
+	var (
+		wg       sync.WaitGroup
+		admitted atomic.Int64
+	)
+	for range 10 {
+		wg.Go(func() {
+			req, _ := http.NewRequest("GET", "/api/v1/some/path", nil)
+			req.Header.Set("X-Krb-Org", strconv.Itoa(int(orgID)))
+			req = req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, "backend"))
+			if err := basic.Admit(req); err == nil {
+				admitted.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+
+	if admitted.Load() != 3 {
+		t.Errorf("Expected 3 requests to be admitted, got %d", admitted.Load())
+	}
+
+	// Requests rejected by the quota of all backends are not counted by the quota of the backend.
+	now := time.Now().UTC()
+	today, _, err := dbGetQuota(
+		t.Context(), testClient, orgID, "backend", now.Format(time.DateOnly), now.Format(monthLayout),
+	)
+	if err != nil {
+		t.Fatalf("dbGetQuota error: %v", err)
+	}
+	if today != 3 {
+		t.Errorf("Expected 3 requests to be counted against the backend quota, got %d", today)
+	}
+}
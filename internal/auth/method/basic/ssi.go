@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
+	models "github.com/trebent/kerberos/internal/auth/method/basic/model"
 	"github.com/trebent/kerberos/internal/config"
 	"github.com/trebent/kerberos/internal/db"
 	authbasicapi "github.com/trebent/kerberos/internal/oapi/auth/basic"
@@ -22,6 +24,7 @@ type (
 		db db.SQLClient
 
 		cookieCfg *config.Cookies
+		quotas    []*config.AuthQuota
 	}
 
 	// customLoginResponse is a custom implementation of [authbasicapi.LoginResponseObject] that allows us to set cookies in the response.
@@ -77,8 +80,12 @@ func makeGenAPIError(msg string) authbasicapi.APIErrorResponse {
 	return authbasicapi.APIErrorResponse{Errors: []string{msg}}
 }
 
-func newSSI(db db.SQLClient, cookieCfg *config.Cookies) authbasicapi.StrictServerInterface {
-	return &impl{db: db, cookieCfg: cookieCfg}
+func newSSI(
+	db db.SQLClient,
+	cookieCfg *config.Cookies,
+	quotas []*config.AuthQuota,
+) authbasicapi.StrictServerInterface {
+	return &impl{db: db, cookieCfg: cookieCfg, quotas: quotas}
 }
 
 // Login implements [StrictServerInterface].
@@ -253,6 +260,69 @@ func (i *impl) ChangePassword(
 	return authbasicapi.ChangePassword204Response{}, nil
 }
 
+// CreateAPIKey implements [StrictServerInterface].
+func (i *impl) CreateAPIKey(
+	ctx context.Context,
+	req authbasicapi.CreateAPIKeyRequestObject,
+) (authbasicapi.CreateAPIKeyResponseObject, error) {
+	now := time.Now()
+	if req.Body.Expires != nil && !req.Body.Expires.After(now) {
+		return authbasicapi.CreateAPIKey400JSONResponse(
+			makeGenAPIError("expires must be in the future"),
+		), nil
+	}
+
+	key, prefix := newAPIKey()
+	apiKey := &models.APIKey{
+		OrgID:     req.OrgID,
+		Name:      req.Body.Name,
+		Prefix:    prefix,
+		HashedKey: hashAPIKey(key),
+		Created:   now.UnixMilli(),
+	}
+	if req.Body.Expires != nil {
+		apiKey.Expires = req.Body.Expires.UnixMilli()
+	}
+
+	var groupIDs []int64
+	if req.Body.Groups != nil {
+		groupIDs = slices.Compact(slices.Sorted(slices.Values(*req.Body.Groups)))
+	}
+
+	id, err := dbCreateAPIKey(ctx, i.db, apiKey, groupIDs)
+	if err != nil {
+		if errors.Is(err, errNoGroup) {
+			return authbasicapi.CreateAPIKey400JSONResponse(makeGenAPIError(err.Error())), nil
+		}
+		if errors.Is(err, db.ErrUnique) {
+			return authbasicapi.CreateAPIKey409JSONResponse(apiErrConflict), nil
+		}
+		zerologr.Error(err, "Failed to create API key")
+		return authbasicapi.CreateAPIKey500JSONResponse(apiErrInternal), nil
+	}
+
+	groups, err := dbGetAPIKeyGroups(ctx, i.db, req.OrgID, id)
+	if err != nil {
+		zerologr.Error(err, "Failed to get API key groups")
+		return authbasicapi.CreateAPIKey500JSONResponse(apiErrInternal), nil
+	}
+
+	resp := authbasicapi.CreateAPIKey201JSONResponse{
+		Id:      id,
+		Name:    apiKey.Name,
+		Prefix:  prefix,
+		Key:     key,
+		Groups:  groups,
+		Created: time.UnixMilli(apiKey.Created).UTC(),
+	}
+	if apiKey.Expires > 0 {
+		expires := time.UnixMilli(apiKey.Expires).UTC()
+		resp.Expires = &expires
+	}
+
+	return resp, nil
+}
+
 // CreateGroup implements [StrictServerInterface].
 func (i *impl) CreateGroup(
 	ctx context.Context,
@@ -321,6 +391,23 @@ func (i *impl) CreateUser(
 	}, nil
 }
 
+// DeleteAPIKey implements [StrictServerInterface].
+func (i *impl) DeleteAPIKey(
+	ctx context.Context,
+	req authbasicapi.DeleteAPIKeyRequestObject,
+) (authbasicapi.DeleteAPIKeyResponseObject, error) {
+	err := dbDeleteAPIKey(ctx, i.db, req.OrgID, req.ApiKeyID)
+	if errors.Is(err, errNoAPIKey) {
+		return authbasicapi.DeleteAPIKey404Response{}, nil
+	}
+	if err != nil {
+		zerologr.Error(err, "Failed to delete API key")
+		return authbasicapi.DeleteAPIKey500JSONResponse(apiErrInternal), nil
+	}
+
+	return authbasicapi.DeleteAPIKey204Response{}, nil
+}
+
 // DeleteGroup implements [StrictServerInterface].
 func (i *impl) DeleteGroup(
 	ctx context.Context,
@@ -394,6 +481,90 @@ func (i *impl) GetOrganisation(
 	return authbasicapi.GetOrganisation200JSONResponse{Id: o.Id, Name: o.Name}, nil
 }
 
+// GetQuotaUsage implements [StrictServerInterface].
+func (i *impl) GetQuotaUsage(
+	ctx context.Context,
+	req authbasicapi.GetQuotaUsageRequestObject,
+) (authbasicapi.GetQuotaUsageResponseObject, error) {
+	o, err := dbGetOrg(ctx, i.db, req.OrgID)
+	if errors.Is(err, errNoOrg) {
+		return authbasicapi.GetQuotaUsage404JSONResponse(
+			makeGenAPIError(http.StatusText(http.StatusNotFound)),
+		), nil
+	}
+	if err != nil {
+		zerologr.Error(err, "Failed to get organisation")
+		return authbasicapi.GetQuotaUsage500JSONResponse(apiErrInternal), nil
+	}
+
+	now := time.Now().UTC()
+	quotas := orgQuotas(i.quotas, o.Name)
+	usage := make([]authbasicapi.QuotaUsage, 0, len(quotas))
+	for _, q := range quotas {
+		today, thisMonth, err := quotaUsage(ctx, i.db, req.OrgID, q, now)
+		if err != nil {
+			zerologr.Error(err, "Failed to get quota usage")
+			return authbasicapi.GetQuotaUsage500JSONResponse(apiErrInternal), nil
+		}
+
+		qu := authbasicapi.QuotaUsage{RequestsToday: today, RequestsThisMonth: thisMonth}
+		if q.Backend != "" {
+			qu.Backend = &q.Backend
+		}
+		if q.RequestsPerDay > 0 {
+			qu.RequestsPerDay = &q.RequestsPerDay
+		}
+		if q.RequestsPerMonth > 0 {
+			qu.RequestsPerMonth = &q.RequestsPerMonth
+		}
+		usage = append(usage, qu)
+	}
+
+	return authbasicapi.GetQuotaUsage200JSONResponse(usage), nil
+}
+
+// GetUsage implements [StrictServerInterface].
+func (i *impl) GetUsage(
+	ctx context.Context,
+	req authbasicapi.GetUsageRequestObject,
+) (authbasicapi.GetUsageResponseObject, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if req.Params.From != nil {
+		from = req.Params.From.Time
+	}
+	to := now
+	if req.Params.To != nil {
+		to = req.Params.To.Time
+	}
+
+	if from.After(to) {
+		return authbasicapi.GetUsage400JSONResponse(
+			makeGenAPIError("from must not be after to"),
+		), nil
+	}
+
+	var backend string
+	if req.Params.Backend != nil {
+		backend = *req.Params.Backend
+	}
+
+	records, err := dbListUsage(
+		ctx,
+		i.db,
+		req.OrgID,
+		backend,
+		from.Format(time.DateOnly),
+		to.Format(time.DateOnly),
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to list usage")
+		return authbasicapi.GetUsage500JSONResponse(apiErrInternal), nil
+	}
+
+	return authbasicapi.GetUsage200JSONResponse(records), nil
+}
+
 // GetUser implements [StrictServerInterface].
 func (i *impl) GetUser(
 	ctx context.Context,
@@ -431,6 +602,29 @@ func (i *impl) GetUserGroups(
 	return authbasicapi.GetUserGroups200JSONResponse(groups), nil
 }
 
+// ListAPIKeys implements [StrictServerInterface].
+func (i *impl) ListAPIKeys(
+	ctx context.Context,
+	req authbasicapi.ListAPIKeysRequestObject,
+) (authbasicapi.ListAPIKeysResponseObject, error) {
+	keys, err := dbListAPIKeys(ctx, i.db, req.OrgID)
+	if err != nil {
+		zerologr.Error(err, "Failed to list API keys")
+		return authbasicapi.ListAPIKeys500JSONResponse(apiErrInternal), nil
+	}
+
+	for idx := range keys {
+		groups, err := dbGetAPIKeyGroups(ctx, i.db, req.OrgID, keys[idx].Id)
+		if err != nil {
+			zerologr.Error(err, "Failed to get API key groups")
+			return authbasicapi.ListAPIKeys500JSONResponse(apiErrInternal), nil
+		}
+		keys[idx].Groups = groups
+	}
+
+	return authbasicapi.ListAPIKeys200JSONResponse(keys), nil
+}
+
 // ListGroups implements [StrictServerInterface].
 func (i *impl) ListGroups(
 	ctx context.Context,
@@ -5,6 +5,7 @@ package basic
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/trebent/kerberos/internal/config"
 	authbasicapi "github.com/trebent/kerberos/internal/oapi/auth/basic"
@@ -13,7 +14,7 @@ import (
 // TestBasicSSIRefreshNoRefreshCookie verifies that Refresh returns 401 when the context
 // contains no refresh token (simulates a missing refresh cookie).
 func TestBasicSSIRefreshNoRefreshCookie(t *testing.T) {
-	ssi := newSSI(testClient, &config.Cookies{})
+	ssi := newSSI(testClient, &config.Cookies{}, nil)
 
 	resp, err := ssi.Refresh(t.Context(), authbasicapi.RefreshRequestObject{OrgID: 0})
 	if err != nil {
@@ -27,7 +28,7 @@ func TestBasicSSIRefreshNoRefreshCookie(t *testing.T) {
 // TestBasicSSIRefresh verifies that Refresh succeeds when the context contains a refresh token
 // linked to a valid session. No session context is needed — only the refresh token.
 func TestBasicSSIRefresh(t *testing.T) {
-	ssi := newSSI(testClient, &config.Cookies{})
+	ssi := newSSI(testClient, &config.Cookies{}, nil)
 
 	orgID, userID := mustCreateOrg(t, uniqueName(t, "ssi-refresh-org"))
 
@@ -48,3 +49,32 @@ func TestBasicSSIRefresh(t *testing.T) {
 		t.Fatalf("expected customRefreshSessionResponse, got %T", resp)
 	}
 }
+
+// TestBasicSSICreateAPIKeyRejected verifies that API keys cannot be created already expired or
+// with groups of other organisations.
+func TestBasicSSICreateAPIKeyRejected(t *testing.T) {
+	ssi := newSSI(testClient, &config.Cookies{}, nil)
+
+	orgID, _ := mustCreateOrg(t, uniqueName(t, "ssi-apikey-org"))
+	otherOrgID, _ := mustCreateOrg(t, uniqueName(t, "ssi-apikey-other-org"))
+	otherGroupID := mustCreateGroup(t, otherOrgID, uniqueName(t, "ssi-apikey-group"))
+
+	expired := time.Now().Add(-time.Minute)
+	for name, body := range map[string]*authbasicapi.CreateAPIKeyJSONRequestBody{
+		"expired":         {Name: "expired", Expires: &expired},
+		"group other org": {Name: "group", Groups: &[]int64{otherGroupID}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := ssi.CreateAPIKey(
+				t.Context(),
+				authbasicapi.CreateAPIKeyRequestObject{OrgID: orgID, Body: body},
+			)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if _, ok := resp.(authbasicapi.CreateAPIKey400JSONResponse); !ok {
+				t.Fatalf("expected CreateAPIKey400JSONResponse, got %T", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package hmac
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/trebent/kerberos/internal/auth/method"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	// HMAC authenticates requests signed with a secret shared with their organisation, e.g.
+	// webhooks of partners that cannot hold sessions or tokens.
+	HMAC interface {
+		method.Method
+	}
+	hmacMethod struct {
+		cfg           *config.AuthMethodHMAC
+		config        map[string]*config.AuthZ
+		organisations map[string]*config.HMACOrganisation
+		nonces        *nonceCache
+		// now is replaced in tests.
+		now func() time.Time
+	}
+	Opts struct {
+		Cfg         *config.AuthMethodHMAC
+		AuthZConfig map[string]*config.AuthZ
+	}
+)
+
+var (
+	_ HMAC = (*hmacMethod)(nil)
+
+	errNoSignature        = errors.New("no signature header")
+	errMalformedSignature = errors.New("malformed signature header")
+	errUnknownOrg         = errors.New("unknown organisation")
+	errInvalidTimestamp   = errors.New("invalid timestamp")
+	errClockSkew          = errors.New("timestamp outside the allowed clock skew")
+	errBodyTooLarge       = errors.New("request body too large to verify")
+	errReadBody           = errors.New("failed to read request body")
+	errSignatureMismatch  = errors.New("signature mismatch")
+	errReplayedNonce      = errors.New("nonce already used")
+	errNonceCacheFull     = errors.New("nonce cache full")
+)
+
+// New returns an HMAC authentication method.
+func New(opts *Opts) (HMAC, error) {
+	if opts.AuthZConfig == nil {
+		return nil, errors.New("authorization config is required for HMAC auth method")
+	}
+
+	organisations := make(map[string]*config.HMACOrganisation, len(opts.Cfg.Organisations))
+	for _, org := range opts.Cfg.Organisations {
+		if _, ok := organisations[org.Organisation]; ok {
+			return nil, errors.New("duplicate HMAC organisation " + org.Organisation)
+		}
+		organisations[org.Organisation] = org
+	}
+
+	return &hmacMethod{
+		cfg:           opts.Cfg,
+		config:        opts.AuthZConfig,
+		organisations: organisations,
+		nonces:        newNonceCache(opts.Cfg.NonceCacheSize),
+		now:           time.Now,
+	}, nil
+}
+
+// Authenticated implements [method.Method]. The organisation of a validly signed request, its user
+// and groups replace any X-Krb-User, X-Krb-Org and X-Krb-Groups headers of the request. Rejected
+// requests return a [method.UnauthenticatedError] with the cause.
+func (h *hmacMethod) Authenticated(req *http.Request) error {
+	zerologr.V(50).Info("Authenticating request " + req.URL.Path)
+
+	org, err := h.verify(req)
+	if err != nil {
+		zerologr.V(20).Info("Denying access", "reason", err.Error())
+		return &method.UnauthenticatedError{Cause: err.Error()}
+	}
+
+	user := org.User
+	if user == "" {
+		user = org.Organisation
+	}
+
+	req.Header.Del("X-Krb-Groups")
+	req.Header.Set("X-Krb-User", user)
+	req.Header.Set("X-Krb-Org", org.Organisation)
+	for _, group := range org.Groups {
+		req.Header.Add("X-Krb-Groups", group)
+	}
+
+	return nil
+}
+
+// verify returns the organisation that signed the request. The nonce is only recorded once the
+// signature is verified, so that unsigned requests cannot fill the nonce cache.
+func (h *hmacMethod) verify(req *http.Request) (*config.HMACOrganisation, error) {
+	header := req.Header.Get(h.cfg.Header)
+	if header == "" {
+		return nil, errNoSignature
+	}
+
+	s, err := parseSignature(header)
+	if err != nil {
+		return nil, err
+	}
+
+	org, ok := h.organisations[s.org]
+	if !ok {
+		return nil, errUnknownOrg
+	}
+
+	now := h.now()
+	skew := time.Duration(h.cfg.MaxClockSkewMs) * time.Millisecond
+	if s.time.Before(now.Add(-skew)) || s.time.After(now.Add(skew)) {
+		return nil, errClockSkew
+	}
+
+	body, err := readBody(req, h.cfg.MaxBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	toSign := stringToSign(req, s.timestamp, s.nonce, h.cfg.SignedHeaders, body)
+	// During rotation either secret is accepted. Both are always tried, so that the time taken
+	// does not tell which one matched.
+	matched := false
+	for _, secret := range org.Secrets {
+		if hmac.Equal(sign([]byte(secret), toSign), s.mac) {
+			matched = true
+		}
+	}
+	if !matched {
+		return nil, errSignatureMismatch
+	}
+
+	if err := h.nonces.add(s.org, s.nonce, s.time.Add(skew), now); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// readBody reads the body of the request, up to limit bytes, and replaces it so that it can be
+// forwarded.
+func readBody(req *http.Request, limit int64) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.ContentLength > limit {
+		return nil, errBodyTooLarge
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, errReadBody
+	}
+	if int64(len(body)) > limit {
+		return nil, errBodyTooLarge
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// Authorized implements [method.Method], checking the groups of the organisation against the
+// authorization rules of the backend.
+func (h *hmacMethod) Authorized(req *http.Request) error {
+	zerologr.V(50).Info("Authorizing request " + req.URL.Path)
+	//nolint:errcheck // bigger problems if this is missing
+	backend := req.Context().Value(composer.BackendContextKey).(string)
+
+	// Authenticated has replaced the groups of the request with those of the organisation.
+	return method.AuthorizeGroups(h.config[backend], req)
+}
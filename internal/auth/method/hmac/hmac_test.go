@@ -0,0 +1,328 @@
+package hmac
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trebent/kerberos/internal/auth/method"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+)
+
+const (
+	testSecret    = "0123456789abcdef0123456789abcdef"
+	rotatedSecret = "fedcba9876543210fedcba9876543210"
+)
+
+var testNow = time.Unix(1_700_000_000, 0)
+
+func newTestHMAC(t *testing.T, authZ map[string]*config.AuthZ) *hmacMethod {
+	t.Helper()
+
+	m, err := New(&Opts{
+		Cfg: &config.AuthMethodHMAC{
+			Header:         "X-Krb-Signature",
+			SignedHeaders:  []string{"Content-Type", "X-Event"},
+			MaxClockSkewMs: 300000,
+			NonceCacheSize: 10,
+			MaxBodyBytes:   64,
+			Organisations: []*config.HMACOrganisation{
+				{
+					Organisation: "acme",
+					Secrets:      []string{testSecret, rotatedSecret},
+					Groups:       []string{"webhooks"},
+				},
+				{Organisation: "globex", Secrets: []string{rotatedSecret}, User: "globex-hooks"},
+			},
+		},
+		AuthZConfig: authZ,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create HMAC method: %v", err)
+	}
+
+	h, _ := m.(*hmacMethod)
+	h.now = func() time.Time { return testNow }
+	return h
+}
+
+// newRequest returns a request to the backend as the router passes it on, with the
+// /gw/backend/backend prefix stripped from its path.
+func newRequest(body string) *http.Request {
+	ctx := context.WithValue(context.Background(), composer.BackendContextKey, "backend")
+	ctx = context.WithValue(ctx, composer.PathContextKey, "/gw/backend/backend/hooks/orders")
+	req, _ := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		"/hooks/orders?source=acme",
+		strings.NewReader(body),
+	)
+	return req
+}
+
+// signedRequest returns a request of the organisation, signed with the secret at the time.
+func signedRequest(org, secret, nonce string, at time.Time, body string) *http.Request {
+	req := newRequest(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event", "order.created")
+
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := sign([]byte(secret), stringToSign(
+		req, timestamp, nonce, []string{"Content-Type", "X-Event"}, []byte(body),
+	))
+	req.Header.Set("X-Krb-Signature", "org="+org+", timestamp="+timestamp+", nonce="+nonce+
+		", signature="+hex.EncodeToString(mac))
+	return req
+}
+
+func TestHMACAuthenticated(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   func() *http.Request
+		cause error
+	}{
+		{
+			name: "Valid",
+			req: func() *http.Request {
+				return signedRequest("acme", testSecret, "n1", testNow, `{"id":1}`)
+			},
+		},
+		{
+			name: "Rotated secret",
+			req: func() *http.Request {
+				return signedRequest("acme", rotatedSecret, "n1", testNow, `{"id":1}`)
+			},
+		},
+		{
+			name: "Within clock skew",
+			req: func() *http.Request {
+				return signedRequest("acme", testSecret, "n1", testNow.Add(-4*time.Minute), "")
+			},
+		},
+		{
+			name: "No signature",
+			req: func() *http.Request {
+				req := signedRequest("acme", testSecret, "n1", testNow, "")
+				req.Header.Del("X-Krb-Signature")
+				return req
+			},
+			cause: errNoSignature,
+		},
+		{
+			name: "Malformed signature",
+			req: func() *http.Request {
+				req := signedRequest("acme", testSecret, "n1", testNow, "")
+				req.Header.Set("X-Krb-Signature", "org=acme,signature=zz")
+				return req
+			},
+			cause: errMalformedSignature,
+		},
+		{
+			name: "Unknown organisation",
+			req: func() *http.Request {
+				return signedRequest("initech", testSecret, "n1", testNow, "")
+			},
+			cause: errUnknownOrg,
+		},
+		{
+			name: "Wrong secret",
+			req: func() *http.Request {
+				return signedRequest("globex", testSecret, "n1", testNow, "")
+			},
+			cause: errSignatureMismatch,
+		},
+		{
+			name: "Stale timestamp",
+			req: func() *http.Request {
+				return signedRequest("acme", testSecret, "n1", testNow.Add(-6*time.Minute), "")
+			},
+			cause: errClockSkew,
+		},
+		{
+			name: "Future timestamp",
+			req: func() *http.Request {
+				return signedRequest("acme", testSecret, "n1", testNow.Add(6*time.Minute), "")
+			},
+			cause: errClockSkew,
+		},
+		{
+			name: "Tampered body",
+			req: func() *http.Request {
+				req := signedRequest("acme", testSecret, "n1", testNow, `{"id":1}`)
+				req.Body = io.NopCloser(strings.NewReader(`{"id":2}`))
+				return req
+			},
+			cause: errSignatureMismatch,
+		},
+		{
+			name: "Tampered signed header",
+			req: func() *http.Request {
+				req := signedRequest("acme", testSecret, "n1", testNow, "")
+				req.Header.Set("X-Event", "order.deleted")
+				return req
+			},
+			cause: errSignatureMismatch,
+		},
+		{
+			name: "Tampered query",
+			req: func() *http.Request {
+				req := signedRequest("acme", testSecret, "n1", testNow, "")
+				req.URL.RawQuery = "source=globex"
+				return req
+			},
+			cause: errSignatureMismatch,
+		},
+		{
+			name: "Other backend",
+			req: func() *http.Request {
+				req := signedRequest("acme", testSecret, "n1", testNow, "")
+				ctx := context.WithValue(
+					req.Context(), composer.PathContextKey, "/gw/backend/other/hooks/orders",
+				)
+				return req.WithContext(ctx)
+			},
+			cause: errSignatureMismatch,
+		},
+		{
+			name: "Body too large",
+			req: func() *http.Request {
+				return signedRequest("acme", testSecret, "n1", testNow, strings.Repeat("a", 65))
+			},
+			cause: errBodyTooLarge,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := newTestHMAC(t, map[string]*config.AuthZ{})
+
+			err := h.Authenticated(test.req())
+			if test.cause == nil {
+				if err != nil {
+					t.Errorf("Expected the request to be accepted, got %v", err)
+				}
+				return
+			}
+
+			var unauthenticated *method.UnauthenticatedError
+			if !errors.As(err, &unauthenticated) {
+				t.Fatalf("Expected an unauthenticated error, got %v", err)
+			}
+			if unauthenticated.Cause != test.cause.Error() {
+				t.Errorf("Expected cause %q, got %q", test.cause, unauthenticated.Cause)
+			}
+		})
+	}
+}
+
+// TestHMACTestVector verifies a signature computed independently of stringToSign, over the path
+// the client sent to the gateway.
+func TestHMACTestVector(t *testing.T) {
+	h := newTestHMAC(t, map[string]*config.AuthZ{})
+
+	req := newRequest(`{"id":1}`)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event", "order.created")
+	req.Header.Set("X-Krb-Signature", "org=acme,timestamp=1700000000,nonce=n1,"+
+		"signature=366be3b0f366d9ab1f46e076597e91ca8e8a017f06ede2863d28f92bbc8a34b5")
+
+	if err := h.Authenticated(req); err != nil {
+		t.Errorf("Expected the test vector to be accepted, got %v", err)
+	}
+}
+
+func TestHMACReplay(t *testing.T) {
+	h := newTestHMAC(t, map[string]*config.AuthZ{})
+
+	if err := h.Authenticated(signedRequest("acme", testSecret, "n1", testNow, "")); err != nil {
+		t.Fatalf("Expected the request to be accepted, got %v", err)
+	}
+	err := h.Authenticated(signedRequest("acme", testSecret, "n1", testNow, ""))
+	if err == nil || !strings.Contains(err.Error(), errReplayedNonce.Error()) {
+		t.Errorf("Expected the replayed request to be rejected, got %v", err)
+	}
+
+	// Nonces are per organisation.
+	if err := h.Authenticated(signedRequest("globex", rotatedSecret, "n1", testNow, "")); err != nil {
+		t.Errorf("Expected the nonce of another organisation to be accepted, got %v", err)
+	}
+
+	// Requests with an invalid signature do not use up their nonce.
+	if err := h.Authenticated(signedRequest("acme", "wrong", "n2", testNow, "")); err == nil {
+		t.Fatal("Expected the request with a wrong secret to be rejected")
+	}
+	if err := h.Authenticated(signedRequest("acme", testSecret, "n2", testNow, "")); err != nil {
+		t.Errorf("Expected the nonce to still be unused, got %v", err)
+	}
+}
+
+func TestHMACNonceCache(t *testing.T) {
+	c := newNonceCache(2)
+	expires := testNow.Add(time.Minute)
+
+	if err := c.add("acme", "n1", expires, testNow); err != nil {
+		t.Fatalf("Expected the nonce to be added, got %v", err)
+	}
+	if err := c.add("acme", "n2", testNow.Add(time.Second), testNow); err != nil {
+		t.Fatalf("Expected the nonce to be added, got %v", err)
+	}
+	if err := c.add("acme", "n3", expires, testNow); !errors.Is(err, errNonceCacheFull) {
+		t.Errorf("Expected the cache to be full, got %v", err)
+	}
+
+	// Expired nonces make room.
+	later := testNow.Add(2 * time.Second)
+	if err := c.add("acme", "n3", expires, later); err != nil {
+		t.Errorf("Expected the expired nonce to be replaced, got %v", err)
+	}
+	if err := c.add("acme", "n1", expires, later); !errors.Is(err, errReplayedNonce) {
+		t.Errorf("Expected the nonce to be replayed, got %v", err)
+	}
+}
+
+func TestHMACIdentity(t *testing.T) {
+	h := newTestHMAC(t, map[string]*config.AuthZ{
+		"backend": {Groups: []string{"webhooks"}},
+	})
+
+	req := signedRequest("acme", testSecret, "n1", testNow, `{"id":1}`)
+	// Identity headers sent by the client are replaced.
+	req.Header.Set("X-Krb-User", "admin")
+	req.Header.Add("X-Krb-Groups", "admin")
+	if err := h.Authenticated(req); err != nil {
+		t.Fatalf("Expected the request to be accepted, got %v", err)
+	}
+	if req.Header.Get("X-Krb-User") != "acme" || req.Header.Get("X-Krb-Org") != "acme" {
+		t.Errorf("Unexpected identity headers: %v", req.Header)
+	}
+	if groups := req.Header.Values("X-Krb-Groups"); !slices.Equal(groups, []string{"webhooks"}) {
+		t.Errorf("Unexpected groups: %v", groups)
+	}
+	if err := h.Authorized(req); err != nil {
+		t.Errorf("Expected the webhooks group to authorize the request, got %v", err)
+	}
+
+	// The verified body is still forwarded.
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != `{"id":1}` {
+		t.Errorf("Expected the body to be forwarded, got %q", body)
+	}
+
+	req = signedRequest("globex", rotatedSecret, "n1", testNow, "")
+	if err := h.Authenticated(req); err != nil {
+		t.Fatalf("Expected the request to be accepted, got %v", err)
+	}
+	if req.Header.Get("X-Krb-User") != "globex-hooks" {
+		t.Errorf("Unexpected user: %s", req.Header.Get("X-Krb-User"))
+	}
+	if err := h.Authorized(req); err == nil {
+		t.Error("Expected an organisation without the webhooks group to be denied access")
+	}
+}
@@ -0,0 +1,46 @@
+package hmac
+
+import (
+	"sync"
+	"time"
+)
+
+// nonceCache remembers the nonces of signed requests until their timestamp falls outside the
+// allowed clock skew, after which replays are rejected by their timestamp instead.
+type nonceCache struct {
+	mu      sync.Mutex
+	size    int
+	expires map[string]time.Time
+}
+
+func newNonceCache(size int) *nonceCache {
+	return &nonceCache{size: size, expires: make(map[string]time.Time)}
+}
+
+// add records the nonce of the organisation until it expires. It returns errReplayedNonce if the
+// nonce is already recorded, and errNonceCacheFull if there is no room for it.
+func (c *nonceCache) add(org, nonce string, expires, now time.Time) error {
+	// Headers cannot hold newlines, so the key is unambiguous.
+	key := org + "\n" + nonce
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.expires[key]; ok && now.Before(e) {
+		return errReplayedNonce
+	}
+
+	if len(c.expires) >= c.size {
+		for k, e := range c.expires {
+			if !now.Before(e) {
+				delete(c.expires, k)
+			}
+		}
+	}
+	if len(c.expires) >= c.size {
+		return errNonceCacheFull
+	}
+
+	c.expires[key] = expires
+	return nil
+}
@@ -0,0 +1,108 @@
+package hmac
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trebent/kerberos/internal/composer"
+)
+
+// signature holds the parameters of a signature header, formatted as
+// "org=<organisation>,timestamp=<unix seconds>,nonce=<nonce>,signature=<hex>".
+type signature struct {
+	org string
+	// timestamp is signed as sent, time is when it was signed.
+	timestamp string
+	time      time.Time
+	nonce     string
+	mac       []byte
+}
+
+const maxNonceLength = 128
+
+// parseSignature parses the signature header of a request.
+func parseSignature(header string) (*signature, error) {
+	var s signature
+	for param := range strings.SplitSeq(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			return nil, errMalformedSignature
+		}
+
+		switch key {
+		case "org":
+			s.org = value
+		case "timestamp":
+			s.timestamp = value
+		case "nonce":
+			s.nonce = value
+		case "signature":
+			mac, err := hex.DecodeString(value)
+			if err != nil {
+				return nil, errMalformedSignature
+			}
+			s.mac = mac
+		}
+	}
+
+	if s.org == "" || s.timestamp == "" || s.nonce == "" || len(s.mac) == 0 {
+		return nil, errMalformedSignature
+	}
+	if len(s.nonce) > maxNonceLength {
+		return nil, errMalformedSignature
+	}
+
+	unix, err := strconv.ParseInt(s.timestamp, 10, 64)
+	if err != nil {
+		return nil, errInvalidTimestamp
+	}
+	s.time = time.Unix(unix, 0)
+
+	return &s, nil
+}
+
+// stringToSign returns what is signed for a request: its method, path and query, the timestamp
+// and nonce of the signature, the signed headers and the SHA-256 digest of the body, separated by
+// newlines. Signed headers are written as "<lower case name>:<values joined by commas>". The path
+// is the one received by the gateway, so that the signature covers the backend the request is
+// addressed to.
+func stringToSign(
+	req *http.Request,
+	timestamp, nonce string,
+	signedHeaders []string,
+	body []byte,
+) string {
+	target := composer.ReceivedPath(req)
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+
+	var b strings.Builder
+	b.WriteString(req.Method + "\n")
+	b.WriteString(target + "\n")
+	b.WriteString(timestamp + "\n")
+	b.WriteString(nonce + "\n")
+	for _, name := range signedHeaders {
+		var values []string
+		for _, value := range req.Header.Values(name) {
+			values = append(values, strings.TrimSpace(value))
+		}
+		b.WriteString(strings.ToLower(name) + ":" + strings.Join(values, ",") + "\n")
+	}
+	digest := sha256.Sum256(body)
+	b.WriteString(hex.EncodeToString(digest[:]))
+
+	return b.String()
+}
+
+// sign returns the signature of the string to sign with the secret.
+func sign(secret []byte, toSign string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(toSign))
+	return mac.Sum(nil)
+}
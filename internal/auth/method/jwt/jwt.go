@@ -0,0 +1,148 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/trebent/kerberos/internal/auth/method"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	// JWT authenticates requests bearing a JSON Web Token, signed by one of the configured keys, in
+	// their Authorization header.
+	JWT interface {
+		method.Method
+
+		// StartKeyReload reloads the key files periodically until ctx is done.
+		StartKeyReload(ctx context.Context)
+	}
+	jwt struct {
+		cfg    *config.AuthMethodJWT
+		config map[string]*config.AuthZ
+		keys   atomic.Pointer[KeySet]
+		// now is replaced in tests.
+		now func() time.Time
+	}
+	Opts struct {
+		Cfg         *config.AuthMethodJWT
+		AuthZConfig map[string]*config.AuthZ
+	}
+)
+
+var (
+	_ JWT = (*jwt)(nil)
+
+	errNoBearer = errors.New("no bearer token")
+)
+
+// New returns a JWT authentication method, loading its keys once.
+func New(opts *Opts) (JWT, error) {
+	if opts.AuthZConfig == nil {
+		return nil, errors.New("authorization config is required for JWT auth method")
+	}
+
+	keys, err := loadKeys(opts.Cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &jwt{
+		cfg:    opts.Cfg,
+		config: opts.AuthZConfig,
+		now:    time.Now,
+	}
+	j.keys.Store(keys)
+
+	return j, nil
+}
+
+// StartKeyReload implements [JWT].
+func (j *jwt) StartKeyReload(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(j.cfg.Keys.ReloadIntervalMs) * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.reloadKeys()
+			}
+		}
+	}()
+}
+
+// reloadKeys replaces the keys with the current content of the key files. The previous keys are
+// kept if the files cannot be loaded.
+func (j *jwt) reloadKeys() {
+	keys, err := loadKeys(j.cfg.Keys)
+	if err != nil {
+		zerologr.Error(err, "Failed to reload JWT keys, keeping the previous keys")
+		return
+	}
+
+	j.keys.Store(keys)
+	zerologr.V(20).Info("Reloaded JWT keys", "keys", keys.Len())
+}
+
+// Authenticated implements [method.Method]. The user, organisation and groups of a valid token
+// replace any X-Krb-User, X-Krb-Org and X-Krb-Groups headers of the request.
+func (j *jwt) Authenticated(req *http.Request) error {
+	zerologr.V(50).Info("Authenticating request " + req.URL.Path)
+
+	c, err := j.verify(req)
+	if err != nil {
+		zerologr.V(20).Info("Denying access", "reason", err.Error())
+		return apierror.ErrUnauthorized
+	}
+
+	req.Header.Del("X-Krb-User")
+	req.Header.Del("X-Krb-Org")
+	req.Header.Del("X-Krb-Groups")
+
+	if user := c.String(j.cfg.Claims.User); user != "" {
+		req.Header.Set("X-Krb-User", user)
+	}
+	if j.cfg.Claims.Organisation != "" {
+		if org := c.String(j.cfg.Claims.Organisation); org != "" {
+			req.Header.Set("X-Krb-Org", org)
+		}
+	}
+	for _, claim := range j.cfg.Claims.Groups {
+		for _, group := range c.Strings(claim) {
+			req.Header.Add("X-Krb-Groups", group)
+		}
+	}
+
+	return nil
+}
+
+// verify returns the claims of the bearer token of the request, if it is valid.
+func (j *jwt) verify(req *http.Request) (Claims, error) {
+	scheme, raw, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || raw == "" {
+		return nil, errNoBearer
+	}
+
+	return Verify(strings.TrimSpace(raw), j.cfg, j.keys.Load(), j.now())
+}
+
+// Authorized implements [method.Method], checking the groups of the token against the
+// authorization rules of the backend.
+func (j *jwt) Authorized(req *http.Request) error {
+	zerologr.V(50).Info("Authorizing request " + req.URL.Path)
+	//nolint:errcheck // bigger problems if this is missing
+	backend := req.Context().Value(composer.BackendContextKey).(string)
+
+	// Authenticated has replaced the groups of the request with those of the token.
+	return method.AuthorizeGroups(j.config[backend], req)
+}
@@ -0,0 +1,383 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+)
+
+// testKeys are the signing keys of the tests, their public halves are published in a JWKS file
+// and a PEM file.
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ecdsa   *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+	secret  []byte
+}
+
+var testNow = time.Unix(1_700_000_000, 0)
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	return &testKeys{rsa: rsaKey, ecdsa: ecdsaKey, ed25519: edKey, secret: []byte("hmac-secret")}
+}
+
+// writeKeyFiles writes the RSA, ECDSA and HMAC keys to a JWKS file, and the Ed25519 key to a PEM
+// file.
+func (k *testKeys) writeKeyFiles(t *testing.T) *config.JWTKeys {
+	t.Helper()
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	ecPoint, err := k.ecdsa.PublicKey.Bytes()
+	if err != nil {
+		t.Fatalf("Failed to encode ECDSA key: %v", err)
+	}
+	set := map[string]any{"keys": []map[string]string{
+		{
+			"kty": "RSA",
+			"kid": "rsa",
+			"n":   b64(k.rsa.N.Bytes()),
+			"e":   b64([]byte{1, 0, 1}),
+		},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecPoint[1:33]), "y": b64(ecPoint[33:])},
+		{"kty": "oct", "kid": "hmac", "alg": config.JWTAlgHS256, "k": b64(k.secret)},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": b64(k.rsa.N.Bytes()), "e": "AQAB"},
+	}}
+	data, _ := json.Marshal(set)
+
+	dir := t.TempDir()
+	jwksFile := filepath.Join(dir, "jwks.json")
+	if err := os.WriteFile(jwksFile, data, 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS file: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(k.ed25519.Public())
+	if err != nil {
+		t.Fatalf("Failed to marshal Ed25519 key: %v", err)
+	}
+	pemFile := filepath.Join(dir, "keys.pem")
+	if err := os.WriteFile(
+		pemFile,
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		0o600,
+	); err != nil {
+		t.Fatalf("Failed to write PEM file: %v", err)
+	}
+
+	return &config.JWTKeys{JWKSFile: jwksFile, PEMFiles: []string{pemFile}}
+}
+
+// sign returns a token with the claims, signed with the key of the algorithm.
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	hdr, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := b64(hdr) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var (
+		signature []byte
+		err       error
+	)
+	switch alg {
+	case config.JWTAlgRS256:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+	case config.JWTAlgES256:
+		var r, s []byte
+		rInt, sInt, signErr := ecdsa.Sign(rand.Reader, k.ecdsa, digest[:])
+		r, s, err = rInt.FillBytes(make([]byte, 32)), sInt.FillBytes(make([]byte, 32)), signErr
+		signature = append(r, s...)
+	case config.JWTAlgEdDSA:
+		signature = ed25519.Sign(k.ed25519, []byte(signingInput))
+	case config.JWTAlgHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	}
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	return signingInput + "." + b64(signature)
+}
+
+func newTestJWT(t *testing.T, keys *config.JWTKeys, authZ map[string]*config.AuthZ) *jwt {
+	t.Helper()
+
+	cfg := &config.AuthMethodJWT{
+		Issuer:    "https://idp.example.com",
+		Audiences: []string{"kerberos"},
+		Algorithms: []string{
+			config.JWTAlgRS256,
+			config.JWTAlgES256,
+			config.JWTAlgEdDSA,
+			config.JWTAlgHS256,
+		},
+		ClockSkewMs: 60000,
+		Keys:        keys,
+		Claims: &config.JWTClaims{
+			User:         "sub",
+			Organisation: "org.id",
+			Groups:       []string{"groups", "scope"},
+		},
+	}
+	m, err := New(&Opts{Cfg: cfg, AuthZConfig: authZ})
+	if err != nil {
+		t.Fatalf("Failed to create JWT method: %v", err)
+	}
+
+	j, _ := m.(*jwt)
+	j.now = func() time.Time { return testNow }
+	return j
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss": "https://idp.example.com",
+		"aud": []string{"other", "kerberos"},
+		"sub": "user-1",
+		"exp": testNow.Add(time.Minute).Unix(),
+	}
+}
+
+// tamper returns the header and claims of the forged token with the signature of the original.
+func tamper(original, forged string) string {
+	return forged[:strings.LastIndex(forged, ".")] + original[strings.LastIndex(original, "."):]
+}
+
+func newRequest(token string) *http.Request {
+	req, _ := http.NewRequestWithContext(
+		context.WithValue(context.Background(), composer.BackendContextKey, "backend"),
+		http.MethodGet,
+		"/orders/1",
+		nil,
+	)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestJWTAuthenticated(t *testing.T) {
+	keys := newTestKeys(t)
+	j := newTestJWT(t, keys.writeKeyFiles(t), map[string]*config.AuthZ{})
+
+	with := func(change func(map[string]any)) map[string]any {
+		c := validClaims()
+		change(c)
+		return c
+	}
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{name: "RS256", token: keys.sign(t, config.JWTAlgRS256, "rsa", validClaims()), valid: true},
+		{name: "ES256", token: keys.sign(t, config.JWTAlgES256, "ec", validClaims()), valid: true},
+		{name: "EdDSA from PEM", token: keys.sign(t, config.JWTAlgEdDSA, "", validClaims()), valid: true},
+		{name: "HS256", token: keys.sign(t, config.JWTAlgHS256, "hmac", validClaims()), valid: true},
+		{name: "No token"},
+		{name: "Malformed", token: "not.a-token"},
+		{name: "Unknown key ID", token: keys.sign(t, config.JWTAlgRS256, "unknown", validClaims())},
+		{name: "Encryption key", token: keys.sign(t, config.JWTAlgRS256, "enc", validClaims())},
+		{
+			name: "Tampered",
+			token: tamper(
+				keys.sign(t, config.JWTAlgRS256, "rsa", validClaims()),
+				keys.sign(t, config.JWTAlgRS256, "rsa", with(func(c map[string]any) {
+					c["sub"] = "admin"
+				})),
+			),
+		},
+		{
+			name: "Wrong issuer",
+			token: keys.sign(t, config.JWTAlgRS256, "rsa", with(func(c map[string]any) {
+				c["iss"] = "https://evil.example.com"
+			})),
+		},
+		{
+			name: "Wrong audience",
+			token: keys.sign(t, config.JWTAlgRS256, "rsa", with(func(c map[string]any) {
+				c["aud"] = "other"
+			})),
+		},
+		{
+			name: "Audience with a space",
+			token: keys.sign(t, config.JWTAlgRS256, "rsa", with(func(c map[string]any) {
+				c["aud"] = "other kerberos"
+			})),
+		},
+		{
+			name: "Expired within skew",
+			token: keys.sign(t, config.JWTAlgRS256, "rsa", with(func(c map[string]any) {
+				c["exp"] = testNow.Add(-30 * time.Second).Unix()
+			})),
+			valid: true,
+		},
+		{
+			name: "Expired",
+			token: keys.sign(t, config.JWTAlgRS256, "rsa", with(func(c map[string]any) {
+				c["exp"] = testNow.Add(-2 * time.Minute).Unix()
+			})),
+		},
+		{
+			name: "No expiry",
+			token: keys.sign(t, config.JWTAlgRS256, "rsa", with(func(c map[string]any) {
+				delete(c, "exp")
+			})),
+		},
+		{
+			name: "Not yet valid",
+			token: keys.sign(t, config.JWTAlgRS256, "rsa", with(func(c map[string]any) {
+				c["nbf"] = testNow.Add(2 * time.Minute).Unix()
+			})),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := j.Authenticated(newRequest(test.token))
+			if test.valid && err != nil {
+				t.Errorf("Expected the token to be valid, got %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("Expected the token to be rejected")
+			}
+		})
+	}
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	keys := newTestKeys(t)
+	j := newTestJWT(t, keys.writeKeyFiles(t), map[string]*config.AuthZ{})
+	j.cfg.Algorithms = []string{config.JWTAlgES256}
+
+	if err := j.Authenticated(
+		newRequest(keys.sign(t, config.JWTAlgRS256, "rsa", validClaims())),
+	); err == nil {
+		t.Error("Expected a token signed with an algorithm that is not accepted to be rejected")
+	}
+
+	// A public RSA key must never be used as an HMAC secret.
+	j.cfg.Algorithms = []string{config.JWTAlgHS256}
+	keys.secret = keys.rsa.N.Bytes()
+	if err := j.Authenticated(
+		newRequest(keys.sign(t, config.JWTAlgHS256, "rsa", validClaims())),
+	); err == nil {
+		t.Error("Expected a token signed with the public key as HMAC secret to be rejected")
+	}
+}
+
+func TestJWTClaims(t *testing.T) {
+	keys := newTestKeys(t)
+	j := newTestJWT(t, keys.writeKeyFiles(t), map[string]*config.AuthZ{
+		"backend": {
+			Groups: []string{"staff"},
+			Paths:  map[string][]string{"/orders/*": {"orders:read"}},
+		},
+	})
+
+	claims := validClaims()
+	claims["org"] = map[string]any{"id": 42}
+	claims["groups"] = []string{"staff"}
+	claims["scope"] = "profile orders:read"
+	req := newRequest(keys.sign(t, config.JWTAlgRS256, "rsa", claims))
+	// Identity headers sent by the client are replaced.
+	req.Header.Set("X-Krb-Org", "1")
+	req.Header.Add("X-Krb-Groups", "admin")
+
+	if err := j.Authenticated(req); err != nil {
+		t.Fatalf("Expected the token to be valid, got %v", err)
+	}
+	if req.Header.Get("X-Krb-User") != "user-1" || req.Header.Get("X-Krb-Org") != "42" {
+		t.Errorf("Unexpected identity headers: %v", req.Header)
+	}
+	groups := req.Header.Values("X-Krb-Groups")
+	if !slices.Equal(groups, []string{"staff", "profile", "orders:read"}) {
+		t.Errorf("Unexpected groups: %v", groups)
+	}
+
+	if err := j.Authorized(req); err != nil {
+		t.Errorf("Expected the path group to authorize the request, got %v", err)
+	}
+
+	claims["scope"] = "profile"
+	req = newRequest(keys.sign(t, config.JWTAlgRS256, "rsa", claims))
+	if err := j.Authenticated(req); err != nil {
+		t.Fatalf("Expected the token to be valid, got %v", err)
+	}
+	if err := j.Authorized(req); err == nil {
+		t.Error("Expected the path rule to override the groups of the backend")
+	}
+}
+
+func TestJWTKeyReload(t *testing.T) {
+	keys := newTestKeys(t)
+	keyFiles := keys.writeKeyFiles(t)
+	j := newTestJWT(t, keyFiles, map[string]*config.AuthZ{})
+
+	// Rotate the Ed25519 key.
+	rotated := newTestKeys(t)
+	rotatedFiles := rotated.writeKeyFiles(t)
+	data, err := os.ReadFile(rotatedFiles.PEMFiles[0])
+	if err != nil {
+		t.Fatalf("Failed to read PEM file: %v", err)
+	}
+	if err := os.WriteFile(keyFiles.PEMFiles[0], data, 0o600); err != nil {
+		t.Fatalf("Failed to write PEM file: %v", err)
+	}
+
+	token := rotated.sign(t, config.JWTAlgEdDSA, "", validClaims())
+	if err := j.Authenticated(newRequest(token)); err == nil {
+		t.Fatal("Expected the rotated key to be unknown before the reload")
+	}
+
+	j.reloadKeys()
+	if err := j.Authenticated(newRequest(token)); err != nil {
+		t.Errorf("Expected the rotated key to be loaded, got %v", err)
+	}
+
+	// Keys that fail to load do not replace the current ones.
+	if err := os.WriteFile(keyFiles.JWKSFile, []byte("{"), 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS file: %v", err)
+	}
+	j.reloadKeys()
+	if err := j.Authenticated(newRequest(token)); err != nil {
+		t.Errorf("Expected the previous keys to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	// KeySet holds the keys that verify the signatures of tokens.
+	KeySet struct {
+		keys []*key
+	}
+	// key verifies the signatures of tokens, it holds either a public key or an HS256 secret.
+	key struct {
+		kid string
+		// alg restricts the key to one algorithm, if set.
+		alg    string
+		public crypto.PublicKey
+		secret []byte
+	}
+	// jwks is a JSON Web Key Set, RFC 7517.
+	jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+		Crv string `json:"crv"`
+		// RSA.
+		N string `json:"n"`
+		E string `json:"e"`
+		// EC and OKP.
+		X string `json:"x"`
+		Y string `json:"y"`
+		// Symmetric.
+		K string `json:"k"`
+	}
+)
+
+var (
+	errNoKeys          = errors.New("no keys loaded")
+	errUnsupportedKey  = errors.New("unsupported key")
+	errInvalidKeyParam = errors.New("invalid key parameter")
+)
+
+// usableFor reports whether the key may verify a token with the algorithm and key ID. Keys without
+// an ID are tried for all tokens, tokens without an ID are verified with all keys.
+func (k *key) usableFor(alg, kid string) bool {
+	if k.alg != "" && k.alg != alg {
+		return false
+	}
+	if k.kid != "" && kid != "" && k.kid != kid {
+		return false
+	}
+
+	switch alg {
+	case config.JWTAlgRS256:
+		_, ok := k.public.(*rsa.PublicKey)
+		return ok
+	case config.JWTAlgES256:
+		pub, ok := k.public.(*ecdsa.PublicKey)
+		return ok && pub.Curve == elliptic.P256()
+	case config.JWTAlgEdDSA:
+		_, ok := k.public.(ed25519.PublicKey)
+		return ok
+	case config.JWTAlgHS256:
+		return k.secret != nil
+	default:
+		return false
+	}
+}
+
+// Len returns the number of keys in the set.
+func (ks *KeySet) Len() int {
+	return len(ks.keys)
+}
+
+// loadKeys reads all configured key files, failing if any of them cannot be read or no key is
+// found.
+func loadKeys(cfg *config.JWTKeys) (*KeySet, error) {
+	var keys []*key
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading JWKS file: %w", err)
+		}
+		parsed, err := ParseJWKS(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing JWKS file %s: %w", cfg.JWKSFile, err)
+		}
+		keys = append(keys, parsed.keys...)
+	}
+
+	for _, file := range cfg.PEMFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading PEM file: %w", err)
+		}
+		parsed, err := parsePEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing PEM file %s: %w", file, err)
+		}
+		keys = append(keys, parsed...)
+	}
+
+	if len(keys) == 0 {
+		return nil, errNoKeys
+	}
+	return &KeySet{keys: keys}, nil
+}
+
+// ParseJWKS parses the signature keys of a JSON Web Key Set. Encryption keys and keys of
+// unsupported types are skipped.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make([]*key, 0, len(set.Keys))
+	for _, jk := range set.Keys {
+		if jk.Use != "" && jk.Use != "sig" {
+			continue
+		}
+
+		k, err := jk.key()
+		if errors.Is(err, errUnsupportedKey) {
+			zerologr.Info("Skipping unsupported JWK", "kid", jk.Kid, "kty", jk.Kty, "crv", jk.Crv)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jk.Kid, err)
+		}
+		keys = append(keys, k)
+	}
+
+	return &KeySet{keys: keys}, nil
+}
+
+func (jk *jwk) key() (*key, error) {
+	k := &key{kid: jk.Kid, alg: jk.Alg}
+
+	switch {
+	case jk.Kty == "RSA":
+		n, err := decodeParam("n", jk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeParam("e", jk.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("%w: e", errInvalidKeyParam)
+		}
+		k.public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	case jk.Kty == "EC" && jk.Crv == "P-256":
+		x, err := decodeParam("x", jk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeParam("y", jk.Y)
+		if err != nil {
+			return nil, err
+		}
+		// The uncompressed point encoding is validated to be on the curve.
+		point := append([]byte{4}, append(pad(x, 32), pad(y, 32)...)...)
+		pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidKeyParam, err)
+		}
+		k.public = pub
+	case jk.Kty == "OKP" && jk.Crv == "Ed25519":
+		x, err := decodeParam("x", jk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: x", errInvalidKeyParam)
+		}
+		k.public = ed25519.PublicKey(x)
+	case jk.Kty == "oct":
+		secret, err := decodeParam("k", jk.K)
+		if err != nil {
+			return nil, err
+		}
+		k.secret = secret
+	default:
+		return nil, errUnsupportedKey
+	}
+
+	return k, nil
+}
+
+func decodeParam(name, value string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 {
+		return nil, fmt.Errorf("%w: %s", errInvalidKeyParam, name)
+	}
+	return decoded, nil
+}
+
+// pad left pads a big-endian coordinate to size bytes.
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+// parsePEM parses the public keys and certificates of a PEM file. The keys have no ID, so they are
+// tried for every token of their algorithm.
+func parsePEM(data []byte) ([]*key, error) {
+	var keys []*key
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var (
+			pub any
+			err error
+		)
+		switch block.Type {
+		case "PUBLIC KEY":
+			pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				pub = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, &key{public: pub})
+	}
+
+	return keys, nil
+}
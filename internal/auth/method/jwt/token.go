@@ -0,0 +1,273 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+)
+
+type (
+	// header is the JOSE header of a token.
+	header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid,omitempty"`
+	}
+	// Claims are the decoded claims of a token.
+	Claims map[string]any
+	// token is a parsed, not yet verified, compact JWS.
+	token struct {
+		header       header
+		claims       Claims
+		signingInput []byte
+		signature    []byte
+	}
+)
+
+// es256SignatureSize is the size of an ES256 signature, the concatenated 32 byte R and S.
+const es256SignatureSize = 64
+
+var (
+	errMalformed        = errors.New("malformed token")
+	errAlgorithm        = errors.New("algorithm not accepted")
+	errSignature        = errors.New("invalid signature")
+	errNoKey            = errors.New("no matching key")
+	errIssuer           = errors.New("issuer not accepted")
+	errAudience         = errors.New("audience not accepted")
+	errExpired          = errors.New("token expired")
+	errNotYetValid      = errors.New("token not yet valid")
+	errMissingExpiry    = errors.New("token has no expiry")
+	errInvalidTimeClaim = errors.New("invalid time claim")
+)
+
+// Verify parses a compact JWS, verifies its signature with one of the keys and validates its
+// registered claims against the configuration, returning its claims.
+func Verify(raw string, cfg *config.AuthMethodJWT, keys *KeySet, now time.Time) (Claims, error) {
+	t, err := parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.verify(cfg.Algorithms, keys.keys); err != nil {
+		return nil, err
+	}
+	if err := t.claims.validate(cfg, now); err != nil {
+		return nil, err
+	}
+
+	return t.claims, nil
+}
+
+// parse splits a compact JWS into its parts and decodes its header and claims.
+func parse(raw string) (*token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", errMalformed, len(parts))
+	}
+
+	t := &token{signingInput: []byte(parts[0] + "." + parts[1])}
+	if err := decodeSegment(parts[0], &t.header); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", errMalformed, err)
+	}
+	if err := decodeSegment(parts[1], &t.claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %w", errMalformed, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %w", errMalformed, err)
+	}
+	t.signature = signature
+
+	return t, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// verify checks the signature of the token with the first of the keys that verifies it. Keys are
+// only tried for the algorithm they are meant for, so that e.g. a public RSA key is never used as
+// an HS256 secret.
+func (t *token) verify(algorithms []string, keys []*key) error {
+	if !slices.Contains(algorithms, t.header.Alg) {
+		return fmt.Errorf("%w: %q", errAlgorithm, t.header.Alg)
+	}
+
+	tried := false
+	for _, k := range keys {
+		if !k.usableFor(t.header.Alg, t.header.Kid) {
+			continue
+		}
+
+		tried = true
+		if verifySignature(t.header.Alg, k, t.signingInput, t.signature) {
+			return nil
+		}
+	}
+
+	if !tried {
+		return fmt.Errorf("%w: alg %q kid %q", errNoKey, t.header.Alg, t.header.Kid)
+	}
+	return errSignature
+}
+
+func verifySignature(alg string, k *key, signingInput, signature []byte) bool {
+	digest := sha256.Sum256(signingInput)
+
+	switch alg {
+	case config.JWTAlgRS256:
+		pub, ok := k.public.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil
+	case config.JWTAlgES256:
+		pub, ok := k.public.(*ecdsa.PublicKey)
+		if !ok || len(signature) != es256SignatureSize {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:es256SignatureSize/2])
+		s := new(big.Int).SetBytes(signature[es256SignatureSize/2:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	case config.JWTAlgEdDSA:
+		pub, ok := k.public.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signingInput, signature)
+	case config.JWTAlgHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(signingInput)
+		return len(k.secret) > 0 && hmac.Equal(mac.Sum(nil), signature)
+	default:
+		return false
+	}
+}
+
+// validate checks the registered claims of the token. The expiry is required, the times are
+// checked with the clock skew as leeway.
+func (c Claims) validate(cfg *config.AuthMethodJWT, now time.Time) error {
+	if iss, _ := c["iss"].(string); iss != cfg.Issuer {
+		return fmt.Errorf("%w: %q", errIssuer, iss)
+	}
+
+	if !slices.ContainsFunc(c.audiences(), func(aud string) bool {
+		return slices.Contains(cfg.Audiences, aud)
+	}) {
+		return errAudience
+	}
+
+	skew := time.Duration(cfg.ClockSkewMs) * time.Millisecond
+	exp, ok, err := c.time("exp")
+	switch {
+	case err != nil:
+		return err
+	case !ok:
+		return errMissingExpiry
+	case !now.Add(-skew).Before(exp):
+		return errExpired
+	}
+
+	nbf, ok, err := c.time("nbf")
+	switch {
+	case err != nil:
+		return err
+	case ok && now.Add(skew).Before(nbf):
+		return errNotYetValid
+	}
+
+	return nil
+}
+
+// audiences returns the "aud" claim. Unlike space-separated claims read with Strings, a string
+// audience is a single value, see RFC 7519 section 4.1.3.
+func (c Claims) audiences() []string {
+	if aud, ok := c["aud"].(string); ok {
+		return []string{aud}
+	}
+
+	return c.Strings("aud")
+}
+
+// time returns a NumericDate claim, ok is false if the token does not have the claim.
+func (c Claims) time(name string) (time.Time, bool, error) {
+	v, ok := c[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %s", errInvalidTimeClaim, name)
+	}
+	seconds, err := n.Float64()
+	if err != nil || math.IsInf(seconds, 0) {
+		return time.Time{}, false, fmt.Errorf("%w: %s", errInvalidTimeClaim, name)
+	}
+
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))), true, nil
+}
+
+// lookup returns the claim at the dot separated path, descending into nested objects.
+func (c Claims) lookup(path string) (any, bool) {
+	var current any = map[string]any(c)
+	for name := range strings.SplitSeq(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// String returns a string or number claim as a string, or an empty string.
+func (c Claims) String(path string) string {
+	v, _ := c.lookup(path)
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+// Strings returns a claim holding a list of strings, or a single space separated string of them.
+func (c Claims) Strings(path string) []string {
+	v, _ := c.lookup(path)
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -1,7 +1,14 @@
 package method
 
 import (
+	"errors"
 	"net/http"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
 )
 
 type (
@@ -9,4 +16,83 @@ type (
 		Authenticated(*http.Request) error
 		Authorized(*http.Request) error
 	}
+	// Metered is implemented by methods that enforce quotas on, and record the usage of,
+	// authenticated requests.
+	Metered interface {
+		// Admit returns a *QuotaExceededError when the request would exceed a quota of its
+		// organisation.
+		Admit(*http.Request) error
+		// Record records the usage of a served request.
+		Record(req *http.Request, requestBytes, responseBytes int64)
+	}
+	// QuotaExceededError is returned by Admit when a quota is used up, RetryAfter is the time
+	// until the quota period ends.
+	QuotaExceededError struct {
+		RetryAfter time.Duration
+	}
+	// UnauthenticatedError is returned by Authenticated with the cause of a rejected request, which
+	// is recorded by the debug transition of the authorizer but not sent to the client.
+	UnauthenticatedError struct {
+		Cause string
+	}
 )
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+func (e *QuotaExceededError) Error() string {
+	return ErrQuotaExceeded.Error()
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+func (e *UnauthenticatedError) Error() string {
+	return apierror.ErrUnauthorized.Error() + ": " + e.Cause
+}
+
+func (e *UnauthenticatedError) Unwrap() error {
+	return apierror.ErrUnauthorized
+}
+
+// RequiredGroups returns the groups of which a user must be a member of at least one to access
+// the path of a backend with the authorization rules, path rules override the groups of the
+// backend. It returns nil if access is not restricted to any groups.
+func RequiredGroups(authZ *config.AuthZ, reqPath string) ([]string, error) {
+	if authZ == nil {
+		return nil, nil
+	}
+
+	for p, pathGroups := range authZ.Paths {
+		match, err := path.Match(p, reqPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if match && len(pathGroups) > 0 {
+			return pathGroups, nil
+		}
+	}
+
+	return authZ.Groups, nil
+}
+
+// AuthorizeGroups checks the X-Krb-Groups headers of the request against the authorization rules
+// of its backend, for methods that set the groups of the user when authenticating it.
+func AuthorizeGroups(authZ *config.AuthZ, req *http.Request) error {
+	required, err := RequiredGroups(authZ, req.URL.Path)
+	if err != nil {
+		return err
+	}
+	if len(required) == 0 {
+		return nil
+	}
+
+	if slices.ContainsFunc(req.Header.Values("X-Krb-Groups"), func(group string) bool {
+		return slices.Contains(required, group)
+	}) {
+		return nil
+	}
+
+	return apierror.ErrForbidden
+}
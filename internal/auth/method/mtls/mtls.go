@@ -0,0 +1,158 @@
+package mtls
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/trebent/kerberos/internal/auth/method"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	// MTLS authenticates requests by the client certificate verified by the gateway listener,
+	// mapping its subject and SANs onto an identity.
+	MTLS interface {
+		method.Method
+	}
+	mtls struct {
+		cfg    *config.AuthMethodMTLS
+		config map[string]*config.AuthZ
+	}
+	Opts struct {
+		Cfg         *config.AuthMethodMTLS
+		AuthZConfig map[string]*config.AuthZ
+	}
+)
+
+var (
+	_ MTLS = (*mtls)(nil)
+
+	errNoCertificate = errors.New("no verified client certificate")
+	errNoIdentity    = errors.New("client certificate matches no identity")
+)
+
+// New returns an mTLS authentication method. The gateway listener must verify client certificates
+// for it to authenticate any request.
+func New(opts *Opts) (MTLS, error) {
+	if opts.AuthZConfig == nil {
+		return nil, errors.New("authorization config is required for mTLS auth method")
+	}
+
+	for i, identity := range opts.Cfg.Identities {
+		for _, pattern := range []string{identity.Subject, identity.DNS, identity.URI} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q of mTLS identity %d: %w", pattern, i, err)
+			}
+		}
+	}
+
+	return &mtls{cfg: opts.Cfg, config: opts.AuthZConfig}, nil
+}
+
+// Authenticated implements [method.Method]. The user, organisation and groups of the identity
+// matching the client certificate replace any X-Krb-User, X-Krb-Org and X-Krb-Groups headers of
+// the request.
+func (m *mtls) Authenticated(req *http.Request) error {
+	zerologr.V(50).Info("Authenticating request " + req.URL.Path)
+
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		zerologr.V(20).Info("Denying access", "reason", errNoCertificate.Error())
+		return apierror.ErrUnauthorized
+	}
+
+	cert := req.TLS.VerifiedChains[0][0]
+	identity, user := m.identify(cert)
+	if identity == nil {
+		zerologr.V(20).Info(
+			"Denying access",
+			"reason", errNoIdentity.Error(),
+			"subject", cert.Subject.String(),
+		)
+		return apierror.ErrUnauthorized
+	}
+
+	req.Header.Del("X-Krb-Org")
+	req.Header.Del("X-Krb-Groups")
+	req.Header.Set("X-Krb-User", user)
+	if identity.Organisation != "" {
+		req.Header.Set("X-Krb-Org", identity.Organisation)
+	}
+	for _, group := range identity.Groups {
+		req.Header.Add("X-Krb-Groups", group)
+	}
+
+	return nil
+}
+
+// identify returns the first identity matching the certificate and the user it is authenticated
+// as, or nil if no identity matches.
+func (m *mtls) identify(cert *x509.Certificate) (*config.MTLSIdentity, string) {
+	subject := cert.Subject.String()
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	for _, identity := range m.cfg.Identities {
+		user := subject
+		if identity.Subject != "" && !match(identity.Subject, subject) {
+			continue
+		}
+
+		if identity.DNS != "" {
+			dns, ok := matchAny(identity.DNS, cert.DNSNames)
+			if !ok {
+				continue
+			}
+			user = dns
+		}
+
+		if identity.URI != "" {
+			uri, ok := matchAny(identity.URI, uris)
+			if !ok {
+				continue
+			}
+			user = uri
+		}
+
+		if identity.User != "" {
+			user = identity.User
+		}
+		return identity, user
+	}
+
+	return nil, ""
+}
+
+// match reports whether the value matches the pattern, which New has validated.
+func match(pattern, value string) bool {
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// matchAny returns the first value matching the pattern.
+func matchAny(pattern string, values []string) (string, bool) {
+	for _, value := range values {
+		if match(pattern, value) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Authorized implements [method.Method], checking the groups of the identity against the
+// authorization rules of the backend.
+func (m *mtls) Authorized(req *http.Request) error {
+	zerologr.V(50).Info("Authorizing request " + req.URL.Path)
+	//nolint:errcheck // bigger problems if this is missing
+	backend := req.Context().Value(composer.BackendContextKey).(string)
+
+	// Authenticated has replaced the groups of the request with those of the identity.
+	return method.AuthorizeGroups(m.config[backend], req)
+}
@@ -0,0 +1,165 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+)
+
+func newTestMTLS(t *testing.T, authZ map[string]*config.AuthZ) *mtls {
+	t.Helper()
+
+	m, err := New(&Opts{
+		Cfg: &config.AuthMethodMTLS{Identities: []*config.MTLSIdentity{
+			{
+				URI:          "spiffe://example.com/partner/*",
+				Organisation: "partners",
+				Groups:       []string{"partner"},
+			},
+			{DNS: "*.billing.example.com", Groups: []string{"billing"}},
+			{Subject: "CN=ops,O=Example", User: "operations", Groups: []string{"ops"}},
+		}},
+		AuthZConfig: authZ,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mTLS method: %v", err)
+	}
+
+	//nolint:errcheck // test
+	return m.(*mtls)
+}
+
+func newCertificate(cn string, dnsNames []string, uris ...string) *x509.Certificate {
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: cn, Organization: []string{"Example"}},
+		DNSNames: dnsNames,
+	}
+	for _, uri := range uris {
+		u, _ := url.Parse(uri)
+		cert.URIs = append(cert.URIs, u)
+	}
+	return cert
+}
+
+func newRequest(cert *x509.Certificate) *http.Request {
+	req, _ := http.NewRequestWithContext(
+		context.WithValue(context.Background(), composer.BackendContextKey, "backend"),
+		http.MethodGet,
+		"/orders/1",
+		nil,
+	)
+	if cert != nil {
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	return req
+}
+
+func TestMTLSAuthenticated(t *testing.T) {
+	m := newTestMTLS(t, map[string]*config.AuthZ{})
+
+	tests := []struct {
+		name   string
+		cert   *x509.Certificate
+		user   string
+		org    string
+		groups []string
+	}{
+		{
+			name:   "SPIFFE ID",
+			cert:   newCertificate("partner", nil, "spiffe://example.com/partner/acme"),
+			user:   "spiffe://example.com/partner/acme",
+			org:    "partners",
+			groups: []string{"partner"},
+		},
+		{
+			name:   "DNS SAN",
+			cert:   newCertificate("billing", []string{"localhost", "eu.billing.example.com"}),
+			user:   "eu.billing.example.com",
+			groups: []string{"billing"},
+		},
+		{
+			name:   "Subject",
+			cert:   newCertificate("ops", nil),
+			user:   "operations",
+			groups: []string{"ops"},
+		},
+		{name: "No certificate"},
+		{name: "No matching identity", cert: newCertificate("other", []string{"other.example.com"})},
+		{
+			name: "SPIFFE ID in other trust domain",
+			cert: newCertificate("partner", nil, "spiffe://evil.com/partner/acme"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := newRequest(test.cert)
+			// Identity headers sent by the client are replaced.
+			req.Header.Set("X-Krb-User", "admin")
+			req.Header.Set("X-Krb-Org", "1")
+			req.Header.Add("X-Krb-Groups", "admin")
+
+			err := m.Authenticated(req)
+			if test.user == "" {
+				if err == nil {
+					t.Error("Expected the certificate to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected the certificate to be accepted, got %v", err)
+			}
+
+			if req.Header.Get("X-Krb-User") != test.user || req.Header.Get("X-Krb-Org") != test.org {
+				t.Errorf("Unexpected identity headers: %v", req.Header)
+			}
+			if groups := req.Header.Values("X-Krb-Groups"); !slices.Equal(groups, test.groups) {
+				t.Errorf("Unexpected groups: %v", groups)
+			}
+		})
+	}
+}
+
+func TestMTLSAuthorized(t *testing.T) {
+	m := newTestMTLS(t, map[string]*config.AuthZ{
+		"backend": {
+			Groups: []string{"partner"},
+			Paths:  map[string][]string{"/invoices/*": {"billing"}},
+		},
+	})
+
+	req := newRequest(newCertificate("partner", nil, "spiffe://example.com/partner/acme"))
+	if err := m.Authenticated(req); err != nil {
+		t.Fatalf("Expected the certificate to be accepted, got %v", err)
+	}
+	if err := m.Authorized(req); err != nil {
+		t.Errorf("Expected the partner group to authorize the request, got %v", err)
+	}
+
+	req = newRequest(newCertificate("billing", []string{"eu.billing.example.com"}))
+	if err := m.Authenticated(req); err != nil {
+		t.Fatalf("Expected the certificate to be accepted, got %v", err)
+	}
+	if err := m.Authorized(req); err == nil {
+		t.Error("Expected the billing group to be denied access to the backend")
+	}
+}
+
+func TestMTLSInvalidPattern(t *testing.T) {
+	_, err := New(&Opts{
+		Cfg: &config.AuthMethodMTLS{Identities: []*config.MTLSIdentity{
+			{DNS: "[partner"},
+		}},
+		AuthZConfig: map[string]*config.AuthZ{},
+	})
+	if err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
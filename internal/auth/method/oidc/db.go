@@ -0,0 +1,219 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/trebent/kerberos/internal/db"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	// login is a login in progress, from the redirect to the provider until the callback.
+	login struct {
+		State    string
+		Nonce    string
+		Verifier string
+		ReturnTo string
+		Expires  int64
+	}
+	// session is a session of a logged in user, identified by the claims of their ID token.
+	session struct {
+		SessionID    string
+		RefreshID    string
+		User         string
+		Organisation string
+		Groups       []string
+		// IDToken is sent to the provider as a hint when logging out.
+		IDToken string
+		Expires int64
+	}
+)
+
+const (
+	// Logins.
+	insertLogin         = "INSERT INTO oidc_logins (state, nonce, verifier, return_to, expires) VALUES(@state, @nonce, @verifier, @returnTo, @expires);"
+	deleteLogin         = "DELETE FROM oidc_logins WHERE state = @state RETURNING state, nonce, verifier, return_to, expires;"
+	deleteExpiredLogins = "DELETE FROM oidc_logins WHERE expires < @now;"
+
+	// Sessions.
+	insertSession          = "INSERT INTO oidc_sessions (session_id, refresh_id, user_name, organisation, user_groups, id_token, expires) VALUES(@session, @refresh, @user, @org, @groups, @idToken, @expires);"
+	selectSession          = "SELECT session_id, refresh_id, user_name, organisation, user_groups, id_token, expires FROM oidc_sessions WHERE session_id = @session;"
+	selectSessionByRefresh = "SELECT session_id, refresh_id, user_name, organisation, user_groups, id_token, expires FROM oidc_sessions WHERE refresh_id = @refresh;"
+	deleteSession          = "DELETE FROM oidc_sessions WHERE session_id = @session;"
+	deleteExpiredSessions  = "DELETE FROM oidc_sessions WHERE expires < @before;"
+
+	// Named arg keys.
+	argState   = "state"
+	argSession = "session"
+	argRefresh = "refresh"
+	argExpires = "expires"
+
+	loginExpiry          = 10 * time.Minute
+	sessionExpiry        = 15 * time.Minute
+	sessionRefreshExpiry = 15 * time.Minute
+)
+
+var (
+	errNoLogin   = errors.New("no login found")
+	errNoSession = errors.New("no valid session found")
+)
+
+// --- Logins ---
+
+// dbCreateLogin stores a new login, removing expired logins.
+func dbCreateLogin(ctx context.Context, client db.SQLClient, l *login) error {
+	if _, err := client.Exec(
+		ctx,
+		deleteExpiredLogins,
+		sql.NamedArg{Name: "now", Value: time.Now().UnixMilli()},
+	); err != nil {
+		zerologr.Error(err, "Failed to delete expired logins")
+		return err
+	}
+
+	_, err := client.Exec(
+		ctx,
+		insertLogin,
+		sql.NamedArg{Name: argState, Value: l.State},
+		sql.NamedArg{Name: "nonce", Value: l.Nonce},
+		sql.NamedArg{Name: "verifier", Value: l.Verifier},
+		sql.NamedArg{Name: "returnTo", Value: l.ReturnTo},
+		sql.NamedArg{Name: argExpires, Value: l.Expires},
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to store new login")
+	}
+	return err
+}
+
+// dbTakeLogin removes and returns the login with the state, so that it can only be completed once.
+// Returns (nil, errNoLogin) when no matching login exists.
+func dbTakeLogin(ctx context.Context, client db.SQLClient, state string) (*login, error) {
+	rows, err := client.Query(ctx, deleteLogin, sql.NamedArg{Name: argState, Value: state})
+	if err != nil {
+		zerologr.Error(err, "Failed to delete login")
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			zerologr.Error(err, "Failed to iterate login rows")
+			return nil, err
+		}
+		return nil, errNoLogin
+	}
+
+	l := &login{}
+	if err := rows.Scan(&l.State, &l.Nonce, &l.Verifier, &l.ReturnTo, &l.Expires); err != nil {
+		zerologr.Error(err, "Failed to scan login row")
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// --- Sessions ---
+
+// dbCreateSession stores a new session, removing sessions that can no longer be refreshed.
+func dbCreateSession(ctx context.Context, client db.SQLClient, s *session) error {
+	if _, err := client.Exec(
+		ctx,
+		deleteExpiredSessions,
+		sql.NamedArg{
+			Name:  "before",
+			Value: time.Now().Add(-sessionRefreshExpiry).UnixMilli(),
+		},
+	); err != nil {
+		zerologr.Error(err, "Failed to delete expired sessions")
+		return err
+	}
+
+	groups, err := json.Marshal(s.Groups)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Exec(
+		ctx,
+		insertSession,
+		sql.NamedArg{Name: argSession, Value: s.SessionID},
+		sql.NamedArg{Name: argRefresh, Value: s.RefreshID},
+		sql.NamedArg{Name: "user", Value: s.User},
+		sql.NamedArg{Name: "org", Value: s.Organisation},
+		sql.NamedArg{Name: "groups", Value: string(groups)},
+		sql.NamedArg{Name: "idToken", Value: s.IDToken},
+		sql.NamedArg{Name: argExpires, Value: s.Expires},
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to store new session")
+	}
+	return err
+}
+
+// dbGetSession queries a session by ID.
+// Returns (nil, errNoSession) when no matching session row exists.
+func dbGetSession(ctx context.Context, client db.SQLClient, sessionID string) (*session, error) {
+	return dbQuerySession(ctx, client, selectSession, sql.NamedArg{Name: argSession, Value: sessionID})
+}
+
+// dbGetSessionByRefresh queries a session by its refresh ID.
+// Returns (nil, errNoSession) when no matching session row exists.
+func dbGetSessionByRefresh(
+	ctx context.Context,
+	client db.SQLClient,
+	refreshID string,
+) (*session, error) {
+	return dbQuerySession(
+		ctx, client, selectSessionByRefresh, sql.NamedArg{Name: argRefresh, Value: refreshID},
+	)
+}
+
+func dbQuerySession(
+	ctx context.Context,
+	client db.SQLClient,
+	stmt string,
+	arg sql.NamedArg,
+) (*session, error) {
+	rows, err := client.Query(ctx, stmt, arg)
+	if err != nil {
+		zerologr.Error(err, "Failed to query session")
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			zerologr.Error(err, "Failed to iterate session rows")
+			return nil, err
+		}
+		return nil, errNoSession
+	}
+
+	s := &session{}
+	var groups string
+	if err := rows.Scan(
+		&s.SessionID, &s.RefreshID, &s.User, &s.Organisation, &groups, &s.IDToken, &s.Expires,
+	); err != nil {
+		zerologr.Error(err, "Failed to scan session row")
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(groups), &s.Groups); err != nil {
+		zerologr.Error(err, "Failed to decode session groups")
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func dbDeleteSession(ctx context.Context, client db.SQLClient, sessionID string) error {
+	_, err := client.Exec(ctx, deleteSession, sql.NamedArg{Name: argSession, Value: sessionID})
+	if err != nil {
+		zerologr.Error(err, "Failed to delete session")
+	}
+	return err
+}
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS oidc_logins (
+  state VARCHAR(100) PRIMARY KEY,
+  nonce VARCHAR(100) NOT NULL,
+  verifier VARCHAR(100) NOT NULL,
+  return_to TEXT NOT NULL,
+  expires INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS oidc_sessions (
+  session_id VARCHAR(100) PRIMARY KEY,
+  refresh_id VARCHAR(100) NOT NULL,
+  user_name TEXT NOT NULL,
+  organisation TEXT NOT NULL,
+  user_groups TEXT NOT NULL,
+  id_token TEXT NOT NULL,
+  expires INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS oidc_session_refresh ON oidc_sessions(refresh_id);
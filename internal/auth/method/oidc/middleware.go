@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	authoidcapi "github.com/trebent/kerberos/internal/oapi/auth/oidc"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/kerberos/internal/security"
+	"github.com/trebent/zerologr"
+)
+
+type contextKey string
+
+var (
+	sessionContextKey contextKey = "session"
+	refreshContextKey contextKey = "refresh"
+	stateContextKey   contextKey = "state"
+)
+
+// AuthMiddleware populates the context with the cookies each operation needs, and requires a valid
+// session to log out.
+func AuthMiddleware(ssi authoidcapi.StrictServerInterface) authoidcapi.StrictMiddlewareFunc {
+	apiImpl, ok := ssi.(*impl)
+	if !ok {
+		panic("expected auth api *impl")
+	}
+
+	return func(f authoidcapi.StrictHandlerFunc, operationID string) authoidcapi.StrictHandlerFunc {
+		return func(
+			ctx context.Context,
+			w http.ResponseWriter,
+			r *http.Request,
+			request any,
+		) (any, error) {
+			zerologr.V(20).Info("Running OIDC auth API middleware", "url", r.URL.Path)
+
+			switch operationID {
+			case "Login":
+				zerologr.V(20).Info("Skipping authentication for the login path")
+				return f(ctx, w, r, request)
+			case "Callback":
+				if c, err := r.Cookie(stateCookieName); err == nil {
+					ctx = context.WithValue(ctx, stateContextKey, c.Value)
+				}
+				return f(ctx, w, r, request)
+			case "Refresh":
+				if c, err := r.Cookie(security.RefreshCookieName); err == nil {
+					ctx = context.WithValue(ctx, refreshContextKey, c.Value)
+				}
+				return f(ctx, w, r, request)
+			}
+
+			cookie, err := r.Cookie(sessionCookieName)
+			if err != nil || cookie.Value == "" {
+				zerologr.V(20).Info("No session cookie found, denying access")
+				return nil, apierror.ErrUnauthorized
+			}
+
+			s, err := dbGetSession(ctx, apiImpl.db, cookie.Value)
+			if errors.Is(err, errNoSession) {
+				zerologr.Error(apierror.ErrUnauthorized, "Failed to find a matching session")
+				return nil, apierror.ErrUnauthorized
+			}
+			if err != nil {
+				return nil, apierror.ErrISE
+			}
+
+			if time.Now().UnixMilli() > s.Expires {
+				zerologr.Error(apierror.ErrUnauthorized, "Session expired")
+				return nil, apierror.ErrUnauthorized
+			}
+
+			return f(withSession(ctx, s), w, r, request)
+		}
+	}
+}
+
+func withSession(ctx context.Context, s *session) context.Context {
+	return context.WithValue(ctx, sessionContextKey, s)
+}
+
+func sessionFromContext(ctx context.Context) *session {
+	//nolint:errcheck // welp
+	return ctx.Value(sessionContextKey).(*session)
+}
@@ -0,0 +1,215 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	_ "embed"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/trebent/kerberos/internal/auth/method"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/kerberos/internal/db"
+	authoidcapi "github.com/trebent/kerberos/internal/oapi/auth/oidc"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/kerberos/internal/oas"
+	"github.com/trebent/kerberos/internal/security"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	// OIDC logs browser users in with an OpenID Connect provider and authenticates their requests
+	// with the resulting session.
+	OIDC interface {
+		method.Method
+
+		// RegisterRoutes registers the login, callback, refresh and logout endpoints.
+		RegisterRoutes(
+			mux *http.ServeMux,
+			middleware ...authoidcapi.StrictMiddlewareFunc,
+		) error
+	}
+	oidc struct {
+		cfg       *config.AuthMethodOIDC
+		config    map[string]*config.AuthZ
+		sqlClient db.SQLClient
+		oasDir    string
+		provider  *provider
+	}
+	Opts struct {
+		Cfg         *config.AuthMethodOIDC
+		AuthZConfig map[string]*config.AuthZ
+		SQLClient   db.SQLClient
+		OASDir      string
+		// Client is used to talk to the provider, optional.
+		Client *http.Client
+	}
+)
+
+const (
+	authOIDCSpecification = "auth_oidc.yaml"
+
+	defaultProviderTimeout = 10 * time.Second
+)
+
+var (
+	_ OIDC = (*oidc)(nil)
+
+	//go:embed dbschema/schema.sql
+	dbschemaBytes []byte
+
+	//go:embed dbschema/schema_postgres.sql
+	dbschemaPostgresBytes []byte
+)
+
+// New returns an OpenID Connect authentication method. The provider is not contacted until the
+// first login.
+func New(opts *Opts) (OIDC, error) {
+	if opts.SQLClient == nil {
+		return nil, errors.New("DB client is required for OIDC auth method")
+	}
+
+	if err := applySchemas(opts.SQLClient); err != nil {
+		return nil, fmt.Errorf("failed to apply OIDC auth DB schema: %w", err)
+	}
+
+	if opts.OASDir == "" {
+		return nil, errors.New("OAS directory is required for OIDC auth method")
+	}
+
+	if opts.AuthZConfig == nil {
+		return nil, errors.New("authorization config is required for OIDC auth method")
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultProviderTimeout}
+	}
+
+	return &oidc{
+		cfg:       opts.Cfg,
+		config:    opts.AuthZConfig,
+		sqlClient: opts.SQLClient,
+		oasDir:    opts.OASDir,
+		provider:  newProvider(opts.Cfg, client),
+	}, nil
+}
+
+// Authenticated implements [method.Method]. The user, organisation and groups of the session
+// replace any X-Krb-User, X-Krb-Org and X-Krb-Groups headers of the request.
+func (o *oidc) Authenticated(req *http.Request) error {
+	zerologr.V(50).Info("Authenticating request " + req.URL.Path)
+
+	cookie, err := req.Cookie(sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		zerologr.V(20).Info("No session cookie found, denying access")
+		return apierror.ErrUnauthorized
+	}
+
+	s, err := dbGetSession(req.Context(), o.sqlClient, cookie.Value)
+	if errors.Is(err, errNoSession) {
+		zerologr.Error(apierror.ErrUnauthorized, "Failed to find a matching session")
+		return apierror.ErrUnauthorized
+	}
+	if err != nil {
+		return apierror.ErrISE
+	}
+
+	if time.Now().UnixMilli() > s.Expires {
+		zerologr.Error(apierror.ErrUnauthorized, "Session expired")
+		return apierror.ErrUnauthorized
+	}
+
+	req.Header.Del("X-Krb-Org")
+	req.Header.Del("X-Krb-Groups")
+	req.Header.Set("X-Krb-User", s.User)
+	if s.Organisation != "" {
+		req.Header.Set("X-Krb-Org", s.Organisation)
+	}
+	for _, group := range s.Groups {
+		req.Header.Add("X-Krb-Groups", group)
+	}
+
+	return nil
+}
+
+// Authorized implements [method.Method], checking the groups of the session against the
+// authorization rules of the backend.
+func (o *oidc) Authorized(req *http.Request) error {
+	zerologr.V(50).Info("Authorizing request " + req.URL.Path)
+	//nolint:errcheck // bigger problems if this is missing
+	backend := req.Context().Value(composer.BackendContextKey).(string)
+
+	// Authenticated has replaced the groups of the request with those of the session.
+	return method.AuthorizeGroups(o.config[backend], req)
+}
+
+// RegisterRoutes implements [OIDC].
+func (o *oidc) RegisterRoutes(
+	mux *http.ServeMux,
+	middleware ...authoidcapi.StrictMiddlewareFunc,
+) error {
+	data, err := os.ReadFile(fmt.Sprintf("%s/%s", o.oasDir, authOIDCSpecification))
+	if err != nil {
+		return fmt.Errorf("failed to read OIDC authentication OAS: %w", err)
+	}
+
+	spec, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return fmt.Errorf("failed to load OIDC authentication OAS: %w", err)
+	}
+
+	ssi := newSSI(o.sqlClient, o.cfg, o.provider)
+	authMiddleware := append([]authoidcapi.StrictMiddlewareFunc{AuthMiddleware(ssi)}, middleware...)
+
+	strictHandler := authoidcapi.NewStrictHandlerWithOptions(
+		ssi,
+		authMiddleware,
+		authoidcapi.StrictHTTPServerOptions{
+			RequestErrorHandlerFunc:  apierror.RequestErrorHandler,
+			ResponseErrorHandlerFunc: apierror.ResponseErrorHandler,
+		},
+	)
+
+	corsMw := security.SelectCORSMiddleware(
+		o.cfg.API.Origins.AllowedOrigins,
+		o.cfg.API.Origins.AllowAll,
+		o.cfg.API.Origins.DenyAll,
+	)
+
+	_ = authoidcapi.HandlerWithOptions(strictHandler, authoidcapi.StdHTTPServerOptions{
+		BaseRouter: mux,
+		Middlewares: []authoidcapi.MiddlewareFunc{
+			// Login and the callback are GET requests, which are not CSRF protected.
+			security.CSRFMiddleware,
+			oas.ValidationMiddleware(spec),
+			corsMw,
+		},
+	})
+
+	// See the basic auth method, OPTIONS requests must reach the CORS middleware.
+	methodNotAllowed := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	mux.HandleFunc("OPTIONS /api/auth/oidc/{path...}", corsMw(methodNotAllowed).ServeHTTP)
+
+	return nil
+}
+
+func applySchemas(sqlClient db.SQLClient) error {
+	schema := dbschemaBytes
+	if sqlClient.Dialect() == db.PostgresDialect {
+		schema = dbschemaPostgresBytes
+	}
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), db.SchemaApplyTimeout)
+	defer cancel()
+	if _, err := sqlClient.Exec(timeoutCtx, string(schema)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,477 @@
+//go:build !postgres_integration
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/kerberos/internal/security"
+)
+
+// testIssuer is a stand-in OpenID Connect provider. Authorizing a login hands out a code, which
+// the token endpoint exchanges for an ID token with the claims once the PKCE verifier matches.
+type testIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*testGrant
+}
+
+type testGrant struct {
+	challenge string
+	claims    map[string]any
+}
+
+const (
+	testClientID     = "kerberos"
+	testClientSecret = "secret"
+	testRedirectURL  = "https://kerberos.example.com/api/auth/oidc/callback"
+)
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	issuer := &testIssuer{t: t, key: key, codes: make(map[string]*testGrant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+discoveryPath, func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/jwks",
+			"end_session_endpoint":   issuer.server.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", issuer.token)
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+// authorize plays the part of the user logging in at the provider, returning the code the
+// provider redirects the browser to the callback with.
+func (i *testIssuer) authorize(authURL string, claims map[string]any) (string, string) {
+	i.t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		i.t.Fatalf("Failed to parse authorization URL: %v", err)
+	}
+	query := u.Query()
+	if query.Get("client_id") != testClientID ||
+		query.Get("redirect_uri") != testRedirectURL ||
+		query.Get("response_type") != "code" ||
+		query.Get("code_challenge_method") != "S256" ||
+		!slices.Contains(strings.Fields(query.Get("scope")), "openid") {
+		i.t.Fatalf("Unexpected authorization request: %s", authURL)
+	}
+
+	claims["iss"] = i.server.URL
+	claims["aud"] = testClientID
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	if _, ok := claims["nonce"]; !ok {
+		claims["nonce"] = query.Get("nonce")
+	}
+
+	code := randomString()
+	i.mu.Lock()
+	i.codes[code] = &testGrant{challenge: query.Get("code_challenge"), claims: claims}
+	i.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+func (i *testIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if id, secret, ok := r.BasicAuth(); !ok || id != testClientID || secret != testClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+		return
+	}
+
+	i.mu.Lock()
+	grant, ok := i.codes[r.PostFormValue("code")]
+	delete(i.codes, r.PostFormValue("code"))
+	i.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok ||
+		r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("redirect_uri") != testRedirectURL ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     i.sign(grant.claims),
+	})
+}
+
+func (i *testIssuer) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": config.JWTAlgRS256, "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		i.t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newTestOIDC(
+	t *testing.T,
+	issuer string,
+	authZ map[string]*config.AuthZ,
+) (*oidc, http.Handler) {
+	t.Helper()
+
+	data, _ := json.Marshal(map[string]any{
+		"auth": map[string]any{
+			"methods": map[string]any{"oidc": map[string]any{
+				"issuer":                issuer,
+				"clientId":              testClientID,
+				"clientSecret":          testClientSecret,
+				"redirectUrl":           testRedirectURL,
+				"postLogoutRedirectUrl": "https://kerberos.example.com/",
+				"claims":                map[string]any{"organisation": "org"},
+			}},
+			"scheme": map[string]any{
+				"mappings": []map[string]any{{"backend": "backend", "method": "oidc"}},
+			},
+		},
+		"gateway": map[string]any{"router": map[string]any{"backends": []map[string]any{
+			{"name": "backend", "host": "localhost", "port": 8080},
+		}}},
+	})
+	cfg := config.New()
+	cfg.Load(data)
+	if err := cfg.Parse(); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	o, err := New(&Opts{
+		Cfg:         cfg.AuthConfig.Methods.OIDC,
+		AuthZConfig: authZ,
+		SQLClient:   testClient,
+		OASDir:      "../../../../openapi",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create OIDC method: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	if err := o.RegisterRoutes(mux); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+
+	//nolint:errcheck // New returns an *oidc
+	return o.(*oidc), mux
+}
+
+func serve(handler http.Handler, method, target string, cookies ...*http.Cookie) *http.Response {
+	req := httptest.NewRequest(method, target, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+func cookieNamed(resp *http.Response, name string) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// logIn logs a user with the claims in, returning the response of the callback.
+func logIn(
+	t *testing.T,
+	issuer *testIssuer,
+	handler http.Handler,
+	claims map[string]any,
+) *http.Response {
+	t.Helper()
+
+	resp := serve(handler, http.MethodGet, "/api/auth/oidc/login?returnTo=/app")
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected login to redirect, got %d", resp.StatusCode)
+	}
+	stateCookie := cookieNamed(resp, stateCookieName)
+	if stateCookie == nil {
+		t.Fatal("Expected a state cookie")
+	}
+
+	code, state := issuer.authorize(resp.Header.Get("Location"), claims)
+	return serve(
+		handler,
+		http.MethodGet,
+		"/api/auth/oidc/callback?code="+code+"&state="+state,
+		stateCookie,
+	)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	issuer := newTestIssuer(t)
+	o, handler := newTestOIDC(t, issuer.server.URL, map[string]*config.AuthZ{
+		"backend": {Groups: []string{"staff"}},
+	})
+
+	resp := logIn(t, issuer, handler, map[string]any{
+		"sub":    "alice",
+		"org":    "acme",
+		"groups": []string{"staff", "dev"},
+	})
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/app" {
+		t.Fatalf("Expected a redirect to /app, got %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	sessionCookie := cookieNamed(resp, sessionCookieName)
+	refreshCookie := cookieNamed(resp, security.RefreshCookieName)
+	if sessionCookie == nil ||
+		refreshCookie == nil ||
+		cookieNamed(resp, security.CSRFCookieName) == nil {
+		t.Fatalf("Expected session, refresh and CSRF cookies, got %v", resp.Cookies())
+	}
+	if refreshCookie.Path != refreshPath {
+		t.Errorf("Expected the refresh cookie path to be %s, got %s", refreshPath, refreshCookie.Path)
+	}
+	if c := cookieNamed(resp, stateCookieName); c == nil || c.MaxAge >= 0 {
+		t.Errorf("Expected the state cookie to be expired, got %v", c)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/backend/orders", nil)
+	req = req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, "backend"))
+	req.Header.Set("X-Krb-User", "mallory")
+	req.Header.Set("X-Krb-Groups", "admin")
+	req.AddCookie(sessionCookie)
+	if err := o.Authenticated(req); err != nil {
+		t.Fatalf("Expected the session to be authenticated, got %v", err)
+	}
+	if req.Header.Get("X-Krb-User") != "alice" || req.Header.Get("X-Krb-Org") != "acme" {
+		t.Errorf("Unexpected identity headers: %v", req.Header)
+	}
+	if groups := req.Header.Values("X-Krb-Groups"); !slices.Equal(groups, []string{"staff", "dev"}) {
+		t.Errorf("Unexpected groups: %v", groups)
+	}
+	if err := o.Authorized(req); err != nil {
+		t.Errorf("Expected a staff member to be authorized, got %v", err)
+	}
+
+	// The session cookie of basic authentication is not an OIDC session.
+	req = httptest.NewRequest(http.MethodGet, "/backend/orders", nil)
+	req.AddCookie(&http.Cookie{Name: security.SessionCookieName, Value: sessionCookie.Value})
+	if err := o.Authenticated(req); err == nil {
+		t.Error("Expected the basic session cookie to be rejected")
+	}
+
+	// Sessions of users outside the required groups are authenticated, but not authorized.
+	resp = logIn(t, issuer, handler, map[string]any{"sub": "bob", "groups": []string{"dev"}})
+	req = httptest.NewRequest(http.MethodGet, "/backend/orders", nil)
+	req = req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, "backend"))
+	req.AddCookie(cookieNamed(resp, sessionCookieName))
+	if err := o.Authenticated(req); err != nil {
+		t.Fatalf("Expected the session to be authenticated, got %v", err)
+	}
+	if err := o.Authorized(req); err == nil {
+		t.Error("Expected a user outside the staff group to be forbidden")
+	}
+}
+
+func TestOIDCLoginRejected(t *testing.T) {
+	issuer := newTestIssuer(t)
+	_, handler := newTestOIDC(t, issuer.server.URL, map[string]*config.AuthZ{})
+
+	t.Run("Absolute returnTo", func(t *testing.T) {
+		for _, returnTo := range []string{"https://evil.example.com", "//evil.example.com"} {
+			resp := serve(
+				handler, http.MethodGet, "/api/auth/oidc/login?returnTo="+url.QueryEscape(returnTo),
+			)
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected 400 for returnTo %s, got %d", returnTo, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("State from another browser", func(t *testing.T) {
+		resp := serve(handler, http.MethodGet, "/api/auth/oidc/login")
+		code, state := issuer.authorize(resp.Header.Get("Location"), map[string]any{"sub": "alice"})
+
+		resp = serve(
+			handler,
+			http.MethodGet,
+			"/api/auth/oidc/callback?code="+code+"&state="+state,
+			&http.Cookie{Name: stateCookieName, Value: "other"},
+		)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Replayed state", func(t *testing.T) {
+		resp := serve(handler, http.MethodGet, "/api/auth/oidc/login")
+		stateCookie := cookieNamed(resp, stateCookieName)
+		code, state := issuer.authorize(resp.Header.Get("Location"), map[string]any{"sub": "alice"})
+
+		target := "/api/auth/oidc/callback?code=" + code + "&state=" + state
+		resp = serve(handler, http.MethodGet, target, stateCookie)
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("Expected the first callback to succeed, got %d", resp.StatusCode)
+		}
+		resp = serve(handler, http.MethodGet, target, stateCookie)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected a replayed callback to be rejected, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Nonce mismatch", func(t *testing.T) {
+		resp := logIn(t, issuer, handler, map[string]any{"sub": "alice", "nonce": "other"})
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("No user claim", func(t *testing.T) {
+		resp := logIn(t, issuer, handler, map[string]any{"groups": []string{"staff"}})
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Unknown code", func(t *testing.T) {
+		resp := serve(handler, http.MethodGet, "/api/auth/oidc/login")
+		stateCookie := cookieNamed(resp, stateCookieName)
+		_, state := issuer.authorize(resp.Header.Get("Location"), map[string]any{"sub": "alice"})
+
+		resp = serve(
+			handler, http.MethodGet, "/api/auth/oidc/callback?code=forged&state="+state, stateCookie,
+		)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Provider error", func(t *testing.T) {
+		resp := serve(handler, http.MethodGet, "/api/auth/oidc/callback?error=access_denied")
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestOIDCProviderUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	_, handler := newTestOIDC(t, server.URL, map[string]*config.AuthZ{})
+
+	resp := serve(handler, http.MethodGet, "/api/auth/oidc/login")
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected 502, got %d", resp.StatusCode)
+	}
+}
+
+func TestOIDCRefreshAndLogout(t *testing.T) {
+	issuer := newTestIssuer(t)
+	o, handler := newTestOIDC(t, issuer.server.URL, map[string]*config.AuthZ{})
+
+	resp := logIn(t, issuer, handler, map[string]any{"sub": "alice", "groups": "staff"})
+	sessionCookie := cookieNamed(resp, sessionCookieName)
+
+	resp = serve(
+		handler,
+		http.MethodPost,
+		"/api/auth/oidc/refresh",
+		cookieNamed(resp, security.RefreshCookieName),
+	)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected the session to be refreshed, got %d", resp.StatusCode)
+	}
+	refreshed := cookieNamed(resp, sessionCookieName)
+	if refreshed == nil || refreshed.Value == sessionCookie.Value {
+		t.Fatalf("Expected a new session cookie, got %v", refreshed)
+	}
+
+	authenticated := func(c *http.Cookie) bool {
+		req := httptest.NewRequest(http.MethodGet, "/backend", nil)
+		req.AddCookie(c)
+		return o.Authenticated(req) == nil
+	}
+	if authenticated(sessionCookie) {
+		t.Error("Expected the refreshed session to be removed")
+	}
+	if !authenticated(refreshed) {
+		t.Fatal("Expected the new session to be authenticated")
+	}
+
+	resp = serve(handler, http.MethodPost, "/api/auth/oidc/logout", refreshed)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the user to be logged out, got %d", resp.StatusCode)
+	}
+	var body struct {
+		LogoutURL string `json:"logoutUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode logout response: %v", err)
+	}
+	logoutURL, err := url.Parse(body.LogoutURL)
+	if err != nil || !strings.HasPrefix(body.LogoutURL, issuer.server.URL+"/logout") {
+		t.Fatalf("Unexpected logout URL %q", body.LogoutURL)
+	}
+	if logoutURL.Query().Get("id_token_hint") == "" ||
+		logoutURL.Query().Get("post_logout_redirect_uri") != "https://kerberos.example.com/" {
+		t.Errorf("Unexpected logout URL query: %v", logoutURL.Query())
+	}
+	if c := cookieNamed(resp, sessionCookieName); c == nil || c.MaxAge >= 0 {
+		t.Errorf("Expected the session cookie to be expired, got %v", c)
+	}
+	if authenticated(refreshed) {
+		t.Error("Expected the session to be removed when logging out")
+	}
+
+	resp = serve(handler, http.MethodPost, "/api/auth/oidc/logout", refreshed)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected logging out without a session to fail, got %d", resp.StatusCode)
+	}
+}
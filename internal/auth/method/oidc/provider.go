@@ -0,0 +1,312 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trebent/kerberos/internal/auth/method/jwt"
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	// provider talks to the OpenID Connect provider. Its discovery document and keys are fetched
+	// when first needed, so that Kerberos starts while the provider is unavailable.
+	provider struct {
+		cfg    *config.AuthMethodOIDC
+		client *http.Client
+		// verifyCfg validates ID tokens, which are issued by the issuer for the client.
+		verifyCfg *config.AuthMethodJWT
+
+		mu          sync.Mutex
+		metadata    *discovery
+		keys        *jwt.KeySet
+		keysFetched time.Time
+		// now is replaced in tests.
+		now func() time.Time
+	}
+	// discovery holds the used fields of the discovery document of the provider.
+	discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+		EndSessionEndpoint    string `json:"end_session_endpoint"`
+	}
+	tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// minKeyRefetchInterval limits how often the keys are fetched again when an ID token is signed
+	// by an unknown key.
+	minKeyRefetchInterval = time.Minute
+	// maxResponseBytes limits the size of the responses read from the provider.
+	maxResponseBytes = 1 << 20
+)
+
+var (
+	// errProvider is returned when the provider cannot be reached or responds unexpectedly.
+	errProvider = errors.New("provider unavailable")
+	// errTokenRejected is returned when the provider does not exchange an authorization code.
+	errTokenRejected = errors.New("token request rejected")
+	errNonce         = errors.New("nonce mismatch")
+	errAuthorizedBy  = errors.New("token authorized for another client")
+)
+
+func newProvider(cfg *config.AuthMethodOIDC, client *http.Client) *provider {
+	return &provider{
+		cfg:    cfg,
+		client: client,
+		verifyCfg: &config.AuthMethodJWT{
+			Issuer:    cfg.Issuer,
+			Audiences: []string{cfg.ClientID},
+			// ID tokens are verified with the published keys of the provider, which are never
+			// symmetric.
+			Algorithms:  []string{config.JWTAlgRS256, config.JWTAlgES256, config.JWTAlgEdDSA},
+			ClockSkewMs: cfg.ClockSkewMs,
+		},
+		now: time.Now,
+	}
+}
+
+// discover returns the discovery document of the provider, fetching it once.
+func (p *provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var d discovery
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+discoveryPath, &d); err != nil {
+		return nil, err
+	}
+	switch {
+	case d.Issuer != p.cfg.Issuer:
+		return nil, fmt.Errorf("%w: discovered issuer %q", errProvider, d.Issuer)
+	case d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "":
+		return nil, fmt.Errorf("%w: incomplete discovery document", errProvider)
+	}
+
+	zerologr.Info("Discovered OIDC provider", "issuer", d.Issuer)
+	p.metadata = &d
+	return p.metadata, nil
+}
+
+// keySet returns the keys of the provider, fetching them again if refetch is set and they were
+// not fetched recently.
+func (p *provider) keySet(ctx context.Context, refetch bool) (*jwt.KeySet, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil && (!refetch || p.now().Sub(p.keysFetched) < minKeyRefetchInterval) {
+		return p.keys, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := jwt.ParseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: parsing JWKS: %w", errProvider, err)
+	}
+
+	zerologr.V(20).Info("Fetched OIDC provider keys", "keys", keys.Len())
+	p.keys = keys
+	p.keysFetched = p.now()
+	return p.keys, nil
+}
+
+// authURL returns the URL of the authorization endpoint the browser is sent to when logging in.
+func (p *provider) authURL(d *discovery, state, nonce, verifier string) (string, error) {
+	u, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errProvider, err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// exchange exchanges an authorization code for an ID token.
+func (p *provider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		// client_secret_basic, the credentials are form encoded before being base64 encoded.
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errProvider, err)
+	}
+	defer resp.Body.Close()
+
+	// The provider responds 400, or 401 if the client failed to authenticate, with an error when it
+	// rejects the code.
+	rejected := resp.StatusCode == http.StatusBadRequest ||
+		resp.StatusCode == http.StatusUnauthorized
+	if resp.StatusCode != http.StatusOK && !rejected {
+		return "", fmt.Errorf("%w: token endpoint responded %d", errProvider, resp.StatusCode)
+	}
+
+	var token tokenResponse
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&token)
+	switch {
+	case rejected:
+		return "", fmt.Errorf("%w: %s %s", errTokenRejected, token.Error, token.ErrorDescription)
+	case err != nil:
+		return "", fmt.Errorf("%w: decoding token response: %w", errProvider, err)
+	case token.IDToken == "":
+		return "", fmt.Errorf("%w: no ID token", errTokenRejected)
+	}
+
+	return token.IDToken, nil
+}
+
+// verify verifies an ID token issued for the login with the nonce, returning its claims. The keys
+// are fetched again once if the token is not signed by a known key, in case they were rotated.
+func (p *provider) verify(ctx context.Context, idToken, nonce string) (jwt.Claims, error) {
+	keys, err := p.keySet(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := jwt.Verify(idToken, p.verifyCfg, keys, p.now())
+	if err != nil {
+		refetched, refetchErr := p.keySet(ctx, true)
+		if refetchErr != nil || refetched == keys {
+			return nil, err
+		}
+		if claims, err = jwt.Verify(idToken, p.verifyCfg, refetched, p.now()); err != nil {
+			return nil, err
+		}
+	}
+
+	if claims.String("nonce") != nonce {
+		return nil, errNonce
+	}
+	if azp := claims.String("azp"); azp != "" && azp != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: %q", errAuthorizedBy, azp)
+	}
+
+	return claims, nil
+}
+
+// logoutURL returns the URL of the end session endpoint the browser is sent to when logging out,
+// or an empty string if the provider does not support RP-initiated logout.
+func (p *provider) logoutURL(ctx context.Context, idToken string) string {
+	d, err := p.discover(ctx)
+	if err != nil || d.EndSessionEndpoint == "" {
+		return ""
+	}
+
+	u, err := url.Parse(d.EndSessionEndpoint)
+	if err != nil {
+		zerologr.Error(err, "Failed to parse the end session endpoint")
+		return ""
+	}
+
+	query := u.Query()
+	query.Set("client_id", p.cfg.ClientID)
+	if idToken != "" {
+		query.Set("id_token_hint", idToken)
+	}
+	if p.cfg.PostLogoutRedirectURL != "" {
+		query.Set("post_logout_redirect_uri", p.cfg.PostLogoutRedirectURL)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func (p *provider) getJSON(ctx context.Context, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	data, err := p.do(req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: decoding %s: %w", errProvider, target, err)
+	}
+	return nil
+}
+
+// do sends the request to the provider, returning the body of its successful response.
+func (p *provider) do(req *http.Request) ([]byte, error) {
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errProvider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s responded %d", errProvider, req.URL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errProvider, err)
+	}
+	return data, nil
+}
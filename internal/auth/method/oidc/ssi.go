@@ -0,0 +1,376 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/kerberos/internal/db"
+	authoidcapi "github.com/trebent/kerberos/internal/oapi/auth/oidc"
+	"github.com/trebent/kerberos/internal/security"
+	utilhttp "github.com/trebent/kerberos/internal/util/http"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	impl struct {
+		db       db.SQLClient
+		cfg      *config.AuthMethodOIDC
+		provider *provider
+	}
+
+	// customRedirectResponse is a custom implementation of [authoidcapi.LoginResponseObject] and
+	// [authoidcapi.CallbackResponseObject] that allows us to set cookies in the response.
+	customRedirectResponse struct {
+		location string
+		cookies  []string
+	}
+
+	// customLogoutResponse is a custom implementation of [authoidcapi.LogoutResponseObject] that
+	// allows us to set cookies in the response.
+	customLogoutResponse struct {
+		body    authoidcapi.LogoutResponse
+		cookies []string
+	}
+
+	// customRefreshSessionResponse is a custom implementation of
+	// [authoidcapi.RefreshResponseObject] that allows us to set cookies in the response.
+	customRefreshSessionResponse struct {
+		cookies []string
+	}
+)
+
+const (
+	// stateCookieName is the cookie binding a login to the browser that started it.
+	stateCookieName = "oidc-state"
+	// sessionCookieName is distinct from the session cookie of basic authentication, so that a
+	// browser can hold sessions of both methods.
+	sessionCookieName = "oidc-session"
+	callbackPath      = "/api/auth/oidc/callback"
+	refreshPath       = "/api/auth/oidc/refresh"
+)
+
+var (
+	_ authoidcapi.StrictServerInterface  = (*impl)(nil)
+	_ authoidcapi.LoginResponseObject    = customRedirectResponse{}
+	_ authoidcapi.CallbackResponseObject = customRedirectResponse{}
+	_ authoidcapi.LogoutResponseObject   = customLogoutResponse{}
+	_ authoidcapi.RefreshResponseObject  = customRefreshSessionResponse{}
+
+	apiErrInternal     = makeGenAPIError(http.StatusText(http.StatusInternalServerError))
+	apiErrUnauthorized = makeGenAPIError(http.StatusText(http.StatusUnauthorized))
+	apiErrBadGateway   = makeGenAPIError(http.StatusText(http.StatusBadGateway))
+)
+
+func (r customRedirectResponse) VisitLoginResponse(w http.ResponseWriter) error {
+	return r.visit(w)
+}
+
+func (r customRedirectResponse) VisitCallbackResponse(w http.ResponseWriter) error {
+	return r.visit(w)
+}
+
+func (r customRedirectResponse) visit(w http.ResponseWriter) error {
+	for _, c := range r.cookies {
+		w.Header().Add("Set-Cookie", c)
+	}
+	w.Header().Set("Location", r.location)
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func (r customLogoutResponse) VisitLogoutResponse(w http.ResponseWriter) error {
+	for _, c := range r.cookies {
+		w.Header().Add("Set-Cookie", c)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(r.body)
+}
+
+func (r customRefreshSessionResponse) VisitRefreshResponse(w http.ResponseWriter) error {
+	for _, c := range r.cookies {
+		w.Header().Add("Set-Cookie", c)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func makeGenAPIError(msg string) authoidcapi.APIErrorResponse {
+	return authoidcapi.APIErrorResponse{Errors: []string{msg}}
+}
+
+func newSSI(
+	db db.SQLClient,
+	cfg *config.AuthMethodOIDC,
+	provider *provider,
+) authoidcapi.StrictServerInterface {
+	return &impl{db: db, cfg: cfg, provider: provider}
+}
+
+// Login implements [authoidcapi.StrictServerInterface].
+func (i *impl) Login(
+	ctx context.Context,
+	req authoidcapi.LoginRequestObject,
+) (authoidcapi.LoginResponseObject, error) {
+	returnTo := "/"
+	if req.Params.ReturnTo != nil {
+		returnTo = *req.Params.ReturnTo
+	}
+	// Only paths are accepted, so that the login cannot redirect users to another site.
+	if !strings.HasPrefix(returnTo, "/") ||
+		strings.HasPrefix(returnTo, "//") ||
+		strings.HasPrefix(returnTo, "/\\") {
+		return authoidcapi.Login400JSONResponse(makeGenAPIError("returnTo must be a path")), nil
+	}
+
+	d, err := i.provider.discover(ctx)
+	if err != nil {
+		zerologr.Error(err, "Failed to discover the OIDC provider")
+		return authoidcapi.Login502JSONResponse(apiErrBadGateway), nil
+	}
+
+	l := &login{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: randomString(),
+		ReturnTo: returnTo,
+		Expires:  time.Now().Add(loginExpiry).UnixMilli(),
+	}
+	location, err := i.provider.authURL(d, l.State, l.Nonce, l.Verifier)
+	if err != nil {
+		zerologr.Error(err, "Failed to build the authorization URL")
+		return authoidcapi.Login502JSONResponse(apiErrBadGateway), nil
+	}
+	if err := dbCreateLogin(ctx, i.db, l); err != nil {
+		return authoidcapi.Login500JSONResponse(apiErrInternal), nil
+	}
+
+	stateCookie := i.stateCookie(l.State, int(loginExpiry.Seconds()))
+	return customRedirectResponse{
+		location: location,
+		cookies:  []string{stateCookie.String()},
+	}, nil
+}
+
+// Callback implements [authoidcapi.StrictServerInterface].
+func (i *impl) Callback(
+	ctx context.Context,
+	req authoidcapi.CallbackRequestObject,
+) (authoidcapi.CallbackResponseObject, error) {
+	if req.Params.Error != nil {
+		zerologr.Info(
+			"OIDC provider denied the login",
+			"error", *req.Params.Error,
+			"description", valueOf(req.Params.ErrorDescription),
+		)
+		return authoidcapi.Callback401JSONResponse(apiErrUnauthorized), nil
+	}
+	if req.Params.Code == nil || req.Params.State == nil {
+		return authoidcapi.Callback400JSONResponse(
+			makeGenAPIError("code and state are required"),
+		), nil
+	}
+
+	// The state must have been issued to this browser, or an attacker could log users in to an
+	// account of their own.
+	if state, ok := ctx.Value(stateContextKey).(string); !ok || state != *req.Params.State {
+		zerologr.Info("OIDC login state does not match the state cookie")
+		return authoidcapi.Callback401JSONResponse(apiErrUnauthorized), nil
+	}
+
+	l, err := dbTakeLogin(ctx, i.db, *req.Params.State)
+	if errors.Is(err, errNoLogin) {
+		return authoidcapi.Callback401JSONResponse(apiErrUnauthorized), nil
+	}
+	if err != nil {
+		return authoidcapi.Callback500JSONResponse(apiErrInternal), nil
+	}
+	if time.Now().UnixMilli() > l.Expires {
+		zerologr.Info("OIDC login expired")
+		return authoidcapi.Callback401JSONResponse(apiErrUnauthorized), nil
+	}
+
+	idToken, err := i.provider.exchange(ctx, *req.Params.Code, l.Verifier)
+	if errors.Is(err, errTokenRejected) {
+		zerologr.Error(err, "OIDC provider rejected the authorization code")
+		return authoidcapi.Callback401JSONResponse(apiErrUnauthorized), nil
+	}
+	if err != nil {
+		zerologr.Error(err, "Failed to exchange the authorization code")
+		return authoidcapi.Callback502JSONResponse(apiErrBadGateway), nil
+	}
+
+	claims, err := i.provider.verify(ctx, idToken, l.Nonce)
+	if errors.Is(err, errProvider) {
+		zerologr.Error(err, "Failed to fetch the OIDC provider keys")
+		return authoidcapi.Callback502JSONResponse(apiErrBadGateway), nil
+	}
+	if err != nil {
+		zerologr.Error(err, "Invalid ID token")
+		return authoidcapi.Callback401JSONResponse(apiErrUnauthorized), nil
+	}
+
+	s := &session{
+		SessionID: uuid.NewString(),
+		RefreshID: uuid.NewString(),
+		User:      claims.String(i.cfg.Claims.User),
+		IDToken:   idToken,
+		Expires:   time.Now().Add(sessionExpiry).UnixMilli(),
+	}
+	if s.User == "" {
+		zerologr.Info("ID token has no user claim", "claim", i.cfg.Claims.User)
+		return authoidcapi.Callback401JSONResponse(apiErrUnauthorized), nil
+	}
+	if i.cfg.Claims.Organisation != "" {
+		s.Organisation = claims.String(i.cfg.Claims.Organisation)
+	}
+	for _, claim := range i.cfg.Claims.Groups {
+		s.Groups = append(s.Groups, claims.Strings(claim)...)
+	}
+
+	if err := dbCreateSession(ctx, i.db, s); err != nil {
+		return authoidcapi.Callback500JSONResponse(apiErrInternal), nil
+	}
+	zerologr.V(10).Info("User has logged in successfully", "user", s.User)
+
+	expiredState := i.stateCookie("expired", -1)
+	return customRedirectResponse{
+		location: l.ReturnTo,
+		cookies:  append(i.sessionCookies(s), expiredState.String()),
+	}, nil
+}
+
+// Refresh implements [authoidcapi.StrictServerInterface].
+func (i *impl) Refresh(
+	ctx context.Context,
+	_ authoidcapi.RefreshRequestObject,
+) (authoidcapi.RefreshResponseObject, error) {
+	oldRefreshID, ok := ctx.Value(refreshContextKey).(string)
+	if !ok {
+		return authoidcapi.Refresh401JSONResponse(apiErrUnauthorized), nil
+	}
+
+	old, err := dbGetSessionByRefresh(ctx, i.db, oldRefreshID)
+	if errors.Is(err, errNoSession) {
+		return authoidcapi.Refresh401JSONResponse(apiErrUnauthorized), nil
+	}
+	if err != nil {
+		return authoidcapi.Refresh500JSONResponse(apiErrInternal), nil
+	}
+
+	if time.Now().After(time.UnixMilli(old.Expires).Add(sessionRefreshExpiry)) {
+		return authoidcapi.Refresh401JSONResponse(apiErrUnauthorized), nil
+	}
+
+	if err := dbDeleteSession(ctx, i.db, old.SessionID); err != nil {
+		return authoidcapi.Refresh500JSONResponse(apiErrInternal), nil
+	}
+
+	s := *old
+	s.SessionID = uuid.NewString()
+	s.RefreshID = uuid.NewString()
+	s.Expires = time.Now().Add(sessionExpiry).UnixMilli()
+	if err := dbCreateSession(ctx, i.db, &s); err != nil {
+		return authoidcapi.Refresh500JSONResponse(apiErrInternal), nil
+	}
+
+	return customRefreshSessionResponse{cookies: i.sessionCookies(&s)}, nil
+}
+
+// Logout implements [authoidcapi.StrictServerInterface]. The response holds the end session URL
+// of the provider, which the browser is sent to in order to log the user out of the provider too.
+func (i *impl) Logout(
+	ctx context.Context,
+	_ authoidcapi.LogoutRequestObject,
+) (authoidcapi.LogoutResponseObject, error) {
+	s := sessionFromContext(ctx)
+	if err := dbDeleteSession(ctx, i.db, s.SessionID); err != nil {
+		return authoidcapi.Logout500JSONResponse(apiErrInternal), nil
+	}
+
+	resp := customLogoutResponse{
+		cookies: []string{
+			i.sessionCookie("expired", -1).String(),
+			security.ExpiredRefreshCookieString(
+				utilhttp.ConvertSameSite(i.cfg.API.Cookies.SameSite),
+				i.cfg.API.Cookies.Domain,
+				refreshPath,
+			),
+		},
+	}
+	if logoutURL := i.provider.logoutURL(ctx, s.IDToken); logoutURL != "" {
+		resp.body.LogoutUrl = &logoutURL
+	}
+
+	return resp, nil
+}
+
+// sessionCookies returns the session, refresh and CSRF cookies of a session.
+func (i *impl) sessionCookies(s *session) []string {
+	return []string{
+		i.sessionCookie(s.SessionID, int(security.SessionMaxAge.Seconds())).String(),
+		security.RefreshCookieString(
+			s.RefreshID,
+			utilhttp.ConvertSameSite(i.cfg.API.Cookies.SameSite),
+			i.cfg.API.Cookies.Domain,
+			refreshPath,
+		),
+		security.CSRFCookieString(
+			uuid.NewString(),
+			utilhttp.ConvertSameSite(i.cfg.API.Cookies.SameSite),
+			i.cfg.API.Cookies.Domain,
+		),
+	}
+}
+
+// sessionCookie returns the cookie holding the ID of a session.
+func (i *impl) sessionCookie(value string, maxAge int) *http.Cookie {
+	//nolint:gosec // SameSite configurable
+	return &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		SameSite: utilhttp.ConvertSameSite(i.cfg.API.Cookies.SameSite),
+		HttpOnly: true,
+		Secure:   true,
+		Domain:   i.cfg.API.Cookies.Domain,
+		Path:     "/",
+		MaxAge:   maxAge,
+	}
+}
+
+// stateCookie returns the cookie holding the state of a login. It is always SameSite=Lax, since
+// the provider redirects the browser to the callback from another site.
+func (i *impl) stateCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     stateCookieName,
+		Value:    value,
+		SameSite: http.SameSiteLaxMode,
+		HttpOnly: true,
+		Secure:   true,
+		Domain:   i.cfg.API.Cookies.Domain,
+		Path:     callbackPath,
+		MaxAge:   maxAge,
+	}
+}
+
+// randomString returns 256 random bits, encoded to be usable as state, nonce and PKCE verifier.
+func randomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
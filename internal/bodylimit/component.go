@@ -0,0 +1,314 @@
+package bodylimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/composer/custom"
+	"github.com/trebent/kerberos/internal/composer/debug"
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/zerologr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type (
+	Limiter interface {
+		composer.FlowComponent
+		custom.Ordered
+	}
+	limiter struct {
+		next     composer.FlowComponent
+		cfg      *config.BodyLimitConfig
+		global   limits
+		mappings map[string]*mapping
+		exceeded metric.Int64Counter
+	}
+	Opts struct {
+		Cfg *config.BodyLimitConfig
+	}
+
+	// limits are the effective body size limits of a request in bytes, zero is unlimited.
+	limits struct {
+		request  int64
+		response int64
+	}
+	mapping struct {
+		limits limits
+		paths  []*pathLimits
+	}
+	pathLimits struct {
+		pattern string
+		limits  limits
+	}
+
+	// requestBody notes whether the request body limit was hit while the body was read, which may
+	// happen on another goroutine than the one serving the request.
+	requestBody struct {
+		io.ReadCloser
+
+		exceeded atomic.Bool
+	}
+)
+
+const (
+	componentName = "body-limiter"
+
+	exceededCounterName = "body.limit.exceeded"
+
+	directionRequest  = "request"
+	directionResponse = "response"
+
+	causeRequestTooLarge  = "request body too large"
+	causeResponseTooLarge = "response body too large"
+)
+
+var (
+	_ Limiter = (*limiter)(nil)
+
+	//nolint:errname // This is intentional to separate pure error types from wrapper API Errors.
+	apiErrResponseTooLarge = apierror.New(http.StatusBadGateway, causeResponseTooLarge)
+)
+
+func NewComponent(opts *Opts) Limiter {
+	exceeded, err := otel.GetMeterProvider().Meter("github.com/trebent/kerberos").Int64Counter(
+		exceededCounterName,
+		metric.WithDescription("Counts the request and response bodies exceeding their size limit."),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	l := &limiter{
+		cfg:      opts.Cfg,
+		global:   limits{request: *opts.Cfg.MaxRequestBytes, response: *opts.Cfg.MaxResponseBytes},
+		mappings: make(map[string]*mapping, len(opts.Cfg.Mappings)),
+		exceeded: exceeded,
+	}
+	for _, m := range l.cfg.Mappings {
+		if err := l.register(m); err != nil {
+			panic(err)
+		}
+	}
+
+	return l
+}
+
+func (l *limiter) Order() int {
+	return l.cfg.Order
+}
+
+// Next implements [composer.FlowComponent].
+func (l *limiter) Next(next composer.FlowComponent) {
+	l.next = next
+}
+
+// GetMeta implements [composer.FlowComponent].
+func (l *limiter) GetMeta() []adminapi.FlowMeta {
+	mappings := make([]adminapi.FlowMetaDataBodyLimitMapping, 0, len(l.cfg.Mappings))
+	for _, m := range l.cfg.Mappings {
+		registered := l.mappings[m.Backend]
+		paths := make([]adminapi.FlowMetaDataBodyLimitPath, 0, len(registered.paths))
+		for _, p := range registered.paths {
+			paths = append(paths, adminapi.FlowMetaDataBodyLimitPath{
+				Path:             p.pattern,
+				MaxRequestBytes:  p.limits.request,
+				MaxResponseBytes: p.limits.response,
+			})
+		}
+
+		mappings = append(mappings, adminapi.FlowMetaDataBodyLimitMapping{
+			Backend:          m.Backend,
+			MaxRequestBytes:  registered.limits.request,
+			MaxResponseBytes: registered.limits.response,
+			Paths:            &paths,
+		})
+	}
+
+	fmd := adminapi.FlowMeta_Data{}
+	if err := fmd.FromFlowMetaDataBodyLimit(adminapi.FlowMetaDataBodyLimit{
+		MaxRequestBytes:  l.global.request,
+		MaxResponseBytes: l.global.response,
+		Mappings:         &mappings,
+	}); err != nil {
+		panic(err)
+	}
+
+	return append([]adminapi.FlowMeta{
+		{
+			Name: componentName,
+			Data: fmd,
+		},
+	}, l.next.GetMeta()...)
+}
+
+// ServeHTTP implements [composer.FlowComponent].
+func (l *limiter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	backend, _ := req.Context().Value(composer.BackendContextKey).(string)
+	lim := l.limitsFor(backend, req.URL.Path)
+	if lim.request == 0 && lim.response == 0 {
+		l.next.ServeHTTP(w, req)
+		return
+	}
+
+	debugStart := time.Now()
+	debugCall := composer.DebugFromContext(req.Context())
+	logger, _ := logr.FromContext(req.Context())
+	logger = logger.WithName(componentName)
+
+	var body *requestBody
+	if lim.request > 0 {
+		if req.ContentLength > lim.request {
+			logger.Info(
+				"Rejected request body",
+				"backend", backend,
+				"length", req.ContentLength,
+				"limit", lim.request,
+			)
+			l.count(req.Context(), backend, directionRequest)
+			apierror.ErrorHandler(w, req, apierror.ErrRequestEntityTooLarge)
+			debugCall.AddTransition(
+				componentName,
+				debug.CallDirectionInbound,
+				debugStart,
+				time.Now(),
+				debug.CallResultFailure,
+				causeRequestTooLarge,
+			)
+			return
+		}
+
+		// Bodies without a length are cut off at the limit while they are read. The forwarder
+		// answers the resulting *http.MaxBytesError with a 413 response.
+		if req.Body != nil && req.Body != http.NoBody {
+			body = &requestBody{ReadCloser: http.MaxBytesReader(w, req.Body, lim.request)}
+			req.Body = body
+		}
+	}
+
+	debugCall.AddTransition(
+		componentName,
+		debug.CallDirectionInbound,
+		debugStart,
+		time.Now(),
+		debug.CallResultSuccess,
+		"",
+	)
+
+	var rw *responseWriter
+	if lim.response > 0 {
+		rw = &responseWriter{ResponseWriter: w, limit: lim.response}
+		w = rw
+	}
+
+	l.next.ServeHTTP(w, req)
+
+	debugStart = time.Now()
+	cause := ""
+	switch {
+	case body != nil && body.exceeded.Load():
+		logger.Info("Cut off request body", "backend", backend, "limit", lim.request)
+		l.count(req.Context(), backend, directionRequest)
+		cause = causeRequestTooLarge
+	case rw != nil && rw.exceeded:
+		logger.Info("Cut off response body", "backend", backend, "limit", lim.response)
+		l.count(req.Context(), backend, directionResponse)
+		cause = causeResponseTooLarge
+	default:
+		return
+	}
+
+	debugCall.AddTransition(
+		componentName,
+		debug.CallDirectionOutbound,
+		debugStart,
+		time.Now(),
+		debug.CallResultFailure,
+		cause,
+	)
+
+	// The response status has already been sent, the composer aborts the response once the other
+	// components have recorded it.
+	if rw != nil && rw.exceeded && !rw.replaced {
+		composer.AbortResponse(req.Context())
+	}
+}
+
+// limitsFor returns the limits of a request to the input backend and path.
+func (l *limiter) limitsFor(backend, requestPath string) limits {
+	m, ok := l.mappings[backend]
+	if !ok {
+		return l.global
+	}
+
+	for _, p := range m.paths {
+		// Patterns are validated when registered.
+		if match, _ := path.Match(p.pattern, requestPath); match {
+			return p.limits
+		}
+	}
+
+	return m.limits
+}
+
+func (l *limiter) count(ctx context.Context, backend, direction string) {
+	l.exceeded.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("krb.backend", backend),
+		attribute.String("krb.direction", direction),
+	))
+}
+
+func (l *limiter) register(m *config.BodyLimitMapping) error {
+	zerologr.Info("Preparing body limits", "backend", m.Backend)
+
+	registered := &mapping{
+		limits: inherit(l.global, m.BodyLimits),
+		paths:  make([]*pathLimits, 0, len(m.Paths)),
+	}
+	for _, p := range m.Paths {
+		if _, err := path.Match(p.Path, ""); err != nil {
+			return fmt.Errorf("backend %s path %q: %w", m.Backend, p.Path, err)
+		}
+
+		registered.paths = append(registered.paths, &pathLimits{
+			pattern: p.Path,
+			limits:  inherit(registered.limits, p.BodyLimits),
+		})
+	}
+	l.mappings[m.Backend] = registered
+
+	return nil
+}
+
+// inherit returns the input limits with the configured ones replacing them.
+func inherit(parent limits, configured config.BodyLimits) limits {
+	if configured.MaxRequestBytes != nil {
+		parent.request = *configured.MaxRequestBytes
+	}
+	if configured.MaxResponseBytes != nil {
+		parent.response = *configured.MaxResponseBytes
+	}
+
+	return parent
+}
+
+func (b *requestBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		b.exceeded.Store(true)
+	}
+
+	return n, err
+}
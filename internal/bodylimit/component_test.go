@@ -0,0 +1,205 @@
+package bodylimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+)
+
+// terminal ends the flow in tests, reading the request body and responding with a body of the
+// configured size.
+type terminal struct {
+	composer.Dummy
+
+	responseBytes int
+	contentLength bool
+}
+
+func (t *terminal) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, err := io.ReadAll(req.Body); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apierror.ErrorHandler(w, req, apierror.ErrRequestEntityTooLarge)
+			return
+		}
+		apierror.ErrorHandler(w, req, err)
+		return
+	}
+
+	if t.contentLength {
+		w.Header().Set("Content-Length", strconv.Itoa(t.responseBytes))
+	}
+	w.WriteHeader(http.StatusOK)
+	for range t.responseBytes {
+		if _, err := w.Write([]byte("a")); err != nil {
+			return
+		}
+	}
+}
+
+func (*terminal) GetMeta() []adminapi.FlowMeta {
+	return nil
+}
+
+func newTestLimiter(t *testing.T, cfg *config.BodyLimitConfig, next *terminal) Limiter {
+	t.Helper()
+
+	if cfg.MaxRequestBytes == nil {
+		cfg.MaxRequestBytes = new(int64(0))
+	}
+	if cfg.MaxResponseBytes == nil {
+		cfg.MaxResponseBytes = new(int64(0))
+	}
+
+	l := NewComponent(&Opts{Cfg: cfg})
+	l.Next(next)
+	return l
+}
+
+func serve(
+	l Limiter,
+	backend, target string,
+	body io.Reader,
+	length int64,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, body)
+	req.ContentLength = length
+
+	recorder := httptest.NewRecorder()
+	l.ServeHTTP(
+		recorder,
+		req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, backend)),
+	)
+	return recorder
+}
+
+func TestBodyLimitRequest(t *testing.T) {
+	l := newTestLimiter(t, &config.BodyLimitConfig{
+		BodyLimits: config.BodyLimits{MaxRequestBytes: new(int64(10))},
+	}, &terminal{})
+
+	tests := []struct {
+		name     string
+		body     string
+		length   int64
+		expected int
+	}{
+		{name: "Within limit", body: "small", length: 5, expected: http.StatusOK},
+		{
+			name:     "Content length over limit",
+			body:     "far too large",
+			length:   13,
+			expected: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "Unknown length over limit",
+			body:     "far too large",
+			length:   -1,
+			expected: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(l, "backend1", "/", strings.NewReader(test.body), test.length)
+			if recorder.Code != test.expected {
+				t.Errorf("Expected status code %d, got %d", test.expected, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestBodyLimitInheritance(t *testing.T) {
+	cfg := &config.BodyLimitConfig{
+		BodyLimits: config.BodyLimits{
+			MaxRequestBytes:  new(int64(10)),
+			MaxResponseBytes: new(int64(100)),
+		},
+		Mappings: []*config.BodyLimitMapping{
+			{
+				Backend:    "uploads",
+				BodyLimits: config.BodyLimits{MaxRequestBytes: new(int64(1000))},
+				Paths: []*config.BodyLimitPath{
+					{Path: "/files/*", BodyLimits: config.BodyLimits{MaxRequestBytes: new(int64(0))}},
+				},
+			},
+		},
+	}
+	//nolint:errcheck // the concrete type is known
+	l := newTestLimiter(t, cfg, &terminal{}).(*limiter)
+
+	tests := []struct {
+		backend  string
+		path     string
+		expected limits
+	}{
+		{backend: "other", path: "/", expected: limits{request: 10, response: 100}},
+		{backend: "uploads", path: "/", expected: limits{request: 1000, response: 100}},
+		{backend: "uploads", path: "/files/a", expected: limits{request: 0, response: 100}},
+		{backend: "uploads", path: "/files/a/b", expected: limits{request: 1000, response: 100}},
+	}
+	for _, test := range tests {
+		if actual := l.limitsFor(test.backend, test.path); actual != test.expected {
+			t.Errorf("Expected %+v for %s %s, got %+v", test.expected, test.backend, test.path, actual)
+		}
+	}
+}
+
+func TestBodyLimitInvalidPath(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected an invalid path pattern to panic")
+		}
+	}()
+
+	newTestLimiter(t, &config.BodyLimitConfig{
+		Mappings: []*config.BodyLimitMapping{
+			{Backend: "backend1", Paths: []*config.BodyLimitPath{{Path: "/files/["}}},
+		},
+	}, &terminal{})
+}
+
+func TestBodyLimitResponse(t *testing.T) {
+	cfg := &config.BodyLimitConfig{
+		BodyLimits: config.BodyLimits{MaxResponseBytes: new(int64(10))},
+	}
+
+	recorder := serve(
+		newTestLimiter(t, cfg, &terminal{responseBytes: 10, contentLength: true}),
+		"backend1", "/", nil, 0,
+	)
+	if recorder.Code != http.StatusOK || recorder.Body.Len() != 10 {
+		t.Errorf("Expected a full response, got %d with %d bytes", recorder.Code, recorder.Body.Len())
+	}
+
+	recorder = serve(
+		newTestLimiter(t, cfg, &terminal{responseBytes: 20, contentLength: true}),
+		"backend1", "/", nil, 0,
+	)
+	if recorder.Code != http.StatusBadGateway {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadGateway, recorder.Code)
+	}
+	if !strings.Contains(recorder.Body.String(), causeResponseTooLarge) {
+		t.Errorf("Expected the error response body, got %q", recorder.Body.String())
+	}
+
+	// Responses without length are aborted by the composer, once the other components are done.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := composer.WithAbort(context.WithValue(req.Context(), composer.BackendContextKey, "backend1"))
+	newTestLimiter(t, cfg, &terminal{responseBytes: 20}).ServeHTTP(
+		httptest.NewRecorder(),
+		req.WithContext(ctx),
+	)
+	if !composer.ResponseAborted(ctx) {
+		t.Error("Expected a response without length over the limit to be aborted")
+	}
+}
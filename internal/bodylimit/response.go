@@ -0,0 +1,94 @@
+package bodylimit
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+)
+
+// responseWriter guards the size of a response body. A response announcing a larger body than the
+// limit is replaced with an error response, a response that grows beyond the limit is cut off.
+type responseWriter struct {
+	http.ResponseWriter
+
+	limit       int64
+	written     int64
+	wroteHeader bool
+	// exceeded is set once the body exceeds the limit, replaced if the response was replaced with
+	// an error response before its header was sent.
+	exceeded bool
+	replaced bool
+}
+
+var (
+	_ http.ResponseWriter = (*responseWriter)(nil)
+	_ http.Flusher        = (*responseWriter)(nil)
+
+	errResponseTooLarge = errors.New(causeResponseTooLarge)
+)
+
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if rw.exceeded || rw.wroteHeader {
+		// Errors reported after the limit was hit must not reach the client.
+		if !rw.exceeded {
+			rw.ResponseWriter.WriteHeader(statusCode)
+		}
+		return
+	}
+
+	// Informational responses are followed by the final one.
+	if statusCode >= http.StatusOK {
+		rw.wroteHeader = true
+	}
+
+	if length, err := strconv.ParseInt(rw.Header().Get("Content-Length"), 10, 64); err == nil &&
+		length > rw.limit {
+		rw.exceeded = true
+		rw.replaced = true
+		clear(rw.Header())
+		apierror.ErrorHandler(rw.ResponseWriter, nil, apiErrResponseTooLarge)
+		return
+	}
+
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if rw.exceeded {
+		return 0, errResponseTooLarge
+	}
+
+	if rw.written+int64(len(p)) > rw.limit {
+		rw.exceeded = true
+		return 0, errResponseTooLarge
+	}
+
+	n, err := rw.ResponseWriter.Write(p)
+	rw.written += int64(n)
+	return n, err
+}
+
+func (rw *responseWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if rw.exceeded {
+		return
+	}
+
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying response writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
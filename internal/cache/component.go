@@ -0,0 +1,431 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	adminext "github.com/trebent/kerberos/internal/admin/extensions"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/composer/custom"
+	"github.com/trebent/kerberos/internal/composer/debug"
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/kerberos/internal/db"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	"github.com/trebent/zerologr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type (
+	Cache interface {
+		composer.FlowComponent
+		custom.Ordered
+		adminext.CachePurger
+	}
+	cache struct {
+		next     composer.FlowComponent
+		cfg      *config.CacheConfig
+		mappings map[string]*config.CacheMapping
+		memory   *memory
+		// persistent is nil without a persistent tier.
+		persistent *persistent
+		lookups    metric.Int64Counter
+	}
+	Opts struct {
+		Cfg *config.CacheConfig
+		// SQLClient backs the persistent tier, required if one is configured.
+		SQLClient db.SQLClient
+	}
+)
+
+const (
+	componentName = "cache"
+
+	lookupCounterName = "cache.lookup.count"
+
+	resultHit         = "hit"
+	resultMiss        = "miss"
+	resultRevalidated = "revalidated"
+	// resultStored is only used in debug transitions, stored responses are counted as misses.
+	resultStored = "stored"
+)
+
+var _ Cache = (*cache)(nil)
+
+func NewComponent(opts *Opts) (Cache, error) {
+	lookups, err := otel.GetMeterProvider().Meter("github.com/trebent/kerberos").Int64Counter(
+		lookupCounterName,
+		metric.WithDescription("Counts the cache lookups of cacheable requests by their result."),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &cache{
+		cfg:      opts.Cfg,
+		mappings: make(map[string]*config.CacheMapping, len(opts.Cfg.Mappings)),
+		memory:   newMemory(opts.Cfg.MaxEntries, opts.Cfg.MaxBytes),
+		lookups:  lookups,
+	}
+	for _, m := range c.cfg.Mappings {
+		zerologr.Info("Caching responses", "backend", m.Backend)
+		c.mappings[m.Backend] = m
+	}
+
+	if c.cfg.Persistent != nil {
+		if c.persistent, err = newPersistent(opts.SQLClient, c.cfg.Persistent.MaxEntries); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (c *cache) Order() int {
+	return c.cfg.Order
+}
+
+// Next implements [composer.FlowComponent].
+func (c *cache) Next(next composer.FlowComponent) {
+	c.next = next
+}
+
+// GetMeta implements [composer.FlowComponent].
+func (c *cache) GetMeta() []adminapi.FlowMeta {
+	mappings := make([]adminapi.FlowMetaDataCacheMapping, 0, len(c.cfg.Mappings))
+	for _, m := range c.cfg.Mappings {
+		mappings = append(mappings, adminapi.FlowMetaDataCacheMapping{
+			Backend:    m.Backend,
+			DefaultTtl: m.DefaultTTLMs,
+		})
+	}
+
+	entries, bytes := c.memory.stats()
+	fmd := adminapi.FlowMeta_Data{}
+	if err := fmd.FromFlowMetaDataCache(adminapi.FlowMetaDataCache{
+		MaxEntries: c.cfg.MaxEntries,
+		MaxBytes:   c.cfg.MaxBytes,
+		Persistent: c.persistent != nil,
+		Entries:    entries,
+		Bytes:      bytes,
+		Mappings:   &mappings,
+	}); err != nil {
+		panic(err)
+	}
+
+	return append([]adminapi.FlowMeta{
+		{
+			Name: componentName,
+			Data: fmd,
+		},
+	}, c.next.GetMeta()...)
+}
+
+// Purge implements [adminext.CachePurger].
+func (c *cache) Purge(ctx context.Context, backend, pathPrefix string) (int, error) {
+	purged := c.memory.purge(backend, pathPrefix)
+	if c.persistent == nil {
+		return purged, nil
+	}
+
+	// The persistent tier holds the entries of the memory tier, unless storing them failed.
+	persisted, err := c.persistent.purge(ctx, backend, pathPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(purged, persisted), nil
+}
+
+// ServeHTTP implements [composer.FlowComponent].
+func (c *cache) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	backend, _ := req.Context().Value(composer.BackendContextKey).(string)
+	mapping, ok := c.mappings[backend]
+	if !ok {
+		c.next.ServeHTTP(w, req)
+		return
+	}
+
+	resource := backend + " " + req.URL.RequestURI()
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		c.serveUncached(w, req, resource)
+		return
+	}
+
+	requestCC := parseCacheControl(req.Header)
+	if requestCC.has("no-store") {
+		c.next.ServeHTTP(w, req)
+		return
+	}
+
+	debugStart := time.Now()
+	debugCall := composer.DebugFromContext(req.Context())
+	logger, _ := logr.FromContext(req.Context())
+	logger = logger.WithName(componentName)
+
+	now := time.Now()
+	e := c.lookup(req.Context(), resource, backend, req)
+	// Authenticated requests are only answered with responses that were explicitly shared, others
+	// may have been personalised for another user.
+	if e != nil && authenticated(req) && !shared(parseCacheControl(e.header)) {
+		e = nil
+	}
+	if e != nil && c.usable(e, requestCC, now) {
+		logger.V(20).Info("Serving cached response", "backend", backend)
+		c.count(req.Context(), backend, resultHit)
+		debugCall.AddTransition(
+			componentName,
+			debug.CallDirectionInbound,
+			debugStart,
+			time.Now(),
+			debug.CallResultSuccess,
+			"",
+			debug.Attr("result", resultHit),
+		)
+		serve(w, req, e, now)
+		return
+	}
+
+	// Stale entries are revalidated with their entity tag, those without one are dropped.
+	outbound := req
+	if e != nil && e.header.Get("Etag") == "" {
+		c.delete(req.Context(), e)
+		e = nil
+	}
+	if e != nil {
+		outbound = conditional(req, e.header.Get("Etag"))
+	}
+
+	debugCall.AddTransition(
+		componentName,
+		debug.CallDirectionInbound,
+		debugStart,
+		time.Now(),
+		debug.CallResultSuccess,
+		"",
+		debug.Attr("result", resultMiss),
+	)
+
+	rw := &responseWriter{ResponseWriter: w, revalidating: e != nil, limit: c.cfg.MaxEntryBytes}
+	c.next.ServeHTTP(rw, outbound)
+
+	debugStart = time.Now()
+	result := c.complete(w, req, rw, e, resource, mapping, now)
+	if result == resultMiss {
+		return
+	}
+
+	debugCall.AddTransition(
+		componentName,
+		debug.CallDirectionOutbound,
+		debugStart,
+		time.Now(),
+		debug.CallResultSuccess,
+		"",
+		debug.Attr("result", result),
+	)
+}
+
+// complete answers a revalidated request from the cache and stores cacheable responses, returning
+// the result of the request.
+func (c *cache) complete(
+	w http.ResponseWriter,
+	req *http.Request,
+	rw *responseWriter,
+	stale *entry,
+	resource string,
+	mapping *config.CacheMapping,
+	sent time.Time,
+) string {
+	logger, _ := logr.FromContext(req.Context())
+	logger = logger.WithName(componentName)
+
+	defaultTTL := time.Duration(mapping.DefaultTTLMs) * time.Millisecond
+	if authenticated(req) {
+		// Only responses explicitly shared are stored for authenticated requests, by their own
+		// freshness information.
+		defaultTTL = 0
+	}
+	if rw.notModified {
+		logger.V(20).Info("Revalidated cached response", "backend", mapping.Backend)
+		c.count(req.Context(), mapping.Backend, resultRevalidated)
+
+		revalidated := stale.revalidated(rw.header, responseTime(rw.header, sent), defaultTTL)
+		c.store(req.Context(), revalidated)
+
+		// Headers of the 304 response are replaced with those of the stored one.
+		clear(w.Header())
+		serve(w, req, revalidated, time.Now())
+		return resultRevalidated
+	}
+
+	c.count(req.Context(), mapping.Backend, resultMiss)
+	if c.record(req, rw, resource, mapping.Backend, defaultTTL, sent) {
+		return resultStored
+	}
+
+	return resultMiss
+}
+
+// serveUncached forwards a request with a method whose responses are not cached. Unsafe methods
+// may change the resource, its cached responses are no longer trusted after them.
+func (c *cache) serveUncached(w http.ResponseWriter, req *http.Request, resource string) {
+	c.next.ServeHTTP(w, req)
+
+	if req.Method != http.MethodOptions && req.Method != http.MethodTrace {
+		c.invalidate(req.Context(), resource)
+	}
+}
+
+// record stores the recorded response if it is cacheable, reporting whether it was stored.
+func (c *cache) record(
+	req *http.Request,
+	rw *responseWriter,
+	resource, backend string,
+	defaultTTL time.Duration,
+	received time.Time,
+) bool {
+	if req.Method != http.MethodGet || rw.truncated || rw.status == 0 {
+		return false
+	}
+
+	cc := parseCacheControl(rw.header)
+	if !cacheable(rw.status, rw.header, cc) || !completeBody(rw.header, int64(rw.body.Len())) {
+		return false
+	}
+	if authenticated(req) && !shared(cc) {
+		return false
+	}
+
+	ttl, ok := lifetime(rw.header, cc, defaultTTL)
+	if cc.has("no-cache") {
+		// Stored to be revalidated before every use.
+		ttl, ok = 0, true
+	}
+	if !ok || (ttl == 0 && rw.header.Get("Etag") == "") {
+		return false
+	}
+
+	c.store(req.Context(), newEntry(
+		resource,
+		backend,
+		req.URL.Path,
+		req,
+		rw.status,
+		rw.header,
+		rw.body.Bytes(),
+		responseTime(rw.header, received),
+		ttl,
+	))
+	return true
+}
+
+// usable reports whether an entry may be served without revalidation given the request's
+// Cache-Control directives.
+func (c *cache) usable(e *entry, requestCC directives, now time.Time) bool {
+	if !e.fresh(now) || requestCC.has("no-cache") {
+		return false
+	}
+
+	if maxAge, ok := requestCC.seconds("max-age"); ok && e.age(now) > maxAge {
+		return false
+	}
+
+	return true
+}
+
+// lookup returns the entry of the resource selected by the request, promoting entries found in the
+// persistent tier to memory.
+func (c *cache) lookup(
+	ctx context.Context,
+	resource, backend string,
+	req *http.Request,
+) *entry {
+	if e := c.memory.get(resource, req); e != nil || c.persistent == nil {
+		return e
+	}
+
+	e, err := c.persistent.get(ctx, resource, backend, req.URL.Path, req)
+	if err != nil || e == nil {
+		return nil
+	}
+
+	c.memory.set(e)
+	return e
+}
+
+func (c *cache) store(ctx context.Context, e *entry) {
+	c.memory.set(e)
+	if c.persistent != nil {
+		// The response has been served, it is stored even if the client has gone away.
+		_ = c.persistent.set(context.WithoutCancel(ctx), e)
+	}
+}
+
+func (c *cache) delete(ctx context.Context, e *entry) {
+	c.memory.delete(e)
+	if c.persistent != nil {
+		_ = c.persistent.delete(context.WithoutCancel(ctx), e)
+	}
+}
+
+func (c *cache) invalidate(ctx context.Context, resource string) {
+	c.memory.invalidate(resource)
+	if c.persistent != nil {
+		_ = c.persistent.invalidate(context.WithoutCancel(ctx), resource)
+	}
+}
+
+func (c *cache) count(ctx context.Context, backend, result string) {
+	c.lookups.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("krb.backend", backend),
+		attribute.String("krb.result", result),
+	))
+}
+
+// serve answers the request with the cached response, or a 304 response if the request's
+// If-None-Match matches it.
+func serve(w http.ResponseWriter, req *http.Request, e *entry, now time.Time) {
+	for name, values := range e.header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Header().Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+
+	if etagMatches(req.Header.Get("If-None-Match"), e.header.Get("Etag")) {
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(e.status)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(e.body)
+	}
+}
+
+// conditional returns a copy of the request that only gets a full response if the resource no
+// longer has the input entity tag.
+func conditional(req *http.Request, etag string) *http.Request {
+	outbound := req.Clone(req.Context())
+	outbound.Header.Set("If-None-Match", etag)
+	outbound.Header.Del("If-Modified-Since")
+
+	return outbound
+}
+
+// responseTime returns when a response was generated, from its Age header and the input time it
+// was received.
+func responseTime(header http.Header, received time.Time) time.Time {
+	age, _ := deltaSeconds(header.Get("Age"))
+	return received.Add(-age)
+}
+
+// completeBody reports whether a recorded body has the length announced by the response.
+func completeBody(header http.Header, length int64) bool {
+	announced, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	return err != nil || announced == length
+}
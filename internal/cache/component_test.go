@@ -0,0 +1,340 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/kerberos/internal/db/sqlite"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+)
+
+// terminal ends the flow in tests, counting the requests reaching it and answering them with the
+// configured headers. Requests with a matching If-None-Match get a 304 response.
+type terminal struct {
+	composer.Dummy
+
+	header http.Header
+	calls  int
+}
+
+func (t *terminal) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	t.calls++
+	for name, values := range t.header {
+		w.Header()[name] = values
+	}
+
+	if etagMatches(req.Header.Get("If-None-Match"), t.header.Get("Etag")) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("body of " + req.URL.Path + " for " + req.Header.Get("Accept-Language")))
+}
+
+func (*terminal) GetMeta() []adminapi.FlowMeta {
+	return nil
+}
+
+func newTestCache(t *testing.T, cfg *config.CacheConfig, next *terminal) Cache {
+	t.Helper()
+
+	if cfg.Mappings == nil {
+		cfg.Mappings = []*config.CacheMapping{{Backend: "backend1"}}
+	}
+	cfg.MaxEntries, cfg.MaxBytes, cfg.MaxEntryBytes = 100, 1<<20, 1<<10
+
+	c, err := NewComponent(&Opts{
+		Cfg:       cfg,
+		SQLClient: sqlite.New(&sqlite.Opts{DSN: filepath.Join(t.TempDir(), "cache.db")}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	c.Next(next)
+	return c
+}
+
+func serveRequest(c Cache, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	recorder := httptest.NewRecorder()
+	c.ServeHTTP(
+		recorder,
+		req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, "backend1")),
+	)
+	return recorder
+}
+
+func TestCacheFreshness(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        http.Header
+		requestHeader http.Header
+		expectedCalls int
+	}{
+		{
+			name:          "Max age",
+			header:        http.Header{"Cache-Control": {"max-age=60"}},
+			expectedCalls: 1,
+		},
+		{
+			name:          "Expires",
+			header:        http.Header{"Expires": {"Thu, 01 Jan 2099 00:00:00 GMT"}},
+			expectedCalls: 1,
+		},
+		{
+			name:          "No freshness",
+			header:        http.Header{},
+			expectedCalls: 2,
+		},
+		{
+			name:          "No store",
+			header:        http.Header{"Cache-Control": {"no-store, max-age=60"}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "Private",
+			header:        http.Header{"Cache-Control": {"private, max-age=60"}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "Set-Cookie",
+			header:        http.Header{"Cache-Control": {"max-age=60"}, "Set-Cookie": {"a=b"}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "Authorised request",
+			header:        http.Header{"Cache-Control": {"max-age=60"}},
+			requestHeader: http.Header{"Authorization": {"Bearer token"}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "Authorised request of public response",
+			header:        http.Header{"Cache-Control": {"public, max-age=60"}},
+			requestHeader: http.Header{"Authorization": {"Bearer token"}},
+			expectedCalls: 1,
+		},
+		{
+			name:          "Authenticated user",
+			header:        http.Header{"Cache-Control": {"max-age=60"}},
+			requestHeader: http.Header{"X-Krb-User": {"1"}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "Request no-cache",
+			header:        http.Header{"Cache-Control": {"max-age=60"}},
+			requestHeader: http.Header{"Cache-Control": {"no-cache"}},
+			expectedCalls: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			next := &terminal{header: test.header}
+			c := newTestCache(t, &config.CacheConfig{}, next)
+
+			for range 2 {
+				recorder := serveRequest(c, http.MethodGet, "/resource", test.requestHeader)
+				if recorder.Code != http.StatusOK || recorder.Body.String() != "body of /resource for " {
+					t.Fatalf("Unexpected response %d %q", recorder.Code, recorder.Body.String())
+				}
+			}
+
+			if next.calls != test.expectedCalls {
+				t.Errorf("Expected %d backend calls, got %d", test.expectedCalls, next.calls)
+			}
+		})
+	}
+}
+
+func TestCacheDefaultTTL(t *testing.T) {
+	next := &terminal{header: http.Header{}}
+	c := newTestCache(t, &config.CacheConfig{
+		Mappings: []*config.CacheMapping{{Backend: "backend1", DefaultTTLMs: 60000}},
+	}, next)
+
+	serveRequest(c, http.MethodGet, "/resource", nil)
+	recorder := serveRequest(c, http.MethodHead, "/resource", nil)
+	if next.calls != 1 {
+		t.Errorf("Expected the default TTL to cache the response, got %d backend calls", next.calls)
+	}
+	if recorder.Code != http.StatusOK || recorder.Body.Len() != 0 {
+		t.Errorf("Expected a HEAD response without body, got %d %q", recorder.Code, recorder.Body)
+	}
+	if recorder.Header().Get("Age") == "" {
+		t.Error("Expected a cached response to have an Age header")
+	}
+}
+
+func TestCacheSessions(t *testing.T) {
+	next := &terminal{header: http.Header{}}
+	c := newTestCache(t, &config.CacheConfig{
+		Mappings: []*config.CacheMapping{{Backend: "backend1", DefaultTTLMs: 60000}},
+	}, next)
+
+	// The authorizer has set the identity of the session of each request.
+	serveRequest(c, http.MethodGet, "/profile", http.Header{
+		"Cookie": {"session=alice"}, "X-Krb-User": {"alice"},
+	})
+	serveRequest(c, http.MethodGet, "/profile", http.Header{
+		"Cookie": {"session=bob"}, "X-Krb-User": {"bob"},
+	})
+	if next.calls != 2 {
+		t.Errorf("Expected the response of one session not to be served to another, got %d calls", next.calls)
+	}
+
+	// Nor is it stored to be served to anonymous requests.
+	serveRequest(c, http.MethodGet, "/profile", nil)
+	if next.calls != 3 {
+		t.Errorf("Expected an anonymous request to reach the backend, got %d calls", next.calls)
+	}
+
+	// Anonymous responses are not served to sessions either, they may get a personalised one.
+	serveRequest(c, http.MethodGet, "/profile", http.Header{"Cookie": {"session=alice"}})
+	if next.calls != 4 {
+		t.Errorf("Expected a session not to be served an anonymous response, got %d calls", next.calls)
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	next := &terminal{header: http.Header{
+		"Cache-Control": {"max-age=60"},
+		"Vary":          {"Accept-Language"},
+	}}
+	c := newTestCache(t, &config.CacheConfig{}, next)
+
+	for _, language := range []string{"en", "sv", "en", "sv"} {
+		recorder := serveRequest(
+			c,
+			http.MethodGet,
+			"/resource",
+			http.Header{"Accept-Language": {language}},
+		)
+		if expected := "body of /resource for " + language; recorder.Body.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, recorder.Body.String())
+		}
+	}
+
+	if next.calls != 2 {
+		t.Errorf("Expected a backend call per variant, got %d", next.calls)
+	}
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	next := &terminal{header: http.Header{
+		"Cache-Control": {"no-cache"},
+		"Etag":          {`"v1"`},
+	}}
+	c := newTestCache(t, &config.CacheConfig{}, next)
+
+	serveRequest(c, http.MethodGet, "/resource", nil)
+
+	recorder := serveRequest(c, http.MethodGet, "/resource", nil)
+	if next.calls != 2 {
+		t.Fatalf("Expected a no-cache response to be revalidated, got %d backend calls", next.calls)
+	}
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "body of /resource for " {
+		t.Errorf("Expected the cached response, got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = serveRequest(
+		c,
+		http.MethodGet,
+		"/resource",
+		http.Header{"If-None-Match": {`W/"v1"`}},
+	)
+	if recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 {
+		t.Errorf("Expected a 304 response to a matching client ETag, got %d", recorder.Code)
+	}
+
+	next.header.Set("Etag", `"v2"`)
+	recorder = serveRequest(c, http.MethodGet, "/resource", nil)
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Etag") != `"v2"` {
+		t.Errorf("Expected the changed response, got %d %v", recorder.Code, recorder.Header())
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	next := &terminal{header: http.Header{"Cache-Control": {"max-age=60"}}}
+	c := newTestCache(t, &config.CacheConfig{}, next)
+
+	serveRequest(c, http.MethodGet, "/resource", nil)
+	serveRequest(c, http.MethodPut, "/resource", nil)
+	serveRequest(c, http.MethodGet, "/resource", nil)
+
+	if next.calls != 3 {
+		t.Errorf("Expected a PUT to invalidate the cached response, got %d backend calls", next.calls)
+	}
+}
+
+func TestCachePurge(t *testing.T) {
+	next := &terminal{header: http.Header{"Cache-Control": {"max-age=60"}}}
+	c := newTestCache(t, &config.CacheConfig{Persistent: &config.CachePersistence{}}, next)
+
+	for _, target := range []string{"/users/1", "/users/2", "/orders/1"} {
+		serveRequest(c, http.MethodGet, target, nil)
+	}
+
+	purged, err := c.Purge(t.Context(), "backend2", "")
+	if err != nil || purged != 0 {
+		t.Fatalf("Expected no purged responses of another backend, got %d, %v", purged, err)
+	}
+
+	purged, err = c.Purge(t.Context(), "backend1", "/users/")
+	if err != nil || purged != 2 {
+		t.Fatalf("Expected 2 purged responses, got %d, %v", purged, err)
+	}
+
+	for _, target := range []string{"/users/1", "/orders/1"} {
+		serveRequest(c, http.MethodGet, target, nil)
+	}
+	if next.calls != 4 {
+		t.Errorf("Expected only the purged response to be fetched again, got %d calls", next.calls)
+	}
+}
+
+func TestCachePersistent(t *testing.T) {
+	sqlClient := sqlite.New(&sqlite.Opts{DSN: filepath.Join(t.TempDir(), "cache.db")})
+	cfg := &config.CacheConfig{
+		MaxEntries:    100,
+		MaxBytes:      1 << 20,
+		MaxEntryBytes: 1 << 10,
+		Persistent:    &config.CachePersistence{MaxEntries: 100},
+		Mappings:      []*config.CacheMapping{{Backend: "backend1"}},
+	}
+	next := &terminal{header: http.Header{
+		"Cache-Control": {"max-age=60"},
+		"Vary":          {"Accept-Language"},
+	}}
+
+	for range 2 {
+		// A new component has an empty memory tier, the response is found in the database.
+		c, err := NewComponent(&Opts{Cfg: cfg, SQLClient: sqlClient})
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		c.Next(next)
+
+		recorder := serveRequest(
+			c,
+			http.MethodGet,
+			"/resource",
+			http.Header{"Accept-Language": {"en"}},
+		)
+		if recorder.Body.String() != "body of /resource for en" {
+			t.Errorf("Unexpected response body %q", recorder.Body.String())
+		}
+	}
+
+	if next.calls != 1 {
+		t.Errorf("Expected the persisted response to be served, got %d backend calls", next.calls)
+	}
+}
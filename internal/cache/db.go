@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/trebent/kerberos/internal/db"
+	"github.com/trebent/zerologr"
+)
+
+// persistent is the database tier of the cache, holding the entries stored in memory until they are
+// pruned or purged. It is bounded by a number of entries, the oldest are pruned first.
+type persistent struct {
+	sqlClient  db.SQLClient
+	maxEntries int
+
+	mu       sync.Mutex
+	prunedAt time.Time
+}
+
+const (
+	selectEntries = "SELECT variant, vary, status, header, body, stored, expires FROM cache_entries WHERE resource = @resource;"
+	upsertEntry   = "INSERT INTO cache_entries (variant, resource, backend, path, vary, status, header, body, stored, expires) VALUES(@variant, @resource, @backend, @path, @vary, @status, @header, @body, @stored, @expires) ON CONFLICT (variant) DO UPDATE SET vary = excluded.vary, status = excluded.status, header = excluded.header, body = excluded.body, stored = excluded.stored, expires = excluded.expires;"
+	deleteEntry   = "DELETE FROM cache_entries WHERE variant = @variant;"
+	deleteEntries = "DELETE FROM cache_entries WHERE resource = @resource;"
+	purgeEntries  = "DELETE FROM cache_entries WHERE substr(path, 1, @prefixLength) = @prefix"
+	pruneEntries  = "DELETE FROM cache_entries WHERE variant NOT IN (SELECT variant FROM cache_entries ORDER BY stored DESC LIMIT @maxEntries);"
+
+	argVariant  = "variant"
+	argResource = "resource"
+
+	// pruneInterval is the shortest time between prunes of the persistent tier.
+	pruneInterval = time.Minute
+)
+
+var (
+	//go:embed dbschema/schema.sql
+	dbschemaBytes []byte
+
+	//go:embed dbschema/schema_postgres.sql
+	dbschemaPostgresBytes []byte
+)
+
+func newPersistent(sqlClient db.SQLClient, maxEntries int) (*persistent, error) {
+	if sqlClient == nil {
+		return nil, errors.New("DB client is required for a persistent cache")
+	}
+
+	if err := applySchemas(sqlClient); err != nil {
+		return nil, err
+	}
+
+	return &persistent{sqlClient: sqlClient, maxEntries: maxEntries}, nil
+}
+
+func applySchemas(sqlClient db.SQLClient) error {
+	schema := dbschemaBytes
+	if sqlClient.Dialect() == db.PostgresDialect {
+		schema = dbschemaPostgresBytes
+	}
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), db.SchemaApplyTimeout)
+	defer cancel()
+	if _, err := sqlClient.Exec(timeoutCtx, string(schema)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// get returns the variant of the resource selected by the input request, or nil.
+func (p *persistent) get(
+	ctx context.Context,
+	resource, backend, path string,
+	req *http.Request,
+) (*entry, error) {
+	rows, err := p.sqlClient.Query(
+		ctx,
+		selectEntries,
+		sql.NamedArg{Name: argResource, Value: resource},
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to query cache entries")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := &entry{resource: resource, backend: backend, path: path}
+		var (
+			vary, header    string
+			stored, expires int64
+		)
+		if err := rows.Scan(
+			&e.variant, &vary, &e.status, &header, &e.body, &stored, &expires,
+		); err != nil {
+			zerologr.Error(err, "Failed to scan cache entry")
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(vary), &e.vary); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(header), &e.header); err != nil {
+			return nil, err
+		}
+		e.stored = time.UnixMilli(stored)
+		e.expires = time.UnixMilli(expires)
+
+		if e.matches(req) {
+			return e, nil
+		}
+	}
+
+	return nil, rows.Err()
+}
+
+// set stores the entry, replacing the stored variant.
+func (p *persistent) set(ctx context.Context, e *entry) error {
+	vary, err := json.Marshal(e.vary)
+	if err != nil {
+		return err
+	}
+	header, err := json.Marshal(e.header)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.sqlClient.Exec(
+		ctx,
+		upsertEntry,
+		sql.NamedArg{Name: argVariant, Value: e.variant},
+		sql.NamedArg{Name: argResource, Value: e.resource},
+		sql.NamedArg{Name: "backend", Value: e.backend},
+		sql.NamedArg{Name: "path", Value: e.path},
+		sql.NamedArg{Name: "vary", Value: string(vary)},
+		sql.NamedArg{Name: "status", Value: e.status},
+		sql.NamedArg{Name: "header", Value: string(header)},
+		sql.NamedArg{Name: "body", Value: e.body},
+		sql.NamedArg{Name: "stored", Value: e.stored.UnixMilli()},
+		sql.NamedArg{Name: "expires", Value: e.expires.UnixMilli()},
+	); err != nil {
+		zerologr.Error(err, "Failed to store cache entry")
+		return err
+	}
+
+	return p.prune(ctx)
+}
+
+// delete removes the stored variant of the entry.
+func (p *persistent) delete(ctx context.Context, e *entry) error {
+	_, err := p.sqlClient.Exec(ctx, deleteEntry, sql.NamedArg{Name: argVariant, Value: e.variant})
+	if err != nil {
+		zerologr.Error(err, "Failed to delete cache entry")
+	}
+	return err
+}
+
+// invalidate removes all variants of the resource.
+func (p *persistent) invalidate(ctx context.Context, resource string) error {
+	_, err := p.sqlClient.Exec(
+		ctx,
+		deleteEntries,
+		sql.NamedArg{Name: argResource, Value: resource},
+	)
+	if err != nil {
+		zerologr.Error(err, "Failed to invalidate cache entries")
+	}
+	return err
+}
+
+// purge removes the entries of the backend with a path starting with the prefix, an empty backend
+// matches all backends. It returns the number of removed entries.
+func (p *persistent) purge(ctx context.Context, backend, pathPrefix string) (int, error) {
+	query := purgeEntries
+	args := []any{
+		sql.NamedArg{Name: "prefixLength", Value: len(pathPrefix)},
+		sql.NamedArg{Name: "prefix", Value: pathPrefix},
+	}
+	if backend != "" {
+		query += " AND backend = @backend"
+		args = append(args, sql.NamedArg{Name: "backend", Value: backend})
+	}
+
+	res, err := p.sqlClient.Exec(ctx, query+";", args...)
+	if err != nil {
+		zerologr.Error(err, "Failed to purge cache entries")
+		return 0, err
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(purged), nil
+}
+
+// prune removes the oldest entries beyond the bounds of the tier, at most once per prune interval.
+func (p *persistent) prune(ctx context.Context) error {
+	p.mu.Lock()
+	if time.Since(p.prunedAt) < pruneInterval {
+		p.mu.Unlock()
+		return nil
+	}
+	p.prunedAt = time.Now()
+	p.mu.Unlock()
+
+	if _, err := p.sqlClient.Exec(
+		ctx,
+		pruneEntries,
+		sql.NamedArg{Name: "maxEntries", Value: p.maxEntries},
+	); err != nil {
+		zerologr.Error(err, "Failed to prune cache entries")
+		return err
+	}
+
+	return nil
+}
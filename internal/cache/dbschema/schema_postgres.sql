@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS cache_entries (
+  variant TEXT PRIMARY KEY,
+  resource TEXT NOT NULL,
+  backend VARCHAR(100) NOT NULL,
+  path TEXT NOT NULL,
+  vary TEXT NOT NULL,
+  status INTEGER NOT NULL,
+  header TEXT NOT NULL,
+  body BYTEA NOT NULL,
+  stored BIGINT NOT NULL,
+  expires BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS cache_entries_resource ON cache_entries(resource);
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// directives are the directives of Cache-Control headers, by lower case name and with unquoted
+// arguments.
+type directives map[string]string
+
+// maxDeltaSeconds caps delta-seconds values, larger values are treated as this value.
+const maxDeltaSeconds = 1 << 31
+
+var (
+	// cacheableStatuses are the status codes cached when a response has freshness information.
+	cacheableStatuses = []int{
+		http.StatusOK,
+		http.StatusNonAuthoritativeInfo,
+		http.StatusNoContent,
+		http.StatusMultipleChoices,
+		http.StatusMovedPermanently,
+		http.StatusNotFound,
+		http.StatusGone,
+	}
+	// revalidatedHeaders are the headers of a stored response replaced by those of a 304 response
+	// validating it.
+	revalidatedHeaders = []string{"Cache-Control", "Date", "Etag", "Expires", "Last-Modified", "Vary"}
+)
+
+func parseCacheControl(header http.Header) directives {
+	d := directives{}
+	for _, value := range header.Values("Cache-Control") {
+		for part := range strings.SplitSeq(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			d[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+
+	return d
+}
+
+func (d directives) has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
+
+// seconds returns the duration of a delta-seconds directive, reporting false if the directive is
+// missing or invalid.
+func (d directives) seconds(name string) (time.Duration, bool) {
+	return deltaSeconds(d[name])
+}
+
+func deltaSeconds(value string) (time.Duration, bool) {
+	s, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || s < 0 {
+		return 0, false
+	}
+
+	return time.Duration(min(s, maxDeltaSeconds)) * time.Second, true
+}
+
+// lifetime returns how long a response is fresh after it was generated, from its s-maxage,
+// max-age or Expires, falling back to the input default. It reports false if the response has no
+// freshness information.
+func lifetime(header http.Header, cc directives, defaultTTL time.Duration) (time.Duration, bool) {
+	if maxAge, ok := cc.seconds("s-maxage"); ok {
+		return maxAge, true
+	}
+	if maxAge, ok := cc.seconds("max-age"); ok {
+		return maxAge, true
+	}
+
+	if expiresHeader := header.Get("Expires"); expiresHeader != "" {
+		expires, err := http.ParseTime(expiresHeader)
+		if err != nil {
+			// Invalid dates, like 0, mean that the response has already expired.
+			return 0, true
+		}
+
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = time.Now()
+		}
+		return max(0, expires.Sub(date)), true
+	}
+
+	if defaultTTL > 0 {
+		return defaultTTL, true
+	}
+
+	return 0, false
+}
+
+// cacheable reports whether a response with the input status code and headers may be stored.
+func cacheable(status int, header http.Header, cc directives) bool {
+	return slices.Contains(cacheableStatuses, status) &&
+		!cc.has("no-store") &&
+		!cc.has("private") &&
+		header.Get("Set-Cookie") == "" &&
+		header.Get("Vary") != "*"
+}
+
+// authenticatedHeaders identify the user of a request: credentials sent by the client, and the
+// identity set by the authorizer, which runs before the cache.
+var authenticatedHeaders = []string{"Authorization", "Cookie", "X-Krb-User", "X-Krb-Org"}
+
+// authenticated reports whether the request identifies its user, its responses may then be
+// personalised and are only shared when explicitly allowed.
+func authenticated(req *http.Request) bool {
+	return slices.ContainsFunc(authenticatedHeaders, func(name string) bool {
+		return req.Header.Get(name) != ""
+	})
+}
+
+// shared reports whether a response may be stored and served for authenticated requests, see
+// RFC 9111 section 3.5.
+func shared(cc directives) bool {
+	return cc.has("public") || cc.has("s-maxage")
+}
+
+// varyNames returns the canonical names of the request headers listed in a response's Vary
+// headers.
+func varyNames(header http.Header) []string {
+	names := make([]string, 0)
+	for _, value := range header.Values("Vary") {
+		for name := range strings.SplitSeq(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	slices.Sort(names)
+
+	return slices.Compact(names)
+}
+
+// varyValues returns the values of the input request headers, the key a variant is selected by.
+func varyValues(names []string, req *http.Request) map[string]string {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		values[name] = strings.Join(req.Header.Values(name), ",")
+	}
+
+	return values
+}
+
+// etagMatches reports whether an If-None-Match header value matches the input entity tag, using
+// the weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+
+	for candidate := range strings.SplitSeq(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseWriter passes a response on to the client while recording it for the cache. The 304
+// response to a revalidation started by the cache is held back, the client is answered from the
+// cache instead.
+type responseWriter struct {
+	http.ResponseWriter
+
+	revalidating bool
+	limit        int64
+
+	status int
+	header http.Header
+	body   bytes.Buffer
+	// truncated is set once the body exceeds the limit or fails to be written, the response is then
+	// not cached.
+	truncated   bool
+	notModified bool
+}
+
+var (
+	_ http.ResponseWriter = (*responseWriter)(nil)
+	_ http.Flusher        = (*responseWriter)(nil)
+)
+
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if rw.status != 0 {
+		return
+	}
+
+	// Informational responses are followed by the final one.
+	if statusCode < http.StatusOK {
+		rw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	rw.status = statusCode
+	rw.header = rw.Header().Clone()
+	if rw.revalidating && statusCode == http.StatusNotModified {
+		rw.notModified = true
+		return
+	}
+
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if rw.notModified {
+		return len(p), nil
+	}
+
+	n, err := rw.ResponseWriter.Write(p)
+	if !rw.truncated {
+		// A body that did not reach the client in full is not cached either.
+		if err != nil || int64(rw.body.Len()+n) > rw.limit {
+			rw.truncated = true
+			rw.body = bytes.Buffer{}
+		} else {
+			rw.body.Write(p[:n])
+		}
+	}
+
+	return n, err
+}
+
+func (rw *responseWriter) Flush() {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if rw.notModified {
+		return
+	}
+
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying response writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
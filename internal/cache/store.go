@@ -0,0 +1,244 @@
+package cache
+
+import (
+	"container/list"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// entry is a cached response. Entries are never modified once stored, revalidation stores a
+	// replacement.
+	entry struct {
+		// resource identifies the cached resource, variant the variant of the resource selected by
+		// the request headers in vary.
+		resource string
+		variant  string
+		vary     map[string]string
+		backend  string
+		path     string
+
+		status int
+		header http.Header
+		body   []byte
+
+		// stored is when the response was generated, expires when it turns stale.
+		stored  time.Time
+		expires time.Time
+	}
+
+	// memory is a bounded in-memory LRU cache of entries.
+	memory struct {
+		maxEntries int
+		maxBytes   int64
+
+		mu  sync.Mutex
+		lru *list.List
+		// resources holds the list elements of the variants of each resource.
+		resources map[string]map[string]*list.Element
+		bytes     int64
+	}
+)
+
+func newEntry(
+	resource, backend, path string,
+	req *http.Request,
+	status int,
+	header http.Header,
+	body []byte,
+	stored time.Time,
+	lifetime time.Duration,
+) *entry {
+	header = header.Clone()
+	// The age is derived from when the response was generated when served.
+	header.Del("Age")
+
+	e := &entry{
+		resource: resource,
+		vary:     varyValues(varyNames(header), req),
+		backend:  backend,
+		path:     path,
+		status:   status,
+		header:   header,
+		body:     body,
+		stored:   stored,
+		expires:  stored.Add(lifetime),
+	}
+	e.variant = variantOf(resource, e.vary)
+
+	return e
+}
+
+// variantOf returns the variant key of a resource selected by the input request header values.
+func variantOf(resource string, vary map[string]string) string {
+	var b strings.Builder
+	b.WriteString(resource)
+	for _, name := range slices.Sorted(maps.Keys(vary)) {
+		b.WriteString("\n" + name + ": " + vary[name])
+	}
+
+	return b.String()
+}
+
+// fresh reports whether the entry may be served without revalidation.
+func (e *entry) fresh(now time.Time) bool {
+	return now.Before(e.expires)
+}
+
+func (e *entry) age(now time.Time) time.Duration {
+	return max(0, now.Sub(e.stored))
+}
+
+// matches reports whether the entry is the variant selected by the input request.
+func (e *entry) matches(req *http.Request) bool {
+	for name, value := range e.vary {
+		if strings.Join(req.Header.Values(name), ",") != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// revalidated returns a replacement of the entry updated with the headers of the 304 response that
+// validated it.
+func (e *entry) revalidated(
+	header http.Header,
+	stored time.Time,
+	defaultTTL time.Duration,
+) *entry {
+	updated := *e
+	updated.header = e.header.Clone()
+	for _, name := range revalidatedHeaders {
+		if values, ok := header[name]; ok {
+			updated.header[name] = slices.Clone(values)
+		}
+	}
+
+	// A stored no-cache entry keeps requiring revalidation, as does one the 304 response gave no
+	// freshness information for.
+	cc := parseCacheControl(updated.header)
+	ttl, _ := lifetime(updated.header, cc, defaultTTL)
+	if cc.has("no-cache") {
+		ttl = 0
+	}
+	updated.stored = stored
+	updated.expires = stored.Add(ttl)
+
+	return &updated
+}
+
+func newMemory(maxEntries int, maxBytes int64) *memory {
+	return &memory{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		lru:        list.New(),
+		resources:  make(map[string]map[string]*list.Element),
+	}
+}
+
+// get returns the variant of the resource selected by the input request, or nil.
+func (m *memory) get(resource string, req *http.Request) *entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, element := range m.resources[resource] {
+		//nolint:errcheck // only entries are stored
+		if e := element.Value.(*entry); e.matches(req) {
+			m.lru.MoveToFront(element)
+			return e
+		}
+	}
+
+	return nil
+}
+
+// set stores the entry, replacing the stored variant and evicting the least recently used entries
+// beyond the bounds of the cache.
+func (m *memory) set(e *entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	variants, ok := m.resources[e.resource]
+	if !ok {
+		variants = make(map[string]*list.Element)
+	}
+	if element, ok := variants[e.variant]; ok {
+		m.remove(element)
+	}
+
+	// Removing the last variant drops the resource.
+	m.resources[e.resource] = variants
+	variants[e.variant] = m.lru.PushFront(e)
+	m.bytes += int64(len(e.body))
+
+	for m.lru.Len() > m.maxEntries || m.bytes > m.maxBytes {
+		m.remove(m.lru.Back())
+	}
+}
+
+// delete removes the stored variant of the entry.
+func (m *memory) delete(e *entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.resources[e.resource][e.variant]; ok {
+		m.remove(element)
+	}
+}
+
+// invalidate removes all variants of the resource.
+func (m *memory) invalidate(resource string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, element := range m.resources[resource] {
+		m.remove(element)
+	}
+}
+
+// purge removes the entries of the backend with a path starting with the prefix, an empty backend
+// matches all backends. It returns the number of removed entries.
+func (m *memory) purge(backend, pathPrefix string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	for element := m.lru.Front(); element != nil; {
+		next := element.Next()
+		//nolint:errcheck // only entries are stored
+		if e := element.Value.(*entry); (backend == "" || e.backend == backend) &&
+			strings.HasPrefix(e.path, pathPrefix) {
+			m.remove(element)
+			purged++
+		}
+		element = next
+	}
+
+	return purged
+}
+
+// stats returns the number of stored entries and their total body size.
+func (m *memory) stats() (int, int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lru.Len(), m.bytes
+}
+
+// remove removes an element of the LRU list. Must be called with mu held.
+func (m *memory) remove(element *list.Element) {
+	//nolint:errcheck // only entries are stored
+	e := m.lru.Remove(element).(*entry)
+	m.bytes -= int64(len(e.body))
+
+	variants := m.resources[e.resource]
+	delete(variants, e.variant)
+	if len(variants) == 0 {
+		delete(m.resources, e.resource)
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testEntry(resource string, body []byte) *entry {
+	return newEntry(
+		resource,
+		"backend1",
+		resource,
+		httptest.NewRequest(http.MethodGet, resource, nil),
+		http.StatusOK,
+		http.Header{},
+		body,
+		time.Now(),
+		time.Minute,
+	)
+}
+
+func TestMemoryEviction(t *testing.T) {
+	m := newMemory(2, 10)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	m.set(testEntry("/a", []byte("aaa")))
+	m.set(testEntry("/b", []byte("bbb")))
+	if m.get("/a", req) == nil {
+		t.Fatal("Expected /a to be cached")
+	}
+
+	// /b is the least recently used entry.
+	m.set(testEntry("/c", []byte("ccc")))
+	if m.get("/b", req) != nil || m.get("/a", req) == nil || m.get("/c", req) == nil {
+		t.Fatal("Expected the least recently used entry to be evicted at the entry limit")
+	}
+
+	m.set(testEntry("/c", []byte("cccccccc")))
+	if entries, bytes := m.stats(); entries != 1 || bytes != 8 {
+		t.Errorf("Expected entries to be evicted at the byte limit, got %d entries of %d bytes",
+			entries, bytes)
+	}
+}
+
+func TestLifetime(t *testing.T) {
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+		ok       bool
+	}{
+		{
+			name:     "Shared max age first",
+			header:   http.Header{"Cache-Control": {"max-age=10, s-maxage=20"}},
+			expected: 20 * time.Second,
+			ok:       true,
+		},
+		{
+			name: "Expires relative to date",
+			header: http.Header{
+				"Date":    {date.Format(http.TimeFormat)},
+				"Expires": {date.Add(time.Hour).Format(http.TimeFormat)},
+			},
+			expected: time.Hour,
+			ok:       true,
+		},
+		{
+			name:   "Invalid expires",
+			header: http.Header{"Expires": {"0"}},
+			ok:     true,
+		},
+		{
+			name:     "Default",
+			header:   http.Header{},
+			expected: time.Minute,
+			ok:       true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, ok := lifetime(test.header, parseCacheControl(test.header), time.Minute)
+			if actual != test.expected || ok != test.ok {
+				t.Errorf("Expected %v %t, got %v %t", test.expected, test.ok, actual, ok)
+			}
+		})
+	}
+}
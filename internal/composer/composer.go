@@ -43,7 +43,14 @@ func New(opts *Opts) Composer {
 }
 
 func (c *impl) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	c.Observability.ServeHTTP(w, req)
+	ctx := WithAbort(req.Context())
+	c.Observability.ServeHTTP(w, req.WithContext(ctx))
+
+	// The status of an aborted response has already been sent, aborting the connection is the only
+	// way to tell the client that the response is incomplete.
+	if ResponseAborted(ctx) {
+		panic(http.ErrAbortHandler)
+	}
 }
 
 // GetFlow returns metadata for the entire FlowComponent chain.
@@ -2,6 +2,8 @@ package composer
 
 import (
 	"context"
+	"net/http"
+	"sync/atomic"
 
 	"github.com/trebent/kerberos/internal/composer/debug"
 )
@@ -15,10 +17,48 @@ const (
 	// BackendContextKey used to store the backend name.
 	BackendContextKey ContextKey = "krb.backend"
 
+	// PathContextKey used to store the escaped path of the request as received, before the router
+	// strips the /gw/backend/{backend-name} prefix.
+	PathContextKey ContextKey = "krb.path"
+
 	// DebugContextKey used to store the debug call.
 	DebugContextKey ContextKey = ContextKey(debug.DebugContextKey)
+
+	// abortContextKey used to store whether the response is to be aborted.
+	abortContextKey ContextKey = "krb.abort"
 )
 
+// WithAbort returns a context in which the response to the request can be marked as aborted with
+// AbortResponse.
+func WithAbort(ctx context.Context) context.Context {
+	return context.WithValue(ctx, abortContextKey, &atomic.Bool{})
+}
+
+// AbortResponse marks the response to the request as incomplete, e.g. when it was cut off after
+// its status was sent. The composer aborts the response once every component has handled it, so
+// that the components record it before the client is told that it is incomplete.
+func AbortResponse(ctx context.Context) {
+	if aborted, ok := ctx.Value(abortContextKey).(*atomic.Bool); ok {
+		aborted.Store(true)
+	}
+}
+
+// ResponseAborted reports whether the response to the request was marked with AbortResponse.
+func ResponseAborted(ctx context.Context) bool {
+	aborted, ok := ctx.Value(abortContextKey).(*atomic.Bool)
+	return ok && aborted.Load()
+}
+
+// ReceivedPath returns the escaped path of the request as received by the gateway, or the current
+// path if the router has not stored it.
+func ReceivedPath(req *http.Request) string {
+	if path, ok := req.Context().Value(PathContextKey).(string); ok {
+		return path
+	}
+
+	return req.URL.EscapedPath()
+}
+
 // DebugFromContext returns the debug call from the context, or a noop call if none is found.
 func DebugFromContext(ctx context.Context) debug.DebuggedCall {
 	if ctx == nil {
@@ -19,7 +19,8 @@ func (n *noopCall) AddTransition(
 	_ CallDirection,
 	_ time.Time, _ time.Time,
 	_ CallResult,
-	_ string) {
+	_ string,
+	_ ...Attribute) {
 }
 
 // SetMethod implements [debug.DebuggedCall].
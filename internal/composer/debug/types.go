@@ -21,7 +21,8 @@ type (
 		SetMethod(method string)
 		// SetStatusCode sets the HTTP status code of the call.
 		SetStatusCode(statusCode int)
-		// AddTransition adds a flow transition to the call.
+		// AddTransition adds a flow transition to the call. Attributes can be used to describe
+		// details of the transition, such as the upstream target chosen by the forwarder.
 		AddTransition(
 			component string,
 			direction CallDirection,
@@ -29,11 +30,18 @@ type (
 			endTime time.Time,
 			result CallResult,
 			failureCause string,
+			attributes ...Attribute,
 		)
 		// Finalise finalises the call and makes it ready for storage.
 		Finalise()
 	}
 
+	// Attribute is a key-value pair describing a detail of a flow transition.
+	Attribute struct {
+		Key   string
+		Value string
+	}
+
 	// Debugger is the interface that defines the methods for debugging calls.
 	Debugger interface {
 		// Start starts a new debugged call and returns it along with a context that has the call stored in it.
@@ -52,3 +60,8 @@ const (
 	// use this key directly, use [composer.DebugContextKey] instead.
 	DebugContextKey string = "krb.debug"
 )
+
+// Attr returns an Attribute with the given key and value.
+func Attr(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
@@ -57,6 +57,7 @@ func (d *testDebuggedCall) AddTransition(
 	endTime time.Time,
 	result CallResult,
 	failureCause string,
+	_ ...Attribute,
 ) {
 	d.transitions = append(d.transitions, testDebugTransition{
 		component:    component,
@@ -0,0 +1,180 @@
+package forwarder
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+)
+
+type (
+	// target is a single upstream instance of a backend.
+	target struct {
+		addr        string
+		outstanding atomic.Int64
+
+		// Health state, guarded by mu.
+		mu            sync.Mutex
+		probeHealthy  bool
+		probeStreak   int
+		failureStreak int
+		window        outcomeWindow
+		ejected       bool
+		ejectedUntil  time.Time
+	}
+	// strategy picks one of the input targets for a request. The input is never empty.
+	strategy interface {
+		pick(targets []*target, req *http.Request) *target
+	}
+
+	roundRobin struct {
+		next atomic.Uint64
+	}
+	leastOutstanding struct{}
+	randomTwoChoices struct{}
+	consistentHash   struct {
+		header string
+		cookie string
+	}
+)
+
+var (
+	_ strategy = (*roundRobin)(nil)
+	_ strategy = (*leastOutstanding)(nil)
+	_ strategy = (*randomTwoChoices)(nil)
+	_ strategy = (*consistentHash)(nil)
+)
+
+func newTargets(backend *config.RouterBackend) []*target {
+	targets := make([]*target, 0, len(backend.GetTargets()))
+	for _, t := range backend.GetTargets() {
+		targets = append(
+			targets,
+			newTarget(net.JoinHostPort(t.Host, strconv.Itoa(t.Port)), backend.HealthCheck),
+		)
+	}
+
+	return targets
+}
+
+func newTarget(addr string, hc *config.HealthCheck) *target {
+	t := &target{
+		addr:         addr,
+		probeHealthy: true,
+	}
+	if hc != nil && hc.Passive != nil && hc.Passive.FailureRatio > 0 {
+		t.window = newOutcomeWindow(hc.Passive.Window)
+	}
+
+	return t
+}
+
+func newStrategy(cfg *config.LoadBalancing) strategy {
+	if cfg == nil {
+		return &roundRobin{}
+	}
+
+	switch cfg.Strategy {
+	case config.LoadBalancingLeastOutstanding:
+		return &leastOutstanding{}
+	case config.LoadBalancingRandomTwoChoices:
+		return &randomTwoChoices{}
+	case config.LoadBalancingConsistentHash:
+		return &consistentHash{header: cfg.Hash.Header, cookie: cfg.Hash.Cookie}
+	default:
+		return &roundRobin{}
+	}
+}
+
+// acquire marks a request as outstanding on the target, release must be called when the request
+// is done.
+func (t *target) acquire() {
+	t.outstanding.Add(1)
+}
+
+// release marks an outstanding request on the target as done.
+func (t *target) release() {
+	t.outstanding.Add(-1)
+}
+
+func (rr *roundRobin) pick(targets []*target, _ *http.Request) *target {
+	return targets[(rr.next.Add(1)-1)%uint64(len(targets))]
+}
+
+// pick returns the target with the fewest outstanding requests. The scan starts at a random offset
+// so that ties are not always broken in favour of the first target.
+func (lo *leastOutstanding) pick(targets []*target, _ *http.Request) *target {
+	offset := rand.IntN(len(targets)) //nolint:gosec // not security sensitive
+	best := targets[offset]
+	for i := 1; i < len(targets); i++ {
+		candidate := targets[(offset+i)%len(targets)]
+		if candidate.outstanding.Load() < best.outstanding.Load() {
+			best = candidate
+		}
+	}
+
+	return best
+}
+
+// pick returns the less loaded of two randomly chosen targets.
+func (rtc *randomTwoChoices) pick(targets []*target, _ *http.Request) *target {
+	if len(targets) == 1 {
+		return targets[0]
+	}
+
+	//nolint:gosec // not security sensitive
+	one, two := rand.IntN(len(targets)), rand.IntN(len(targets)-1)
+	if two >= one {
+		two++
+	}
+
+	if targets[two].outstanding.Load() < targets[one].outstanding.Load() {
+		return targets[two]
+	}
+
+	return targets[one]
+}
+
+// pick returns the target with the highest rendezvous hash for the configured request value, so
+// that the same value keeps mapping to the same target as long as it exists. Requests lacking the
+// value are spread randomly.
+func (ch *consistentHash) pick(targets []*target, req *http.Request) *target {
+	key := ch.key(req)
+	if key == "" {
+		return targets[rand.IntN(len(targets))] //nolint:gosec // not security sensitive
+	}
+
+	var (
+		best      *target
+		bestScore uint64
+	)
+	for _, t := range targets {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		_, _ = h.Write([]byte(t.addr))
+		if score := h.Sum64(); best == nil || score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+
+	return best
+}
+
+func (ch *consistentHash) key(req *http.Request) string {
+	if ch.header != "" {
+		return req.Header.Get(ch.header)
+	}
+
+	cookie, err := req.Cookie(ch.cookie)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
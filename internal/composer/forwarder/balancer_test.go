@@ -0,0 +1,46 @@
+package forwarder
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLeastOutstanding(t *testing.T) {
+	targets := []*target{{addr: "one:80"}, {addr: "two:80"}, {addr: "three:80"}}
+	targets[0].outstanding.Store(2)
+	targets[1].outstanding.Store(1)
+	targets[2].outstanding.Store(3)
+
+	lo := &leastOutstanding{}
+	for range 10 {
+		if picked := lo.pick(targets, httptest.NewRequest("GET", "/", nil)); picked != targets[1] {
+			t.Fatalf("Expected target %s, got %s", targets[1].addr, picked.addr)
+		}
+	}
+}
+
+func TestRandomTwoChoices(t *testing.T) {
+	targets := []*target{{addr: "one:80"}, {addr: "two:80"}}
+	targets[0].outstanding.Store(5)
+
+	rtc := &randomTwoChoices{}
+	for range 10 {
+		if picked := rtc.pick(targets, httptest.NewRequest("GET", "/", nil)); picked != targets[1] {
+			t.Fatalf("Expected target %s, got %s", targets[1].addr, picked.addr)
+		}
+	}
+}
+
+func TestConsistentHashCookie(t *testing.T) {
+	targets := []*target{{addr: "one:80"}, {addr: "two:80"}, {addr: "three:80"}}
+	ch := &consistentHash{cookie: "session"}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Cookie", "session=abc")
+	first := ch.pick(targets, req)
+	for range 10 {
+		if picked := ch.pick(targets, req); picked != first {
+			t.Fatalf("Expected target %s, got %s", first.addr, picked.addr)
+		}
+	}
+}
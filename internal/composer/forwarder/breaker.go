@@ -0,0 +1,157 @@
+package forwarder
+
+import (
+	"sync"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	circuitState int64
+
+	// breaker is the circuit breaker of a single backend. It opens when the failures of forwarded
+	// requests cross the configured limits, rejecting requests until the cooldown has passed. It then
+	// turns half-open, admitting a few trial requests that either close it or open it again.
+	breaker struct {
+		backend string
+		cfg     *config.CircuitBreaker
+
+		mu            sync.Mutex
+		state         circuitState
+		changedAt     time.Time
+		failureStreak int
+		window        outcomeWindow
+		// Trial requests admitted and succeeded while half-open.
+		admitted  int
+		succeeded int
+	}
+)
+
+const (
+	circuitClosed circuitState = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitHalfOpen:
+		return "half-open"
+	case circuitOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+func newBreaker(backend string, cfg *config.CircuitBreaker) *breaker {
+	return &breaker{
+		backend: backend,
+		cfg:     cfg,
+		window:  newOutcomeWindow(cfg.Window),
+	}
+}
+
+// allow reports whether a request may be forwarded. When it may not, the returned duration is the
+// time left until the breaker admits trial requests again.
+func (b *breaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cooldown := time.Duration(b.cfg.CooldownMs) * time.Millisecond
+	now := time.Now()
+
+	switch b.state {
+	case circuitOpen:
+		if remaining := b.changedAt.Add(cooldown).Sub(now); remaining > 0 {
+			return remaining, false
+		}
+		b.transition(circuitHalfOpen, "cooldown passed")
+	case circuitHalfOpen:
+		// Trials that never report back, e.g. cancelled by the client, must not keep the breaker
+		// half-open forever.
+		if now.Sub(b.changedAt) > cooldown {
+			b.transition(circuitHalfOpen, "trials timed out")
+		}
+	case circuitClosed:
+		return 0, true
+	}
+
+	if b.admitted >= b.cfg.HalfOpenRequests {
+		return cooldown, false
+	}
+	b.admitted++
+
+	return 0, true
+}
+
+// record records the outcome of a forwarded request.
+func (b *breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		return
+	case circuitHalfOpen:
+		if !success {
+			b.transition(circuitOpen, "trial request failed")
+			return
+		}
+
+		b.succeeded++
+		if b.succeeded >= b.cfg.HalfOpenRequests {
+			b.transition(circuitClosed, "trial requests succeeded")
+		}
+		return
+	case circuitClosed:
+	}
+
+	if success {
+		b.failureStreak = 0
+	} else {
+		b.failureStreak++
+	}
+
+	if b.cfg.FailureRatio > 0 {
+		b.window.record(!success)
+	}
+
+	switch {
+	case b.cfg.ConsecutiveFailures > 0 && b.failureStreak >= b.cfg.ConsecutiveFailures:
+		b.transition(circuitOpen, "consecutive failures")
+	case b.cfg.FailureRatio > 0 && b.window.full() && b.window.ratio() >= b.cfg.FailureRatio:
+		b.transition(circuitOpen, "failure ratio")
+	}
+}
+
+// current returns the current state of the breaker.
+func (b *breaker) current() circuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// transition moves the breaker to the input state, resetting all counters. Must be called with mu
+// held.
+func (b *breaker) transition(state circuitState, reason string) {
+	if state != b.state {
+		zerologr.Info(
+			"Circuit breaker state changed",
+			"backend", b.backend,
+			"from", b.state.String(),
+			"to", state.String(),
+			"reason", reason,
+		)
+	}
+
+	b.state = state
+	b.changedAt = time.Now()
+	b.failureStreak = 0
+	b.window.reset()
+	b.admitted = 0
+	b.succeeded = 0
+}
@@ -0,0 +1,77 @@
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+)
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	b := newBreaker("backend", &config.CircuitBreaker{
+		ConsecutiveFailures: 2,
+		Window:              10,
+		CooldownMs:          20,
+		HalfOpenRequests:    1,
+	})
+
+	b.record(false)
+	if b.current() != circuitClosed {
+		t.Fatalf("Expected circuit %s, got %s", circuitClosed, b.current())
+	}
+
+	b.record(false)
+	if b.current() != circuitOpen {
+		t.Fatalf("Expected circuit %s, got %s", circuitOpen, b.current())
+	}
+
+	if retryAfter, ok := b.allow(); ok || retryAfter <= 0 {
+		t.Fatalf("Expected open circuit to reject with a retry after, got %t, %s", ok, retryAfter)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, ok := b.allow(); !ok {
+		t.Fatal("Expected a trial request to be admitted after the cooldown")
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatal("Expected only one trial request to be admitted")
+	}
+
+	b.record(true)
+	if b.current() != circuitClosed {
+		t.Fatalf("Expected circuit %s, got %s", circuitClosed, b.current())
+	}
+}
+
+func TestBreakerFailureRatio(t *testing.T) {
+	b := newBreaker("backend", &config.CircuitBreaker{
+		FailureRatio:     0.5,
+		Window:           4,
+		CooldownMs:       20,
+		HalfOpenRequests: 1,
+	})
+
+	for _, success := range []bool{true, false, true} {
+		b.record(success)
+	}
+	if b.current() != circuitClosed {
+		t.Fatalf("Expected circuit %s before the window is full, got %s", circuitClosed, b.current())
+	}
+
+	b.record(false)
+	if b.current() != circuitOpen {
+		t.Fatalf("Expected circuit %s, got %s", circuitOpen, b.current())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, ok := b.allow(); !ok {
+		t.Fatal("Expected a trial request to be admitted after the cooldown")
+	}
+
+	b.record(false)
+	if b.current() != circuitOpen {
+		t.Fatalf("Expected failed trial to reopen the circuit, got %s", b.current())
+	}
+}
@@ -1,63 +1,330 @@
 package forwarder
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"net"
+	"math"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
+	adminext "github.com/trebent/kerberos/internal/admin/extensions"
 	"github.com/trebent/kerberos/internal/composer"
 	composerdebug "github.com/trebent/kerberos/internal/composer/debug"
 	"github.com/trebent/kerberos/internal/config"
 	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
 	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/zerologr"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type (
+	// Forwarder is the terminal flow component, forwarding requests to the targets of their
+	// backend.
+	Forwarder interface {
+		composer.FlowComponent
+		adminext.TrafficSplitter
+		// StartHealthChecks starts the active health checks of all backends, they run until ctx is
+		// done.
+		StartHealthChecks(ctx context.Context)
+		// StartDiscovery resolves the targets of all backends with discovery once, then keeps
+		// refreshing them until ctx is done.
+		StartDiscovery(ctx context.Context)
+		// TargetHealthy reports whether a target ("host:port") of a backend is currently healthy.
+		TargetHealthy(backend, addr string) bool
+		// DiscoveredTargets returns the current targets ("host:port") of a backend with discovery,
+		// the time of their last successful refresh and the error of the last refresh, if it failed.
+		DiscoveredTargets(backend string) (addrs []string, refreshed time.Time, err error)
+	}
 	Opts struct {
 		Backends []*config.RouterBackend
+		// ProxyHeaders is optional, when set the configured proxy headers are emitted.
+		ProxyHeaders *config.ProxyHeaders
+		// Splits are the initial traffic splits, they can be adjusted at runtime.
+		Splits []*config.TrafficSplit
 	}
 	forwarder struct {
 		targetContextKey composer.ContextKey
-		clients          map[string]*http.Client // keyed by RouterBackend.Name
+		upstreams        map[string]*upstream // keyed by RouterBackend.Name
+		proxyHeaders     *proxyHeaders
+		upgrades         *upgradeMetrics
+		// backends are the configured backends, the targets of split requests.
+		backends map[string]*config.RouterBackend
+		splits   atomic.Pointer[map[string]*split]
+		splitsMu sync.Mutex
+	}
+	// outbound holds the parts of the upstream request shared by all attempts.
+	outbound struct {
+		url    *url.URL
+		header http.Header
+		body   func() io.Reader
+		// upgrade is the protocol requested by an upgrade request, empty for other requests.
+		upgrade string
+	}
+	// circuitOpenError is returned while the circuit of a backend is open.
+	circuitOpenError struct {
+		retryAfter time.Duration
 	}
 )
 
+const (
+	tracerName = "krb"
+
+	circuitGaugeName      = "upstream.circuit.state"
+	healthyGaugeName      = "upstream.healthy"
+	healthTransitionsName = "upstream.health.transitions"
+)
+
 var (
-	_ composer.FlowComponent = (*forwarder)(nil)
+	_ Forwarder = (*forwarder)(nil)
+
+	tracer = otel.Tracer(tracerName)
 
 	errFailedTargetExtract = errors.New("could not determine target from context")
 	errFailedForwarding    = errors.New("failed to forward request")
+	errNoHealthyTarget     = errors.New("no healthy target")
+	errCircuitOpen         = errors.New("circuit open")
 	//nolint:errname // This is intentional to separate pure error types from wrapper API Errors.
 	apiErrFailedForwarding = apierror.New(
 		http.StatusInternalServerError,
 		errFailedForwarding.Error(),
 	)
+	//nolint:errname // This is intentional to separate pure error types from wrapper API Errors.
+	apiErrNoHealthyTarget = apierror.New(
+		http.StatusServiceUnavailable,
+		errNoHealthyTarget.Error(),
+	)
+	//nolint:errname // This is intentional to separate pure error types from wrapper API Errors.
+	apiErrCircuitOpen = apierror.New(
+		http.StatusServiceUnavailable,
+		errCircuitOpen.Error(),
+	)
+	//nolint:errname // This is intentional to separate pure error types from wrapper API Errors.
+	apiErrShed = apierror.New(
+		http.StatusServiceUnavailable,
+		errShed.Error(),
+	)
 )
 
-func NewComponent(opts *Opts) (composer.FlowComponent, error) {
-	clients := make(map[string]*http.Client, len(opts.Backends))
+func NewComponent(opts *Opts) (Forwarder, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/trebent/kerberos")
+	transitions, err := meter.Int64Counter(
+		healthTransitionsName,
+		metric.WithDescription("Counts target health changes."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating health transition counter: %w", err)
+	}
+
+	shedCounter, err := meter.Int64Counter(
+		shedCounterName,
+		metric.WithDescription("Counts the requests shed by the concurrency limit of a backend."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating shed counter: %w", err)
+	}
+
+	mirrorMetrics, err := newMirrorMetrics(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreams := make(map[string]*upstream, len(opts.Backends))
+	backends := make(map[string]*config.RouterBackend, len(opts.Backends))
 	for _, b := range opts.Backends {
-		t, err := newTransport(b.Name, b.TLS)
+		u, err := newUpstream(b, transitions, shedCounter, mirrorMetrics)
 		if err != nil {
-			return nil, fmt.Errorf("building transport for backend %q: %w", b.Name, err)
+			return nil, err
 		}
+		upstreams[b.Name] = u
+		backends[b.Name] = b
+	}
 
-		clients[b.Name] = &http.Client{
-			Transport: t,
-			Timeout:   time.Duration(b.TimeoutMs) * time.Millisecond,
+	splits := make(map[string]*split, len(opts.Splits))
+	for _, cfg := range opts.Splits {
+		s, err := newSplit(cfg, backends)
+		if err != nil {
+			return nil, err
 		}
+		if _, ok := splits[cfg.Backend]; ok {
+			return nil, fmt.Errorf("%w: backend %s is split twice", errInvalidSplit, cfg.Backend)
+		}
+		zerologr.Info("Registering traffic split", "backend", cfg.Backend)
+		splits[cfg.Backend] = s
 	}
-	return &forwarder{
+
+	upgrades, err := newUpgradeMetrics(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &forwarder{
 		targetContextKey: composer.TargetContextKey,
-		clients:          clients,
-	}, nil
+		upstreams:        upstreams,
+		upgrades:         upgrades,
+		backends:         backends,
+	}
+	f.splits.Store(&splits)
+
+	if opts.ProxyHeaders != nil {
+		if f.proxyHeaders, err = newProxyHeaders(opts.ProxyHeaders); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := meter.Int64ObservableGauge(
+		healthyGaugeName,
+		metric.WithDescription("Reports 1 for healthy and 0 for unhealthy targets."),
+		metric.WithInt64Callback(f.observeHealth),
+	); err != nil {
+		return nil, fmt.Errorf("creating health gauge: %w", err)
+	}
+
+	if _, err := meter.Int64ObservableGauge(
+		circuitGaugeName,
+		metric.WithDescription("Reports the circuit breaker state, 0 closed, 1 half-open and 2 open."),
+		metric.WithInt64Callback(f.observeCircuits),
+	); err != nil {
+		return nil, fmt.Errorf("creating circuit gauge: %w", err)
+	}
+
+	if _, err := meter.Int64ObservableGauge(
+		queueDepthGaugeName,
+		metric.WithDescription("Reports the number of requests waiting for a concurrency slot."),
+		metric.WithInt64Callback(f.observeQueues),
+	); err != nil {
+		return nil, fmt.Errorf("creating queue depth gauge: %w", err)
+	}
+
+	return f, nil
+}
+
+func (e *circuitOpenError) Error() string {
+	return errCircuitOpen.Error()
+}
+
+func (e *circuitOpenError) Unwrap() error {
+	return errCircuitOpen
+}
+
+// StartHealthChecks implements [Forwarder].
+func (f *forwarder) StartHealthChecks(ctx context.Context) {
+	for _, u := range f.upstreams {
+		if u.healthCheck == nil || u.healthCheck.Active == nil {
+			continue
+		}
+
+		zerologr.Info("Starting active health checks", "backend", u.backend)
+		go u.probeLoop(ctx)
+	}
+}
+
+// StartDiscovery implements [Forwarder].
+func (f *forwarder) StartDiscovery(ctx context.Context) {
+	for _, u := range f.upstreams {
+		if u.discovery == nil {
+			continue
+		}
+
+		zerologr.Info("Starting target discovery", "backend", u.backend)
+		u.refresh(ctx)
+		go u.discoveryLoop(ctx)
+	}
+}
+
+// TargetHealthy implements [Forwarder].
+func (f *forwarder) TargetHealthy(backend, addr string) bool {
+	u, ok := f.upstreams[backend]
+	if !ok {
+		return false
+	}
+
+	for _, t := range u.currentTargets() {
+		if t.addr == addr {
+			return u.healthy(t)
+		}
+	}
+
+	return false
+}
+
+// DiscoveredTargets implements [Forwarder].
+func (f *forwarder) DiscoveredTargets(backend string) ([]string, time.Time, error) {
+	u, ok := f.upstreams[backend]
+	if !ok || u.discovery == nil {
+		return nil, time.Time{}, nil
+	}
+
+	targets := u.currentTargets()
+	addrs := make([]string, 0, len(targets))
+	for _, t := range targets {
+		addrs = append(addrs, t.addr)
+	}
+
+	refreshed, err := u.discovery.state()
+	return addrs, refreshed, err
+}
+
+func (f *forwarder) observeHealth(_ context.Context, observer metric.Int64Observer) error {
+	for _, u := range f.upstreams {
+		for _, t := range u.currentTargets() {
+			var value int64
+			if u.healthy(t) {
+				value = 1
+			}
+			observer.Observe(value, metric.WithAttributes(
+				attribute.String("krb.backend", u.backend),
+				attribute.String("krb.target", t.addr),
+			))
+		}
+	}
+
+	return nil
+}
+
+// observeCircuits reports the breaker state of every backend with a circuit breaker.
+func (f *forwarder) observeCircuits(_ context.Context, observer metric.Int64Observer) error {
+	for _, u := range f.upstreams {
+		if u.breaker == nil {
+			continue
+		}
+
+		observer.Observe(
+			int64(u.breaker.current()),
+			metric.WithAttributes(attribute.String("krb.backend", u.backend)),
+		)
+	}
+
+	return nil
+}
+
+// observeQueues reports the queue depth of every backend with a concurrency limit.
+func (f *forwarder) observeQueues(_ context.Context, observer metric.Int64Observer) error {
+	for _, u := range f.upstreams {
+		if u.limiter == nil {
+			continue
+		}
+
+		observer.Observe(
+			int64(u.limiter.queued()),
+			metric.WithAttributes(attribute.String("krb.backend", u.backend)),
+		)
+	}
+
+	return nil
 }
 
 // Next implements [composer.FlowComponent].
@@ -82,7 +349,6 @@ func (f *forwarder) GetMeta() []adminapi.FlowMeta {
 
 // ServeHTTP implements [composer.FlowComponent].
 func (f *forwarder) ServeHTTP(wrapped http.ResponseWriter, req *http.Request) {
-	debugStart := time.Now()
 	debugCall := composer.DebugFromContext(req.Context())
 
 	// Obtain matching backend to route to.
@@ -91,13 +357,34 @@ func (f *forwarder) ServeHTTP(wrapped http.ResponseWriter, req *http.Request) {
 	rLogger = rLogger.WithName("forwarder")
 	rLogger.V(20).Info("Forwarding request")
 
-	resp, err := f.handleInbound(req)
+	req, variant := f.route(req)
+	shadow := f.startMirror(req)
+	sent := time.Now()
+	resp, err := f.handleInbound(req, debugCall, variant)
+	shadow.complete(resp, time.Since(sent))
 	if err != nil {
 		rLogger.Error(err, "Failed to forward request")
+		apierror.ErrorHandler(wrapped, req, inboundAPIError(wrapped, err))
+		return
+	}
+	defer resp.Body.Close()
+
+	debugStart := time.Now()
+	// handleInbound only succeeds for known backends.
+	backend, _ := req.Context().Value(f.targetContextKey).(*config.RouterBackend)
+	u := f.upstreams[backend.Name]
+
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		f.serveUpgrade(wrapped, req, resp, u.backend, debugCall, rLogger)
+		return
+	}
+
+	if err := f.handleOutbound(u, resp, wrapped, rLogger); err != nil {
+		rLogger.Error(err, "Failed to handle outbound response")
 		apierror.ErrorHandler(wrapped, req, apiErrFailedForwarding)
 		debugCall.AddTransition(
 			"forwarder",
-			composerdebug.CallDirectionInbound,
+			composerdebug.CallDirectionOutbound,
 			debugStart,
 			time.Now(),
 			composerdebug.CallResultFailure,
@@ -105,23 +392,39 @@ func (f *forwarder) ServeHTTP(wrapped http.ResponseWriter, req *http.Request) {
 		)
 		return
 	}
-	defer resp.Body.Close()
 
 	debugCall.AddTransition(
 		"forwarder",
-		composerdebug.CallDirectionInbound,
+		composerdebug.CallDirectionOutbound,
 		debugStart,
 		time.Now(),
 		composerdebug.CallResultSuccess,
 		"",
 	)
 
-	// Reset to track outbound transition time.
-	debugStart = time.Now()
+	rLogger.V(50).Info("Forwarded request")
+}
 
-	if err := f.handleOutbound(resp, wrapped, rLogger); err != nil {
-		rLogger.Error(err, "Failed to handle outbound response")
-		apierror.ErrorHandler(wrapped, req, apiErrFailedForwarding)
+// serveUpgrade tunnels a connection upgraded by the backend, the outbound transition spans the
+// lifetime of the tunnel.
+func (f *forwarder) serveUpgrade(
+	wrapped http.ResponseWriter,
+	req *http.Request,
+	resp *http.Response,
+	backend string,
+	debugCall composerdebug.DebuggedCall,
+	rLogger logr.Logger,
+) {
+	debugStart := time.Now()
+	protocol := upgradeType(resp.Header)
+	rLogger.Info("Tunnelling upgraded connection", "protocol", protocol)
+
+	if err := f.handleUpgrade(req, resp, wrapped, backend); err != nil {
+		rLogger.Error(err, "Failed to tunnel upgraded connection")
+		// Errors before the hijack can still be reported to the client.
+		if errors.Is(err, errUpgradeMismatch) || errors.Is(err, errFailedForwarding) {
+			apierror.ErrorHandler(wrapped, req, apiErrFailedForwarding)
+		}
 		debugCall.AddTransition(
 			"forwarder",
 			composerdebug.CallDirectionOutbound,
@@ -129,6 +432,7 @@ func (f *forwarder) ServeHTTP(wrapped http.ResponseWriter, req *http.Request) {
 			time.Now(),
 			composerdebug.CallResultFailure,
 			err.Error(),
+			composerdebug.Attr("upgrade", protocol),
 		)
 		return
 	}
@@ -140,57 +444,311 @@ func (f *forwarder) ServeHTTP(wrapped http.ResponseWriter, req *http.Request) {
 		time.Now(),
 		composerdebug.CallResultSuccess,
 		"",
+		composerdebug.Attr("upgrade", protocol),
 	)
-
-	rLogger.V(50).Info("Forwarded request")
+	rLogger.V(50).Info("Closed upgraded connection")
 }
 
-func (f *forwarder) handleInbound(req *http.Request) (*http.Response, error) {
-	target, ok := req.Context().Value(f.targetContextKey).(*config.RouterBackend)
+// handleInbound forwards the request to a target of its backend, retrying according to the retry
+// policy of the backend. Every attempt is recorded as an inbound transition of the debug call,
+// along with the variant the request was routed to if its backend is split.
+func (f *forwarder) handleInbound(
+	req *http.Request,
+	debugCall composerdebug.DebuggedCall,
+	variant string,
+) (*http.Response, error) {
+	var base []composerdebug.Attribute
+	if variant != "" {
+		base = append(base, composerdebug.Attr("variant", variant))
+	}
+
+	debugStart := time.Now()
+	failed := func(err error, attributes ...composerdebug.Attribute) error {
+		debugCall.AddTransition(
+			"forwarder",
+			composerdebug.CallDirectionInbound,
+			debugStart,
+			time.Now(),
+			composerdebug.CallResultFailure,
+			err.Error(),
+			append(base, attributes...)...,
+		)
+		return err
+	}
+
+	backend, ok := req.Context().Value(f.targetContextKey).(*config.RouterBackend)
 	if !ok {
-		return nil, fmt.Errorf("%w: no target for: %s", errFailedTargetExtract, req.URL.Path)
+		return nil, failed(
+			fmt.Errorf("%w: no target for: %s", errFailedTargetExtract, req.URL.Path),
+		)
 	}
 
-	client, ok := f.clients[target.Name]
+	u, ok := f.upstreams[backend.Name]
 	if !ok {
-		return nil, fmt.Errorf("%w: no client for: %s", errFailedTargetExtract, req.URL.Path)
+		return nil, failed(
+			fmt.Errorf("%w: no client for: %s", errFailedTargetExtract, req.URL.Path),
+		)
 	}
 
-	scheme := "http"
-	if target.TLS != nil {
-		scheme = "https"
+	forwardURL, err := u.forwardURL(req.URL)
+	if err != nil {
+		return nil, failed(fmt.Errorf("rewriting URL: %w", err))
+	}
+
+	out := &outbound{
+		url:     forwardURL,
+		header:  f.outboundHeader(req),
+		body:    func() io.Reader { return req.Body },
+		upgrade: upgradeType(req.Header),
+	}
+	if out.upgrade != "" {
+		// Upgrade headers are hop-by-hop, but have to reach the backend for it to switch.
+		out.header.Set("Connection", "Upgrade")
+		out.header.Set("Upgrade", out.upgrade)
 	}
 
+	attempts := maxAttempts(u.retry, req)
+	if attempts > 1 {
+		replayable, ok, err := replayableBody(req.Body, u.retry.MaxBufferBytes)
+		if err != nil {
+			return nil, failed(fmt.Errorf("buffering request body: %w", err))
+		}
+		if !ok {
+			attempts = 1
+		}
+		out.body = replayable
+	}
+
+	for attempt := 1; ; attempt++ {
+		debugStart = time.Now()
+		attributes := slices.Clone(base)
+		if attempts > 1 {
+			attributes = append(attributes, composerdebug.Attr("attempt", strconv.Itoa(attempt)))
+		}
+
+		// Every attempt asks the breaker, so that retries stop once an earlier attempt has opened
+		// it.
+		if u.breaker != nil {
+			if retryAfter, ok := u.breaker.allow(); !ok {
+				return nil, failed(&circuitOpenError{retryAfter: retryAfter}, attributes...)
+			}
+		}
+
+		// Every attempt takes its own slot, so that backoffs between attempts do not hold one.
+		release, err := u.acquire(req.Context())
+		if err != nil {
+			return nil, failed(err, attributes...)
+		}
+
+		resp, t, err := f.attempt(req, u, out, attempt, release)
+		if t != nil {
+			attributes = append(attributes, composerdebug.Attr("target", t.addr))
+		}
+
+		if attempt < attempts {
+			if cause, retry := retryable(req.Context(), u.retry, resp, err); retry {
+				if resp != nil {
+					_, _ = io.Copy(io.Discard, resp.Body)
+					_ = resp.Body.Close()
+				}
+				_ = failed(errors.New(cause), attributes...)
+
+				// A client gone during the backoff ends the request, which is recorded as well.
+				debugStart = time.Now()
+				if err := sleep(req.Context(), backoff(u.retry, attempt)); err != nil {
+					return nil, failed(err, attributes...)
+				}
+				continue
+			}
+		}
+
+		if err != nil {
+			return nil, failed(err, attributes...)
+		}
+
+		debugCall.AddTransition(
+			"forwarder",
+			composerdebug.CallDirectionInbound,
+			debugStart,
+			time.Now(),
+			composerdebug.CallResultSuccess,
+			"",
+			attributes...,
+		)
+		return resp, nil
+	}
+}
+
+// attempt picks a healthy target of the upstream and sends the request to it within its own span.
+// The target, the concurrency slot and the resources of the attempt are released when the response
+// body is closed.
+func (f *forwarder) attempt(
+	req *http.Request,
+	u *upstream,
+	out *outbound,
+	attempt int,
+	release func(),
+) (*http.Response, *target, error) {
+	targets := u.healthyTargets()
+	if len(targets) == 0 {
+		release()
+		return nil, nil, fmt.Errorf("%w: backend %s", errNoHealthyTarget, u.backend)
+	}
+
+	t := u.strategy.pick(targets, req)
+	t.acquire()
+	trace.SpanFromContext(req.Context()).SetAttributes(attribute.String("krb.target", t.addr))
+
+	ctx, span := tracer.Start(
+		req.Context(),
+		"forwarder.attempt",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("krb.target", t.addr),
+			attribute.Int("krb.attempt", attempt),
+		),
+	)
+	ctx, cancel := context.WithCancelCause(ctx)
+	var perTryTimeout time.Duration
+	if u.retry != nil {
+		perTryTimeout = time.Duration(u.retry.PerTryTimeoutMs) * time.Millisecond
+	}
+	deadline := newDeadline(cancel, u.timeout, perTryTimeout)
+	done := func() {
+		deadline.stop()
+		cancel(nil)
+		t.release()
+		release()
+	}
+
+	targetURL := *out.url
+	targetURL.Host = t.addr
+
 	//nolint:gosec // ignoring SSRF warning since the target is determined by our own routing logic and not user input.
 	forwardRequest, err := http.NewRequestWithContext(
-		req.Context(),
+		ctx,
 		req.Method,
-		fmt.Sprintf(
-			"%s://%s%s",
-			scheme,
-			net.JoinHostPort(target.Host, strconv.Itoa(target.Port)),
-			req.URL.Path,
-		),
-		req.Body,
+		targetURL.String(),
+		out.body(),
 	)
 	if err != nil {
-		return nil, err
+		span.End()
+		done()
+		return nil, t, err
 	}
 
-	forwardRequest.Header = req.Header
-	otel.GetTextMapPropagator().
-		Inject(req.Context(), propagation.HeaderCarrier(forwardRequest.Header))
+	forwardRequest.Header = out.header
+	forwardRequest.Trailer = req.Trailer
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(forwardRequest.Header))
 
 	//nolint:gosec // ignoring SSRF warning since the target is determined by our own
 	// routing logic and not user input.
-	return client.Do(forwardRequest)
+	sent := time.Now()
+	resp, err := u.client.Do(forwardRequest)
+	// Requests cancelled by the client say nothing about the health of the target.
+	if req.Context().Err() == nil {
+		success := err == nil && resp.StatusCode < http.StatusInternalServerError
+		u.observe(t, success)
+		if u.breaker != nil {
+			u.breaker.record(success)
+		}
+		if u.limiter != nil {
+			u.limiter.sample(time.Since(sent), success)
+		}
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		done()
+		return nil, t, err
+	}
+
+	span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	span.End()
+	u.wrapBody(resp, deadline, cancel, done)
+
+	return resp, t, nil
+}
+
+// wrapBody wraps the response body of an attempt so that closing it releases the attempt. The
+// deadline of the attempt is lifted for streams, which are bounded by the idle timeout instead, and
+// for upgraded connections.
+func (u *upstream) wrapBody(
+	resp *http.Response,
+	deadline *deadline,
+	cancel context.CancelCauseFunc,
+	done func(),
+) {
+	if rw, ok := resp.Body.(io.ReadWriteCloser); ok &&
+		resp.StatusCode == http.StatusSwitchingProtocols {
+		deadline.stop()
+		resp.Body = &upgradedBody{
+			attemptBody: &attemptBody{ReadCloser: resp.Body, done: done},
+			Writer:      rw,
+		}
+		return
+	}
+
+	body := resp.Body
+	if u.isStream(resp) {
+		deadline.stop()
+		body = newStreamBody(body, u.idleTimeout(), cancel)
+	}
+	resp.Body = &attemptBody{ReadCloser: body, done: done}
+}
+
+// inboundAPIError maps an error of handleInbound to the API error returned to the client.
+func inboundAPIError(wrapped http.ResponseWriter, err error) error {
+	var circuitErr *circuitOpenError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &circuitErr):
+		wrapped.Header().Set(
+			"Retry-After",
+			strconv.Itoa(int(math.Ceil(circuitErr.retryAfter.Seconds()))),
+		)
+		return apiErrCircuitOpen
+	case errors.Is(err, errNoHealthyTarget):
+		return apiErrNoHealthyTarget
+	case errors.Is(err, errShed):
+		return apiErrShed
+	case errors.As(err, &maxBytesErr):
+		// A body limit cut off the request body while it was sent.
+		return apierror.ErrRequestEntityTooLarge
+	default:
+		return apiErrFailedForwarding
+	}
+}
+
+// outboundHeader returns the header of the upstream request, without hop-by-hop headers and with
+// the configured proxy headers.
+func (f *forwarder) outboundHeader(req *http.Request) http.Header {
+	header := req.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	removeHopByHopHeaders(header)
+	if f.proxyHeaders != nil {
+		f.proxyHeaders.apply(header, req)
+	}
+
+	return header
 }
 
 func (f *forwarder) handleOutbound(
+	u *upstream,
 	resp *http.Response,
 	wrapped http.ResponseWriter,
 	rLogger logr.Logger,
 ) error {
+	removeHopByHopHeaders(resp.Header)
+
 	for key, values := range resp.Header {
 		rLogger.V(100).Info("Adding header to response", "key", key, "values", values)
 		for _, value := range values {
@@ -198,8 +756,21 @@ func (f *forwarder) handleOutbound(
 		}
 	}
 
+	announceTrailers(wrapped, resp)
+	announced := len(resp.Trailer)
 	wrapped.WriteHeader(resp.StatusCode)
 
-	_, err := io.Copy(wrapped, resp.Body)
-	return err
+	var err error
+	if u.isStream(resp) {
+		rLogger.V(50).Info("Streaming response")
+		err = copyStream(wrapped, resp.Body, u.idleTimeout())
+	} else {
+		_, err = io.Copy(wrapped, resp.Body)
+	}
+	if err != nil {
+		return err
+	}
+
+	copyTrailers(wrapped, resp, announced)
+	return nil
 }
@@ -1,6 +1,7 @@
 package forwarder_test
 
 import (
+	"bufio"
 	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
@@ -9,20 +10,27 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
+	"io"
 	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/trebent/kerberos/internal/composer"
 	"github.com/trebent/kerberos/internal/composer/forwarder"
 	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/kerberos/internal/response"
 )
 
 func TestForwarder(t *testing.T) {
@@ -432,3 +440,1090 @@ func (ca testCA) signCert(t *testing.T, serial int64, ipSANs []net.IP) signedCer
 
 	return signedCert{certFile: cf.Name(), keyFile: kf.Name(), tlsCert: tlsCert}
 }
+
+func TestForwarderLoadBalancing(t *testing.T) {
+	var hits [2]int
+	targets := make([]*config.BackendTarget, 0, len(hits))
+	for i := range hits {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits[i]++
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		serverURL, _ := url.Parse(server.URL)
+		port, _ := strconv.Atoi(serverURL.Port())
+		targets = append(targets, &config.BackendTarget{Host: serverURL.Hostname(), Port: port})
+	}
+
+	tests := []struct {
+		name          string
+		loadBalancing *config.LoadBalancing
+		header        string
+		expectedHits  [2]int
+	}{
+		{
+			name:          "round-robin",
+			loadBalancing: &config.LoadBalancing{Strategy: config.LoadBalancingRoundRobin},
+			expectedHits:  [2]int{2, 2},
+		},
+		{
+			name: "consistent-hash",
+			loadBalancing: &config.LoadBalancing{
+				Strategy: config.LoadBalancingConsistentHash,
+				Hash:     &config.LoadBalancingHash{Header: "X-User"},
+			},
+			header: "user-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits = [2]int{}
+			backend := &config.RouterBackend{
+				Name:          "lb-backend",
+				Targets:       targets,
+				LoadBalancing: tt.loadBalancing,
+			}
+			fwd, err := forwarder.NewComponent(&forwarder.Opts{
+				Backends: []*config.RouterBackend{backend},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create forwarder component: %v", err)
+			}
+
+			for range 4 {
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest(http.MethodGet, "/test", nil)
+				request.Header.Set("X-User", tt.header)
+				ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+				fwd.ServeHTTP(recorder, request.WithContext(ctx))
+
+				if recorder.Code != http.StatusOK {
+					t.Fatalf("Expected status code %d, got %d", http.StatusOK, recorder.Code)
+				}
+			}
+
+			if tt.expectedHits != [2]int{} && hits != tt.expectedHits {
+				t.Errorf("Expected hits %v, got %v", tt.expectedHits, hits)
+			}
+			if tt.expectedHits == [2]int{} && hits[0] != 4 && hits[1] != 4 {
+				t.Errorf("Expected all requests on one target, got %v", hits)
+			}
+		})
+	}
+}
+
+func TestForwarderPassiveHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name: "passive-backend",
+		Host: serverURL.Hostname(),
+		Port: port,
+		HealthCheck: &config.HealthCheck{
+			Passive: &config.PassiveHealthCheck{ConsecutiveFailures: 2, EjectionMs: 60000},
+		},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	for _, expected := range []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusServiceUnavailable} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/test", nil)
+		ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+		fwd.ServeHTTP(recorder, request.WithContext(ctx))
+
+		if recorder.Code != expected {
+			t.Fatalf("Expected status code %d, got %d", expected, recorder.Code)
+		}
+	}
+
+	if fwd.TargetHealthy(backend.Name, serverURL.Host) {
+		t.Errorf("Expected target %s to be ejected", serverURL.Host)
+	}
+}
+
+func TestForwarderActiveHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" && !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name: "active-backend",
+		Host: serverURL.Hostname(),
+		Port: port,
+		HealthCheck: &config.HealthCheck{
+			Active: &config.ActiveHealthCheck{
+				Path:               "/healthz",
+				IntervalMs:         10,
+				TimeoutMs:          1000,
+				HealthyThreshold:   1,
+				UnhealthyThreshold: 1,
+			},
+		},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+	fwd.StartHealthChecks(t.Context())
+
+	waitForHealth := func(expected bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for fwd.TargetHealthy(backend.Name, serverURL.Host) != expected {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for target health %t", expected)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	healthy.Store(false)
+	waitForHealth(false)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/test", nil)
+	ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+	fwd.ServeHTTP(recorder, request.WithContext(ctx))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status code %d, got %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+
+	healthy.Store(true)
+	waitForHealth(true)
+}
+
+func TestForwarderCircuitBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name: "breaker-backend",
+		Host: serverURL.Hostname(),
+		Port: port,
+		CircuitBreaker: &config.CircuitBreaker{
+			ConsecutiveFailures: 1,
+			Window:              10,
+			CooldownMs:          5000,
+			HalfOpenRequests:    1,
+		},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	for _, expected := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/test", nil)
+		ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+		fwd.ServeHTTP(recorder, request.WithContext(ctx))
+
+		if recorder.Code != expected {
+			t.Fatalf("Expected status code %d, got %d", expected, recorder.Code)
+		}
+
+		if expected == http.StatusServiceUnavailable && recorder.Header().Get("Retry-After") != "5" {
+			t.Errorf("Expected Retry-After 5, got %q", recorder.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestForwarderRetryCircuitBreaker(t *testing.T) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name: "retry-breaker-backend",
+		Host: serverURL.Hostname(),
+		Port: port,
+		Retry: &config.RetryPolicy{
+			MaxAttempts:    3,
+			BackoffMs:      1,
+			MaxBackoffMs:   5,
+			RetryOn:        []string{config.RetryOnServiceUnavailable},
+			MaxBufferBytes: 1024,
+		},
+		CircuitBreaker: &config.CircuitBreaker{
+			ConsecutiveFailures: 1,
+			Window:              10,
+			CooldownMs:          5000,
+			HalfOpenRequests:    1,
+		},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/test", nil)
+	ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+	fwd.ServeHTTP(recorder, request.WithContext(ctx))
+
+	// The first attempt opens the breaker, so the retry is not sent.
+	if hits.Load() != 1 {
+		t.Errorf("Expected 1 attempt, got %d", hits.Load())
+	}
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status code %d, got %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") != "5" {
+		t.Errorf("Expected Retry-After 5, got %q", recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestForwarderConcurrencyLimit(t *testing.T) {
+	arrived := make(chan struct{})
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		arrived <- struct{}{}
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name:        "limited-backend",
+		Host:        serverURL.Hostname(),
+		Port:        port,
+		Concurrency: &config.ConcurrencyLimit{MaxInFlight: 1, QueueTimeoutMs: 1000},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	serve := func() int {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/test", nil)
+		ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+		fwd.ServeHTTP(recorder, request.WithContext(ctx))
+		return recorder.Code
+	}
+
+	inFlight := make(chan int)
+	go func() { inFlight <- serve() }()
+	<-arrived
+
+	if code := serve(); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the request over the limit to be shed with %d, got %d",
+			http.StatusServiceUnavailable, code)
+	}
+
+	unblock <- struct{}{}
+	if code := <-inFlight; code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+	}
+
+	// The slot is free again.
+	go func() {
+		<-arrived
+		unblock <- struct{}{}
+	}()
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+	}
+}
+
+func TestForwarderRequestBodyLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name: "body-limit-backend",
+		Host: serverURL.Hostname(),
+		Port: port,
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	// A body limit cuts off bodies of unknown length while they are sent.
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/test", nil)
+	body := io.NopCloser(strings.NewReader(strings.Repeat("a", 1<<20)))
+	request.Body = http.MaxBytesReader(recorder, body, 10)
+	request.ContentLength = -1
+	ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+	fwd.ServeHTTP(recorder, request.WithContext(ctx))
+
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status code %d, got %d", http.StatusRequestEntityTooLarge, recorder.Code)
+	}
+}
+
+func TestForwarderRetry(t *testing.T) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if hits.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name: "retry-backend",
+		Host: serverURL.Hostname(),
+		Port: port,
+		Retry: &config.RetryPolicy{
+			MaxAttempts:    3,
+			BackoffMs:      1,
+			MaxBackoffMs:   5,
+			RetryOn:        []string{config.RetryOnServiceUnavailable},
+			MaxBufferBytes: 1024,
+		},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	tests := []struct {
+		method       string
+		expectedCode int
+		expectedHits int64
+	}{
+		{method: http.MethodPut, expectedCode: http.StatusOK, expectedHits: 2},
+		{method: http.MethodPost, expectedCode: http.StatusServiceUnavailable, expectedHits: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			hits.Store(0)
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(tt.method, "/test", strings.NewReader("replayed"))
+			ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+			fwd.ServeHTTP(recorder, request.WithContext(ctx))
+
+			if recorder.Code != tt.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedCode, recorder.Code)
+			}
+
+			if hits.Load() != tt.expectedHits {
+				t.Errorf("Expected %d attempts, got %d", tt.expectedHits, hits.Load())
+			}
+
+			if tt.expectedCode == http.StatusOK && recorder.Body.String() != "replayed" {
+				t.Errorf("Expected replayed body, got %q", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestForwarderMirror(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	// The shadow backend holds on to its responses until released.
+	type mirrored struct {
+		header http.Header
+		path   string
+		body   string
+	}
+	received := make(chan mirrored, 1)
+	release := make(chan struct{})
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- mirrored{header: r.Header, path: r.URL.Path, body: string(body)}
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer shadow.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	shadowURL, _ := url.Parse(shadow.URL)
+	shadowPort, _ := strconv.Atoi(shadowURL.Port())
+	backend := &config.RouterBackend{
+		Name: "mirrored-backend",
+		Host: serverURL.Hostname(),
+		Port: port,
+		Mirror: &config.Mirror{
+			Host:         shadowURL.Hostname(),
+			Port:         shadowPort,
+			Percentage:   100,
+			TimeoutMs:    5000,
+			MaxBodyBytes: 8,
+			MaxInFlight:  10,
+		},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	forward := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+		fwd.ServeHTTP(recorder, request.WithContext(ctx))
+		return recorder
+	}
+
+	// The client is answered while the shadow request is still in flight.
+	recorder := forward("order")
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "order" {
+		t.Fatalf("Expected the primary response, got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	m := <-received
+	if m.path != "/orders" || m.body != "order" || m.header.Get("X-Krb-Mirror") != backend.Name {
+		t.Errorf("Unexpected shadow request %+v", m)
+	}
+	close(release)
+
+	// Bodies over the limit reach the primary backend in full, but are not mirrored.
+	recorder = forward("too large to mirror")
+	if recorder.Body.String() != "too large to mirror" {
+		t.Errorf("Expected the full body to be forwarded, got %q", recorder.Body.String())
+	}
+
+	forward("small")
+	if m := <-received; m.body != "small" {
+		t.Errorf("Expected only the small body to be mirrored, got %q", m.body)
+	}
+}
+
+func TestForwarderSplit(t *testing.T) {
+	newBackend := func(name string) *config.RouterBackend {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(name))
+		}))
+		t.Cleanup(server.Close)
+
+		serverURL, _ := url.Parse(server.URL)
+		port, _ := strconv.Atoi(serverURL.Port())
+		return &config.RouterBackend{Name: name, Host: serverURL.Hostname(), Port: port}
+	}
+	stable, canary := newBackend("stable"), newBackend("canary")
+
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{stable, canary},
+		Splits: []*config.TrafficSplit{{
+			Backend: stable.Name,
+			Variants: []*config.SplitVariant{{
+				Backend: canary.Name,
+				Headers: map[string]string{"X-Canary": "true"},
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	forward := func(header http.Header) string {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		request.Header = header
+		ctx := context.WithValue(request.Context(), composer.TargetContextKey, stable)
+		fwd.ServeHTTP(recorder, request.WithContext(ctx))
+		return recorder.Body.String()
+	}
+
+	if actual := forward(http.Header{"X-Canary": {"true"}}); actual != canary.Name {
+		t.Errorf("Expected the matching request to reach the canary, got %q", actual)
+	}
+	if actual := forward(http.Header{}); actual != stable.Name {
+		t.Errorf("Expected the other request to stay on the backend, got %q", actual)
+	}
+
+	// Adjusting the split at runtime sends all traffic to the canary.
+	if err := fwd.SetSplit(adminapi.TrafficSplit{
+		Backend:  stable.Name,
+		Variants: []adminapi.TrafficSplitVariant{{Backend: canary.Name, Weight: 100}},
+	}); err != nil {
+		t.Fatalf("Failed to set split: %v", err)
+	}
+	if actual := forward(http.Header{}); actual != canary.Name {
+		t.Errorf("Expected the fully weighted canary to be picked, got %q", actual)
+	}
+
+	// Weights above 100 percent and unknown backends are rejected.
+	err = fwd.SetSplit(adminapi.TrafficSplit{
+		Backend: stable.Name,
+		Variants: []adminapi.TrafficSplitVariant{
+			{Backend: canary.Name, Weight: 60},
+			{Backend: canary.Name, Weight: 60},
+		},
+	})
+	if apiErr := (&apierror.Error{}); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a bad request error, got %v", err)
+	}
+	if err := fwd.SetSplit(adminapi.TrafficSplit{Backend: "unknown"}); !errors.Is(
+		err,
+		apierror.ErrNotFound,
+	) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+
+	if err := fwd.DeleteSplit(stable.Name); err != nil {
+		t.Fatalf("Failed to delete split: %v", err)
+	}
+	if actual := forward(http.Header{"X-Canary": {"true"}}); actual != stable.Name {
+		t.Errorf("Expected requests to stay on the backend without a split, got %q", actual)
+	}
+	if len(fwd.Splits()) != 0 {
+		t.Errorf("Expected no splits, got %+v", fwd.Splits())
+	}
+}
+
+func TestForwarderPreservesURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.EscapedPath() + "?" + r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name: "url-backend",
+		Host: serverURL.Hostname(),
+		Port: port,
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/files/a%2Fb?q=a%26b&x=1", nil)
+	ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+	fwd.ServeHTTP(recorder, request.WithContext(ctx))
+
+	if expected := "/files/a%2Fb?q=a%26b&x=1"; recorder.Body.String() != expected {
+		t.Errorf("Expected upstream URL %s, got %s", expected, recorder.Body.String())
+	}
+}
+
+func TestForwarderUpgrade(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "echo" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Failed to hijack backend connection: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		_ = brw.Flush()
+		_, _ = io.Copy(conn, brw)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name:      "upgrade-backend",
+		Host:      serverURL.Hostname(),
+		Port:      port,
+		TimeoutMs: 100,
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), composer.TargetContextKey, backend)
+		fwd.ServeHTTP(response.NewResponseWrapper(w), r.WithContext(ctx))
+	}))
+	defer gateway.Close()
+
+	conn, err := net.Dial("tcp", gateway.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial gateway: %v", err)
+	}
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: gateway\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Failed to read upgrade response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status code %d, got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+	if resp.Header.Get("Upgrade") != "echo" {
+		t.Errorf("Expected Upgrade echo, got %q", resp.Header.Get("Upgrade"))
+	}
+
+	// Outlive the backend timeout to make sure it does not apply to the tunnel.
+	time.Sleep(200 * time.Millisecond)
+
+	for _, message := range []string{"ping", "pong"} {
+		_, _ = conn.Write([]byte(message))
+		echoed := make([]byte, len(message))
+		if _, err := io.ReadFull(reader, echoed); err != nil {
+			t.Fatalf("Failed to read echo: %v", err)
+		}
+		if string(echoed) != message {
+			t.Errorf("Expected echo %q, got %q", message, echoed)
+		}
+	}
+}
+
+func TestForwarderStreaming(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum")
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		w.WriteHeader(http.StatusOK)
+
+		_, _ = w.Write([]byte("data: one\n\n"))
+		w.(http.Flusher).Flush()
+
+		// Outlive the backend timeout, streams are only bounded by the idle timeout.
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte("data: two\n\n"))
+		w.Header().Set("X-Checksum", "abc")
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name:      "stream-backend",
+		Host:      serverURL.Hostname(),
+		Port:      port,
+		TimeoutMs: 100,
+		Streaming: &config.Streaming{IdleTimeoutMs: 1000},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), composer.TargetContextKey, backend)
+		fwd.ServeHTTP(response.NewResponseWrapper(w), r.WithContext(ctx))
+	}))
+	defer gateway.Close()
+
+	resp, err := http.Get(gateway.URL + "/events")
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	first, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read the first event before the stream ended: %v", err)
+	}
+	if first != "data: one\n" {
+		t.Errorf("Expected the first event, got %q", first)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read the rest of the stream: %v", err)
+	}
+	if string(rest) != "\ndata: two\n\n" {
+		t.Errorf("Expected the second event, got %q", rest)
+	}
+	if resp.Trailer.Get("X-Checksum") != "abc" {
+		t.Errorf("Expected trailer X-Checksum abc, got %q", resp.Trailer.Get("X-Checksum"))
+	}
+}
+
+func TestForwarderStreamingIdleTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: one\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name:      "idle-backend",
+		Host:      serverURL.Hostname(),
+		Port:      port,
+		Streaming: &config.Streaming{IdleTimeoutMs: 100},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/events", nil)
+	ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+
+	start := time.Now()
+	fwd.ServeHTTP(recorder, request.WithContext(ctx))
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the idle stream to be closed, it took %s", elapsed)
+	}
+	if !strings.HasPrefix(recorder.Body.String(), "data: one\n\n") {
+		t.Errorf("Expected the first event, got %q", recorder.Body.String())
+	}
+	if !recorder.Flushed {
+		t.Error("Expected the stream to be flushed")
+	}
+}
+
+func TestForwarderGRPC(t *testing.T) {
+	h2c := &http.Protocols{}
+	h2c.SetUnencryptedHTTP2(true)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("Expected HTTP/2 to the backend, got %s", r.Proto)
+		}
+		if r.Header.Get("Te") != "trailers" {
+			t.Errorf("Expected TE trailers to reach the backend, got %q", r.Header.Get("Te"))
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte{0, 0, 0, 0, 0})
+		w.Header().Set("Grpc-Status", "5")
+		w.Header().Set("Grpc-Message", "not found")
+	}))
+	server.Config.Protocols = h2c
+	server.Start()
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name:     "grpc-backend",
+		Host:     serverURL.Hostname(),
+		Port:     port,
+		Protocol: config.ProtocolGRPC,
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	grpcStatus := make(chan int, 1)
+	gateway := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wrapper, _ := response.NewResponseWrapper(w).(*response.Wrapper)
+		ctx := context.WithValue(r.Context(), composer.TargetContextKey, backend)
+		fwd.ServeHTTP(wrapper, r.WithContext(ctx))
+
+		status, ok := wrapper.GRPCStatus()
+		if !ok {
+			status = -1
+		}
+		grpcStatus <- status
+	}))
+	gateway.Config.Protocols = h2c
+	gateway.Start()
+	defer gateway.Close()
+
+	client := &http.Client{Transport: &http.Transport{Protocols: h2c}}
+	request, _ := http.NewRequestWithContext(
+		t.Context(),
+		http.MethodPost,
+		gateway.URL+"/pkg.Service/Method",
+		strings.NewReader("\x00\x00\x00\x00\x00"),
+	)
+	request.Header.Set("Content-Type", "application/grpc")
+	request.Header.Set("Te", "trailers")
+
+	resp, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("Failed to call gateway: %v", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2 from the gateway, got %s", resp.Proto)
+	}
+	if resp.Trailer.Get("Grpc-Status") != "5" || resp.Trailer.Get("Grpc-Message") != "not found" {
+		t.Errorf("Expected the gRPC trailers, got %v", resp.Trailer)
+	}
+	if status := <-grpcStatus; status != 5 {
+		t.Errorf("Expected gRPC status 5 on the wrapper, got %d", status)
+	}
+}
+
+func TestForwarderH2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name:     "h2-backend",
+		Host:     serverURL.Hostname(),
+		Port:     port,
+		Protocol: config.ProtocolH2,
+		TLS:      &config.BackendTLS{InsecureSkipVerify: true},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/test", nil)
+	ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+	fwd.ServeHTTP(recorder, request.WithContext(ctx))
+
+	if recorder.Body.String() != "HTTP/2.0" {
+		t.Errorf("Expected the backend to be called over HTTP/2.0, got %q", recorder.Body.String())
+	}
+}
+
+func TestForwarderFileDiscovery(t *testing.T) {
+	var hits [2]atomic.Int64
+	addrs := make([]string, 0, len(hits))
+	for i := range hits {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits[i].Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		serverURL, _ := url.Parse(server.URL)
+		addrs = append(addrs, serverURL.Host)
+	}
+
+	path := t.TempDir() + "/targets.json"
+	writeTargets := func(modTime time.Time, addrs ...string) {
+		t.Helper()
+		targets := make([]string, 0, len(addrs))
+		for _, addr := range addrs {
+			host, port, _ := net.SplitHostPort(addr)
+			targets = append(targets, `{"host":"`+host+`","port":`+port+`}`)
+		}
+		if err := os.WriteFile(path, []byte("["+strings.Join(targets, ",")+"]"), 0o600); err != nil {
+			t.Fatalf("Failed to write target file: %v", err)
+		}
+		// The file is only read again when its modification time or size changes.
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set target file time: %v", err)
+		}
+	}
+	writeTargets(time.Now().Add(-time.Minute), addrs[0])
+
+	backend := &config.RouterBackend{
+		Name: "discovered-backend",
+		Discovery: &config.Discovery{
+			File:       &config.FileDiscovery{Path: path},
+			IntervalMs: 10,
+		},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+	fwd.StartDiscovery(t.Context())
+
+	discovered, refreshed, err := fwd.DiscoveredTargets(backend.Name)
+	if err != nil || refreshed.IsZero() || len(discovered) != 1 || discovered[0] != addrs[0] {
+		t.Fatalf("Unexpected discovered targets %v, refreshed %s, error %v", discovered, refreshed, err)
+	}
+
+	serve := func() {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/test", nil)
+		ctx := context.WithValue(request.Context(), composer.TargetContextKey, backend)
+		fwd.ServeHTTP(recorder, request.WithContext(ctx))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+	}
+
+	serve()
+	if hits[0].Load() != 1 {
+		t.Fatalf("Expected the request on the first target, got %d hits", hits[0].Load())
+	}
+
+	writeTargets(time.Now(), addrs[1])
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		discovered, _, _ = fwd.DiscoveredTargets(backend.Name)
+		if len(discovered) == 1 && discovered[0] == addrs[1] {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the target file change, got %v", discovered)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	serve()
+	if hits[1].Load() != 1 {
+		t.Fatalf("Expected the request on the second target, got %d hits", hits[1].Load())
+	}
+
+	// An invalid file keeps the previous targets and reports the error.
+	if err := os.WriteFile(path, []byte(`[{"host":""}]`), 0o600); err != nil {
+		t.Fatalf("Failed to write target file: %v", err)
+	}
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		discovered, _, err = fwd.DiscoveredTargets(backend.Name)
+		if err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the invalid target file to be reported")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if len(discovered) != 1 || discovered[0] != addrs[1] {
+		t.Errorf("Expected the previous targets to be kept, got %v", discovered)
+	}
+	serve()
+
+	// So does a file without targets.
+	writeTargets(time.Now().Add(time.Minute))
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		discovered, _, err = fwd.DiscoveredTargets(backend.Name)
+		if err != nil && strings.Contains(err.Error(), "no targets") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the empty target file to be reported, got %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if len(discovered) != 1 || discovered[0] != addrs[1] {
+		t.Errorf("Expected the previous targets to be kept, got %v", discovered)
+	}
+	serve()
+}
+
+func TestForwarderDNSDiscovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	backend := &config.RouterBackend{
+		Name: "dns-backend",
+		Discovery: &config.Discovery{
+			DNS:        &config.DNSDiscovery{Name: "localhost", Port: port},
+			IntervalMs: 1000,
+		},
+	}
+	fwd, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends: []*config.RouterBackend{backend},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create forwarder component: %v", err)
+	}
+	fwd.StartDiscovery(t.Context())
+
+	discovered, _, err := fwd.DiscoveredTargets(backend.Name)
+	if err != nil {
+		t.Fatalf("Failed to discover targets: %v", err)
+	}
+	if !slices.Contains(discovered, serverURL.Host) {
+		t.Fatalf("Expected %s among the discovered targets, got %v", serverURL.Host, discovered)
+	}
+}
@@ -0,0 +1,227 @@
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/zerologr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type (
+	// limiter limits the requests forwarded to a single backend at the same time. Requests over the
+	// limit wait in a bounded FIFO queue until a slot is released or their queue timeout passes.
+	limiter struct {
+		backend      string
+		maxQueue     int
+		queueTimeout time.Duration
+		shedCounter  metric.Int64Counter
+		// adaptive is nil for a static limit.
+		adaptive *aimd
+
+		mu       sync.Mutex
+		limit    int
+		inFlight int
+		queue    []chan struct{}
+	}
+	// aimd adjusts the limit of a limiter with additive increase and multiplicative decrease based
+	// on the latency of forwarded requests, compared to the lowest recently observed latency.
+	aimd struct {
+		cfg      *config.AdaptiveConcurrency
+		maxLimit int
+
+		decreasedAt time.Time
+		// Lowest latency of the current and the previous baseline window.
+		windowStart time.Time
+		current     time.Duration
+		previous    time.Duration
+	}
+	// shedError is returned for requests shed by the limiter.
+	shedError struct {
+		reason string
+	}
+)
+
+const (
+	queueDepthGaugeName = "request.queue.depth"
+	shedCounterName     = "request.shed.count"
+
+	shedQueueFull    = "queue full"
+	shedQueueTimeout = "queue timeout"
+
+	// baselineWindow is how long the lowest observed latency is kept as the baseline of an adaptive
+	// limit, so that the baseline follows lasting changes in the latency of the backend.
+	baselineWindow = 30 * time.Second
+)
+
+var errShed = errors.New("concurrency limit reached")
+
+func newLimiter(
+	backend string,
+	cfg *config.ConcurrencyLimit,
+	shedCounter metric.Int64Counter,
+) *limiter {
+	l := &limiter{
+		backend:      backend,
+		maxQueue:     cfg.MaxQueue,
+		queueTimeout: time.Duration(cfg.QueueTimeoutMs) * time.Millisecond,
+		shedCounter:  shedCounter,
+		// An adaptive limit starts at its upper bound and is lowered once the backend slows down.
+		limit: cfg.MaxInFlight,
+	}
+	if cfg.Adaptive != nil {
+		l.adaptive = &aimd{cfg: cfg.Adaptive, maxLimit: cfg.MaxInFlight}
+	}
+
+	return l
+}
+
+func (e *shedError) Error() string {
+	return errShed.Error() + ": " + e.reason
+}
+
+func (e *shedError) Unwrap() error {
+	return errShed
+}
+
+// acquire takes a slot of the limiter, waiting in the queue while all slots are taken. The
+// returned function releases the slot.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	l.mu.Lock()
+	if l.inFlight < l.limit && len(l.queue) == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return l.release, nil
+	}
+
+	if len(l.queue) >= l.maxQueue {
+		l.mu.Unlock()
+		return nil, l.shed(ctx, shedQueueFull)
+	}
+
+	ready := make(chan struct{})
+	l.queue = append(l.queue, ready)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-ready:
+		return l.release, nil
+	case <-timer.C:
+		err = &shedError{reason: shedQueueTimeout}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if i := slices.Index(l.queue, ready); i >= 0 {
+		l.queue = slices.Delete(l.queue, i, i+1)
+	} else {
+		// The slot was handed over while giving up, pass it on.
+		l.inFlight--
+		l.grant()
+	}
+
+	if errors.Is(err, errShed) {
+		return nil, l.shed(ctx, shedQueueTimeout)
+	}
+	return nil, err
+}
+
+// release gives a slot back, handing it to the first queued request.
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	l.grant()
+}
+
+// grant hands free slots to queued requests. Must be called with mu held.
+func (l *limiter) grant() {
+	for l.inFlight < l.limit && len(l.queue) > 0 {
+		close(l.queue[0])
+		l.queue = l.queue[1:]
+		l.inFlight++
+	}
+}
+
+// shed counts a shed request and returns its error.
+func (l *limiter) shed(ctx context.Context, reason string) error {
+	zerologr.V(20).Info("Shedding request", "backend", l.backend, "reason", reason)
+	l.shedCounter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("krb.backend", l.backend),
+		attribute.String("krb.reason", reason),
+	))
+
+	return &shedError{reason: reason}
+}
+
+// sample adjusts an adaptive limit to the outcome of a forwarded request, a failed or too slow
+// request lowers the limit while other requests raise it as long as at least half of it is used.
+// The limit is lowered at most once per latency of the request, as the requests in flight at the
+// previous decrease say nothing about the lowered limit.
+func (l *limiter) sample(latency time.Duration, success bool) {
+	if l.adaptive == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a := l.adaptive
+	now := time.Now()
+	tolerated := time.Duration(float64(a.baseline(latency, now)) * a.cfg.Tolerance)
+
+	previous := l.limit
+	switch {
+	case !success || latency > tolerated:
+		if now.Sub(a.decreasedAt) < latency {
+			return
+		}
+		a.decreasedAt = now
+		l.limit = max(a.cfg.MinLimit, int(float64(l.limit)*a.cfg.Backoff))
+	case l.inFlight*2 >= l.limit:
+		l.limit = min(a.maxLimit, l.limit+1)
+	}
+
+	if l.limit != previous {
+		zerologr.V(20).Info("Adjusted concurrency limit", "backend", l.backend, "limit", l.limit)
+	}
+	l.grant()
+}
+
+// queued returns the number of queued requests.
+func (l *limiter) queued() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.queue)
+}
+
+// baseline records the input latency and returns the lowest latency of the current and the
+// previous baseline window.
+func (a *aimd) baseline(latency time.Duration, now time.Time) time.Duration {
+	if now.Sub(a.windowStart) >= baselineWindow {
+		a.previous, a.current, a.windowStart = a.current, 0, now
+	}
+
+	if a.current == 0 || latency < a.current {
+		a.current = latency
+	}
+
+	if a.previous != 0 && a.previous < a.current {
+		return a.previous
+	}
+	return a.current
+}
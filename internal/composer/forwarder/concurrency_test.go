@@ -0,0 +1,115 @@
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+func TestLimiterQueue(t *testing.T) {
+	l := newLimiter("backend", &config.ConcurrencyLimit{
+		MaxInFlight:    1,
+		MaxQueue:       1,
+		QueueTimeoutMs: 5000,
+	}, noop.Int64Counter{})
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected a free slot, got %v", err)
+	}
+
+	queued := make(chan error)
+	go func() {
+		release, err := l.acquire(context.Background())
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+
+	for l.queued() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := l.acquire(context.Background()); !errors.Is(err, errShed) {
+		t.Fatalf("Expected the request to be shed with a full queue, got %v", err)
+	}
+
+	release()
+	if err := <-queued; err != nil {
+		t.Fatalf("Expected the queued request to get the released slot, got %v", err)
+	}
+
+	if l.inFlight != 0 || l.queued() != 0 {
+		t.Errorf("Expected no requests in flight or queued, got %d and %d", l.inFlight, l.queued())
+	}
+}
+
+func TestLimiterQueueTimeout(t *testing.T) {
+	l := newLimiter("backend", &config.ConcurrencyLimit{
+		MaxInFlight:    1,
+		MaxQueue:       1,
+		QueueTimeoutMs: 10,
+	}, noop.Int64Counter{})
+
+	if _, err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("Expected a free slot, got %v", err)
+	}
+
+	var shedErr *shedError
+	if _, err := l.acquire(context.Background()); !errors.As(err, &shedErr) ||
+		shedErr.reason != shedQueueTimeout {
+		t.Fatalf("Expected the request to be shed after the queue timeout, got %v", err)
+	}
+
+	if l.queued() != 0 {
+		t.Errorf("Expected the timed out request to leave the queue, got %d queued", l.queued())
+	}
+}
+
+func TestLimiterAdaptive(t *testing.T) {
+	l := newLimiter("backend", &config.ConcurrencyLimit{
+		MaxInFlight: 10,
+		Adaptive: &config.AdaptiveConcurrency{
+			MinLimit:  2,
+			Tolerance: 2,
+			Backoff:   0.5,
+		},
+	}, noop.Int64Counter{})
+
+	l.sample(10*time.Millisecond, true)
+	if l.limit != 10 {
+		t.Fatalf("Expected the limit to stay at its maximum, got %d", l.limit)
+	}
+
+	l.sample(100*time.Millisecond, true)
+	if l.limit != 5 {
+		t.Fatalf("Expected a slow request to halve the limit, got %d", l.limit)
+	}
+
+	l.sample(100*time.Millisecond, true)
+	if l.limit != 5 {
+		t.Fatalf("Expected the limit to be lowered once per latency, got %d", l.limit)
+	}
+
+	l.adaptive.decreasedAt = time.Time{}
+	l.sample(10*time.Millisecond, false)
+	if l.limit != 2 {
+		t.Fatalf("Expected a failed request to lower the limit to its minimum, got %d", l.limit)
+	}
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected a free slot, got %v", err)
+	}
+	defer release()
+
+	l.sample(10*time.Millisecond, true)
+	if l.limit != 3 {
+		t.Fatalf("Expected a fast request to raise the used limit, got %d", l.limit)
+	}
+}
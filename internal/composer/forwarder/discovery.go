@@ -0,0 +1,231 @@
+package forwarder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	// discovery periodically refreshes the targets of an upstream.
+	discovery struct {
+		interval   time.Duration
+		discoverer discoverer
+
+		// Refresh state, guarded by mu.
+		mu        sync.Mutex
+		refreshed time.Time
+		err       error
+	}
+	// discoverer resolves the current target addresses ("host:port") of a backend.
+	discoverer interface {
+		discover(ctx context.Context) ([]string, error)
+	}
+
+	dnsDiscoverer struct {
+		name     string
+		port     int
+		resolver *net.Resolver
+	}
+	// fileDiscoverer is only called from the discovery loop of its upstream, so it keeps the last
+	// read targets without locking.
+	fileDiscoverer struct {
+		path    string
+		read    bool
+		modTime time.Time
+		size    int64
+		addrs   []string
+	}
+)
+
+var (
+	_ discoverer = (*dnsDiscoverer)(nil)
+	_ discoverer = (*fileDiscoverer)(nil)
+
+	errInvalidTarget = errors.New("invalid target")
+	errNoTargets     = errors.New("no targets discovered")
+)
+
+func newDiscovery(cfg *config.Discovery) *discovery {
+	d := &discovery{interval: time.Duration(cfg.IntervalMs) * time.Millisecond}
+	if cfg.DNS != nil {
+		d.discoverer = &dnsDiscoverer{
+			name:     cfg.DNS.Name,
+			port:     cfg.DNS.Port,
+			resolver: net.DefaultResolver,
+		}
+	} else {
+		d.discoverer = &fileDiscoverer{path: cfg.File.Path}
+	}
+
+	return d
+}
+
+// state returns the time of the last successful refresh and the error of the last refresh, if it
+// failed.
+func (d *discovery) state() (time.Time, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.refreshed, d.err
+}
+
+// discoveryLoop refreshes the targets of the upstream every interval until ctx is done.
+func (u *upstream) discoveryLoop(ctx context.Context) {
+	ticker := time.NewTicker(u.discovery.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		u.refresh(ctx)
+	}
+}
+
+// refresh resolves the targets of the upstream once. When resolution fails or finds no targets the
+// previous targets are kept, since an upstream without targets cannot serve any request.
+func (u *upstream) refresh(ctx context.Context) {
+	d := u.discovery
+
+	refreshCtx, cancel := context.WithTimeout(ctx, d.interval)
+	defer cancel()
+
+	addrs, err := d.discoverer.discover(refreshCtx)
+	// Shutting down, the outcome says nothing about the targets.
+	if ctx.Err() != nil {
+		return
+	}
+	if err == nil && len(addrs) == 0 {
+		err = errNoTargets
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err != nil {
+		zerologr.Info(
+			"WARN: Failed to discover targets, keeping the previous ones",
+			"backend", u.backend,
+			"error", err.Error(),
+		)
+		d.err = err
+		return
+	}
+
+	d.err = nil
+	d.refreshed = time.Now()
+	u.replaceTargets(addrs)
+}
+
+// replaceTargets replaces the targets of the upstream with the input addresses. Targets that are
+// kept keep their health state.
+func (u *upstream) replaceTargets(addrs []string) {
+	addrs = slices.Compact(slices.Sorted(slices.Values(addrs)))
+
+	current := u.currentTargets()
+	if slices.EqualFunc(current, addrs, func(t *target, addr string) bool { return t.addr == addr }) {
+		return
+	}
+
+	existing := make(map[string]*target, len(current))
+	for _, t := range current {
+		existing[t.addr] = t
+	}
+
+	targets := make([]*target, 0, len(addrs))
+	for _, addr := range addrs {
+		t, ok := existing[addr]
+		if !ok {
+			t = newTarget(addr, u.healthCheck)
+		}
+		targets = append(targets, t)
+	}
+
+	zerologr.Info("Discovered targets", "backend", u.backend, "targets", addrs)
+	u.targets.Store(&targets)
+}
+
+func (d *dnsDiscoverer) discover(ctx context.Context) ([]string, error) {
+	if d.port != 0 {
+		hosts, err := d.resolver.LookupHost(ctx, d.name)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", d.name, err)
+		}
+
+		addrs := make([]string, 0, len(hosts))
+		for _, host := range hosts {
+			addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(d.port)))
+		}
+		return addrs, nil
+	}
+
+	_, records, err := d.resolver.LookupSRV(ctx, "", "", d.name)
+	if err != nil {
+		return nil, fmt.Errorf("resolving SRV records of %s: %w", d.name, err)
+	}
+
+	// The records are sorted by priority, only the most preferred ones are used as the others are
+	// fallbacks.
+	addrs := make([]string, 0, len(records))
+	for _, record := range records {
+		if record.Priority != records[0].Priority {
+			break
+		}
+		addrs = append(
+			addrs,
+			net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))),
+		)
+	}
+
+	return addrs, nil
+}
+
+func (f *fileDiscoverer) discover(_ context.Context) ([]string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("reading target file: %w", err)
+	}
+
+	if f.read && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.addrs, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("reading target file: %w", err)
+	}
+
+	var targets []*config.BackendTarget
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("parsing target file: %w", err)
+	}
+
+	addrs := make([]string, 0, len(targets))
+	for i, t := range targets {
+		if t == nil || t.Host == "" || t.Port < 1 || t.Port > 65535 {
+			return nil, fmt.Errorf("%w at index %d of the target file", errInvalidTarget, i)
+		}
+		addrs = append(addrs, net.JoinHostPort(t.Host, strconv.Itoa(t.Port)))
+	}
+
+	f.read = true
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	f.addrs = addrs
+	return addrs, nil
+}
@@ -0,0 +1,128 @@
+package forwarder
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/trebent/kerberos/internal/config"
+	utilhttp "github.com/trebent/kerberos/internal/util/http"
+)
+
+// proxyHeaders emits the standard proxy headers on forwarded requests. Incoming proxy headers are
+// only trusted when the request comes from a trusted proxy, otherwise they are replaced.
+type proxyHeaders struct {
+	cfg     *config.ProxyHeaders
+	trusted *utilhttp.TrustedProxies
+}
+
+// hopByHopHeaders are the headers meaningful only for a single connection, see RFC 7230 section
+// 6.1. Headers listed in the Connection header are hop-by-hop as well.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// removeHopByHopHeaders removes all hop-by-hop headers from the input header. A "TE: trailers"
+// request header is kept, since it signals that the client accepts trailers end-to-end.
+func removeHopByHopHeaders(header http.Header) {
+	for _, connection := range header.Values("Connection") {
+		for name := range strings.SplitSeq(connection, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+
+	teTrailers := slices.ContainsFunc(header.Values("Te"), func(value string) bool {
+		return strings.EqualFold(strings.TrimSpace(value), "trailers")
+	})
+
+	for _, name := range hopByHopHeaders {
+		header.Del(name)
+	}
+
+	if teTrailers {
+		header.Set("Te", "trailers")
+	}
+}
+
+func newProxyHeaders(cfg *config.ProxyHeaders) (*proxyHeaders, error) {
+	trusted, err := utilhttp.NewTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proxyHeaders{cfg: cfg, trusted: trusted}, nil
+}
+
+// apply sets the configured proxy headers of the outbound header, based on the inbound request.
+func (ph *proxyHeaders) apply(header http.Header, req *http.Request) {
+	remote := utilhttp.RemoteIP(req)
+	trusted := ph.trusted.IsTrusted(remote)
+	client := ph.trusted.ClientIP(header, remote)
+
+	proto := "http"
+	if req.TLS != nil {
+		proto = "https"
+	}
+
+	if ph.cfg.XForwarded {
+		forwardedFor := remote
+		if prior := strings.Join(header.Values("X-Forwarded-For"), ", "); trusted && prior != "" {
+			forwardedFor = prior + ", " + remote
+		}
+		header.Set("X-Forwarded-For", forwardedFor)
+
+		if !trusted || header.Get("X-Forwarded-Proto") == "" {
+			header.Set("X-Forwarded-Proto", proto)
+		}
+		if !trusted || header.Get("X-Forwarded-Host") == "" {
+			header.Set("X-Forwarded-Host", req.Host)
+		}
+	}
+
+	if ph.cfg.Forwarded {
+		element := fmt.Sprintf(
+			"for=%s;host=%s;proto=%s",
+			forwardedNode(remote),
+			quoteForwarded(req.Host),
+			proto,
+		)
+		if prior := strings.Join(header.Values("Forwarded"), ", "); trusted && prior != "" {
+			element = prior + ", " + element
+		}
+		header.Set("Forwarded", element)
+	}
+
+	if ph.cfg.XRealIP {
+		header.Set("X-Real-Ip", client)
+	}
+}
+
+// forwardedNode formats an IP address as a node of the Forwarded header, quoting IPv6 addresses.
+func forwardedNode(ip string) string {
+	if strings.Contains(ip, ":") {
+		return `"[` + ip + `]"`
+	}
+
+	return ip
+}
+
+// quoteForwarded quotes a Forwarded header value when it is not a valid token, e.g. when it holds
+// a port.
+func quoteForwarded(value string) string {
+	if strings.ContainsAny(value, `:[]"`) {
+		return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	}
+
+	return value
+}
@@ -0,0 +1,125 @@
+package forwarder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/trebent/kerberos/internal/config"
+)
+
+func TestRemoveHopByHopHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Connection", "keep-alive, X-Session")
+	header.Set("Keep-Alive", "timeout=5")
+	header.Set("Transfer-Encoding", "chunked")
+	header.Set("Te", "trailers")
+	header.Set("X-Session", "abc")
+	header.Set("X-Request-Id", "1")
+
+	removeHopByHopHeaders(header)
+
+	for _, name := range []string{"Connection", "Keep-Alive", "Transfer-Encoding", "X-Session"} {
+		if header.Get(name) != "" {
+			t.Errorf("Expected header %s to be removed", name)
+		}
+	}
+
+	if header.Get("Te") != "trailers" {
+		t.Errorf("Expected TE: trailers to be kept, got %q", header.Get("Te"))
+	}
+
+	if header.Get("X-Request-Id") != "1" {
+		t.Errorf("Expected end-to-end header to be kept")
+	}
+}
+
+func TestProxyHeaders(t *testing.T) {
+	ph, err := newProxyHeaders(&config.ProxyHeaders{
+		XForwarded:     true,
+		Forwarded:      true,
+		XRealIP:        true,
+		TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy headers: %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		remoteAddr        string
+		inbound           http.Header
+		expectedFor       string
+		expectedRealIP    string
+		expectedHost      string
+		expectedForwarded string
+	}{
+		{
+			name:       "untrusted peer replaces inbound headers",
+			remoteAddr: "203.0.113.7:1234",
+			inbound: http.Header{
+				"X-Forwarded-For":  {"1.2.3.4"},
+				"X-Forwarded-Host": {"spoofed.example.com"},
+				"Forwarded":        {"for=1.2.3.4"},
+			},
+			expectedFor:       "203.0.113.7",
+			expectedRealIP:    "203.0.113.7",
+			expectedHost:      "api.example.com",
+			expectedForwarded: "for=203.0.113.7;host=api.example.com;proto=http",
+		},
+		{
+			name:       "trusted peer extends inbound headers",
+			remoteAddr: "10.1.2.3:1234",
+			inbound: http.Header{
+				"X-Forwarded-For":  {"198.51.100.9, 192.0.2.1"},
+				"X-Forwarded-Host": {"public.example.com"},
+				"Forwarded":        {"for=198.51.100.9"},
+			},
+			expectedFor:       "198.51.100.9, 192.0.2.1, 10.1.2.3",
+			expectedRealIP:    "198.51.100.9",
+			expectedHost:      "public.example.com",
+			expectedForwarded: "for=198.51.100.9, for=10.1.2.3;host=api.example.com;proto=http",
+		},
+		{
+			name:              "IPv6 peer is quoted in Forwarded",
+			remoteAddr:        "[2001:db8::1]:1234",
+			inbound:           http.Header{},
+			expectedFor:       "2001:db8::1",
+			expectedRealIP:    "2001:db8::1",
+			expectedHost:      "api.example.com",
+			expectedForwarded: `for="[2001:db8::1]";host=api.example.com;proto=http`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://api.example.com/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			header := tt.inbound.Clone()
+
+			ph.apply(header, req)
+
+			if got := header.Get("X-Forwarded-For"); got != tt.expectedFor {
+				t.Errorf("Expected X-Forwarded-For %q, got %q", tt.expectedFor, got)
+			}
+			if got := header.Get("X-Real-Ip"); got != tt.expectedRealIP {
+				t.Errorf("Expected X-Real-IP %q, got %q", tt.expectedRealIP, got)
+			}
+			if got := header.Get("X-Forwarded-Host"); got != tt.expectedHost {
+				t.Errorf("Expected X-Forwarded-Host %q, got %q", tt.expectedHost, got)
+			}
+			if got := header.Get("X-Forwarded-Proto"); got != "http" {
+				t.Errorf("Expected X-Forwarded-Proto http, got %q", got)
+			}
+			if got := header.Get("Forwarded"); got != tt.expectedForwarded {
+				t.Errorf("Expected Forwarded %q, got %q", tt.expectedForwarded, got)
+			}
+		})
+	}
+}
+
+func TestProxyHeadersInvalidTrustedProxy(t *testing.T) {
+	if _, err := newProxyHeaders(&config.ProxyHeaders{TrustedProxies: []string{"not-an-ip"}}); err == nil {
+		t.Fatal("Expected an error for an invalid trusted proxy")
+	}
+}
@@ -0,0 +1,237 @@
+package forwarder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/trebent/zerologr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// healthyTargets returns the targets of the upstream that are currently eligible for requests.
+func (u *upstream) healthyTargets() []*target {
+	targets := u.currentTargets()
+	if u.healthCheck == nil {
+		return targets
+	}
+
+	healthy := make([]*target, 0, len(targets))
+	for _, t := range targets {
+		if u.healthy(t) {
+			healthy = append(healthy, t)
+		}
+	}
+
+	return healthy
+}
+
+// healthy reports whether the target is healthy, re-admitting it if its ejection has ended.
+func (u *upstream) healthy(t *target) bool {
+	return u.update(t, func() string {
+		if t.ejected && !time.Now().Before(t.ejectedUntil) {
+			t.ejected = false
+			return "ejection ended"
+		}
+
+		return ""
+	})
+}
+
+// observe records the outcome of a forwarded request for passive health checking, ejecting the
+// target when its failures cross the configured limits.
+func (u *upstream) observe(t *target, success bool) {
+	if u.healthCheck == nil || u.healthCheck.Passive == nil {
+		return
+	}
+
+	passive := u.healthCheck.Passive
+	u.update(t, func() string {
+		if t.ejected {
+			return ""
+		}
+
+		if success {
+			t.failureStreak = 0
+		} else {
+			t.failureStreak++
+		}
+
+		if passive.FailureRatio > 0 {
+			t.window.record(!success)
+		}
+
+		var reason string
+		switch {
+		case t.failureStreak >= passive.ConsecutiveFailures:
+			reason = "consecutive failures"
+		case passive.FailureRatio > 0 && t.window.full() && t.window.ratio() >= passive.FailureRatio:
+			reason = "failure ratio"
+		default:
+			return ""
+		}
+
+		t.ejected = true
+		t.ejectedUntil = time.Now().Add(time.Duration(passive.EjectionMs) * time.Millisecond)
+		t.failureStreak = 0
+		t.window.reset()
+		return reason
+	})
+}
+
+// probeLoop probes all targets of the upstream every interval until ctx is done.
+func (u *upstream) probeLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(u.healthCheck.Active.IntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		var wg sync.WaitGroup
+		for _, t := range u.currentTargets() {
+			wg.Go(func() { u.probe(ctx, t) })
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe sends a single active health check request to the target. The target changes health once
+// the configured number of consecutive probes disagree with its current state.
+func (u *upstream) probe(ctx context.Context, t *target) {
+	active := u.healthCheck.Active
+
+	probeCtx, cancel := context.WithTimeout(ctx, time.Duration(active.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	success := false
+	//nolint:gosec // ignoring SSRF warning since the target is taken from configuration.
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, u.scheme+"://"+t.addr+active.Path, nil)
+	if err == nil {
+		//nolint:gosec // ignoring SSRF warning since the target is taken from configuration.
+		resp, err := u.client.Do(req)
+		if err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			success = resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusBadRequest
+		}
+	}
+
+	// Shutting down, the probe outcome says nothing about the target.
+	if ctx.Err() != nil {
+		return
+	}
+
+	u.update(t, func() string {
+		if success == t.probeHealthy {
+			t.probeStreak = 0
+			return ""
+		}
+
+		t.probeStreak++
+		threshold := active.UnhealthyThreshold
+		if success {
+			threshold = active.HealthyThreshold
+		}
+		if t.probeStreak < threshold {
+			return ""
+		}
+
+		t.probeHealthy = success
+		t.probeStreak = 0
+		if success {
+			return "probe succeeded"
+		}
+		return "probe failed"
+	})
+}
+
+// update applies fn to the health state of the target and reports a change in health, using the
+// reason returned by fn. It returns the resulting health of the target.
+func (u *upstream) update(t *target, fn func() string) bool {
+	t.mu.Lock()
+	before := t.healthyLocked()
+	reason := fn()
+	after := t.healthyLocked()
+	t.mu.Unlock()
+
+	if before != after {
+		zerologr.Info(
+			"Target health changed",
+			"backend", u.backend,
+			"target", t.addr,
+			"healthy", after,
+			"reason", reason,
+		)
+		u.transitions.Add(
+			context.Background(),
+			1,
+			metric.WithAttributes(
+				attribute.String("krb.backend", u.backend),
+				attribute.String("krb.target", t.addr),
+				attribute.Bool("healthy", after),
+			),
+		)
+	}
+
+	return after
+}
+
+func (t *target) healthyLocked() bool {
+	return t.probeHealthy && !t.ejected
+}
+
+// outcomeWindow is a ring buffer of the most recent request outcomes of a target.
+type outcomeWindow struct {
+	failed   []bool
+	next     int
+	count    int
+	failures int
+}
+
+func newOutcomeWindow(size int) outcomeWindow {
+	return outcomeWindow{failed: make([]bool, size)}
+}
+
+func (w *outcomeWindow) record(failed bool) {
+	if len(w.failed) == 0 {
+		return
+	}
+
+	if w.count == len(w.failed) {
+		if w.failed[w.next] {
+			w.failures--
+		}
+	} else {
+		w.count++
+	}
+
+	w.failed[w.next] = failed
+	if failed {
+		w.failures++
+	}
+	w.next = (w.next + 1) % len(w.failed)
+}
+
+func (w *outcomeWindow) full() bool {
+	return len(w.failed) > 0 && w.count == len(w.failed)
+}
+
+func (w *outcomeWindow) ratio() float64 {
+	if w.count == 0 {
+		return 0
+	}
+
+	return float64(w.failures) / float64(w.count)
+}
+
+func (w *outcomeWindow) reset() {
+	clear(w.failed)
+	w.next, w.count, w.failures = 0, 0, 0
+}
@@ -0,0 +1,288 @@
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/trebent/kerberos/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// mirror sends copies of a share of the requests of a backend to its shadow backend. Shadow
+	// requests are sent in the background and their responses are discarded, once both have
+	// completed the shadow response is compared to the primary one.
+	mirror struct {
+		backend      string
+		scheme       string
+		addr         string
+		client       *http.Client
+		percentage   float64
+		timeout      time.Duration
+		maxBodyBytes int64
+		metrics      *mirrorMetrics
+		// inFlight holds a slot per shadow request in flight.
+		inFlight chan struct{}
+	}
+	// mirrorMetrics compare the responses of shadow backends to those of their primary backend.
+	mirrorMetrics struct {
+		responses metric.Int64Counter
+		duration  metric.Float64Histogram
+	}
+	// shadow is a mirrored request, waiting for the outcome of its primary request.
+	shadow struct {
+		primary chan outcome
+	}
+	// outcome is the status and the time to the response header of a request, the status is zero
+	// if the request failed.
+	outcome struct {
+		status   int
+		duration time.Duration
+	}
+	// bufferedBody replays the buffered start of a request body before the rest of it.
+	bufferedBody struct {
+		io.Reader
+		io.Closer
+	}
+)
+
+const (
+	mirrorCountName    = "upstream.mirror.count"
+	mirrorDurationName = "upstream.mirror.duration"
+
+	// mirrorHeader marks shadow requests, its value is the name of the primary backend.
+	mirrorHeader = "X-Krb-Mirror"
+
+	rolePrimary = "primary"
+	roleShadow  = "shadow"
+	// statusError is the status label of failed requests.
+	statusError = "error"
+)
+
+func newMirrorMetrics(meter metric.Meter) (*mirrorMetrics, error) {
+	responses, err := meter.Int64Counter(
+		mirrorCountName,
+		metric.WithDescription("Counts the mirrored requests by the status of both responses."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating mirror counter: %w", err)
+	}
+
+	duration, err := meter.Float64Histogram(
+		mirrorDurationName,
+		metric.WithUnit("ms"),
+		metric.WithDescription("Measures the time to the response header of mirrored requests."),
+		metric.WithExplicitBucketBoundaries(1, 10, 100, 1000, 10000),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating mirror duration histogram: %w", err)
+	}
+
+	return &mirrorMetrics{responses: responses, duration: duration}, nil
+}
+
+func newMirror(b *config.RouterBackend, metrics *mirrorMetrics) (*mirror, error) {
+	cfg := b.Mirror
+	t, err := newTransport(b.Name+" mirror", cfg.TLS, b.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "http"
+	if cfg.TLS != nil {
+		scheme = "https"
+	}
+
+	return &mirror{
+		backend:      b.Name,
+		scheme:       scheme,
+		addr:         net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		client:       &http.Client{Transport: t},
+		percentage:   cfg.Percentage,
+		timeout:      time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		maxBodyBytes: cfg.MaxBodyBytes,
+		metrics:      metrics,
+		inFlight:     make(chan struct{}, max(cfg.MaxInFlight, 1)),
+	}, nil
+}
+
+// startMirror mirrors the request if its backend has a mirror and the request is sampled,
+// returning the shadow request to report the outcome of the primary request to, or nil.
+func (f *forwarder) startMirror(req *http.Request) *shadow {
+	backend, ok := req.Context().Value(f.targetContextKey).(*config.RouterBackend)
+	if !ok {
+		return nil
+	}
+
+	u, ok := f.upstreams[backend.Name]
+	if !ok || u.mirror == nil || upgradeType(req.Header) != "" {
+		return nil
+	}
+
+	//nolint:gosec // not security sensitive
+	if rand.Float64()*100 >= u.mirror.percentage {
+		return nil
+	}
+
+	forwardURL, err := u.forwardURL(req.URL)
+	if err != nil {
+		return nil
+	}
+
+	return u.mirror.start(req, forwardURL, f.outboundHeader(req))
+}
+
+// start sends a copy of the request to the shadow backend in the background. Requests are not
+// mirrored while all in-flight slots are taken or if their body is larger than the limit.
+func (m *mirror) start(req *http.Request, forwardURL *url.URL, header http.Header) *shadow {
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		return nil
+	}
+
+	body, ok := bufferBody(req, m.maxBodyBytes)
+	if !ok {
+		<-m.inFlight
+		return nil
+	}
+
+	target := *forwardURL
+	target.Scheme = m.scheme
+	target.Host = m.addr
+	header.Set(mirrorHeader, m.backend)
+
+	// The shadow request outlives the primary one, but not its timeout.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), m.timeout)
+	//nolint:gosec // ignoring SSRF warning since the target is the configured shadow backend.
+	shadowRequest, err := http.NewRequestWithContext(
+		ctx,
+		req.Method,
+		target.String(),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		cancel()
+		<-m.inFlight
+		return nil
+	}
+	shadowRequest.Header = header
+
+	s := &shadow{primary: make(chan outcome, 1)}
+	go func() {
+		defer func() { <-m.inFlight }()
+		defer cancel()
+
+		m.send(shadowRequest, s.primary)
+	}()
+
+	return s
+}
+
+// send sends the shadow request, then records its outcome next to that of the primary request.
+func (m *mirror) send(req *http.Request, primary <-chan outcome) {
+	logger, _ := logr.FromContext(req.Context())
+	logger = logger.WithName("forwarder")
+
+	ctx, span := tracer.Start(
+		req.Context(),
+		"forwarder.mirror",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("krb.target", m.addr)),
+	)
+	defer span.End()
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	var result outcome
+	sent := time.Now()
+	resp, err := m.client.Do(req)
+	if err != nil {
+		logger.V(20).Info("Failed to mirror request", "error", err.Error())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		result = outcome{status: resp.StatusCode, duration: time.Since(sent)}
+		span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	m.record(ctx, <-primary, result)
+}
+
+// record compares the outcomes of the primary and the shadow request.
+func (m *mirror) record(ctx context.Context, primary, shadow outcome) {
+	backend := attribute.String("krb.backend", m.backend)
+	m.metrics.responses.Add(ctx, 1, metric.WithAttributes(
+		backend,
+		attribute.String("krb.primary_status", statusLabel(primary.status)),
+		attribute.String("krb.shadow_status", statusLabel(shadow.status)),
+		attribute.Bool("krb.match", primary.status != 0 && primary.status == shadow.status),
+	))
+
+	for role, o := range map[string]outcome{rolePrimary: primary, roleShadow: shadow} {
+		if o.status == 0 {
+			continue
+		}
+		m.metrics.duration.Record(
+			ctx,
+			float64(o.duration)/float64(time.Millisecond),
+			metric.WithAttributes(backend, attribute.String("krb.role", role)),
+		)
+	}
+}
+
+// complete reports the outcome of the primary request, resp is nil if it failed. A nil shadow
+// is ignored.
+func (s *shadow) complete(resp *http.Response, duration time.Duration) {
+	if s == nil {
+		return
+	}
+
+	result := outcome{duration: duration}
+	if resp != nil {
+		result.status = resp.StatusCode
+	}
+	s.primary <- result
+}
+
+// bufferBody buffers a request body of at most limit bytes for mirroring, the request keeps its
+// body as if it had not been read. ok is false if the body is larger or fails to be read.
+func bufferBody(req *http.Request, limit int64) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+
+	buffered, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	// After a read error the rest of the body returns it again, for the primary request to fail
+	// with.
+	req.Body = &bufferedBody{
+		Reader: io.MultiReader(bytes.NewReader(buffered), req.Body),
+		Closer: req.Body,
+	}
+
+	return buffered, err == nil && int64(len(buffered)) <= limit
+}
+
+func statusLabel(status int) string {
+	if status == 0 {
+		return statusError
+	}
+	return strconv.Itoa(status)
+}
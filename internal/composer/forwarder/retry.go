@@ -0,0 +1,129 @@
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+)
+
+// idempotentMethods are the methods retried by default, see RFC 9110 section 9.2.2.
+var idempotentMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodTrace,
+	http.MethodPut,
+	http.MethodDelete,
+}
+
+// maxAttempts returns the number of attempts allowed for the request.
+func maxAttempts(policy *config.RetryPolicy, req *http.Request) int {
+	if policy == nil {
+		return 1
+	}
+
+	if !policy.NonIdempotent && !slices.Contains(idempotentMethods, req.Method) {
+		return 1
+	}
+
+	return policy.MaxAttempts
+}
+
+// retryable reports whether the outcome of an attempt matches one of the retry conditions of the
+// policy, in which case the cause is returned.
+func retryable(
+	ctx context.Context,
+	policy *config.RetryPolicy,
+	resp *http.Response,
+	err error,
+) (string, bool) {
+	// The client is gone, there is no one to retry for.
+	if ctx.Err() != nil {
+		return "", false
+	}
+
+	if err != nil {
+		condition := config.RetryOnConnectError
+		if errors.Is(err, context.DeadlineExceeded) {
+			condition = config.RetryOnTimeout
+		}
+
+		return err.Error(), slices.Contains(policy.RetryOn, condition)
+	}
+
+	return "upstream responded " + strconv.Itoa(resp.StatusCode),
+		slices.Contains(policy.RetryOn, strconv.Itoa(resp.StatusCode))
+}
+
+// backoff returns the jittered wait before the attempt following the input attempt.
+func backoff(policy *config.RetryPolicy, attempt int) time.Duration {
+	wait := time.Duration(policy.BackoffMs) * time.Millisecond << (attempt - 1)
+	if maxWait := time.Duration(policy.MaxBackoffMs) * time.Millisecond; wait > maxWait || wait <= 0 {
+		wait = maxWait
+	}
+
+	return rand.N(wait + 1) //nolint:gosec // not security sensitive
+}
+
+// sleep waits for d, returning early with the context error if ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// replayableBody buffers a request body of at most limit bytes so that it can be sent once per
+// attempt. Larger bodies are returned as a single-use body, and ok is false.
+func replayableBody(body io.ReadCloser, limit int64) (func() io.Reader, bool, error) {
+	if body == nil || body == http.NoBody {
+		return func() io.Reader { return nil }, true, nil
+	}
+
+	buffered, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if int64(len(buffered)) > limit {
+		rest := io.MultiReader(bytes.NewReader(buffered), body)
+		return func() io.Reader { return rest }, false, nil
+	}
+
+	return func() io.Reader { return bytes.NewReader(buffered) }, true, nil
+}
+
+// attemptBody releases the resources of the attempt that produced a response once its body is
+// closed.
+type attemptBody struct {
+	io.ReadCloser
+
+	done func()
+	once sync.Once
+}
+
+// upgradedBody is the attemptBody of an upgraded connection, which is written to as well.
+type upgradedBody struct {
+	*attemptBody
+	io.Writer
+}
+
+func (b *attemptBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
@@ -0,0 +1,32 @@
+package forwarder
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReplayableBody(t *testing.T) {
+	body, ok, err := replayableBody(io.NopCloser(strings.NewReader("small")), 10)
+	if err != nil || !ok {
+		t.Fatalf("Expected small body to be replayable, got %t, %v", ok, err)
+	}
+
+	for range 2 {
+		data, _ := io.ReadAll(body())
+		if string(data) != "small" {
+			t.Fatalf("Expected body %q, got %q", "small", data)
+		}
+	}
+
+	large := bytes.Repeat([]byte("a"), 20)
+	body, ok, err = replayableBody(io.NopCloser(bytes.NewReader(large)), 10)
+	if err != nil || ok {
+		t.Fatalf("Expected large body not to be replayable, got %t, %v", ok, err)
+	}
+
+	if data, _ := io.ReadAll(body()); !bytes.Equal(data, large) {
+		t.Fatalf("Expected the full large body, got %d bytes", len(data))
+	}
+}
@@ -0,0 +1,105 @@
+package forwarder
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/trebent/kerberos/internal/config"
+)
+
+type (
+	// rewriter rewrites the URL of requests forwarded to a backend.
+	rewriter struct {
+		cfg          *config.Rewrite
+		replacements []*replacement
+	}
+	replacement struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}
+)
+
+func newRewriter(cfg *config.Rewrite) (*rewriter, error) {
+	rw := &rewriter{cfg: cfg}
+	for _, r := range cfg.Replace {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compiling rewrite pattern %q: %w", r.Pattern, err)
+		}
+
+		rw.replacements = append(rw.replacements, &replacement{
+			pattern:     pattern,
+			replacement: r.Replacement,
+		})
+	}
+
+	return rw, nil
+}
+
+// rewrite applies the rewrite rules to the path and query of the input URL. The rules operate on
+// the escaped path so that encoded segments, such as %2F, are forwarded as received.
+func (rw *rewriter) rewrite(u *url.URL) (string, string, error) {
+	escapedPath := u.EscapedPath()
+
+	if prefix := strings.TrimSuffix(rw.cfg.StripPrefix, "/"); prefix != "" {
+		if rest, ok := strings.CutPrefix(escapedPath, prefix); ok && (rest == "" || rest[0] == '/') {
+			escapedPath = rest
+		}
+	}
+
+	if rw.cfg.AddPrefix != "" {
+		escapedPath = strings.TrimSuffix(rw.cfg.AddPrefix, "/") + escapedPath
+	}
+
+	for _, r := range rw.replacements {
+		escapedPath = r.pattern.ReplaceAllString(escapedPath, r.replacement)
+	}
+
+	if !strings.HasPrefix(escapedPath, "/") {
+		escapedPath = "/" + escapedPath
+	}
+
+	rawQuery := u.RawQuery
+	if q := rw.cfg.Query; q != nil {
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			return "", "", fmt.Errorf("parsing query: %w", err)
+		}
+
+		for _, key := range q.Remove {
+			query.Del(key)
+		}
+		for key, value := range q.Add {
+			query.Set(key, value)
+		}
+		rawQuery = query.Encode()
+	}
+
+	return escapedPath, rawQuery, nil
+}
+
+// forwardURL returns the URL of the request on the upstream, lacking only the target host. The
+// path is forwarded with its original encoding and the query as received, unless rewritten.
+func (u *upstream) forwardURL(reqURL *url.URL) (*url.URL, error) {
+	escapedPath, rawQuery := reqURL.EscapedPath(), reqURL.RawQuery
+	if u.rewriter != nil {
+		var err error
+		if escapedPath, rawQuery, err = u.rewriter.rewrite(reqURL); err != nil {
+			return nil, err
+		}
+	}
+
+	path, err := url.PathUnescape(escapedPath)
+	if err != nil {
+		return nil, fmt.Errorf("unescaping path %q: %w", escapedPath, err)
+	}
+
+	return &url.URL{
+		Scheme:   u.scheme,
+		Path:     path,
+		RawPath:  escapedPath,
+		RawQuery: rawQuery,
+	}, nil
+}
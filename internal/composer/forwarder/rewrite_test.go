@@ -0,0 +1,90 @@
+package forwarder
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/trebent/kerberos/internal/config"
+)
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           *config.Rewrite
+		url           string
+		expectedPath  string
+		expectedQuery string
+	}{
+		{
+			name:          "strip and add prefix",
+			cfg:           &config.Rewrite{StripPrefix: "/api", AddPrefix: "/v2"},
+			url:           "/api/orders?id=1",
+			expectedPath:  "/v2/orders",
+			expectedQuery: "id=1",
+		},
+		{
+			name:          "strip prefix on segment boundary only",
+			cfg:           &config.Rewrite{StripPrefix: "/api"},
+			url:           "/apiary",
+			expectedPath:  "/apiary",
+			expectedQuery: "",
+		},
+		{
+			name:          "strip prefix to root",
+			cfg:           &config.Rewrite{StripPrefix: "/api/"},
+			url:           "/api",
+			expectedPath:  "/",
+			expectedQuery: "",
+		},
+		{
+			name: "replace with capture groups keeps encoded segments",
+			cfg: &config.Rewrite{Replace: []*config.RewriteReplace{
+				{Pattern: `^/users/([^/]+)/files/(.*)$`, Replacement: "/files/$2/owner/$1"},
+			}},
+			url:           "/users/ann/files/a%2Fb",
+			expectedPath:  "/files/a%2Fb/owner/ann",
+			expectedQuery: "",
+		},
+		{
+			name: "query add and remove",
+			cfg: &config.Rewrite{Query: &config.RewriteQuery{
+				Add:    map[string]string{"version": "2", "id": "3"},
+				Remove: []string{"debug"},
+			}},
+			url:           "/orders?id=1&debug=true",
+			expectedPath:  "/orders",
+			expectedQuery: "id=3&version=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw, err := newRewriter(tt.cfg)
+			if err != nil {
+				t.Fatalf("Failed to create rewriter: %v", err)
+			}
+
+			u, _ := url.Parse(tt.url)
+			path, query, err := rw.rewrite(u)
+			if err != nil {
+				t.Fatalf("Failed to rewrite: %v", err)
+			}
+
+			if path != tt.expectedPath {
+				t.Errorf("Expected path %s, got %s", tt.expectedPath, path)
+			}
+
+			if query != tt.expectedQuery {
+				t.Errorf("Expected query %s, got %s", tt.expectedQuery, query)
+			}
+		})
+	}
+}
+
+func TestRewriteInvalidPattern(t *testing.T) {
+	if _, err := newRewriter(&config.Rewrite{Replace: []*config.RewriteReplace{
+		{Pattern: "(", Replacement: ""},
+	}}); err == nil {
+		t.Fatal("Expected an error for an invalid pattern")
+	}
+}
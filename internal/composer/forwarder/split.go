@@ -0,0 +1,234 @@
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	"github.com/trebent/zerologr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// split routes the requests of a backend to its variants. Splits are immutable, adjusting the
+	// split of a backend replaces it.
+	split struct {
+		cfg      *config.TrafficSplit
+		variants []*variant
+	}
+	// variant is an alternate backend of a split.
+	variant struct {
+		cfg    *config.SplitVariant
+		target *config.RouterBackend
+	}
+)
+
+var errInvalidSplit = errors.New("invalid traffic split")
+
+// newSplit validates the split against the configured backends.
+func newSplit(cfg *config.TrafficSplit, backends map[string]*config.RouterBackend) (*split, error) {
+	if _, ok := backends[cfg.Backend]; !ok {
+		return nil, fmt.Errorf("%w: unknown backend %s", errInvalidSplit, cfg.Backend)
+	}
+	if len(cfg.Variants) == 0 {
+		return nil, fmt.Errorf("%w: backend %s has no variants", errInvalidSplit, cfg.Backend)
+	}
+
+	s := &split{cfg: cfg, variants: make([]*variant, 0, len(cfg.Variants))}
+	total := 0.0
+	for _, v := range cfg.Variants {
+		target, ok := backends[v.Backend]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%w: unknown variant backend %s", errInvalidSplit, v.Backend)
+		case v.Backend == cfg.Backend:
+			return nil, fmt.Errorf(
+				"%w: backend %s is a variant of itself", errInvalidSplit, cfg.Backend,
+			)
+		case v.Weight < 0 || v.Weight > 100:
+			return nil, fmt.Errorf(
+				"%w: weight of variant %s is not a percentage", errInvalidSplit, v.Backend,
+			)
+		case v.Weight == 0 && len(v.Headers) == 0 && len(v.Cookies) == 0:
+			return nil, fmt.Errorf(
+				"%w: variant %s has neither a weight nor headers or cookies",
+				errInvalidSplit,
+				v.Backend,
+			)
+		}
+
+		total += v.Weight
+		s.variants = append(s.variants, &variant{cfg: v, target: target})
+	}
+	if total > 100 {
+		return nil, fmt.Errorf(
+			"%w: weights of backend %s exceed 100 percent", errInvalidSplit, cfg.Backend,
+		)
+	}
+
+	return s, nil
+}
+
+// pick returns the variant the request is routed to, or nil if it stays on the backend. The first
+// variant whose headers and cookies match wins, otherwise a variant is picked by weight.
+func (s *split) pick(req *http.Request) *variant {
+	for _, v := range s.variants {
+		if v.matches(req) {
+			return v
+		}
+	}
+
+	//nolint:gosec // not security sensitive
+	n := rand.Float64() * 100
+	for _, v := range s.variants {
+		if n < v.cfg.Weight {
+			return v
+		}
+		n -= v.cfg.Weight
+	}
+
+	return nil
+}
+
+// matches reports whether the request has all headers and cookies of the variant. Variants
+// without any only receive their weighted share.
+func (v *variant) matches(req *http.Request) bool {
+	if len(v.cfg.Headers) == 0 && len(v.cfg.Cookies) == 0 {
+		return false
+	}
+
+	for name, value := range v.cfg.Headers {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	for name, value := range v.cfg.Cookies {
+		if c, err := req.Cookie(name); err != nil || c.Value != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// route routes the request to a variant if its backend is split, replacing its target. It returns
+// the name of the backend the request is routed to, or an empty string if it is not split.
+func (f *forwarder) route(req *http.Request) (*http.Request, string) {
+	backend, ok := req.Context().Value(f.targetContextKey).(*config.RouterBackend)
+	if !ok {
+		return req, ""
+	}
+
+	s, ok := (*f.splits.Load())[backend.Name]
+	if !ok {
+		return req, ""
+	}
+
+	v := s.pick(req)
+	if v == nil {
+		trace.SpanFromContext(req.Context()).SetAttributes(
+			attribute.String("krb.variant", backend.Name),
+		)
+		return req, backend.Name
+	}
+
+	trace.SpanFromContext(req.Context()).SetAttributes(
+		attribute.String("krb.variant", v.target.Name),
+	)
+	return req.WithContext(
+		context.WithValue(req.Context(), f.targetContextKey, v.target),
+	), v.target.Name
+}
+
+// Splits implements [adminext.TrafficSplitter].
+func (f *forwarder) Splits() []adminapi.TrafficSplit {
+	splits := *f.splits.Load()
+	result := make([]adminapi.TrafficSplit, 0, len(splits))
+	for _, backend := range slices.Sorted(maps.Keys(splits)) {
+		s := splits[backend]
+		variants := make([]adminapi.TrafficSplitVariant, 0, len(s.variants))
+		for _, v := range s.variants {
+			variant := adminapi.TrafficSplitVariant{Backend: v.cfg.Backend, Weight: v.cfg.Weight}
+			if len(v.cfg.Headers) > 0 {
+				variant.Headers = &v.cfg.Headers
+			}
+			if len(v.cfg.Cookies) > 0 {
+				variant.Cookies = &v.cfg.Cookies
+			}
+			variants = append(variants, variant)
+		}
+		result = append(result, adminapi.TrafficSplit{Backend: backend, Variants: variants})
+	}
+
+	return result
+}
+
+// SetSplit implements [adminext.TrafficSplitter].
+func (f *forwarder) SetSplit(ts adminapi.TrafficSplit) error {
+	if _, ok := f.backends[ts.Backend]; !ok {
+		return apierror.ErrNotFound
+	}
+
+	cfg := &config.TrafficSplit{
+		Backend:  ts.Backend,
+		Variants: make([]*config.SplitVariant, 0, len(ts.Variants)),
+	}
+	for _, v := range ts.Variants {
+		variant := &config.SplitVariant{Backend: v.Backend, Weight: v.Weight}
+		if v.Headers != nil {
+			variant.Headers = *v.Headers
+		}
+		if v.Cookies != nil {
+			variant.Cookies = *v.Cookies
+		}
+		cfg.Variants = append(cfg.Variants, variant)
+	}
+
+	s, err := newSplit(cfg, f.backends)
+	if err != nil {
+		return apierror.New(http.StatusBadRequest, err.Error())
+	}
+
+	f.updateSplits(func(splits map[string]*split) bool {
+		splits[ts.Backend] = s
+		return true
+	})
+	zerologr.Info("Updated traffic split", "backend", ts.Backend)
+	return nil
+}
+
+// DeleteSplit implements [adminext.TrafficSplitter].
+func (f *forwarder) DeleteSplit(backend string) error {
+	if !f.updateSplits(func(splits map[string]*split) bool {
+		_, ok := splits[backend]
+		delete(splits, backend)
+		return ok
+	}) {
+		return apierror.ErrNotFound
+	}
+
+	zerologr.Info("Removed traffic split", "backend", backend)
+	return nil
+}
+
+// updateSplits applies update to a copy of the current splits, which replaces them if update
+// reports a change. Requests in flight keep the splits they loaded.
+func (f *forwarder) updateSplits(update func(map[string]*split) bool) bool {
+	f.splitsMu.Lock()
+	defer f.splitsMu.Unlock()
+
+	splits := maps.Clone(*f.splits.Load())
+	if !update(splits) {
+		return false
+	}
+	f.splits.Store(&splits)
+	return true
+}
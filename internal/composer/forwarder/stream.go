@@ -0,0 +1,177 @@
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+)
+
+type (
+	// deadline cancels an attempt once its timeout passes. Unlike a context deadline, it can be
+	// lifted once the response turns out to be a stream or an upgraded connection.
+	deadline struct {
+		timer *time.Timer
+	}
+	// streamBody cancels its attempt when no data has been read for the idle timeout.
+	streamBody struct {
+		io.ReadCloser
+
+		idle  time.Duration
+		timer *time.Timer
+	}
+)
+
+const streamBufferSize = 32 * 1024
+
+var (
+	errBackendTimeout = fmt.Errorf("backend timeout: %w", context.DeadlineExceeded)
+	errStreamIdle     = fmt.Errorf("stream idle timeout: %w", context.DeadlineExceeded)
+)
+
+// newDeadline cancels the attempt after the shortest of the input timeouts, zero timeouts are
+// ignored.
+func newDeadline(cancel context.CancelCauseFunc, timeouts ...time.Duration) *deadline {
+	var shortest time.Duration
+	for _, timeout := range timeouts {
+		if timeout > 0 && (shortest == 0 || timeout < shortest) {
+			shortest = timeout
+		}
+	}
+
+	if shortest == 0 {
+		return &deadline{}
+	}
+
+	return &deadline{timer: time.AfterFunc(shortest, func() { cancel(errBackendTimeout) })}
+}
+
+func (d *deadline) stop() {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
+
+// isStream reports whether the response is forwarded as a stream. gRPC responses are always
+// streams, the status of a call is only known from the trailers that follow the messages.
+func (u *upstream) isStream(resp *http.Response) bool {
+	if u.protocol == config.ProtocolGRPC || (u.streaming != nil && u.streaming.Enabled) {
+		return true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return strings.EqualFold(mediaType, "text/event-stream") ||
+		strings.HasPrefix(strings.ToLower(mediaType), "application/grpc")
+}
+
+// idleTimeout returns the idle timeout of streams, zero if streams may idle forever.
+func (u *upstream) idleTimeout() time.Duration {
+	if u.streaming == nil {
+		return 0
+	}
+
+	return time.Duration(u.streaming.IdleTimeoutMs) * time.Millisecond
+}
+
+// newStreamBody wraps the body of a streamed response, cancelling the attempt when it idles.
+func newStreamBody(body io.ReadCloser, idle time.Duration, cancel context.CancelCauseFunc) io.ReadCloser {
+	if idle <= 0 {
+		return body
+	}
+
+	return &streamBody{
+		ReadCloser: body,
+		idle:       idle,
+		timer:      time.AfterFunc(idle, func() { cancel(errStreamIdle) }),
+	}
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.timer.Reset(b.idle)
+	}
+	return n, err
+}
+
+func (b *streamBody) Close() error {
+	b.timer.Stop()
+	return b.ReadCloser.Close()
+}
+
+// copyStream copies a streamed response body, flushing after every write. The write deadline of
+// the client connection is extended by the idle timeout before every write, so that the server
+// write timeout does not cut off the stream.
+func copyStream(wrapped http.ResponseWriter, body io.Reader, idle time.Duration) error {
+	rc := http.NewResponseController(wrapped)
+	extendDeadline := func() error {
+		var writeDeadline time.Time
+		if idle > 0 {
+			writeDeadline = time.Now().Add(idle)
+		}
+		if err := rc.SetWriteDeadline(writeDeadline); err != nil &&
+			!errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	if err := extendDeadline(); err != nil {
+		return err
+	}
+	// Send the header right away, clients wait for it before the first event.
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	buf := make([]byte, streamBufferSize)
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			if err := extendDeadline(); err != nil {
+				return err
+			}
+			if _, err := wrapped.Write(buf[:n]); err != nil {
+				return err
+			}
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// announceTrailers declares the trailers of the backend response before the header is written.
+func announceTrailers(wrapped http.ResponseWriter, resp *http.Response) {
+	for name := range resp.Trailer {
+		wrapped.Header().Add("Trailer", name)
+	}
+}
+
+// copyTrailers copies the trailers of the backend response, available once its body is read.
+// Trailers that were not announced up front are sent with the http.TrailerPrefix.
+func copyTrailers(wrapped http.ResponseWriter, resp *http.Response, announced int) {
+	prefix := ""
+	if len(resp.Trailer) != announced {
+		prefix = http.TrailerPrefix
+	}
+
+	for name, values := range resp.Trailer {
+		for _, value := range values {
+			wrapped.Header().Add(prefix+name, value)
+		}
+	}
+}
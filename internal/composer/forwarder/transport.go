@@ -15,11 +15,12 @@ import (
 // backend. It is called once per backend at component construction so that the underlying
 // connection pool and TLS session cache are shared across all requests to that backend.
 //
-// When tlsCfg is nil the returned transport uses Go's defaults (plain HTTP).
-func newTransport(name string, tlsCfg *config.BackendTLS) (*http.Transport, error) {
-	zerologr.Info("Loading backend transport", "backend", name)
+// When tlsCfg is nil the returned transport uses Go's defaults (plain HTTP). The protocol, one of
+// the config.Protocol* constants, restricts the transport to the matching HTTP version.
+func newTransport(name string, tlsCfg *config.BackendTLS, protocol string) (*http.Transport, error) {
+	zerologr.Info("Loading backend transport", "backend", name, "protocol", protocol)
 	if tlsCfg == nil {
-		return &http.Transport{}, nil
+		return withProtocol(&http.Transport{}, protocol, false), nil
 	}
 
 	tc := &tls.Config{
@@ -58,5 +59,28 @@ func newTransport(name string, tlsCfg *config.BackendTLS) (*http.Transport, erro
 		tc.Certificates = []tls.Certificate{cert}
 	}
 
-	return &http.Transport{TLSClientConfig: tc}, nil
+	return withProtocol(&http.Transport{TLSClientConfig: tc}, protocol, true), nil
+}
+
+// withProtocol restricts the transport to the HTTP version of the protocol. HTTP/1.1 transports
+// are left as is.
+func withProtocol(t *http.Transport, protocol string, tls bool) *http.Transport {
+	protocols := &http.Protocols{}
+	switch protocol {
+	case config.ProtocolH2:
+		protocols.SetHTTP2(true)
+	case config.ProtocolH2C:
+		protocols.SetUnencryptedHTTP2(true)
+	case config.ProtocolGRPC:
+		if tls {
+			protocols.SetHTTP2(true)
+		} else {
+			protocols.SetUnencryptedHTTP2(true)
+		}
+	default:
+		return t
+	}
+
+	t.Protocols = protocols
+	return t
 }
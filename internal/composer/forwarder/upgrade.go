@@ -0,0 +1,177 @@
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type (
+	// upgradeMetrics count the connections tunnelled after a protocol upgrade.
+	upgradeMetrics struct {
+		active metric.Int64UpDownCounter
+		bytes  metric.Int64Counter
+	}
+	// countingWriter records the bytes written through it on the upgrade byte counter.
+	countingWriter struct {
+		io.Writer
+
+		ctx     context.Context
+		counter metric.Int64Counter
+		attrs   metric.MeasurementOption
+	}
+)
+
+const (
+	upgradeActiveName = "upstream.upgrade.active"
+	upgradeBytesName  = "upstream.upgrade.bytes"
+
+	// directionInbound is the client to backend direction of a tunnel, directionOutbound the
+	// backend to client direction.
+	directionInbound  = "inbound"
+	directionOutbound = "outbound"
+)
+
+var errUpgradeMismatch = errors.New("backend switched to a protocol other than requested")
+
+func newUpgradeMetrics(meter metric.Meter) (*upgradeMetrics, error) {
+	active, err := meter.Int64UpDownCounter(
+		upgradeActiveName,
+		metric.WithDescription("Counts the currently tunnelled upgraded connections."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating upgrade connection counter: %w", err)
+	}
+
+	bytes, err := meter.Int64Counter(
+		upgradeBytesName,
+		metric.WithDescription("Counts the bytes transferred over upgraded connections."),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating upgrade byte counter: %w", err)
+	}
+
+	return &upgradeMetrics{active: active, bytes: bytes}, nil
+}
+
+// upgradeType returns the protocol a request asks to upgrade to, or an empty string if the request
+// is not an upgrade request.
+func upgradeType(header http.Header) string {
+	for _, connection := range header.Values("Connection") {
+		for token := range strings.SplitSeq(connection, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return header.Get("Upgrade")
+			}
+		}
+	}
+
+	return ""
+}
+
+// handleUpgrade completes a protocol upgrade accepted by the backend, then tunnels bytes in both
+// directions until either side closes the connection or the request is cancelled.
+func (f *forwarder) handleUpgrade(
+	req *http.Request,
+	resp *http.Response,
+	wrapped http.ResponseWriter,
+	backend string,
+) error {
+	requested := upgradeType(req.Header)
+	accepted := upgradeType(resp.Header)
+	if !strings.EqualFold(requested, accepted) {
+		return fmt.Errorf("%w: requested %q, got %q", errUpgradeMismatch, requested, accepted)
+	}
+
+	backConn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		return fmt.Errorf("%w: backend connection is not writable", errFailedForwarding)
+	}
+
+	removeHopByHopHeaders(resp.Header)
+	for key, values := range resp.Header {
+		for _, value := range values {
+			wrapped.Header().Add(key, value)
+		}
+	}
+	wrapped.Header().Set("Connection", "Upgrade")
+	wrapped.Header().Set("Upgrade", accepted)
+
+	conn, brw, err := http.NewResponseController(wrapped).Hijack()
+	if err != nil {
+		return fmt.Errorf("hijacking client connection: %w", err)
+	}
+	defer conn.Close()
+
+	// The server may have set deadlines for the request, they do not apply to the tunnel.
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return fmt.Errorf("clearing client connection deadline: %w", err)
+	}
+
+	switched := &http.Response{
+		StatusCode: resp.StatusCode,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     wrapped.Header(),
+	}
+	if err := switched.Write(brw); err != nil {
+		return fmt.Errorf("writing switching protocols response: %w", err)
+	}
+	if err := brw.Flush(); err != nil {
+		return fmt.Errorf("flushing switching protocols response: %w", err)
+	}
+
+	backendAttr := attribute.String("krb.backend", backend)
+	f.upgrades.active.Add(req.Context(), 1, metric.WithAttributes(backendAttr))
+	defer f.upgrades.active.Add(req.Context(), -1, metric.WithAttributes(backendAttr))
+
+	tunnel := func(dst io.Writer, src io.Reader, direction string) <-chan error {
+		errc := make(chan error, 1)
+		go func() {
+			_, err := io.Copy(&countingWriter{
+				Writer:  dst,
+				ctx:     req.Context(),
+				counter: f.upgrades.bytes,
+				attrs: metric.WithAttributes(
+					backendAttr,
+					attribute.String("krb.direction", direction),
+				),
+			}, src)
+			errc <- err
+		}()
+		return errc
+	}
+
+	// The buffered reader of the hijacked connection may hold client bytes read ahead already.
+	toBackend := tunnel(backConn, brw.Reader, directionInbound)
+	toClient := tunnel(conn, backConn, directionOutbound)
+
+	select {
+	case err = <-toBackend:
+	case err = <-toClient:
+	case <-req.Context().Done():
+	}
+
+	// Closing both ends stops the remaining copy.
+	_ = backConn.Close()
+	_ = conn.Close()
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("tunnelling upgraded connection: %w", err)
+	}
+
+	return nil
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.counter.Add(w.ctx, int64(n), w.attrs)
+	return n, err
+}
@@ -0,0 +1,110 @@
+package forwarder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/trebent/kerberos/internal/config"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// upstream holds the client, the targets and the resilience state of a single backend.
+type upstream struct {
+	backend   string
+	scheme    string
+	client    *http.Client
+	timeout   time.Duration
+	streaming *config.Streaming
+	protocol  string
+	// targets is replaced as a whole when the targets of the backend are discovered.
+	targets     atomic.Pointer[[]*target]
+	discovery   *discovery
+	strategy    strategy
+	healthCheck *config.HealthCheck
+	transitions metric.Int64Counter
+	breaker     *breaker
+	limiter     *limiter
+	retry       *config.RetryPolicy
+	rewriter    *rewriter
+	// mirror is nil unless the backend has a shadow backend.
+	mirror *mirror
+}
+
+func newUpstream(
+	b *config.RouterBackend,
+	transitions metric.Int64Counter,
+	shedCounter metric.Int64Counter,
+	mirrorMetrics *mirrorMetrics,
+) (*upstream, error) {
+	t, err := newTransport(b.Name, b.TLS, b.Protocol)
+	if err != nil {
+		return nil, fmt.Errorf("building transport for backend %q: %w", b.Name, err)
+	}
+
+	scheme := "http"
+	if b.TLS != nil {
+		scheme = "https"
+	}
+
+	u := &upstream{
+		backend: b.Name,
+		scheme:  scheme,
+		// The timeout is enforced per attempt by a deadline, which is lifted for streams.
+		client:      &http.Client{Transport: t},
+		timeout:     time.Duration(b.TimeoutMs) * time.Millisecond,
+		streaming:   b.Streaming,
+		protocol:    b.Protocol,
+		strategy:    newStrategy(b.LoadBalancing),
+		healthCheck: b.HealthCheck,
+		transitions: transitions,
+		retry:       b.Retry,
+	}
+
+	targets := newTargets(b)
+	u.targets.Store(&targets)
+
+	if b.Discovery != nil {
+		u.discovery = newDiscovery(b.Discovery)
+	}
+
+	if b.CircuitBreaker != nil {
+		u.breaker = newBreaker(b.Name, b.CircuitBreaker)
+	}
+
+	if b.Concurrency != nil {
+		u.limiter = newLimiter(b.Name, b.Concurrency, shedCounter)
+	}
+
+	if b.Rewrite != nil {
+		u.rewriter, err = newRewriter(b.Rewrite)
+		if err != nil {
+			return nil, fmt.Errorf("building rewriter for backend %q: %w", b.Name, err)
+		}
+	}
+
+	if b.Mirror != nil {
+		u.mirror, err = newMirror(b, mirrorMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("building mirror for backend %q: %w", b.Name, err)
+		}
+	}
+
+	return u, nil
+}
+
+// currentTargets returns all targets of the upstream, healthy or not.
+func (u *upstream) currentTargets() []*target {
+	return *u.targets.Load()
+}
+
+// acquire takes a concurrency slot of the upstream, the returned function releases it.
+func (u *upstream) acquire(ctx context.Context) (func(), error) {
+	if u.limiter == nil {
+		return func() {}, nil
+	}
+
+	return u.limiter.acquire(ctx)
+}
@@ -33,6 +33,7 @@ type (
 
 		logger   logr.Logger
 		debugger debug.Debugger
+		resolver router.Resolver
 
 		spanOpts                 []trace.SpanStartOption
 		requestCounter           metric.Int64Counter
@@ -47,6 +48,8 @@ type (
 		Version string
 
 		Debugger debug.Debugger
+		// Resolver resolves the backend name of incoming requests.
+		Resolver router.Resolver
 	}
 )
 
@@ -59,6 +62,8 @@ const (
 
 	responseCounterName       = "response"
 	responseSizeHistogramName = "response.size"
+
+	causeAborted = "response aborted"
 )
 
 // nolint: gochecknoglobals
@@ -140,6 +145,7 @@ func NewComponent(opts *Opts) composer.FlowComponent {
 		cfg:      opts.Cfg,
 		logger:   logger,
 		debugger: opts.Debugger,
+		resolver: opts.Resolver,
 
 		requestCounter:           requestCountCounter,
 		requestSizeHistogram:     requestSizeHistogram,
@@ -205,19 +211,20 @@ func (o *obs) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	bw, _ := response.NewBodyWrapper(req.Body).(*response.BodyWrapper)
 
 	// Extract the backend backendName to enable debugging and context enrichment early on.
-	backendName, err := router.GetBackendName(req)
+	backendName, err := o.resolver.GetBackendName(req)
 	if err != nil {
-		rLogger.Error(err, "Failed to extract backend name from request path")
+		rLogger.Error(err, "Failed to resolve backend name from request")
 		apierror.ErrorHandler(wrapped, req, err)
 		krbAttributes := extractKrbAttributes(ctx)
 		//nolint:errcheck // no point
-		o.bumpMetrics(ctx, wrapped.(*response.Wrapper), bw, req, 0, krbAttributes)
+		wrapper := wrapped.(*response.Wrapper)
+		o.bumpMetrics(ctx, wrapper, bw, req, 0, krbAttributes)
 
-		span.SetStatus(codes.Error, http.StatusText(http.StatusBadRequest))
+		span.SetStatus(wrapper.SpanStatus())
 		span.SetAttributes(krbAttributes...)
 
 		rLogger.Info(
-			req.Method + " " + originalPath + " " + strconv.Itoa(http.StatusBadRequest),
+			req.Method + " " + originalPath + " " + strconv.Itoa(wrapper.StatusCode()),
 		)
 
 		// Debugging this failure is pointless since the session matching will inevitably
@@ -270,9 +277,21 @@ func (o *obs) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	span.SetStatus(wrapper.SpanStatus())
 	span.SetAttributes(krbAttributes...)
 
-	rLogger.Info(
-		req.Method + " " + originalPath + " " + strconv.Itoa(wrapper.StatusCode()),
-	)
+	status := strconv.Itoa(wrapper.StatusCode())
+	if grpcStatus, ok := wrapper.GRPCStatus(); ok {
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(grpcStatus))
+		status += " grpc-status " + strconv.Itoa(grpcStatus)
+	}
+
+	// Aborted responses are cut off after their status was sent, the composer aborts them once
+	// they are recorded here.
+	result, cause := debug.CallResultSuccess, ""
+	if composer.ResponseAborted(ctx) {
+		span.SetStatus(codes.Error, causeAborted)
+		status += " " + causeAborted
+		result, cause = debug.CallResultFailure, causeAborted
+	}
+	rLogger.Info(req.Method + " " + originalPath + " " + status)
 
 	debugCall.SetStatusCode(wrapper.StatusCode())
 	debugCall.AddTransition(
@@ -280,8 +299,8 @@ func (o *obs) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		debug.CallDirectionOutbound,
 		debugStart,
 		time.Now(),
-		debug.CallResultSuccess,
-		"",
+		result,
+		cause,
 	)
 }
 
@@ -296,6 +315,12 @@ func (o *obs) bumpMetrics(
 	// Update metrics, can't separate request and response handling since the handler is
 	// called by ServeHTTP, no
 	statusCodeOpt := metric.WithAttributes(semconv.HTTPStatusCode(wrapper.StatusCode()))
+	if grpcStatus, ok := wrapper.GRPCStatus(); ok {
+		statusCodeOpt = metric.WithAttributes(
+			semconv.HTTPStatusCode(wrapper.StatusCode()),
+			semconv.RPCGRPCStatusCodeKey.Int(grpcStatus),
+		)
+	}
 	requestMeta := metric.WithAttributes(semconv.HTTPMethod(req.Method))
 	krbMetricMeta := metric.WithAttributes(attributes...)
 
@@ -346,9 +371,9 @@ func dummyComponent(logger logr.Logger, opts *Opts) composer.FlowComponent {
 		rLogger := logger.WithValues("path", req.URL.Path, "method", req.Method)
 		rLogger.Info(req.Method + " " + req.URL.Path)
 
-		name, err := router.GetBackendName(req)
+		name, err := opts.Resolver.GetBackendName(req)
 		if err != nil {
-			rLogger.Error(err, "Failed to extract backend name from request path")
+			rLogger.Error(err, "Failed to resolve backend name from request")
 			apierror.ErrorHandler(w, req, err)
 			return
 		}
@@ -375,8 +400,12 @@ func dummyComponent(logger logr.Logger, opts *Opts) composer.FlowComponent {
 
 		// Set debugging metadata for the response, including status code and log the request.
 		debugCall.SetStatusCode(wrapper.StatusCode())
+		status := strconv.Itoa(wrapper.StatusCode())
+		if composer.ResponseAborted(ctx) {
+			status += " " + causeAborted
+		}
 		rLogger.Info(
-			req.Method+" "+req.URL.Path+" "+strconv.Itoa(wrapper.StatusCode()),
+			req.Method+" "+req.URL.Path+" "+status,
 			string(semconv.HTTPStatusCodeKey), wrapper.StatusCode(),
 		)
 	}}
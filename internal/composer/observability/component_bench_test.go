@@ -7,6 +7,7 @@ import (
 
 	"github.com/trebent/kerberos/internal/composer"
 	obs "github.com/trebent/kerberos/internal/composer/observability"
+	"github.com/trebent/kerberos/internal/composer/router"
 	"github.com/trebent/kerberos/internal/config"
 	"github.com/trebent/zerologr"
 )
@@ -15,8 +16,9 @@ func BenchmarkObs_Standard(b *testing.B) {
 	zerologr.Set(zerologr.New(&zerologr.Opts{Console: true, V: 20}))
 
 	comp := obs.NewComponent(&obs.Opts{
-		Cfg:     &config.ObservabilityConfig{},
-		Version: "1.0.0",
+		Cfg:      &config.ObservabilityConfig{},
+		Version:  "1.0.0",
+		Resolver: router.NewComponent(&router.Opts{Cfg: &config.Router{}}),
 	})
 	dummy := composer.Dummy{
 		CustomHandler: func(_ composer.FlowComponent, w http.ResponseWriter, _ *http.Request) {
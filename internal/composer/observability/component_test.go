@@ -2,15 +2,24 @@ package obs
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
 	"github.com/trebent/kerberos/internal/composer"
 	"github.com/trebent/kerberos/internal/composer/debug"
+	"github.com/trebent/kerberos/internal/composer/router"
 	"github.com/trebent/kerberos/internal/config"
 	"github.com/trebent/kerberos/internal/response"
+	"github.com/trebent/zerologr"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 func TestObservabilityDisabled(t *testing.T) {
@@ -18,7 +27,11 @@ func TestObservabilityDisabled(t *testing.T) {
 		Enabled: false,
 	}
 
-	opts := &Opts{Cfg: cfg, Debugger: debug.NewDummy(nil)}
+	opts := &Opts{
+		Cfg:      cfg,
+		Debugger: debug.NewDummy(nil),
+		Resolver: router.NewComponent(&router.Opts{Cfg: &config.Router{}}),
+	}
 	component := NewComponent(opts)
 
 	dummy := &composer.Dummy{
@@ -57,7 +70,11 @@ func TestObservability(t *testing.T) {
 		RuntimeMetrics: false,
 	}
 
-	opts := &Opts{Cfg: cfg, Debugger: debug.NewDummy(nil)}
+	opts := &Opts{
+		Cfg:      cfg,
+		Debugger: debug.NewDummy(nil),
+		Resolver: router.NewComponent(&router.Opts{Cfg: &config.Router{}}),
+	}
 	component := NewComponent(opts)
 
 	dummy := &composer.Dummy{
@@ -89,3 +106,72 @@ func TestObservability(t *testing.T) {
 		t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
 	}
 }
+
+func TestObservabilityAbortedResponse(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		logs []string
+	)
+	zerologr.Set(funcr.New(func(prefix, args string) {
+		mu.Lock()
+		defer mu.Unlock()
+		logs = append(logs, prefix+" "+args)
+	}, funcr.Options{}))
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	component := NewComponent(&Opts{
+		Cfg:      &config.ObservabilityConfig{Enabled: true},
+		Debugger: debug.NewDummy(nil),
+		Resolver: router.NewComponent(&router.Opts{Cfg: &config.Router{}}),
+	})
+	// The response is cut off after its status was sent, e.g. by the body limiter.
+	forwarder := &composer.Dummy{
+		CustomHandler: func(_ composer.FlowComponent, w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("partial"))
+			composer.AbortResponse(req.Context())
+		},
+	}
+	c := composer.New(&composer.Opts{
+		Observability: component,
+		Router:        &composer.Dummy{},
+		Custom:        &composer.Dummy{},
+		Forwarder:     forwarder,
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r != http.ErrAbortHandler { //nolint:errorlint // panic value
+				t.Fatalf("Expected the composer to abort the response, got %v", r)
+			}
+		}()
+		c.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/gw/backend/one/", nil))
+	}()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Failed to collect metrics: %v", err)
+	}
+	counted := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == responseCounterName {
+				counted = len(sum.DataPoints) == 1 && sum.DataPoints[0].Value == 1
+			}
+		}
+	}
+	if !counted {
+		t.Error("Expected the aborted response to be counted")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	logged := false
+	for _, line := range logs {
+		logged = logged || strings.Contains(line, "GET /gw/backend/one/ 200 "+causeAborted)
+	}
+	if !logged {
+		t.Errorf("Expected the aborted response to be logged, got %v", logs)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -19,18 +22,42 @@ import (
 )
 
 type (
+	// Router routes requests to their configured backend.
+	Router interface {
+		composer.FlowComponent
+		Resolver
+	}
+	// Resolver resolves the name of the backend a request is intended for.
+	Resolver interface {
+		GetBackendName(req *http.Request) (string, error)
+	}
+	// HealthReporter reports the health of backend targets.
+	HealthReporter interface {
+		TargetHealthy(backend, addr string) bool
+	}
+	// DiscoveryReporter reports the targets of backends with discovery.
+	DiscoveryReporter interface {
+		DiscoveredTargets(backend string) (addrs []string, refreshed time.Time, err error)
+	}
 	// Opts are the options used to configure the router.
 	Opts struct {
 		Cfg *config.Router
+		// Health is optional, when set target health is included in the router metadata.
+		Health HealthReporter
+		// Discovery is optional, when set discovered targets are included in the router metadata.
+		Discovery DiscoveryReporter
 	}
 	router struct {
-		cfg  *config.Router
-		next composer.FlowComponent
+		cfg       *config.Router
+		routes    []*config.RouterRoute
+		health    HealthReporter
+		discovery DiscoveryReporter
+		next      composer.FlowComponent
 	}
 )
 
 var (
-	_ composer.FlowComponent = (*router)(nil)
+	_ Router = (*router)(nil)
 
 	//nolint:errname // This is intentional to separate pure error types from wrapper API Errors.
 	apiErrNoBackendFound = apierror.New(http.StatusNotFound, "no backend found")
@@ -41,7 +68,7 @@ const (
 	prefix                 = "/gw/backend/"
 )
 
-func NewComponent(opts *Opts) composer.FlowComponent {
+func NewComponent(opts *Opts) Router {
 	for _, backend := range opts.Cfg.Backends {
 		zerologr.Info(
 			"Configured backend",
@@ -49,9 +76,37 @@ func NewComponent(opts *Opts) composer.FlowComponent {
 			"host", backend.Host,
 			"port", backend.Port,
 		)
+		for _, target := range backend.Targets {
+			zerologr.Info(
+				"Configured backend target",
+				"backend", backend.Name,
+				"host", target.Host,
+				"port", target.Port,
+			)
+		}
+		if backend.Discovery != nil {
+			zerologr.Info(
+				"Configured backend discovery",
+				"backend", backend.Name,
+				"type", discoveryType(backend.Discovery),
+				"source", discoverySource(backend.Discovery),
+			)
+		}
+	}
+	for _, route := range opts.Cfg.Routes {
+		zerologr.Info(
+			"Configured route",
+			"backend", route.Backend,
+			"host", route.Host,
+			"pathPrefix", route.PathPrefix,
+			"methods", route.Methods,
+		)
 	}
 	return &router{
-		cfg: opts.Cfg,
+		cfg:       opts.Cfg,
+		routes:    sortRoutes(opts.Cfg.Routes),
+		health:    opts.Health,
+		discovery: opts.Discovery,
 	}
 }
 
@@ -67,14 +122,36 @@ func (r *router) GetMeta() []adminapi.FlowMeta {
 		Backends: func() *[]adminapi.FlowMetaDataRouterBackend {
 			var backends []adminapi.FlowMetaDataRouterBackend
 			for _, backend := range r.cfg.Backends {
+				targets, discovery := r.targetsMeta(backend)
+
+				var loadBalancing *string
+				if backend.LoadBalancing != nil {
+					loadBalancing = &backend.LoadBalancing.Strategy
+				}
+
 				backends = append(backends, adminapi.FlowMetaDataRouterBackend{
-					Name: backend.Name,
-					Host: backend.Host,
-					Port: backend.Port,
+					Name:          backend.Name,
+					Host:          backend.Host,
+					Port:          backend.Port,
+					Targets:       &targets,
+					LoadBalancing: loadBalancing,
+					Discovery:     discovery,
 				})
 			}
 			return &backends
 		}(),
+		Routes: func() *[]adminapi.FlowMetaDataRouterRoute {
+			routes := make([]adminapi.FlowMetaDataRouterRoute, 0, len(r.routes))
+			for _, route := range r.routes {
+				routes = append(routes, adminapi.FlowMetaDataRouterRoute{
+					Backend:    route.Backend,
+					Host:       &route.Host,
+					PathPrefix: &route.PathPrefix,
+					Methods:    &route.Methods,
+				})
+			}
+			return &routes
+		}(),
 	}); err != nil {
 		panic(err)
 	}
@@ -87,6 +164,77 @@ func (r *router) GetMeta() []adminapi.FlowMeta {
 	}, r.next.GetMeta()...)
 }
 
+// targetsMeta returns the metadata of the targets of a backend, configured or discovered, and of
+// its discovery when it has one.
+func (r *router) targetsMeta(
+	backend *config.RouterBackend,
+) ([]adminapi.FlowMetaDataRouterTarget, *adminapi.FlowMetaDataRouterDiscovery) {
+	var (
+		addrs     []string
+		discovery *adminapi.FlowMetaDataRouterDiscovery
+	)
+	if backend.Discovery != nil {
+		discovery = &adminapi.FlowMetaDataRouterDiscovery{
+			Type:     discoveryType(backend.Discovery),
+			Source:   discoverySource(backend.Discovery),
+			Interval: backend.Discovery.IntervalMs,
+		}
+
+		if r.discovery != nil {
+			var (
+				refreshed time.Time
+				err       error
+			)
+			addrs, refreshed, err = r.discovery.DiscoveredTargets(backend.Name)
+			if !refreshed.IsZero() {
+				discovery.LastRefresh = &refreshed
+			}
+			if err != nil {
+				discovery.Error = new(err.Error())
+			}
+		}
+	} else {
+		for _, target := range backend.GetTargets() {
+			addrs = append(addrs, net.JoinHostPort(target.Host, strconv.Itoa(target.Port)))
+		}
+	}
+
+	targets := make([]adminapi.FlowMetaDataRouterTarget, 0, len(addrs))
+	for _, addr := range addrs {
+		host, port, _ := net.SplitHostPort(addr)
+		portNumber, _ := strconv.Atoi(port)
+
+		var healthy *bool
+		if r.health != nil {
+			healthy = new(r.health.TargetHealthy(backend.Name, addr))
+		}
+
+		targets = append(targets, adminapi.FlowMetaDataRouterTarget{
+			Host:    host,
+			Port:    portNumber,
+			Healthy: healthy,
+		})
+	}
+
+	return targets, discovery
+}
+
+func discoveryType(discovery *config.Discovery) string {
+	if discovery.DNS != nil {
+		return "dns"
+	}
+
+	return "file"
+}
+
+func discoverySource(discovery *config.Discovery) string {
+	if discovery.DNS != nil {
+		return discovery.DNS.Name
+	}
+
+	return discovery.File.Path
+}
+
 // ServeHTTP implements [composer.FlowComponent].
 func (r *router) ServeHTTP(wrapped http.ResponseWriter, req *http.Request) {
 	debugStart := time.Now()
@@ -149,13 +297,17 @@ func (r *router) ServeHTTP(wrapped http.ResponseWriter, req *http.Request) {
 	// Set backend in context logger to forward. Don't append to the name.
 	ctx := logr.NewContext(req.Context(), logger.WithValues("backend", backend.Name))
 	ctx = context.WithValue(ctx, composer.TargetContextKey, backend)
+	ctx = context.WithValue(ctx, composer.PathContextKey, req.URL.EscapedPath())
 
 	// Update the wrapper request context to be able to extract in higher level middleware.
 	wrapper, _ := wrapped.(*response.Wrapper)
 	wrapper.SetRequestContext(ctx)
 
-	// Strip the /gw/backend/{backend-name} prefix from the request URL path.
-	req.URL.Path = stripKrbPrefix(req.URL.Path, backend.Name)
+	// Strip the /gw/backend/{backend-name} prefix from the request URL path, requests matched by
+	// a route are forwarded with their path unchanged.
+	if hasKrbPrefix(req.URL.Path, backend.Name) {
+		stripKrbPrefix(req.URL, backend.Name)
+	}
 
 	debuggedCall.AddTransition(
 		"router",
@@ -182,7 +334,12 @@ func (r *router) GetBackend(req *http.Request) (*config.RouterBackend, error) {
 	return nil, fmt.Errorf("%w: %s", apiErrNoBackendFound, req.URL.Path)
 }
 
-// stripKrbPrefix strips the /gw/backend/{backend-name} prefix from the request URL path.
-func stripKrbPrefix(path, backend string) string {
-	return path[len(prefix)+len(backend):]
+// stripKrbPrefix strips the /gw/backend/{backend-name} prefix from the request URL path. The
+// prefix only holds characters that are never escaped, so the raw path, holding the original
+// encoding of the path, is stripped by the same length.
+func stripKrbPrefix(u *url.URL, backend string) {
+	u.Path = u.Path[len(prefix)+len(backend):]
+	if u.RawPath != "" {
+		u.RawPath = u.RawPath[len(prefix)+len(backend):]
+	}
 }
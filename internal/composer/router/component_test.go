@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/trebent/kerberos/internal/composer"
 	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
 	"github.com/trebent/kerberos/internal/response"
 )
 
@@ -59,4 +61,111 @@ func TestRouter(t *testing.T) {
 	if recorder.Code != http.StatusNoContent {
 		t.Fatalf("expected status code %d, got %d", http.StatusNoContent, recorder.Code)
 	}
+
+	// Requests matched by a route are not stripped.
+	req = httptest.NewRequest(http.MethodGet, "/some/path", nil)
+	recorder = httptest.NewRecorder()
+	wrapped = response.NewResponseWrapper(recorder)
+	router.ServeHTTP(wrapped, req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, cfg.Backends[1].Name)))
+
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected status code %d, got %d", http.StatusNoContent, recorder.Code)
+	}
+}
+
+func TestRouterPreservesEncoding(t *testing.T) {
+	cfg := &config.Router{
+		Backends: []*config.RouterBackend{
+			{
+				Name: "backend1",
+				Host: "localhost",
+				Port: 8080,
+			},
+		},
+	}
+
+	dummy := composer.Dummy{
+		CustomHandler: func(_ composer.FlowComponent, w http.ResponseWriter, req *http.Request) {
+			if req.URL.EscapedPath() != "/files/a%2Fb" {
+				t.Errorf("Expected escaped path /files/a%%2Fb, got %s", req.URL.EscapedPath())
+			}
+			if req.URL.RawQuery != "q=a%26b&x=1" {
+				t.Errorf("Expected raw query q=a%%26b&x=1, got %s", req.URL.RawQuery)
+			}
+			if path := composer.ReceivedPath(req); path != "/gw/backend/backend1/files/a%2Fb" {
+				t.Errorf("Expected received path /gw/backend/backend1/files/a%%2Fb, got %s", path)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+
+	router := NewComponent(&Opts{Cfg: cfg})
+	router.Next(&dummy)
+
+	recorder := httptest.NewRecorder()
+	wrapped := response.NewResponseWrapper(recorder)
+	req := httptest.NewRequest(http.MethodGet, "/gw/backend/backend1/files/a%2Fb?q=a%26b&x=1", nil)
+	router.ServeHTTP(wrapped, req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, cfg.Backends[0].Name)))
+
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected status code %d, got %d", http.StatusNoContent, recorder.Code)
+	}
+}
+
+type discoveryReporter struct {
+	addrs     []string
+	refreshed time.Time
+}
+
+func (d *discoveryReporter) DiscoveredTargets(_ string) ([]string, time.Time, error) {
+	return d.addrs, d.refreshed, nil
+}
+
+func TestRouterDiscoveryMeta(t *testing.T) {
+	cfg := &config.Router{
+		Backends: []*config.RouterBackend{
+			{
+				Name: "backend1",
+				Discovery: &config.Discovery{
+					DNS:        &config.DNSDiscovery{Name: "_http._tcp.backend1.local"},
+					IntervalMs: 10000,
+				},
+			},
+		},
+	}
+
+	refreshed := time.Now()
+	router := NewComponent(&Opts{
+		Cfg:       cfg,
+		Discovery: &discoveryReporter{addrs: []string{"10.0.0.1:8080"}, refreshed: refreshed},
+	})
+	router.Next(&terminal{})
+
+	meta, err := router.GetMeta()[0].Data.AsFlowMetaDataRouter()
+	if err != nil {
+		t.Fatalf("Failed to read router metadata: %v", err)
+	}
+
+	backend := (*meta.Backends)[0]
+	if len(*backend.Targets) != 1 ||
+		(*backend.Targets)[0].Host != "10.0.0.1" ||
+		(*backend.Targets)[0].Port != 8080 {
+		t.Errorf("Unexpected targets %+v", *backend.Targets)
+	}
+
+	if backend.Discovery == nil ||
+		backend.Discovery.Type != "dns" ||
+		backend.Discovery.Source != "_http._tcp.backend1.local" ||
+		backend.Discovery.LastRefresh == nil ||
+		!backend.Discovery.LastRefresh.Equal(refreshed) {
+		t.Errorf("Unexpected discovery %+v", backend.Discovery)
+	}
+}
+
+type terminal struct {
+	composer.Dummy
+}
+
+func (t *terminal) GetMeta() []adminapi.FlowMeta {
+	return nil
 }
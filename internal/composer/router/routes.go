@@ -0,0 +1,97 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/trebent/kerberos/internal/config"
+)
+
+// sortRoutes returns a copy of the input routes sorted by precedence:
+//  1. Routes with a host before routes without.
+//  2. Longer path prefixes before shorter ones.
+//  3. Routes restricted by method before unrestricted ones.
+//  4. Configuration order.
+func sortRoutes(routes []*config.RouterRoute) []*config.RouterRoute {
+	sorted := slices.Clone(routes)
+	slices.SortStableFunc(sorted, func(one, two *config.RouterRoute) int {
+		if (one.Host != "") != (two.Host != "") {
+			if one.Host != "" {
+				return -1
+			}
+			return 1
+		}
+
+		if len(one.PathPrefix) != len(two.PathPrefix) {
+			return len(two.PathPrefix) - len(one.PathPrefix)
+		}
+
+		if (len(one.Methods) > 0) != (len(two.Methods) > 0) {
+			if len(one.Methods) > 0 {
+				return -1
+			}
+			return 1
+		}
+
+		return 0
+	})
+
+	return sorted
+}
+
+// matchRoute returns the first route matching the request, or nil if no route matches. The routes
+// are expected to be sorted by precedence, see sortRoutes.
+func matchRoute(routes []*config.RouterRoute, req *http.Request) *config.RouterRoute {
+	host := requestHost(req)
+	for _, route := range routes {
+		if route.Host != "" && !matchHost(route.Host, host) {
+			continue
+		}
+
+		if route.PathPrefix != "" && !matchPathPrefix(route.PathPrefix, req.URL.Path) {
+			continue
+		}
+
+		if len(route.Methods) > 0 && !slices.Contains(route.Methods, req.Method) {
+			continue
+		}
+
+		return route
+	}
+
+	return nil
+}
+
+// requestHost returns the lower-cased request host without port.
+func requestHost(req *http.Request) string {
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(host)
+}
+
+// matchHost matches a host against a route host pattern. A leading "*." in the pattern matches any
+// subdomain, but not the parent domain itself.
+func matchHost(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+
+	return pattern == host
+}
+
+// matchPathPrefix matches a path against a prefix on segment boundaries.
+func matchPathPrefix(prefix, path string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+
+	rest, ok := strings.CutPrefix(path, prefix)
+	return ok && (rest == "" || rest[0] == '/')
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
 
 	apierror "github.com/trebent/kerberos/internal/oapi/error"
 )
@@ -18,17 +19,31 @@ var (
 	)
 )
 
-// GetBackendName extracts the backend name from the request URL path.
-func GetBackendName(req *http.Request) (string, error) {
-	reqPath := routePattern.FindStringSubmatch(req.URL.Path)
+// GetBackendName implements [Resolver]. Requests using the legacy /gw/backend/<name>/ prefix are
+// always resolved from the path, other requests are matched against the configured routes.
+func (r *router) GetBackendName(req *http.Request) (string, error) {
+	if strings.HasPrefix(req.URL.Path, prefix) {
+		reqPath := routePattern.FindStringSubmatch(req.URL.Path)
 
-	if len(reqPath) < expectedPatternMatches {
-		return "", fmt.Errorf("%w: %s", apiErrBadRequest, req.URL.Path)
+		if len(reqPath) < expectedPatternMatches {
+			return "", fmt.Errorf("%w: %s", apiErrBadRequest, req.URL.Path)
+		}
+
+		if reqPath[1] == "" {
+			return "", fmt.Errorf("%w: %s", apiErrBadRequest, req.URL.Path)
+		}
+
+		return reqPath[1], nil
 	}
 
-	if reqPath[1] == "" {
-		return "", fmt.Errorf("%w: %s", apiErrBadRequest, req.URL.Path)
+	if route := matchRoute(r.routes, req); route != nil {
+		return route.Backend, nil
 	}
 
-	return reqPath[1], nil
+	return "", fmt.Errorf("%w: %s", apiErrNoBackendFound, req.URL.Path)
+}
+
+// hasKrbPrefix returns true if the path uses the legacy /gw/backend/{backend-name} prefix.
+func hasKrbPrefix(path, backend string) bool {
+	return strings.HasPrefix(path, prefix+backend+"/")
 }
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/trebent/kerberos/internal/config"
 )
 
 func TestGetBackendName(t *testing.T) {
@@ -39,10 +41,125 @@ func TestGetBackendName(t *testing.T) {
 		},
 	}
 
+	r := NewComponent(&Opts{Cfg: &config.Router{}})
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, tt.reqPath, nil)
-			actual, err := GetBackendName(req)
+			actual, err := r.GetBackendName(req)
+
+			if (err != nil) != tt.expectError {
+				t.Errorf("GetBackendName() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if actual != tt.expected {
+				t.Errorf("GetBackendName() = %v, expected %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetBackendNameRoutes(t *testing.T) {
+	r := NewComponent(&Opts{Cfg: &config.Router{
+		Routes: []*config.RouterRoute{
+			{Backend: "orders", PathPrefix: "/api/orders"},
+			{Backend: "orders-write", PathPrefix: "/api/orders", Methods: []string{http.MethodPost}},
+			{Backend: "orders-v2", PathPrefix: "/api/orders/v2"},
+			{Backend: "api", PathPrefix: "/api"},
+			{Backend: "shop", Host: "shop.example.com"},
+			{Backend: "shop-api", Host: "shop.example.com", PathPrefix: "/api"},
+			{Backend: "tenants", Host: "*.tenants.example.com"},
+		},
+	}})
+
+	tests := []struct {
+		name        string
+		method      string
+		host        string
+		reqPath     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "legacy prefix takes precedence",
+			method:   http.MethodGet,
+			host:     "shop.example.com",
+			reqPath:  "/gw/backend/backend1/api/orders",
+			expected: "backend1",
+		},
+		{
+			name:     "path prefix",
+			method:   http.MethodGet,
+			reqPath:  "/api/orders/1",
+			expected: "orders",
+		},
+		{
+			name:     "path prefix exact",
+			method:   http.MethodGet,
+			reqPath:  "/api/orders",
+			expected: "orders",
+		},
+		{
+			name:     "path prefix segment boundary",
+			method:   http.MethodGet,
+			reqPath:  "/api/ordersx",
+			expected: "api",
+		},
+		{
+			name:     "longer path prefix",
+			method:   http.MethodPost,
+			reqPath:  "/api/orders/v2/1",
+			expected: "orders-v2",
+		},
+		{
+			name:     "method restricted",
+			method:   http.MethodPost,
+			reqPath:  "/api/orders/1",
+			expected: "orders-write",
+		},
+		{
+			name:     "host",
+			method:   http.MethodGet,
+			host:     "shop.example.com:8080",
+			reqPath:  "/index.html",
+			expected: "shop",
+		},
+		{
+			name:     "host and path",
+			method:   http.MethodGet,
+			host:     "SHOP.example.com",
+			reqPath:  "/api/orders",
+			expected: "shop-api",
+		},
+		{
+			name:     "wildcard host",
+			method:   http.MethodGet,
+			host:     "one.tenants.example.com",
+			reqPath:  "/",
+			expected: "tenants",
+		},
+		{
+			name:        "wildcard host does not match parent",
+			method:      http.MethodGet,
+			host:        "tenants.example.com",
+			reqPath:     "/",
+			expectError: true,
+		},
+		{
+			name:        "no match",
+			method:      http.MethodGet,
+			reqPath:     "/other",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.reqPath, nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			actual, err := r.GetBackendName(req)
 
 			if (err != nil) != tt.expectError {
 				t.Errorf("GetBackendName() error = %v, expectError %v", err, tt.expectError)
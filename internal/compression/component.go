@@ -0,0 +1,150 @@
+package compression
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/composer/custom"
+	"github.com/trebent/kerberos/internal/composer/debug"
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	"github.com/trebent/zerologr"
+)
+
+type (
+	Compressor interface {
+		composer.FlowComponent
+		custom.Ordered
+	}
+	compressor struct {
+		next     composer.FlowComponent
+		cfg      *config.CompressionConfig
+		global   *settings
+		mappings map[string]*settings
+	}
+	Opts struct {
+		Cfg *config.CompressionConfig
+	}
+
+	// settings are the effective compression settings of a backend.
+	settings struct {
+		minSize      int64
+		contentTypes []string
+	}
+)
+
+const componentName = "compressor"
+
+var _ Compressor = (*compressor)(nil)
+
+func NewComponent(opts *Opts) Compressor {
+	c := &compressor{
+		cfg: opts.Cfg,
+		global: &settings{
+			minSize:      *opts.Cfg.MinSize,
+			contentTypes: opts.Cfg.ContentTypes,
+		},
+		mappings: make(map[string]*settings, len(opts.Cfg.Mappings)),
+	}
+	for _, m := range c.cfg.Mappings {
+		zerologr.Info("Registering compression settings", "backend", m.Backend)
+
+		// Omitted settings are inherited from the global ones.
+		s := *c.global
+		if m.MinSize != nil {
+			s.minSize = *m.MinSize
+		}
+		if m.ContentTypes != nil {
+			s.contentTypes = m.ContentTypes
+		}
+		c.mappings[m.Backend] = &s
+	}
+
+	return c
+}
+
+func (c *compressor) Order() int {
+	return c.cfg.Order
+}
+
+// Next implements [composer.FlowComponent].
+func (c *compressor) Next(next composer.FlowComponent) {
+	c.next = next
+}
+
+// GetMeta implements [composer.FlowComponent].
+func (c *compressor) GetMeta() []adminapi.FlowMeta {
+	mappings := make([]adminapi.FlowMetaDataCompressionMapping, 0, len(c.cfg.Mappings))
+	for _, m := range c.cfg.Mappings {
+		s := c.mappings[m.Backend]
+		mappings = append(mappings, adminapi.FlowMetaDataCompressionMapping{
+			Backend:      m.Backend,
+			MinSize:      s.minSize,
+			ContentTypes: s.contentTypes,
+		})
+	}
+
+	fmd := adminapi.FlowMeta_Data{}
+	if err := fmd.FromFlowMetaDataCompression(adminapi.FlowMetaDataCompression{
+		Encodings:    c.cfg.Encodings,
+		MinSize:      c.global.minSize,
+		ContentTypes: c.global.contentTypes,
+		Mappings:     &mappings,
+	}); err != nil {
+		panic(err)
+	}
+
+	return append([]adminapi.FlowMeta{
+		{
+			Name: componentName,
+			Data: fmd,
+		},
+	}, c.next.GetMeta()...)
+}
+
+// ServeHTTP implements [composer.FlowComponent].
+func (c *compressor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	backend, _ := req.Context().Value(composer.BackendContextKey).(string)
+	s, ok := c.mappings[backend]
+	if !ok {
+		s = c.global
+	}
+
+	rw := &responseWriter{
+		ResponseWriter: w,
+		head:           req.Method == http.MethodHead,
+		encoding:       negotiate(req.Header.Get("Accept-Encoding"), c.cfg.Encodings),
+		settings:       s,
+	}
+	c.next.ServeHTTP(rw, req)
+
+	debugStart := time.Now()
+	compressed := rw.encoder != nil
+	err := rw.close()
+	if !compressed {
+		return
+	}
+
+	logger, _ := logr.FromContext(req.Context())
+	logger.WithName(componentName).V(20).Info(
+		"Compressed response",
+		"backend", backend,
+		"encoding", rw.encoding,
+	)
+
+	result, cause := debug.CallResultSuccess, ""
+	if err != nil {
+		result, cause = debug.CallResultFailure, err.Error()
+	}
+	composer.DebugFromContext(req.Context()).AddTransition(
+		componentName,
+		debug.CallDirectionOutbound,
+		debugStart,
+		time.Now(),
+		result,
+		cause,
+		debug.Attr("encoding", rw.encoding),
+	)
+}
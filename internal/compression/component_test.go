@@ -0,0 +1,336 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	"github.com/trebent/kerberos/internal/response"
+)
+
+// terminal ends the flow in tests, answering with the configured header and body. A streamed
+// body is flushed after every chunk.
+type terminal struct {
+	composer.Dummy
+
+	header http.Header
+	status int
+	body   []string
+	stream bool
+}
+
+func (t *terminal) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	for name, values := range t.header {
+		w.Header()[name] = values
+	}
+
+	status := t.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+
+	for _, chunk := range t.body {
+		_, _ = w.Write([]byte(chunk))
+		if t.stream {
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func (*terminal) GetMeta() []adminapi.FlowMeta {
+	return nil
+}
+
+func newTestCompressor(t *testing.T, cfg *config.CompressionConfig, next *terminal) Compressor {
+	t.Helper()
+
+	if cfg.Encodings == nil {
+		cfg.Encodings = []string{config.EncodingZstd, config.EncodingGzip, config.EncodingDeflate}
+	}
+	if cfg.MinSize == nil {
+		cfg.MinSize = new(int64(16))
+	}
+	if cfg.ContentTypes == nil {
+		cfg.ContentTypes = []string{"text/*", "application/json"}
+	}
+
+	c := NewComponent(&Opts{Cfg: cfg})
+	c.Next(next)
+	return c
+}
+
+func serveRequest(c Compressor, method, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/resource", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	recorder := httptest.NewRecorder()
+	c.ServeHTTP(
+		recorder,
+		req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, "backend1")),
+	)
+	return recorder
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+
+	var (
+		r   io.Reader
+		err error
+	)
+	switch encoding {
+	case "":
+		return string(body)
+	case config.EncodingGzip:
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case config.EncodingDeflate:
+		r, err = zlib.NewReader(bytes.NewReader(body))
+	case config.EncodingZstd:
+		var d *zstd.Decoder
+		d, err = zstd.NewReader(bytes.NewReader(body))
+		if err == nil {
+			defer d.Close()
+			r = d
+		}
+	default:
+		t.Fatalf("Unexpected content encoding %q", encoding)
+	}
+	if err != nil {
+		t.Fatalf("Failed to create %s reader: %v", encoding, err)
+	}
+
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to decode %s body: %v", encoding, err)
+	}
+	return string(decoded)
+}
+
+func TestNegotiate(t *testing.T) {
+	supported := []string{config.EncodingZstd, config.EncodingGzip, config.EncodingDeflate}
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{acceptEncoding: "", expected: ""},
+		{acceptEncoding: "gzip", expected: config.EncodingGzip},
+		{acceptEncoding: "gzip, deflate, zstd", expected: config.EncodingZstd},
+		{acceptEncoding: "gzip;q=1.0, zstd;q=0.5", expected: config.EncodingGzip},
+		{acceptEncoding: "br", expected: ""},
+		{acceptEncoding: "*", expected: config.EncodingZstd},
+		{acceptEncoding: "*, zstd;q=0", expected: config.EncodingGzip},
+		{acceptEncoding: "gzip;q=0.5, identity", expected: ""},
+		{acceptEncoding: "GZIP ; Q=0.8", expected: config.EncodingGzip},
+	}
+	for _, test := range tests {
+		t.Run(test.acceptEncoding, func(t *testing.T) {
+			if actual := negotiate(test.acceptEncoding, supported); actual != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCompression(t *testing.T) {
+	body := strings.Repeat("compressible ", 10)
+	tests := []struct {
+		name             string
+		method           string
+		acceptEncoding   string
+		header           http.Header
+		status           int
+		body             []string
+		expectedEncoding string
+		expectedVary     bool
+	}{
+		{
+			name:             "Gzip",
+			acceptEncoding:   "gzip",
+			header:           http.Header{"Content-Type": {"text/plain"}},
+			body:             []string{body},
+			expectedEncoding: config.EncodingGzip,
+			expectedVary:     true,
+		},
+		{
+			name:           "Deflate with length",
+			acceptEncoding: "deflate",
+			header: http.Header{
+				"Content-Type":   {"application/json"},
+				"Content-Length": {"130"},
+			},
+			body:             []string{body},
+			expectedEncoding: config.EncodingDeflate,
+			expectedVary:     true,
+		},
+		{
+			name:             "Zstd in chunks",
+			acceptEncoding:   "zstd",
+			header:           http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+			body:             []string{"small", body, body},
+			expectedEncoding: config.EncodingZstd,
+			expectedVary:     true,
+		},
+		{
+			name:           "Not accepted",
+			header:         http.Header{"Content-Type": {"text/plain"}},
+			body:           []string{body},
+			expectedVary:   true,
+			acceptEncoding: "br",
+		},
+		{
+			name:           "Below minimum size",
+			acceptEncoding: "gzip",
+			header:         http.Header{"Content-Type": {"text/plain"}},
+			body:           []string{"small"},
+			expectedVary:   true,
+		},
+		{
+			name:           "Content type not allowed",
+			acceptEncoding: "gzip",
+			header:         http.Header{"Content-Type": {"image/png"}},
+			body:           []string{body},
+		},
+		{
+			name:           "Already compressed",
+			acceptEncoding: "gzip",
+			header:         http.Header{"Content-Type": {"text/plain"}, "Content-Encoding": {"br"}},
+			body:           []string{body},
+		},
+		{
+			name:           "No transform",
+			acceptEncoding: "gzip",
+			header: http.Header{
+				"Content-Type":  {"text/plain"},
+				"Cache-Control": {"no-transform"},
+			},
+			body: []string{body},
+		},
+		{
+			name:           "Partial content",
+			acceptEncoding: "gzip",
+			header:         http.Header{"Content-Type": {"text/plain"}},
+			status:         http.StatusPartialContent,
+			body:           []string{body},
+		},
+		{
+			name:           "HEAD",
+			method:         http.MethodHead,
+			acceptEncoding: "gzip",
+			header:         http.Header{"Content-Type": {"text/plain"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			header := test.header.Clone()
+			c := newTestCompressor(t, &config.CompressionConfig{}, &terminal{
+				header: header,
+				status: test.status,
+				body:   test.body,
+			})
+
+			recorder := serveRequest(c, method, test.acceptEncoding)
+
+			// Responses compressed by the backend keep their encoding.
+			expectedEncoding := test.expectedEncoding
+			if backendEncoding := test.header.Get("Content-Encoding"); backendEncoding != "" {
+				expectedEncoding = backendEncoding
+			}
+			if encoding := recorder.Header().Get("Content-Encoding"); encoding != expectedEncoding {
+				t.Fatalf("Expected encoding %q, got %q", expectedEncoding, encoding)
+			}
+			if vary := recorder.Header().Get("Vary") == "Accept-Encoding"; vary != test.expectedVary {
+				t.Errorf("Expected Vary %t, got %v", test.expectedVary, recorder.Header()["Vary"])
+			}
+			if test.expectedEncoding != "" && recorder.Header().Get("Content-Length") != "" {
+				t.Error("Expected a compressed response not to have a Content-Length")
+			}
+
+			actual := decode(t, test.expectedEncoding, recorder.Body.Bytes())
+			if actual != strings.Join(test.body, "") {
+				t.Errorf("Unexpected body %q", actual)
+			}
+		})
+	}
+}
+
+func TestCompressionStream(t *testing.T) {
+	next := &terminal{
+		header: http.Header{"Content-Type": {"text/event-stream"}, "Etag": {`"v1"`}},
+		body:   []string{"data: 1\n\n", "data: 2\n\n"},
+		stream: true,
+	}
+	c := newTestCompressor(t, &config.CompressionConfig{
+		CompressionSettings: config.CompressionSettings{ContentTypes: []string{"text/*"}},
+	}, next)
+
+	recorder := serveRequest(c, http.MethodGet, "gzip")
+
+	if !recorder.Flushed || recorder.Header().Get("Content-Encoding") != config.EncodingGzip {
+		t.Fatalf("Expected a flushed gzip stream, got %v", recorder.Header())
+	}
+	if etag := recorder.Header().Get("Etag"); etag != `W/"v1"` {
+		t.Errorf("Expected the entity tag of a compressed response to be weak, got %s", etag)
+	}
+	actual := decode(t, config.EncodingGzip, recorder.Body.Bytes())
+	if actual != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("Unexpected body %q", actual)
+	}
+}
+
+func TestCompressionMapping(t *testing.T) {
+	next := &terminal{
+		header: http.Header{"Content-Type": {"text/plain"}},
+		body:   []string{strings.Repeat("compressible ", 10)},
+	}
+	c := newTestCompressor(t, &config.CompressionConfig{
+		Mappings: []*config.CompressionMapping{{
+			Backend:             "backend1",
+			CompressionSettings: config.CompressionSettings{MinSize: new(int64(1 << 10))},
+		}},
+	}, next)
+
+	recorder := serveRequest(c, http.MethodGet, "gzip")
+	if encoding := recorder.Header().Get("Content-Encoding"); encoding != "" {
+		t.Errorf("Expected the backend's minimum size to apply, got encoding %q", encoding)
+	}
+}
+
+func TestCompressionResponseSize(t *testing.T) {
+	body := strings.Repeat("compressible ", 100)
+	next := &terminal{
+		header: http.Header{"Content-Type": {"text/plain"}},
+		body:   []string{body},
+	}
+	c := newTestCompressor(t, &config.CompressionConfig{}, next)
+
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	wrapper, _ := response.NewResponseWrapper(recorder).(*response.Wrapper)
+	c.ServeHTTP(wrapper, req)
+
+	if wrapper.NumBytes() != int64(recorder.Body.Len()) || recorder.Body.Len() >= len(body) {
+		t.Errorf(
+			"Expected the %d compressed bytes to be counted, got %d",
+			recorder.Body.Len(),
+			wrapper.NumBytes(),
+		)
+	}
+}
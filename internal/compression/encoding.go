@@ -0,0 +1,140 @@
+package compression
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/trebent/kerberos/internal/config"
+)
+
+// encoder compresses a response body, it is reset for every response it is used for.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+const identity = "identity"
+
+// encoders pools the encoders of each supported content coding. Encoders hold large buffers, they
+// are reused between responses.
+var encoders = map[string]*sync.Pool{
+	config.EncodingGzip: {New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}},
+	config.EncodingDeflate: {New: func() any {
+		return zlib.NewWriter(io.Discard)
+	}},
+	config.EncodingZstd: {New: func() any {
+		// Responses are compressed on the goroutine serving them, the encoder does not need its own.
+		e, err := zstd.NewWriter(
+			io.Discard,
+			zstd.WithEncoderConcurrency(1),
+			zstd.WithLowerEncoderMem(true),
+		)
+		if err != nil {
+			panic(err)
+		}
+		return e
+	}},
+}
+
+func getEncoder(encoding string, w io.Writer) encoder {
+	e, _ := encoders[encoding].Get().(encoder)
+	e.Reset(w)
+	return e
+}
+
+func putEncoder(encoding string, e encoder) {
+	// The encoder must not hold on to the response writer.
+	e.Reset(io.Discard)
+	encoders[encoding].Put(e)
+}
+
+// negotiate returns the content coding to compress a response with given the request's
+// Accept-Encoding, or an empty string if the response is not compressed. The client's
+// preference is respected, ties are broken by the order of the supported encodings.
+func negotiate(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]float64)
+	for coding := range strings.SplitSeq(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok &&
+			strings.EqualFold(strings.TrimSpace(key), "q") {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		accepted[name] = quality
+	}
+
+	qualityOf := func(name string) (float64, bool) {
+		if quality, ok := accepted[name]; ok {
+			return quality, true
+		}
+		quality, ok := accepted["*"]
+		return quality, ok
+	}
+
+	selected, best := "", 0.0
+	for _, encoding := range supported {
+		if quality, ok := qualityOf(encoding); ok && quality > best {
+			selected, best = encoding, quality
+		}
+	}
+
+	// Identity is acceptable unless excluded explicitly, a client preferring it gets it.
+	if quality, ok := qualityOf(identity); ok && quality > best {
+		return ""
+	}
+
+	return selected
+}
+
+// allowedType reports whether the media type of a response matches one of the content types,
+// given either exactly or as all subtypes of a type, e.g. "text/*".
+func allowedType(contentType string, contentTypes []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	return slices.ContainsFunc(contentTypes, func(allowed string) bool {
+		allowed = strings.ToLower(allowed)
+		return allowed == mediaType || allowed == mainType+"/*"
+	})
+}
+
+// addVary adds Accept-Encoding to the Vary header, unless the response already varies on it.
+func addVary(header http.Header) {
+	for _, value := range header.Values("Vary") {
+		for name := range strings.SplitSeq(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" || strings.EqualFold(name, "Accept-Encoding") {
+				return
+			}
+		}
+	}
+
+	header.Add("Vary", "Accept-Encoding")
+}
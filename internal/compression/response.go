@@ -0,0 +1,182 @@
+package compression
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// responseWriter compresses a response on its way to the client. The header is held back until it
+// is known whether the response is compressed: immediately if it announces its length, otherwise
+// once the body reaches the minimum size or the response is flushed. Responses that end before
+// that are written uncompressed.
+type responseWriter struct {
+	http.ResponseWriter
+
+	head     bool
+	encoding string
+	settings *settings
+
+	status int
+	// decided is set once the header has been written.
+	decided bool
+	buf     bytes.Buffer
+	// encoder is nil unless the response is compressed.
+	encoder encoder
+}
+
+var (
+	_ http.ResponseWriter = (*responseWriter)(nil)
+	_ http.Flusher        = (*responseWriter)(nil)
+)
+
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if rw.status != 0 {
+		return
+	}
+
+	// Informational responses are followed by the final one.
+	if statusCode < http.StatusOK {
+		rw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	rw.status = statusCode
+	if !rw.eligible() {
+		rw.writeHeader(false)
+		return
+	}
+
+	// The representation depends on the Accept-Encoding of the request, whether this one is
+	// compressed or not.
+	addVary(rw.Header())
+	if rw.encoding == "" {
+		rw.writeHeader(false)
+		return
+	}
+
+	if length, err := strconv.ParseInt(rw.Header().Get("Content-Length"), 10, 64); err == nil {
+		rw.writeHeader(length >= rw.settings.minSize)
+	}
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if rw.encoder != nil {
+		return rw.encoder.Write(p)
+	}
+	if rw.decided {
+		return rw.ResponseWriter.Write(p)
+	}
+
+	rw.buf.Write(p)
+	if int64(rw.buf.Len()) < rw.settings.minSize {
+		return len(p), nil
+	}
+
+	rw.writeHeader(true)
+	if _, err := rw.encoder.Write(rw.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	rw.buf.Reset()
+
+	return len(p), nil
+}
+
+// Flush compresses a response that has not been decided on yet, a flushed response is likely a
+// stream whose length is not known up front.
+func (rw *responseWriter) Flush() {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if !rw.decided {
+		rw.writeHeader(true)
+		if _, err := rw.encoder.Write(rw.buf.Bytes()); err != nil {
+			return
+		}
+		rw.buf.Reset()
+	}
+
+	if rw.encoder != nil {
+		if err := rw.encoder.Flush(); err != nil {
+			return
+		}
+	}
+
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying response writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// close ends the response once the flow has served it, writing a body below the minimum size
+// uncompressed and the end of a compressed one.
+func (rw *responseWriter) close() error {
+	if rw.status == 0 {
+		return nil
+	}
+
+	if !rw.decided {
+		rw.writeHeader(false)
+		_, err := rw.ResponseWriter.Write(rw.buf.Bytes())
+		return err
+	}
+
+	if rw.encoder == nil {
+		return nil
+	}
+
+	err := rw.encoder.Close()
+	putEncoder(rw.encoding, rw.encoder)
+	rw.encoder = nil
+	return err
+}
+
+// eligible reports whether the response may be compressed, regardless of the request.
+func (rw *responseWriter) eligible() bool {
+	header := rw.Header()
+	switch {
+	case rw.head,
+		rw.status == http.StatusNoContent,
+		rw.status == http.StatusPartialContent,
+		rw.status == http.StatusNotModified:
+		return false
+	case header.Get("Content-Encoding") != "" && header.Get("Content-Encoding") != identity:
+		// Already compressed by the backend.
+		return false
+	case header.Get("Content-Range") != "",
+		strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-transform"):
+		return false
+	}
+
+	return allowedType(header.Get("Content-Type"), rw.settings.contentTypes)
+}
+
+// writeHeader writes the header of the response, compressed or not.
+func (rw *responseWriter) writeHeader(compress bool) {
+	rw.decided = true
+	if compress {
+		header := rw.Header()
+		header.Del("Content-Length")
+		// Ranges of the uncompressed representation cannot be served.
+		header.Del("Accept-Ranges")
+		header.Set("Content-Encoding", rw.encoding)
+		// The compressed representation is not byte-for-byte the one the entity tag identifies.
+		if etag := header.Get("Etag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("Etag", "W/"+etag)
+		}
+
+		rw.encoder = getEncoder(rw.encoding, rw.ResponseWriter)
+	}
+
+	rw.ResponseWriter.WriteHeader(rw.status)
+}
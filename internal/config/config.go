@@ -27,6 +27,11 @@ type (
 		*ObservabilityConfig `json:"observability"`
 		*AdminConfig         `json:"admin"`
 		*OASConfig           `json:"oas,omitempty"`
+		*HeadersConfig       `json:"headers,omitempty"`
+		*RateLimitConfig     `json:"rateLimit,omitempty"`
+		*BodyLimitConfig     `json:"bodyLimit,omitempty"`
+		*CacheConfig         `json:"cache,omitempty"`
+		*CompressionConfig   `json:"compression,omitempty"`
 		*AuthConfig          `json:"auth,omitempty"`
 		*PersistenceConfig   `json:"persistence,omitempty"`
 	}
@@ -50,6 +55,16 @@ var (
 	schemaBytesRouter []byte
 	//go:embed schemas/oas_schema.json
 	schemaBytesOAS []byte
+	//go:embed schemas/headers_schema.json
+	schemaBytesHeaders []byte
+	//go:embed schemas/ratelimit_schema.json
+	schemaBytesRateLimit []byte
+	//go:embed schemas/bodylimit_schema.json
+	schemaBytesBodyLimit []byte
+	//go:embed schemas/cache_schema.json
+	schemaBytesCache []byte
+	//go:embed schemas/compression_schema.json
+	schemaBytesCompression []byte
 	//go:embed schemas/config_schema.json
 	schemaBytesConfig []byte
 	//go:embed schemas/persistence_schema.json
@@ -68,6 +83,26 @@ func (rc *RootConfig) OASEnabled() bool {
 	return rc.OASConfig != nil
 }
 
+func (rc *RootConfig) HeadersEnabled() bool {
+	return rc.HeadersConfig != nil
+}
+
+func (rc *RootConfig) RateLimitEnabled() bool {
+	return rc.RateLimitConfig != nil
+}
+
+func (rc *RootConfig) BodyLimitEnabled() bool {
+	return rc.BodyLimitConfig != nil
+}
+
+func (rc *RootConfig) CacheEnabled() bool {
+	return rc.CacheConfig != nil
+}
+
+func (rc *RootConfig) CompressionEnabled() bool {
+	return rc.CompressionConfig != nil
+}
+
 func New() *RootConfig {
 	return &RootConfig{
 		values: make(map[string]any),
@@ -96,6 +131,11 @@ func (rc *RootConfig) Parse() error {
 		return err
 	}
 
+	// References between sections cannot be expressed by the schema.
+	if err := rc.GatewayConfig.validate(); err != nil {
+		return err
+	}
+
 	// Free allocated memory for intermediate data structures.
 	rc.data = nil
 	rc.escapedData = nil
@@ -400,6 +440,11 @@ func (rc *RootConfig) validateSchema() error {
 		gojsonschema.NewBytesLoader(schemaBytesObservability),
 		gojsonschema.NewBytesLoader(schemaBytesRouter),
 		gojsonschema.NewBytesLoader(schemaBytesOAS),
+		gojsonschema.NewBytesLoader(schemaBytesHeaders),
+		gojsonschema.NewBytesLoader(schemaBytesRateLimit),
+		gojsonschema.NewBytesLoader(schemaBytesBodyLimit),
+		gojsonschema.NewBytesLoader(schemaBytesCache),
+		gojsonschema.NewBytesLoader(schemaBytesCompression),
 		gojsonschema.NewBytesLoader(schemaBytesPersistence),
 		gojsonschema.NewBytesLoader(schemaBytesOrigins),
 		gojsonschema.NewBytesLoader(schemaBytesCookies),
@@ -449,6 +494,18 @@ func (rc *RootConfig) postProcess() {
 	if rc.OASConfig != nil {
 		rc.OASConfig.postProcess()
 	}
+	if rc.RateLimitConfig != nil {
+		rc.RateLimitConfig.postProcess()
+	}
+	if rc.BodyLimitConfig != nil {
+		rc.BodyLimitConfig.postProcess()
+	}
+	if rc.CacheConfig != nil {
+		rc.CacheConfig.postProcess()
+	}
+	if rc.CompressionConfig != nil {
+		rc.CompressionConfig.postProcess()
+	}
 	rc.GatewayConfig.postProcess()
 	rc.ObservabilityConfig.postProcess()
 	rc.PersistenceConfig.postProcess()
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"slices"
 	"testing"
+
+	utilhttp "github.com/trebent/kerberos/internal/util/http"
 )
 
 func TestConfigBad(t *testing.T) {
@@ -74,6 +78,205 @@ func TestConfigAuth(t *testing.T) {
 			t.Fatalf("expected basic auth API origins config to be non-nil, got nil")
 		}
 	})
+
+	t.Run("Basic auth quotas", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_basic_quotas.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		quotas := cfg.AuthConfig.Methods.Basic.Quotas
+		if len(quotas) != 2 {
+			t.Fatalf("expected 2 quotas, got %d", len(quotas))
+		}
+
+		if quotas[0].Organisation != "" || quotas[0].Backend != "" || quotas[0].RequestsPerMonth != 100000 {
+			t.Errorf("unexpected default quota: %+v", quotas[0])
+		}
+
+		if quotas[1].Organisation != "acme" ||
+			quotas[1].Backend != "backend" ||
+			quotas[1].RequestsPerDay != 1000 ||
+			quotas[1].RequestsPerMonth != 20000 {
+			t.Errorf("unexpected organisation quota: %+v", quotas[1])
+		}
+	})
+
+	t.Run("Basic auth quota without limits", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_basic_quotas_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading a quota without limits, got nil")
+		}
+	})
+
+	t.Run("JWT", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_jwt.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		jwt := cfg.AuthConfig.Methods.JWT
+		if jwt.Issuer != "https://idp.example.com" ||
+			len(jwt.Algorithms) != 4 ||
+			jwt.ClockSkewMs != defaultJWTClockSkewMs ||
+			jwt.Keys.ReloadIntervalMs != defaultJWTReloadIntervalMs {
+			t.Errorf("unexpected JWT method: %+v", jwt)
+		}
+		if jwt.Claims.User != defaultJWTUserClaim ||
+			jwt.Claims.Organisation != "org" ||
+			!slices.Equal(jwt.Claims.Groups, []string{defaultJWTGroupsClaim}) {
+			t.Errorf("unexpected JWT claims: %+v", jwt.Claims)
+		}
+	})
+
+	t.Run("JWT without keys", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_jwt_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading a JWT method without key files, got nil")
+		}
+	})
+
+	t.Run("OIDC", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_oidc.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		oidc := cfg.AuthConfig.Methods.OIDC
+		if oidc.ClientID != "kerberos" ||
+			!slices.Equal(oidc.Scopes, []string{oidcScopeOpenID, "groups"}) ||
+			oidc.ClockSkewMs != defaultJWTClockSkewMs {
+			t.Errorf("unexpected OIDC method: %+v", oidc)
+		}
+		if oidc.Claims.User != defaultJWTUserClaim ||
+			oidc.Claims.Organisation != "org" ||
+			!slices.Equal(oidc.Claims.Groups, []string{defaultJWTGroupsClaim}) {
+			t.Errorf("unexpected OIDC claims: %+v", oidc.Claims)
+		}
+		if oidc.API.Cookies.SameSite != utilhttp.SameSiteLax {
+			t.Errorf("expected OIDC cookies to default to SameSite Lax, got %s", oidc.API.Cookies.SameSite)
+		}
+	})
+
+	t.Run("OIDC without redirect URL", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_oidc_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading an OIDC method without a redirect URL, got nil")
+		}
+	})
+
+	t.Run("mTLS", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_mtls.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		identities := cfg.AuthConfig.Methods.MTLS.Identities
+		if len(identities) != 2 {
+			t.Fatalf("expected 2 mTLS identities, got %d", len(identities))
+		}
+		if identities[0].URI != "spiffe://example.com/partner/*" ||
+			identities[0].Organisation != "partners" ||
+			!slices.Equal(identities[0].Groups, []string{"partner"}) {
+			t.Errorf("unexpected mTLS identity: %+v", identities[0])
+		}
+		if cfg.GatewayConfig.TLS.ClientCAFile != "/certs/clients.crt" {
+			t.Errorf("expected gateway client CA file '/certs/clients.crt', got '%s'", cfg.GatewayConfig.TLS.ClientCAFile)
+		}
+	})
+
+	t.Run("mTLS without client CA", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_mtls_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading an mTLS method without a gateway client CA, got nil")
+		}
+	})
+
+	t.Run("HMAC", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_hmac.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		hmac := cfg.AuthConfig.Methods.HMAC
+		if hmac.Header != defaultHMACHeader ||
+			!slices.Equal(hmac.SignedHeaders, []string{"Content-Type"}) ||
+			hmac.MaxClockSkewMs != defaultHMACMaxClockSkewMs ||
+			hmac.NonceCacheSize != defaultHMACNonceCacheSize ||
+			hmac.MaxBodyBytes != defaultHMACMaxBodyBytes {
+			t.Errorf("unexpected HMAC method: %+v", hmac)
+		}
+		if len(hmac.Organisations) != 1 || len(hmac.Organisations[0].Secrets) != 2 {
+			t.Errorf("unexpected HMAC organisations: %+v", hmac.Organisations)
+		}
+	})
+
+	t.Run("HMAC with three secrets", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_auth_hmac_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading an HMAC organisation with three secrets, got nil")
+		}
+	})
 }
 
 func TestConfigAdmin(t *testing.T) {
@@ -222,6 +425,216 @@ func TestConfigOAS(t *testing.T) {
 	}
 }
 
+func TestConfigHeaders(t *testing.T) {
+	data, err := os.ReadFile("./testconfig/testconfig_headers.json")
+	if err != nil {
+		t.Fatalf("failed to read test config: %v", err)
+	}
+
+	cfg := New()
+	cfg.Load(data)
+	if err := cfg.Parse(); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if !cfg.HeadersEnabled() {
+		t.Fatal("expected headers to be enabled")
+	}
+
+	if cfg.HeadersConfig.Order != 3 {
+		t.Errorf("expected headers order to be 3, got %d", cfg.HeadersConfig.Order)
+	}
+
+	mapping := cfg.HeadersConfig.Mappings[0]
+	if mapping.Request.Set["X-Org"] != "{{ .OrgID }}" {
+		t.Errorf("expected request set X-Org to be '{{ .OrgID }}', got '%s'", mapping.Request.Set["X-Org"])
+	}
+
+	if len(mapping.Response.Remove) != 1 || mapping.Response.Remove[0] != "X-Debug-*" {
+		t.Errorf("expected response remove to be [X-Debug-*], got %v", mapping.Response.Remove)
+	}
+}
+
+func TestConfigRateLimit(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_ratelimit.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		if !cfg.RateLimitEnabled() {
+			t.Fatal("expected rate limiting to be enabled")
+		}
+
+		org := cfg.RateLimitConfig.Policies[0]
+		if org.Key != RateLimitKeyOrg || org.PeriodMs != 60000 || org.Burst != 100 {
+			t.Errorf("unexpected org policy: %+v", org)
+		}
+
+		ip := cfg.RateLimitConfig.Policies[1]
+		if ip.Key != RateLimitKeyIP || ip.PeriodMs != defaultRateLimitPeriodMs || ip.Burst != 20 {
+			t.Errorf("unexpected ip policy: %+v", ip)
+		}
+	})
+
+	t.Run("Header key without header", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_ratelimit_header_missing.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with a header key without header, got nil")
+		}
+	})
+}
+
+func TestConfigBodyLimit(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_bodylimit.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		if !cfg.BodyLimitEnabled() {
+			t.Fatal("expected body limits to be enabled")
+		}
+
+		bl := cfg.BodyLimitConfig
+		if *bl.MaxRequestBytes != 1048576 || *bl.MaxResponseBytes != 0 {
+			t.Errorf("unexpected global limits: %d, %d", *bl.MaxRequestBytes, *bl.MaxResponseBytes)
+		}
+
+		mapping := bl.Mappings[0]
+		if mapping.MaxRequestBytes != nil || *mapping.MaxResponseBytes != 10485760 {
+			t.Errorf("unexpected mapping limits: %+v", mapping.BodyLimits)
+		}
+
+		path := mapping.Paths[0]
+		if path.Path != "/uploads/*" ||
+			path.MaxRequestBytes == nil ||
+			*path.MaxRequestBytes != 0 ||
+			path.MaxResponseBytes != nil {
+			t.Errorf("unexpected path limits: %+v", path)
+		}
+	})
+
+	t.Run("Negative limit", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_bodylimit_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with a negative limit, got nil")
+		}
+	})
+}
+
+func TestConfigCache(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_cache.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		if !cfg.CacheEnabled() {
+			t.Fatal("expected the cache to be enabled")
+		}
+
+		cc := cfg.CacheConfig
+		if cc.MaxEntries != 1000 || cc.MaxBytes != 524288 || cc.MaxEntryBytes != 524288 {
+			t.Errorf("unexpected cache bounds: %d, %d, %d", cc.MaxEntries, cc.MaxBytes, cc.MaxEntryBytes)
+		}
+		if cc.Persistent == nil || cc.Persistent.MaxEntries != 10000 {
+			t.Errorf("unexpected persistent tier: %+v", cc.Persistent)
+		}
+		if cc.Mappings[0].Backend != "backend1" || cc.Mappings[0].DefaultTTLMs != 30000 {
+			t.Errorf("unexpected mapping: %+v", cc.Mappings[0])
+		}
+	})
+
+	t.Run("Zero max entries", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_cache_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with zero max entries, got nil")
+		}
+	})
+}
+
+func TestConfigCompression(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_compression.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		if !cfg.CompressionEnabled() {
+			t.Fatal("expected compression to be enabled")
+		}
+
+		cc := cfg.CompressionConfig
+		if !slices.Equal(cc.Encodings, []string{EncodingZstd, EncodingGzip, EncodingDeflate}) {
+			t.Errorf("unexpected default encodings: %v", cc.Encodings)
+		}
+		if *cc.MinSize != 1024 || !slices.Contains(cc.ContentTypes, "application/json") {
+			t.Errorf("unexpected global settings: %d, %v", *cc.MinSize, cc.ContentTypes)
+		}
+
+		mapping := cc.Mappings[0]
+		if *mapping.MinSize != 256 || mapping.ContentTypes == nil || len(mapping.ContentTypes) != 0 {
+			t.Errorf("unexpected mapping settings: %+v", mapping.CompressionSettings)
+		}
+	})
+
+	t.Run("Unsupported encoding", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_compression_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with an unsupported encoding, got nil")
+		}
+	})
+}
+
 func TestConfigPersistence(t *testing.T) {
 	t.Run("Postgres happy", func(t *testing.T) {
 		data, err := os.ReadFile("./testconfig/testconfig_persistence.json")
@@ -459,6 +872,385 @@ func TestConfigGateway(t *testing.T) {
 		}
 	})
 
+	t.Run("Routes", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_routes.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		if len(cfg.GatewayConfig.Router.Routes) != 2 {
+			t.Fatalf("expected 2 routes, got %d", len(cfg.GatewayConfig.Router.Routes))
+		}
+
+		route := cfg.GatewayConfig.Router.Routes[0]
+		if route.Backend != "orders" || route.Host != "shop.example.com" || route.PathPrefix != "/api/orders" {
+			t.Errorf("unexpected route: %+v", route)
+		}
+
+		if len(route.Methods) != 2 {
+			t.Errorf("expected route to have 2 methods, got %d", len(route.Methods))
+		}
+
+		// A trailing "/*" is removed.
+		if route := cfg.GatewayConfig.Router.Routes[1]; route.PathPrefix != "/orders" {
+			t.Errorf("expected path prefix /orders, got %s", route.PathPrefix)
+		}
+	})
+
+	t.Run("Routes with wildcard inside path prefix", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_routes_wildcard.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with a wildcard inside a path prefix, got nil")
+		}
+	})
+
+	t.Run("Routes without host or path prefix", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_routes_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with a route lacking host and path prefix, got nil")
+		}
+	})
+
+	t.Run("Routes with unknown backend", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_routes_unknown_backend.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); !errors.Is(err, errUnknownBackend) {
+			t.Fatalf("expected an unknown backend error, got %v", err)
+		}
+	})
+
+	t.Run("Targets", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_targets.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		orders := cfg.GatewayConfig.Router.Backends[0]
+		if len(orders.GetTargets()) != 2 {
+			t.Fatalf("expected 2 targets, got %d", len(orders.GetTargets()))
+		}
+
+		if orders.LoadBalancing.Strategy != LoadBalancingConsistentHash ||
+			orders.LoadBalancing.Hash.Header != "X-User" {
+			t.Errorf("unexpected load balancing: %+v", orders.LoadBalancing)
+		}
+
+		users := cfg.GatewayConfig.Router.Backends[1]
+		if len(users.GetTargets()) != 1 || users.GetTargets()[0].Host != "users" {
+			t.Errorf("expected host and port to form the only target, got %+v", users.GetTargets())
+		}
+
+		if users.LoadBalancing.Strategy != LoadBalancingRoundRobin {
+			t.Errorf("expected default strategy %s, got %s", LoadBalancingRoundRobin, users.LoadBalancing.Strategy)
+		}
+	})
+
+	t.Run("Targets combined with host and port", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_targets_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with both targets and host/port, got nil")
+		}
+	})
+
+	t.Run("Discovery", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_discovery.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		orders := cfg.GatewayConfig.Router.Backends[0]
+		if orders.Discovery.DNS.Name != "_http._tcp.orders.service.local" ||
+			orders.Discovery.IntervalMs != defaultDiscoveryIntervalMs {
+			t.Errorf("unexpected discovery: %+v", orders.Discovery)
+		}
+
+		if len(orders.GetTargets()) != 0 {
+			t.Errorf("expected no static targets, got %+v", orders.GetTargets())
+		}
+
+		users := cfg.GatewayConfig.Router.Backends[1]
+		if users.Discovery.File.Path != "/etc/kerberos/users.json" || users.Discovery.IntervalMs != 2000 {
+			t.Errorf("unexpected discovery: %+v", users.Discovery)
+		}
+	})
+
+	t.Run("Discovery combined with host and port", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_discovery_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with both discovery and host/port, got nil")
+		}
+	})
+
+	t.Run("Resilience defaults", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_healthcheck.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		hc := cfg.GatewayConfig.Router.Backends[0].HealthCheck
+		if hc.Active.IntervalMs != defaultHealthCheckIntervalMs ||
+			hc.Active.TimeoutMs != defaultHealthCheckTimeoutMs ||
+			hc.Active.HealthyThreshold != defaultHealthCheckHealthyThreshold ||
+			hc.Active.UnhealthyThreshold != defaultHealthCheckUnhealthyThreshold {
+			t.Errorf("unexpected active health check: %+v", hc.Active)
+		}
+
+		if hc.Passive.ConsecutiveFailures != defaultHealthCheckConsecutiveFailures ||
+			hc.Passive.Window != defaultHealthCheckWindow ||
+			hc.Passive.EjectionMs != defaultHealthCheckEjectionMs ||
+			hc.Passive.FailureRatio != 0.5 {
+			t.Errorf("unexpected passive health check: %+v", hc.Passive)
+		}
+
+		cb := cfg.GatewayConfig.Router.Backends[0].CircuitBreaker
+		if cb.ConsecutiveFailures != 3 ||
+			cb.Window != defaultCircuitBreakerWindow ||
+			cb.CooldownMs != defaultCircuitBreakerCooldownMs ||
+			cb.HalfOpenRequests != defaultCircuitBreakerHalfOpenRequests {
+			t.Errorf("unexpected circuit breaker: %+v", cb)
+		}
+
+		concurrency := cfg.GatewayConfig.Router.Backends[0].Concurrency
+		if concurrency.MaxInFlight != 100 ||
+			concurrency.MaxQueue != 50 ||
+			concurrency.QueueTimeoutMs != defaultConcurrencyQueueTimeoutMs ||
+			concurrency.Adaptive.MinLimit != defaultAdaptiveMinLimit ||
+			concurrency.Adaptive.Tolerance != defaultAdaptiveTolerance ||
+			concurrency.Adaptive.Backoff != defaultAdaptiveBackoff {
+			t.Errorf("unexpected concurrency limit: %+v", concurrency)
+		}
+
+		retry := cfg.GatewayConfig.Router.Backends[0].Retry
+		if retry.MaxAttempts != 3 ||
+			retry.BackoffMs != defaultRetryBackoffMs ||
+			retry.MaxBackoffMs != defaultRetryMaxBackoffMs ||
+			retry.MaxBufferBytes != defaultRetryMaxBufferBytes ||
+			len(retry.RetryOn) != 4 {
+			t.Errorf("unexpected retry policy: %+v", retry)
+		}
+
+		streaming := cfg.GatewayConfig.Router.Backends[0].Streaming
+		if streaming.Enabled || streaming.IdleTimeoutMs != defaultStreamingIdleTimeoutMs {
+			t.Errorf("unexpected streaming: %+v", streaming)
+		}
+	})
+
+	t.Run("Concurrency limit without max in-flight", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_concurrency_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config without maxInFlight, got nil")
+		}
+	})
+
+	t.Run("Rewrite", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_rewrite.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		rewrite := cfg.GatewayConfig.Router.Backends[0].Rewrite
+		if rewrite.StripPrefix != "/api" || rewrite.AddPrefix != "/v2" || len(rewrite.Replace) != 1 {
+			t.Errorf("unexpected rewrite: %+v", rewrite)
+		}
+
+		if rewrite.Query.Add["source"] != "kerberos" || len(rewrite.Query.Remove) != 1 {
+			t.Errorf("unexpected query rewrite: %+v", rewrite.Query)
+		}
+	})
+
+	t.Run("Mirror", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_mirror.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		mirror := cfg.GatewayConfig.Router.Backends[0].Mirror
+		if mirror.Host != "orders-next" ||
+			mirror.TLS == nil ||
+			mirror.Percentage != 12.5 ||
+			mirror.TimeoutMs != 2000 ||
+			mirror.MaxBodyBytes != defaultMirrorMaxBodyBytes ||
+			mirror.MaxInFlight != defaultMirrorMaxInFlight {
+			t.Errorf("unexpected mirror: %+v", mirror)
+		}
+	})
+
+	t.Run("Mirror without percentage", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_mirror_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with a zero mirror percentage, got nil")
+		}
+	})
+
+	t.Run("Splits", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_splits.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		splits := cfg.GatewayConfig.Router.Splits
+		if len(splits) != 1 || splits[0].Backend != "orders" || len(splits[0].Variants) != 1 {
+			t.Fatalf("unexpected splits: %+v", splits)
+		}
+
+		variant := splits[0].Variants[0]
+		if variant.Backend != "orders-canary" ||
+			variant.Weight != 5 ||
+			variant.Headers["X-Canary"] != "true" ||
+			variant.Cookies["canary"] != "1" {
+			t.Errorf("unexpected variant: %+v", variant)
+		}
+	})
+
+	t.Run("Splits without weight or matchers", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_splits_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with a variant that matches nothing, got nil")
+		}
+	})
+
+	t.Run("Proxy headers", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_proxy_headers.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		ph := cfg.GatewayConfig.ProxyHeaders
+		if !ph.XForwarded || ph.Forwarded || !ph.XRealIP || len(ph.TrustedProxies) != 1 {
+			t.Errorf("unexpected proxy headers: %+v", ph)
+		}
+	})
+
+	t.Run("Protocol", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_protocol.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		if !cfg.GatewayConfig.H2C {
+			t.Error("expected h2c to be enabled")
+		}
+
+		backends := cfg.GatewayConfig.Router.Backends
+		if backends[0].Protocol != ProtocolHTTP1 || backends[1].Protocol != ProtocolGRPC {
+			t.Errorf("unexpected protocols: %s, %s", backends[0].Protocol, backends[1].Protocol)
+		}
+	})
+
+	t.Run("Protocol h2 without TLS", func(t *testing.T) {
+		data, err := os.ReadFile("./testconfig/testconfig_gw_router_protocol_invalid.json")
+		if err != nil {
+			t.Fatalf("failed to read test config: %v", err)
+		}
+
+		cfg := New()
+		cfg.Load(data)
+		if err := cfg.Parse(); err == nil {
+			t.Fatalf("expected error when loading config with protocol h2 without tls, got nil")
+		}
+	})
+
 	t.Run("Origins misconfigured", func(t *testing.T) {
 		data, err := os.ReadFile("./testconfig/testconfig_gw_router_origins_invalid.json")
 		if err != nil {
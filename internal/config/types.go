@@ -1,6 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
 	utilhttp "github.com/trebent/kerberos/internal/util/http"
 )
 
@@ -19,25 +24,382 @@ type (
 		ValidateBody bool `json:"validateBody"`
 	}
 
+	// HeadersConfig holds configuration for per-backend request and response header
+	// transformations.
+	HeadersConfig struct {
+		Order    int                      `json:"order"`
+		Mappings []*HeadersBackendMapping `json:"mappings"`
+	}
+	HeadersBackendMapping struct {
+		Backend  string       `json:"backend"`
+		Request  *HeaderRules `json:"request,omitempty"`
+		Response *HeaderRules `json:"response,omitempty"`
+	}
+	// HeaderRules are applied in the order Remove, Rename, Set, Add. Values of Set and Add are Go
+	// templates, see docs/configuration.md for the available fields.
+	HeaderRules struct {
+		// Remove lists header names to remove, a trailing "*" matches any header with that prefix.
+		Remove []string `json:"remove,omitempty"`
+		// Rename maps existing header names to new header names.
+		Rename map[string]string `json:"rename,omitempty"`
+		// Set replaces any existing values of a header.
+		Set map[string]string `json:"set,omitempty"`
+		// Add appends a value to a header.
+		Add map[string]string `json:"add,omitempty"`
+	}
+
+	// RateLimitConfig holds configuration for per-backend token bucket rate limiting.
+	RateLimitConfig struct {
+		Order    int                `json:"order"`
+		Policies []*RateLimitPolicy `json:"policies"`
+	}
+	// RateLimitPolicy is a token bucket holding Burst tokens, refilled with Requests tokens per
+	// PeriodMs. Every request takes a token from the bucket of its key.
+	RateLimitPolicy struct {
+		Backend string `json:"backend"`
+		// Key is one of the RateLimitKey* constants and selects what a bucket is kept for.
+		Key string `json:"key"`
+		// Header is the header keying buckets when Key is RateLimitKeyHeader.
+		Header   string `json:"header,omitempty"`
+		Requests int    `json:"requests"`
+		PeriodMs int    `json:"period,omitempty"`
+		Burst    int    `json:"burst,omitempty"`
+	}
+
+	// BodyLimitConfig holds configuration for request and response body size limits. The limits of
+	// a mapping replace the global limits for its backend, the limits of a path replace those of its
+	// mapping.
+	BodyLimitConfig struct {
+		Order int `json:"order"`
+		BodyLimits
+		Mappings []*BodyLimitMapping `json:"mappings,omitempty"`
+	}
+	// BodyLimits holds body size limits in bytes. Omitted limits are inherited, zero is unlimited.
+	BodyLimits struct {
+		MaxRequestBytes  *int64 `json:"maxRequestBytes,omitempty"`
+		MaxResponseBytes *int64 `json:"maxResponseBytes,omitempty"`
+	}
+	BodyLimitMapping struct {
+		Backend string `json:"backend"`
+		BodyLimits
+		// Paths are matched in order against the request path, the first match applies.
+		Paths []*BodyLimitPath `json:"paths,omitempty"`
+	}
+	BodyLimitPath struct {
+		// Path is a path.Match pattern, e.g. "/uploads/*".
+		Path string `json:"path"`
+		BodyLimits
+	}
+
+	// CacheConfig holds configuration for the HTTP response cache. Only the responses of mapped
+	// backends are cached.
+	CacheConfig struct {
+		Order         int   `json:"order"`
+		MaxEntries    int   `json:"maxEntries,omitempty"`
+		MaxBytes      int64 `json:"maxBytes,omitempty"`
+		MaxEntryBytes int64 `json:"maxEntryBytes,omitempty"`
+		// Persistent keeps cached responses in the database as a second tier behind the in-memory
+		// cache, when set.
+		Persistent *CachePersistence `json:"persistent,omitempty"`
+		Mappings   []*CacheMapping   `json:"mappings"`
+	}
+	CachePersistence struct {
+		MaxEntries int `json:"maxEntries,omitempty"`
+	}
+	CacheMapping struct {
+		Backend string `json:"backend"`
+		// DefaultTTLMs is how long responses without explicit freshness information are fresh, zero
+		// means that they are not cached.
+		DefaultTTLMs int `json:"defaultTtl,omitempty"`
+	}
+
+	// CompressionConfig holds configuration for response compression. The settings of a mapping
+	// replace the global settings for its backend.
+	CompressionConfig struct {
+		Order int `json:"order"`
+		// Encodings are the supported content codings, in order of preference.
+		Encodings []string `json:"encodings,omitempty"`
+		CompressionSettings
+		Mappings []*CompressionMapping `json:"mappings,omitempty"`
+	}
+	// CompressionSettings holds when responses are compressed. Omitted settings are inherited.
+	CompressionSettings struct {
+		// MinSize is the smallest response body in bytes that is compressed.
+		MinSize *int64 `json:"minSize,omitempty"`
+		// ContentTypes are the compressed media types, either exact or as "type/*".
+		ContentTypes []string `json:"contentTypes,omitempty"`
+	}
+	CompressionMapping struct {
+		Backend string `json:"backend"`
+		CompressionSettings
+	}
+
 	// GatewayConfig holds configuration for the API gateway.
 	GatewayConfig struct {
-		Router *Router    `json:"router"`
-		TLS    *ServerTLS `json:"tls,omitempty"`
+		Router *Router     `json:"router"`
+		TLS    *GatewayTLS `json:"tls,omitempty"`
+		// ProxyHeaders controls the proxy headers set on forwarded requests.
+		ProxyHeaders *ProxyHeaders `json:"proxyHeaders,omitempty"`
+		// H2C accepts HTTP/2 without TLS (prior knowledge) on the gateway listener.
+		H2C bool `json:"h2c,omitempty"`
+	}
+	// ProxyHeaders selects the proxy headers set on forwarded requests. Inbound proxy headers are
+	// only kept and extended when the request comes from one of the TrustedProxies.
+	ProxyHeaders struct {
+		// XForwarded sets X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Host.
+		XForwarded bool `json:"xForwarded,omitempty"`
+		// Forwarded sets the RFC 7239 Forwarded header.
+		Forwarded bool `json:"forwarded,omitempty"`
+		// XRealIP sets X-Real-IP to the client address.
+		XRealIP bool `json:"xRealIP,omitempty"`
+		// TrustedProxies holds the IP addresses and CIDR ranges of trusted proxies.
+		TrustedProxies []string `json:"trustedProxies,omitempty"`
 	}
 
 	// Router holds configuration for the request router.
 	Router struct {
 		Backends []*RouterBackend `json:"backends"`
+		// Routes map requests onto backends based on the Host header, path prefix and method, in
+		// addition to the legacy /gw/backend/<name>/ prefix which is always available.
+		Routes []*RouterRoute `json:"routes,omitempty"`
+		// Splits route a share of the requests of a backend to alternate backends.
+		Splits []*TrafficSplit `json:"splits,omitempty"`
+	}
+	// RouterRoute is a rule mapping matching requests onto a RouterBackend. At least one of Host and
+	// PathPrefix must be set.
+	RouterRoute struct {
+		// Backend is the name of the RouterBackend matching requests are routed to.
+		Backend string `json:"backend"`
+		// Host matches the request Host header, ignoring port and case. A leading "*." matches any
+		// subdomain.
+		Host string `json:"host,omitempty"`
+		// PathPrefix matches request paths on segment boundaries, i.e. "/api/orders" matches
+		// "/api/orders" and "/api/orders/1" but not "/api/ordersx". A trailing "/*" is accepted and
+		// removed when the configuration is loaded, "/api/orders/*" being the same as "/api/orders".
+		PathPrefix string `json:"pathPrefix,omitempty"`
+		// Methods restricts the route to the given HTTP methods. Empty matches all methods.
+		Methods []string `json:"methods,omitempty"`
+	}
+	// TrafficSplit routes the requests of Backend to its variants. Requests matching the headers and
+	// cookies of a variant are routed to it, a weighted share of the remaining requests is routed to
+	// each variant and the rest stay on Backend.
+	TrafficSplit struct {
+		// Backend is the name of the RouterBackend whose requests are split.
+		Backend  string          `json:"backend"`
+		Variants []*SplitVariant `json:"variants"`
+	}
+	// SplitVariant is an alternate RouterBackend of a TrafficSplit.
+	SplitVariant struct {
+		// Backend is the name of the RouterBackend requests are routed to.
+		Backend string `json:"backend"`
+		// Weight is the percentage of the requests not matched by any variant that are routed to
+		// the variant.
+		Weight float64 `json:"weight,omitempty"`
+		// Headers route all requests with these header values to the variant.
+		Headers map[string]string `json:"headers,omitempty"`
+		// Cookies route all requests with these cookie values to the variant, in addition to
+		// Headers.
+		Cookies map[string]string `json:"cookies,omitempty"`
 	}
 	RouterBackend struct {
 		Name      string `json:"name"`
-		Host      string `json:"host"`
-		Port      int    `json:"port"`
+		Host      string `json:"host,omitempty"`
+		Port      int    `json:"port,omitempty"`
 		TimeoutMs int    `json:"timeout,omitempty"`
+		// Protocol is one of the Protocol* constants and selects the HTTP version spoken to the
+		// backend.
+		Protocol string `json:"protocol,omitempty"`
 		// Origins holds configuration for CORS origins. In addition, Origins other than the allowed ones
 		// will be rejected with a 403 response.
 		Origins *Origins    `json:"origins,omitempty"`
 		TLS     *BackendTLS `json:"tls,omitempty"`
+		// Targets are the upstream instances of the backend. When omitted, Host and Port form the
+		// only target.
+		Targets []*BackendTarget `json:"targets,omitempty"`
+		// Discovery resolves the targets of the backend at runtime, instead of Host and Port or
+		// Targets.
+		Discovery *Discovery `json:"discovery,omitempty"`
+		// LoadBalancing selects how a target is picked for each request.
+		LoadBalancing *LoadBalancing `json:"loadBalancing,omitempty"`
+		// HealthCheck enables active and/or passive health checking of the backend targets.
+		HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+		// CircuitBreaker enables failing fast while the backend is failing.
+		CircuitBreaker *CircuitBreaker `json:"circuitBreaker,omitempty"`
+		// Concurrency limits the number of requests forwarded to the backend at the same time.
+		Concurrency *ConcurrencyLimit `json:"concurrency,omitempty"`
+		// Retry enables retrying failed requests.
+		Retry *RetryPolicy `json:"retry,omitempty"`
+		// Rewrite rewrites the path and query of requests before they are forwarded.
+		Rewrite *Rewrite `json:"rewrite,omitempty"`
+		// Streaming controls how streamed responses are forwarded.
+		Streaming *Streaming `json:"streaming,omitempty"`
+		// Mirror sends copies of requests to a shadow backend.
+		Mirror *Mirror `json:"mirror,omitempty"`
+	}
+	// BackendTarget is a single upstream instance of a backend.
+	BackendTarget struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	// Discovery holds the target discovery settings of a backend, exactly one of DNS and File is
+	// set. The targets are refreshed every IntervalMs without restarting the gateway.
+	Discovery struct {
+		DNS        *DNSDiscovery  `json:"dns,omitempty"`
+		File       *FileDiscovery `json:"file,omitempty"`
+		IntervalMs int            `json:"interval,omitempty"`
+	}
+	// DNSDiscovery resolves the targets from DNS. When Port is set the A/AAAA records of Name are
+	// resolved and combined with Port, otherwise the SRV records of Name provide hosts and ports.
+	DNSDiscovery struct {
+		Name string `json:"name"`
+		Port int    `json:"port,omitempty"`
+	}
+	// FileDiscovery reads the targets from a JSON file holding a list of BackendTarget objects. The
+	// file is read again whenever its modification time or size changes.
+	FileDiscovery struct {
+		Path string `json:"path"`
+	}
+	// LoadBalancing holds the load balancing settings of a backend.
+	LoadBalancing struct {
+		// Strategy is one of the LoadBalancing* strategy constants.
+		Strategy string `json:"strategy"`
+		// Hash selects the request value hashed by the consistent-hash strategy.
+		Hash *LoadBalancingHash `json:"hash,omitempty"`
+	}
+	// LoadBalancingHash selects the request value to hash, exactly one of Header and Cookie is set.
+	LoadBalancingHash struct {
+		Header string `json:"header,omitempty"`
+		Cookie string `json:"cookie,omitempty"`
+	}
+	// HealthCheck holds the health checking settings of a backend. Requests are only forwarded to
+	// healthy targets.
+	HealthCheck struct {
+		Active  *ActiveHealthCheck  `json:"active,omitempty"`
+		Passive *PassiveHealthCheck `json:"passive,omitempty"`
+	}
+	// ActiveHealthCheck periodically probes each target with an HTTP GET request. A 2xx or 3xx
+	// response counts as a successful probe.
+	ActiveHealthCheck struct {
+		Path       string `json:"path"`
+		IntervalMs int    `json:"interval,omitempty"`
+		TimeoutMs  int    `json:"timeout,omitempty"`
+		// HealthyThreshold is the number of consecutive successful probes for an unhealthy target
+		// to become healthy.
+		HealthyThreshold int `json:"healthyThreshold,omitempty"`
+		// UnhealthyThreshold is the number of consecutive failed probes for a healthy target to
+		// become unhealthy.
+		UnhealthyThreshold int `json:"unhealthyThreshold,omitempty"`
+	}
+	// PassiveHealthCheck ejects targets based on the outcome of forwarded requests, where connection
+	// errors and 5xx responses count as failures.
+	PassiveHealthCheck struct {
+		// ConsecutiveFailures ejects a target after this many failures in a row.
+		ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+		// FailureRatio ejects a target when the ratio of failures among the last Window requests
+		// reaches it. Zero disables ratio based ejection.
+		FailureRatio float64 `json:"failureRatio,omitempty"`
+		Window       int     `json:"window,omitempty"`
+		// EjectionMs is how long an ejected target is kept out of rotation.
+		EjectionMs int `json:"ejection,omitempty"`
+	}
+	// CircuitBreaker holds the circuit breaker settings of a backend. Connection errors and 5xx
+	// responses count as failures.
+	CircuitBreaker struct {
+		// ConsecutiveFailures opens the circuit after this many failures in a row. Zero disables
+		// consecutive failure tripping.
+		ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+		// FailureRatio opens the circuit when the ratio of failures among the last Window requests
+		// reaches it. Zero disables ratio based tripping.
+		FailureRatio float64 `json:"failureRatio,omitempty"`
+		Window       int     `json:"window,omitempty"`
+		// CooldownMs is how long the circuit stays open before admitting trial requests.
+		CooldownMs int `json:"cooldown,omitempty"`
+		// HalfOpenRequests is the number of trial requests that must succeed to close the circuit.
+		HalfOpenRequests int `json:"halfOpenRequests,omitempty"`
+	}
+	// ConcurrencyLimit holds the concurrency limit of a backend. Requests over the limit wait in a
+	// bounded queue for at most QueueTimeoutMs, requests that cannot be queued or time out are shed.
+	ConcurrencyLimit struct {
+		// MaxInFlight is the maximum number of requests forwarded to the backend at the same time.
+		// With Adaptive set it is the upper bound of the adaptive limit.
+		MaxInFlight int `json:"maxInFlight"`
+		// MaxQueue is the number of requests that may wait for a free slot. Zero sheds requests
+		// over the limit immediately.
+		MaxQueue       int `json:"maxQueue,omitempty"`
+		QueueTimeoutMs int `json:"queueTimeout,omitempty"`
+		// Adaptive adjusts the limit to the observed latency of the backend.
+		Adaptive *AdaptiveConcurrency `json:"adaptive,omitempty"`
+	}
+	// AdaptiveConcurrency adjusts the concurrency limit of a backend with additive increase and
+	// multiplicative decrease. A request that fails, or takes longer than Tolerance times the lowest
+	// recently observed latency, multiplies the limit by Backoff. Other requests raise the limit by
+	// one while at least half of it is in use.
+	AdaptiveConcurrency struct {
+		MinLimit  int     `json:"minLimit,omitempty"`
+		Tolerance float64 `json:"tolerance,omitempty"`
+		Backoff   float64 `json:"backoff,omitempty"`
+	}
+	// RetryPolicy holds the retry settings of a backend. Only idempotent requests are retried unless
+	// NonIdempotent is set.
+	RetryPolicy struct {
+		// MaxAttempts is the maximum number of attempts, including the first one.
+		MaxAttempts     int `json:"maxAttempts"`
+		PerTryTimeoutMs int `json:"perTryTimeout,omitempty"`
+		// BackoffMs is the base of the exponential backoff between attempts, MaxBackoffMs caps it.
+		// The actual wait is picked randomly between zero and the backoff.
+		BackoffMs    int `json:"backoff,omitempty"`
+		MaxBackoffMs int `json:"maxBackoff,omitempty"`
+		// RetryOn holds the RetryOn* conditions that trigger a retry.
+		RetryOn       []string `json:"retryOn,omitempty"`
+		NonIdempotent bool     `json:"nonIdempotent,omitempty"`
+		// MaxBufferBytes is the largest request body buffered for replay, requests with larger
+		// bodies are not retried.
+		MaxBufferBytes int64 `json:"maxBufferBytes,omitempty"`
+	}
+	// Rewrite holds the URL rewrite rules of a backend. The path rules are applied in field order
+	// to the escaped path, so percent-encoded segments are preserved.
+	Rewrite struct {
+		// StripPrefix is removed from paths starting with it on a segment boundary.
+		StripPrefix string `json:"stripPrefix,omitempty"`
+		// AddPrefix is prepended to the path.
+		AddPrefix string `json:"addPrefix,omitempty"`
+		// Replace holds regular expression replacements, applied in order.
+		Replace []*RewriteReplace `json:"replace,omitempty"`
+		Query   *RewriteQuery     `json:"query,omitempty"`
+	}
+	// RewriteReplace replaces all matches of Pattern in the path with Replacement, which may
+	// reference capture groups as $1 or ${name}.
+	RewriteReplace struct {
+		Pattern     string `json:"pattern"`
+		Replacement string `json:"replacement"`
+	}
+	// RewriteQuery holds the query parameter rewrite rules, Remove is applied before Add.
+	RewriteQuery struct {
+		// Add sets query parameters, replacing any existing values.
+		Add    map[string]string `json:"add,omitempty"`
+		Remove []string          `json:"remove,omitempty"`
+	}
+	// Streaming controls the forwarding of streamed responses, which are flushed on every write and
+	// bounded by IdleTimeoutMs instead of the backend timeout. Server-Sent Events are always
+	// streamed, Enabled streams every response of the backend.
+	Streaming struct {
+		Enabled       bool `json:"enabled,omitempty"`
+		IdleTimeoutMs int  `json:"idleTimeout,omitempty"`
+	}
+	// Mirror sends copies of a share of the requests of a backend to a shadow backend in the
+	// background. The responses of the shadow backend are discarded, they never reach the client.
+	Mirror struct {
+		Host string      `json:"host"`
+		Port int         `json:"port"`
+		TLS  *BackendTLS `json:"tls,omitempty"`
+		// Percentage of the requests that are mirrored.
+		Percentage float64 `json:"percentage,omitempty"`
+		// TimeoutMs bounds shadow requests, defaults to the timeout of the backend.
+		TimeoutMs int `json:"timeout,omitempty"`
+		// MaxBodyBytes is the largest request body mirrored, requests with larger bodies are not.
+		MaxBodyBytes int64 `json:"maxBodyBytes,omitempty"`
+		// MaxInFlight bounds the shadow requests in flight, requests beyond it are not mirrored.
+		MaxInFlight int `json:"maxInFlight,omitempty"`
 	}
 	// BackendTLS holds per-backend TLS settings.
 	// When nil, the forwarder uses plain HTTP for that backend.
@@ -68,6 +430,10 @@ type (
 	}
 	AuthMethods struct {
 		Basic *AuthMethodBasic `json:"basic"`
+		JWT   *AuthMethodJWT   `json:"jwt,omitempty"`
+		OIDC  *AuthMethodOIDC  `json:"oidc,omitempty"`
+		MTLS  *AuthMethodMTLS  `json:"mtls,omitempty"`
+		HMAC  *AuthMethodHMAC  `json:"hmac,omitempty"`
 	}
 	AuthScheme struct {
 		Mappings []*AuthMapping `json:"mappings"`
@@ -84,11 +450,143 @@ type (
 	}
 	AuthMethodBasic struct {
 		API *AuthMethodBasicAPI `json:"api,omitempty"`
+		// Quotas cap the number of requests organisations may make.
+		Quotas []*AuthQuota `json:"quotas,omitempty"`
+		// APIKeyHeader is a header API keys may be sent in, in addition to the Authorization
+		// header as bearer tokens.
+		APIKeyHeader string `json:"apiKeyHeader,omitempty"`
+	}
+	// AuthQuota caps the requests an organisation may make per UTC day and/or month. A quota with
+	// an Organisation replaces the quota without one for the same Backend. A quota without a
+	// Backend counts the requests to all backends.
+	AuthQuota struct {
+		Organisation     string `json:"organisation,omitempty"`
+		Backend          string `json:"backend,omitempty"`
+		RequestsPerDay   int64  `json:"requestsPerDay,omitempty"`
+		RequestsPerMonth int64  `json:"requestsPerMonth,omitempty"`
 	}
 	AuthMethodBasicAPI struct {
 		Cookies *Cookies `json:"cookies,omitempty"`
 		Origins *Origins `json:"origins,omitempty"`
 	}
+	// AuthMethodJWT authenticates requests bearing a JSON Web Token in the Authorization header.
+	AuthMethodJWT struct {
+		// Issuer is the required "iss" claim.
+		Issuer string `json:"issuer"`
+		// Audiences holds the accepted "aud" claims, a token must be issued for at least one of
+		// them.
+		Audiences []string `json:"audiences"`
+		// Algorithms holds the accepted JWTAlg* signature algorithms, defaults to all of them.
+		Algorithms []string `json:"algorithms,omitempty"`
+		// ClockSkewMs is the leeway given when checking the "exp" and "nbf" claims.
+		ClockSkewMs int      `json:"clockSkew,omitempty"`
+		Keys        *JWTKeys `json:"keys"`
+		// Claims maps the claims of a token onto the user, organisation and groups of the request.
+		Claims *JWTClaims `json:"claims,omitempty"`
+	}
+	// JWTKeys holds the files the token verification keys are loaded from, at least one of JWKSFile
+	// and PEMFiles must be set. The files are reloaded every ReloadIntervalMs.
+	JWTKeys struct {
+		JWKSFile         string   `json:"jwksFile,omitempty"`
+		PEMFiles         []string `json:"pemFiles,omitempty"`
+		ReloadIntervalMs int      `json:"reloadInterval,omitempty"`
+	}
+	// JWTClaims names the claims that identify the user of a token. Nested claims are named by
+	// their path, separated by dots, e.g. "realm_access.roles".
+	JWTClaims struct {
+		// User is set as the X-Krb-User header, defaults to "sub".
+		User string `json:"user,omitempty"`
+		// Organisation is set as the X-Krb-Org header, if the token has it.
+		Organisation string `json:"organisation,omitempty"`
+		// Groups are set as X-Krb-Groups headers and checked against the AuthZ rules of the
+		// backend, defaults to "groups". A claim holds either a list of groups or a space separated
+		// string of them.
+		Groups []string `json:"groups,omitempty"`
+	}
+	// AuthMethodOIDC logs browser users in with an OpenID Connect provider, using the
+	// authorization code flow with PKCE, and authenticates their requests with a session cookie.
+	AuthMethodOIDC struct {
+		// Issuer identifies the provider, its discovery document is served at
+		// <issuer>/.well-known/openid-configuration.
+		Issuer   string `json:"issuer"`
+		ClientID string `json:"clientId"`
+		// ClientSecret authenticates Kerberos at the token endpoint, omit it for public clients.
+		ClientSecret string `json:"clientSecret,omitempty"`
+		// RedirectURL is the URL of the callback endpoint, as registered with the provider.
+		RedirectURL string `json:"redirectUrl"`
+		// PostLogoutRedirectURL is where the provider sends users after logging them out, if set.
+		PostLogoutRedirectURL string `json:"postLogoutRedirectUrl,omitempty"`
+		// Scopes are requested when logging in, "openid" is always requested. Defaults to
+		// "openid", "profile" and "email".
+		Scopes []string `json:"scopes,omitempty"`
+		// ClockSkewMs is the leeway given when checking the "exp" and "nbf" claims of ID tokens.
+		ClockSkewMs int `json:"clockSkew,omitempty"`
+		// Claims maps the claims of ID tokens onto the user, organisation and groups of the
+		// session.
+		Claims *JWTClaims         `json:"claims,omitempty"`
+		API    *AuthMethodOIDCAPI `json:"api,omitempty"`
+	}
+	AuthMethodOIDCAPI struct {
+		Cookies *Cookies `json:"cookies,omitempty"`
+		Origins *Origins `json:"origins,omitempty"`
+	}
+	// AuthMethodMTLS authenticates requests by the client certificate verified by the gateway
+	// listener, which requires the gateway TLS clientCAFile to be set.
+	AuthMethodMTLS struct {
+		// Identities map certificates onto users, the first identity matching the certificate is
+		// used. Certificates matching no identity are denied.
+		Identities []*MTLSIdentity `json:"identities"`
+	}
+	// MTLSIdentity matches client certificates by their subject and SANs. Subject, DNS and URI are
+	// patterns as understood by path.Match, all that are set must match the certificate.
+	MTLSIdentity struct {
+		// Subject matches the distinguished name of the certificate subject, e.g.
+		// "CN=partner,O=Example".
+		Subject string `json:"subject,omitempty"`
+		// DNS matches any of the DNS SANs of the certificate.
+		DNS string `json:"dns,omitempty"`
+		// URI matches any of the URI SANs of the certificate, e.g. a SPIFFE ID.
+		URI string `json:"uri,omitempty"`
+		// User is set as the X-Krb-User header, defaults to the matched URI SAN, DNS SAN or
+		// subject, in that order.
+		User string `json:"user,omitempty"`
+		// Organisation is set as the X-Krb-Org header, if set.
+		Organisation string `json:"organisation,omitempty"`
+		// Groups are set as X-Krb-Groups headers and checked against the AuthZ rules of the
+		// backend.
+		Groups []string `json:"groups,omitempty"`
+	}
+	// AuthMethodHMAC authenticates requests signed with a secret shared with their organisation,
+	// e.g. webhooks of partners that cannot hold sessions or tokens.
+	AuthMethodHMAC struct {
+		// Header carries the signature of requests, defaults to "X-Krb-Signature".
+		Header string `json:"header,omitempty"`
+		// SignedHeaders are signed in addition to the method, path, timestamp, nonce and body.
+		SignedHeaders []string `json:"signedHeaders,omitempty"`
+		// MaxClockSkewMs is the largest difference accepted between the timestamp of a request and
+		// the clock of Kerberos.
+		MaxClockSkewMs int `json:"maxClockSkew,omitempty"`
+		// NonceCacheSize bounds the nonces remembered to reject replayed requests, requests are
+		// rejected while the cache is full.
+		NonceCacheSize int `json:"nonceCacheSize,omitempty"`
+		// MaxBodyBytes is the largest request body that is verified, larger requests are rejected.
+		MaxBodyBytes int64 `json:"maxBodyBytes,omitempty"`
+		// Organisations hold the shared secrets of each organisation.
+		Organisations []*HMACOrganisation `json:"organisations"`
+	}
+	// HMACOrganisation holds the shared secrets of an organisation. Two secrets are accepted at once
+	// so that they can be rotated without downtime.
+	HMACOrganisation struct {
+		// Organisation identifies the organisation in signatures, and is set as the X-Krb-Org
+		// header.
+		Organisation string   `json:"organisation"`
+		Secrets      []string `json:"secrets"`
+		// User is set as the X-Krb-User header, defaults to the organisation.
+		User string `json:"user,omitempty"`
+		// Groups are set as X-Krb-Groups headers and checked against the AuthZ rules of the
+		// backend.
+		Groups []string `json:"groups,omitempty"`
+	}
 
 	// AdminConfig holds configuration for the admin API.
 	AdminConfig struct {
@@ -133,6 +631,16 @@ type (
 		CertFile string `json:"serverCertFile"`
 		KeyFile  string `json:"serverKeyFile"`
 	}
+	// GatewayTLS holds TLS settings for the gateway listener. Client certificates are requested and
+	// verified against ClientCAFile when it is set, e.g. for the mTLS auth method.
+	GatewayTLS struct {
+		ServerTLS
+		// ClientCAFile is the path to a PEM-encoded CA bundle used to verify client certificates.
+		ClientCAFile string `json:"clientCAFile,omitempty"`
+		// RequireClientCert rejects TLS handshakes without a valid client certificate, by default
+		// clients without one are let through to be handled by the auth methods.
+		RequireClientCert bool `json:"requireClientCert,omitempty"`
+	}
 
 	// PersistenceConfig holds configuration for the backing database.
 	PersistenceConfig struct {
@@ -156,7 +664,103 @@ type (
 	}
 )
 
-const defaultCalloutTimeoutMs = 5000
+const (
+	defaultCalloutTimeoutMs = 5000
+
+	defaultHealthCheckIntervalMs          = 10000
+	defaultHealthCheckTimeoutMs           = 2000
+	defaultHealthCheckHealthyThreshold    = 2
+	defaultHealthCheckUnhealthyThreshold  = 3
+	defaultHealthCheckConsecutiveFailures = 5
+	defaultHealthCheckWindow              = 20
+	defaultHealthCheckEjectionMs          = 30000
+
+	defaultCircuitBreakerWindow           = 20
+	defaultCircuitBreakerCooldownMs       = 30000
+	defaultCircuitBreakerHalfOpenRequests = 1
+
+	defaultConcurrencyQueueTimeoutMs = 1000
+	defaultAdaptiveMinLimit          = 1
+	defaultAdaptiveTolerance         = 2.0
+	defaultAdaptiveBackoff           = 0.9
+
+	defaultRetryBackoffMs      = 25
+	defaultRetryMaxBackoffMs   = 250
+	defaultRetryMaxBufferBytes = 64 * 1024
+
+	defaultStreamingIdleTimeoutMs = 60000
+
+	defaultMirrorPercentage   = 100
+	defaultMirrorMaxBodyBytes = 64 * 1024
+	defaultMirrorMaxInFlight  = 100
+
+	defaultDiscoveryIntervalMs = 10000
+
+	// RateLimitKeyBackend keeps a single bucket for the backend.
+	RateLimitKeyBackend = "backend"
+	// RateLimitKeyIP keeps a bucket per client IP address.
+	RateLimitKeyIP = "ip"
+	// RateLimitKeyOrg keeps a bucket per authenticated organisation, read from X-Krb-Org.
+	RateLimitKeyOrg = "org"
+	// RateLimitKeyUser keeps a bucket per authenticated user, read from X-Krb-User.
+	RateLimitKeyUser = "user"
+	// RateLimitKeyHeader keeps a bucket per value of RateLimitPolicy.Header.
+	RateLimitKeyHeader = "header"
+
+	defaultRateLimitPeriodMs = 1000
+
+	defaultCacheMaxEntries           = 1000
+	defaultCacheMaxBytes             = 64 << 20
+	defaultCacheMaxEntryBytes        = 1 << 20
+	defaultCachePersistentMaxEntries = 10000
+
+	defaultCompressionMinSize = 1024
+
+	defaultJWTClockSkewMs      = 60000
+	defaultJWTReloadIntervalMs = 60000
+	defaultJWTUserClaim        = "sub"
+	defaultJWTGroupsClaim      = "groups"
+
+	oidcScopeOpenID = "openid"
+
+	defaultHMACHeader         = "X-Krb-Signature"
+	defaultHMACMaxClockSkewMs = 300000
+	defaultHMACNonceCacheSize = 100000
+	defaultHMACMaxBodyBytes   = 1 << 20
+
+	// JWTAlgRS256, JWTAlgES256, JWTAlgEdDSA and JWTAlgHS256 are the supported token signature
+	// algorithms.
+	JWTAlgRS256 = "RS256"
+	JWTAlgES256 = "ES256"
+	JWTAlgEdDSA = "EdDSA"
+	JWTAlgHS256 = "HS256"
+
+	// EncodingGzip, EncodingDeflate and EncodingZstd are the supported compression content codings.
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
+
+	// ProtocolHTTP1 is HTTP/1.1, the default.
+	ProtocolHTTP1 = "http1"
+	// ProtocolH2 is HTTP/2 over TLS.
+	ProtocolH2 = "h2"
+	// ProtocolH2C is HTTP/2 without TLS (prior knowledge).
+	ProtocolH2C = "h2c"
+	// ProtocolGRPC is HTTP/2, over TLS when the backend has TLS configured, with responses
+	// forwarded as streams.
+	ProtocolGRPC = "grpc"
+
+	LoadBalancingRoundRobin       = "round-robin"
+	LoadBalancingLeastOutstanding = "least-outstanding"
+	LoadBalancingRandomTwoChoices = "random-two-choices"
+	LoadBalancingConsistentHash   = "consistent-hash"
+
+	RetryOnConnectError       = "connect-error"
+	RetryOnTimeout            = "timeout"
+	RetryOnBadGateway         = "502"
+	RetryOnServiceUnavailable = "503"
+	RetryOnGatewayTimeout     = "504"
+)
 
 func newAdminConfig() *AdminConfig {
 	return &AdminConfig{
@@ -205,18 +809,332 @@ func (ac *AuthConfig) postProcess() {
 	if ac.Methods.Basic != nil && ac.Methods.Basic.API.Origins == nil {
 		ac.Methods.Basic.API.Origins = &Origins{}
 	}
+
+	if ac.Methods.JWT != nil {
+		ac.Methods.JWT.postProcess()
+	}
+
+	if ac.Methods.OIDC != nil {
+		ac.Methods.OIDC.postProcess()
+	}
+
+	if ac.Methods.HMAC != nil {
+		ac.Methods.HMAC.postProcess()
+	}
+}
+
+func (j *AuthMethodJWT) postProcess() {
+	if len(j.Algorithms) == 0 {
+		j.Algorithms = []string{JWTAlgRS256, JWTAlgES256, JWTAlgEdDSA, JWTAlgHS256}
+	}
+	if j.ClockSkewMs == 0 {
+		j.ClockSkewMs = defaultJWTClockSkewMs
+	}
+	if j.Keys.ReloadIntervalMs == 0 {
+		j.Keys.ReloadIntervalMs = defaultJWTReloadIntervalMs
+	}
+
+	if j.Claims == nil {
+		j.Claims = &JWTClaims{}
+	}
+	if j.Claims.User == "" {
+		j.Claims.User = defaultJWTUserClaim
+	}
+	if len(j.Claims.Groups) == 0 {
+		j.Claims.Groups = []string{defaultJWTGroupsClaim}
+	}
+}
+
+func (o *AuthMethodOIDC) postProcess() {
+	if len(o.Scopes) == 0 {
+		o.Scopes = []string{oidcScopeOpenID, "profile", "email"}
+	}
+	if !slices.Contains(o.Scopes, oidcScopeOpenID) {
+		o.Scopes = append([]string{oidcScopeOpenID}, o.Scopes...)
+	}
+	if o.ClockSkewMs == 0 {
+		o.ClockSkewMs = defaultJWTClockSkewMs
+	}
+
+	if o.Claims == nil {
+		o.Claims = &JWTClaims{}
+	}
+	if o.Claims.User == "" {
+		o.Claims.User = defaultJWTUserClaim
+	}
+	if len(o.Claims.Groups) == 0 {
+		o.Claims.Groups = []string{defaultJWTGroupsClaim}
+	}
+
+	if o.API == nil {
+		o.API = &AuthMethodOIDCAPI{}
+	}
+	// The provider redirects users back to the callback endpoint, so the session cookies must be
+	// sent on cross-site navigations by default.
+	if o.API.Cookies == nil {
+		o.API.Cookies = &Cookies{SameSite: utilhttp.SameSiteLax}
+	}
+	if o.API.Origins == nil {
+		o.API.Origins = &Origins{}
+	}
+}
+
+func (h *AuthMethodHMAC) postProcess() {
+	if h.Header == "" {
+		h.Header = defaultHMACHeader
+	}
+	if h.MaxClockSkewMs == 0 {
+		h.MaxClockSkewMs = defaultHMACMaxClockSkewMs
+	}
+	if h.NonceCacheSize == 0 {
+		h.NonceCacheSize = defaultHMACNonceCacheSize
+	}
+	if h.MaxBodyBytes == 0 {
+		h.MaxBodyBytes = defaultHMACMaxBodyBytes
+	}
+}
+
+// errUnknownBackend is returned when a configuration section refers to a backend that is not
+// configured.
+var errUnknownBackend = errors.New("unknown backend")
+
+// validate checks that the routes refer to configured backends.
+func (gc *GatewayConfig) validate() error {
+	backends := make(map[string]bool, len(gc.Router.Backends))
+	for _, b := range gc.Router.Backends {
+		backends[b.Name] = true
+	}
+
+	for i, r := range gc.Router.Routes {
+		if !backends[r.Backend] {
+			return fmt.Errorf("%w %q in gateway route %d", errUnknownBackend, r.Backend, i)
+		}
+	}
+
+	return nil
 }
 
 func (gc *GatewayConfig) postProcess() {
+	for _, r := range gc.Router.Routes {
+		r.PathPrefix = strings.TrimSuffix(r.PathPrefix, "/*")
+	}
+
 	for _, b := range gc.Router.Backends {
 		if b.TimeoutMs == 0 {
 			b.TimeoutMs = defaultCalloutTimeoutMs
 		}
+
+		if b.Protocol == "" {
+			b.Protocol = ProtocolHTTP1
+		}
+
+		if b.LoadBalancing == nil {
+			b.LoadBalancing = &LoadBalancing{Strategy: LoadBalancingRoundRobin}
+		}
+
+		if b.HealthCheck != nil {
+			b.HealthCheck.postProcess()
+		}
+
+		if b.Discovery != nil && b.Discovery.IntervalMs == 0 {
+			b.Discovery.IntervalMs = defaultDiscoveryIntervalMs
+		}
+
+		if b.CircuitBreaker != nil {
+			b.CircuitBreaker.postProcess()
+		}
+
+		if b.Concurrency != nil {
+			b.Concurrency.postProcess()
+		}
+
+		if b.Retry != nil {
+			b.Retry.postProcess()
+		}
+
+		// Server-Sent Events are streamed regardless of configuration, so they always need an
+		// idle timeout.
+		if b.Streaming == nil {
+			b.Streaming = &Streaming{}
+		}
+		if b.Streaming.IdleTimeoutMs == 0 {
+			b.Streaming.IdleTimeoutMs = defaultStreamingIdleTimeoutMs
+		}
+
+		if b.Mirror != nil {
+			b.Mirror.postProcess(b)
+		}
+	}
+}
+
+func (m *Mirror) postProcess(b *RouterBackend) {
+	if m.Percentage == 0 {
+		m.Percentage = defaultMirrorPercentage
+	}
+	if m.TimeoutMs == 0 {
+		m.TimeoutMs = b.TimeoutMs
+	}
+	if m.MaxBodyBytes == 0 {
+		m.MaxBodyBytes = defaultMirrorMaxBodyBytes
+	}
+	if m.MaxInFlight == 0 {
+		m.MaxInFlight = defaultMirrorMaxInFlight
 	}
 }
+
+func (rp *RetryPolicy) postProcess() {
+	if rp.BackoffMs == 0 {
+		rp.BackoffMs = defaultRetryBackoffMs
+	}
+	if rp.MaxBackoffMs == 0 {
+		rp.MaxBackoffMs = defaultRetryMaxBackoffMs
+	}
+	if len(rp.RetryOn) == 0 {
+		rp.RetryOn = []string{
+			RetryOnConnectError,
+			RetryOnBadGateway,
+			RetryOnServiceUnavailable,
+			RetryOnGatewayTimeout,
+		}
+	}
+	if rp.MaxBufferBytes == 0 {
+		rp.MaxBufferBytes = defaultRetryMaxBufferBytes
+	}
+}
+
+func (cb *CircuitBreaker) postProcess() {
+	if cb.Window == 0 {
+		cb.Window = defaultCircuitBreakerWindow
+	}
+	if cb.CooldownMs == 0 {
+		cb.CooldownMs = defaultCircuitBreakerCooldownMs
+	}
+	if cb.HalfOpenRequests == 0 {
+		cb.HalfOpenRequests = defaultCircuitBreakerHalfOpenRequests
+	}
+}
+
+func (cl *ConcurrencyLimit) postProcess() {
+	if cl.QueueTimeoutMs == 0 {
+		cl.QueueTimeoutMs = defaultConcurrencyQueueTimeoutMs
+	}
+
+	if a := cl.Adaptive; a != nil {
+		if a.MinLimit == 0 {
+			a.MinLimit = defaultAdaptiveMinLimit
+		}
+		if a.Tolerance == 0 {
+			a.Tolerance = defaultAdaptiveTolerance
+		}
+		if a.Backoff == 0 {
+			a.Backoff = defaultAdaptiveBackoff
+		}
+	}
+}
+
+func (hc *HealthCheck) postProcess() {
+	if active := hc.Active; active != nil {
+		if active.IntervalMs == 0 {
+			active.IntervalMs = defaultHealthCheckIntervalMs
+		}
+		if active.TimeoutMs == 0 {
+			active.TimeoutMs = defaultHealthCheckTimeoutMs
+		}
+		if active.HealthyThreshold == 0 {
+			active.HealthyThreshold = defaultHealthCheckHealthyThreshold
+		}
+		if active.UnhealthyThreshold == 0 {
+			active.UnhealthyThreshold = defaultHealthCheckUnhealthyThreshold
+		}
+	}
+
+	if passive := hc.Passive; passive != nil {
+		if passive.ConsecutiveFailures == 0 {
+			passive.ConsecutiveFailures = defaultHealthCheckConsecutiveFailures
+		}
+		if passive.Window == 0 {
+			passive.Window = defaultHealthCheckWindow
+		}
+		if passive.EjectionMs == 0 {
+			passive.EjectionMs = defaultHealthCheckEjectionMs
+		}
+	}
+}
+
+// GetTargets returns the configured targets of the backend, or a single target made up of Host and
+// Port when no targets are configured.
+func (b *RouterBackend) GetTargets() []*BackendTarget {
+	// Discovered targets are only known at runtime.
+	if b.Discovery != nil {
+		return nil
+	}
+
+	if len(b.Targets) > 0 {
+		return b.Targets
+	}
+
+	return []*BackendTarget{{Host: b.Host, Port: b.Port}}
+}
+
+func (bc *BodyLimitConfig) postProcess() {
+	// The global limits are the root of inheritance, omitted means unlimited.
+	if bc.MaxRequestBytes == nil {
+		bc.MaxRequestBytes = new(int64(0))
+	}
+	if bc.MaxResponseBytes == nil {
+		bc.MaxResponseBytes = new(int64(0))
+	}
+}
+
+func (cc *CacheConfig) postProcess() {
+	if cc.MaxEntries == 0 {
+		cc.MaxEntries = defaultCacheMaxEntries
+	}
+	if cc.MaxBytes == 0 {
+		cc.MaxBytes = defaultCacheMaxBytes
+	}
+	if cc.MaxEntryBytes == 0 {
+		cc.MaxEntryBytes = min(defaultCacheMaxEntryBytes, cc.MaxBytes)
+	}
+	if cc.Persistent != nil && cc.Persistent.MaxEntries == 0 {
+		cc.Persistent.MaxEntries = defaultCachePersistentMaxEntries
+	}
+}
+
+func (cc *CompressionConfig) postProcess() {
+	if len(cc.Encodings) == 0 {
+		cc.Encodings = []string{EncodingZstd, EncodingGzip, EncodingDeflate}
+	}
+	if cc.MinSize == nil {
+		cc.MinSize = new(int64(defaultCompressionMinSize))
+	}
+	if cc.ContentTypes == nil {
+		cc.ContentTypes = []string{
+			"text/*",
+			"application/json",
+			"application/problem+json",
+			"application/javascript",
+			"application/xml",
+			"application/yaml",
+			"image/svg+xml",
+		}
+	}
+}
+
 func (pc *PersistenceConfig) postProcess()   {}
 func (oc *ObservabilityConfig) postProcess() {}
 func (ac *AdminConfig) postProcess()         {}
+func (rc *RateLimitConfig) postProcess() {
+	for _, p := range rc.Policies {
+		if p.PeriodMs == 0 {
+			p.PeriodMs = defaultRateLimitPeriodMs
+		}
+		if p.Burst == 0 {
+			p.Burst = p.Requests
+		}
+	}
+}
+
 func (oc *OASConfig) postProcess() {
 	for _, m := range oc.Mappings {
 		if m.Options == nil {
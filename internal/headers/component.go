@@ -0,0 +1,212 @@
+package headers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/composer/custom"
+	"github.com/trebent/kerberos/internal/composer/debug"
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	"github.com/trebent/zerologr"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	Transformer interface {
+		composer.FlowComponent
+		custom.Ordered
+	}
+	transformer struct {
+		next     composer.FlowComponent
+		cfg      *config.HeadersConfig
+		mappings map[string]*mapping
+	}
+	Opts struct {
+		Cfg *config.HeadersConfig
+	}
+
+	mapping struct {
+		request  *rules
+		response *rules
+	}
+	// responseWriter applies the response rules right before the response header is written.
+	responseWriter struct {
+		http.ResponseWriter
+
+		apply   func(http.Header)
+		applied bool
+	}
+)
+
+const (
+	componentName = "header-transformer"
+
+	orgHeader  = "X-Krb-Org"
+	userHeader = "X-Krb-User"
+)
+
+var (
+	_ Transformer = (*transformer)(nil)
+
+	_ http.ResponseWriter = (*responseWriter)(nil)
+	_ http.Flusher        = (*responseWriter)(nil)
+)
+
+func NewComponent(opts *Opts) Transformer {
+	t := &transformer{
+		cfg:      opts.Cfg,
+		mappings: make(map[string]*mapping, len(opts.Cfg.Mappings)),
+	}
+	for _, m := range t.cfg.Mappings {
+		if err := t.register(m); err != nil {
+			panic(err)
+		}
+	}
+
+	return t
+}
+
+func (t *transformer) Order() int {
+	return t.cfg.Order
+}
+
+// Next implements [composer.FlowComponent].
+func (t *transformer) Next(next composer.FlowComponent) {
+	t.next = next
+}
+
+// GetMeta implements [composer.FlowComponent].
+func (t *transformer) GetMeta() []adminapi.FlowMeta {
+	mappings := make([]adminapi.FlowMetaDataHeadersMapping, 0, len(t.cfg.Mappings))
+	for _, m := range t.cfg.Mappings {
+		mappings = append(mappings, adminapi.FlowMetaDataHeadersMapping{
+			Backend:  m.Backend,
+			Request:  t.mappings[m.Backend].request.meta(),
+			Response: t.mappings[m.Backend].response.meta(),
+		})
+	}
+
+	fmd := adminapi.FlowMeta_Data{}
+	if err := fmd.FromFlowMetaDataHeaders(adminapi.FlowMetaDataHeaders{
+		Mappings: &mappings,
+	}); err != nil {
+		panic(err)
+	}
+
+	return append([]adminapi.FlowMeta{
+		{
+			Name: componentName,
+			Data: fmd,
+		},
+	}, t.next.GetMeta()...)
+}
+
+// ServeHTTP implements [composer.FlowComponent].
+func (t *transformer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	backend, _ := req.Context().Value(composer.BackendContextKey).(string)
+	m, ok := t.mappings[backend]
+	if !ok {
+		t.next.ServeHTTP(w, req)
+		return
+	}
+
+	debugStart := time.Now()
+	debugCall := composer.DebugFromContext(req.Context())
+	logger, _ := logr.FromContext(req.Context())
+	logger = logger.WithName(componentName)
+	logger.V(20).Info("Transforming headers", "backend", backend)
+
+	// Capture the template data before the request rules run, they may remove the headers it is
+	// read from.
+	data := &templateData{
+		Backend: backend,
+		OrgID:   req.Header.Get(orgHeader),
+		UserID:  req.Header.Get(userHeader),
+	}
+	if sc := trace.SpanContextFromContext(req.Context()); sc.HasTraceID() {
+		data.TraceID = sc.TraceID().String()
+	}
+
+	if m.request != nil {
+		m.request.apply(logger, req.Header, data)
+	}
+	debugCall.AddTransition(
+		componentName,
+		debug.CallDirectionInbound,
+		debugStart,
+		time.Now(),
+		debug.CallResultSuccess,
+		"",
+	)
+
+	if m.response != nil {
+		w = &responseWriter{
+			ResponseWriter: w,
+			apply: func(header http.Header) {
+				debugStart := time.Now()
+				m.response.apply(logger, header, data)
+				debugCall.AddTransition(
+					componentName,
+					debug.CallDirectionOutbound,
+					debugStart,
+					time.Now(),
+					debug.CallResultSuccess,
+					"",
+				)
+			},
+		}
+	}
+
+	t.next.ServeHTTP(w, req)
+}
+
+func (t *transformer) register(m *config.HeadersBackendMapping) error {
+	zerologr.Info("Preparing header transformer", "backend", m.Backend)
+
+	request, err := newRules(m.Request)
+	if err != nil {
+		return fmt.Errorf("backend %s request rules: %w", m.Backend, err)
+	}
+
+	response, err := newRules(m.Response)
+	if err != nil {
+		return fmt.Errorf("backend %s response rules: %w", m.Backend, err)
+	}
+
+	t.mappings[m.Backend] = &mapping{request: request, response: response}
+
+	return nil
+}
+
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	rw.applyOnce()
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	rw.applyOnce()
+	return rw.ResponseWriter.Write(p)
+}
+
+func (rw *responseWriter) Flush() {
+	rw.applyOnce()
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying response writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *responseWriter) applyOnce() {
+	if !rw.applied {
+		rw.applied = true
+		rw.apply(rw.ResponseWriter.Header())
+	}
+}
@@ -0,0 +1,218 @@
+package headers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// terminal ends the flow in GetMeta tests.
+type terminal struct {
+	composer.Dummy
+}
+
+func (*terminal) GetMeta() []adminapi.FlowMeta {
+	return nil
+}
+
+func testConfig() *config.HeadersConfig {
+	return &config.HeadersConfig{
+		Order: 1,
+		Mappings: []*config.HeadersBackendMapping{
+			{
+				Backend: "backend1",
+				Request: &config.HeaderRules{
+					Remove: []string{"X-Internal"},
+					Rename: map[string]string{"x-old": "X-New"},
+					Set: map[string]string{
+						"X-Api-Version": "2",
+						"X-Org":         "{{ .OrgID }}",
+						"X-Trace":       "{{ .TraceID }}",
+					},
+					Add: map[string]string{"X-Via": "{{ .Backend }}/{{ .UserID }}"},
+				},
+				Response: &config.HeaderRules{
+					Remove: []string{"X-Debug-*"},
+					Set:    map[string]string{"Cache-Control": "no-store"},
+				},
+			},
+		},
+	}
+}
+
+func serve(t *testing.T, transformer Transformer, backend string, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	transformer.ServeHTTP(
+		recorder,
+		req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, backend)),
+	)
+
+	return recorder
+}
+
+func TestRequestRules(t *testing.T) {
+	transformer := NewComponent(&Opts{Cfg: testConfig()})
+
+	var got http.Header
+	transformer.Next(&composer.Dummy{
+		CustomHandler: func(_ composer.FlowComponent, w http.ResponseWriter, req *http.Request) {
+			got = req.Header.Clone()
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	ctx := trace.ContextWithSpanContext(
+		context.Background(),
+		trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}}),
+	)
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	req.Header.Set("X-Internal", "secret")
+	req.Header.Set("X-Old", "value")
+	req.Header.Set("X-Api-Version", "1")
+	req.Header.Set("X-Via", "client")
+	req.Header.Set(orgHeader, "7")
+	req.Header.Set(userHeader, "42")
+
+	serve(t, transformer, "backend1", req)
+
+	if got.Get("X-Internal") != "" {
+		t.Errorf("Expected X-Internal to be removed, got %q", got.Get("X-Internal"))
+	}
+	if got.Get("X-Old") != "" || got.Get("X-New") != "value" {
+		t.Errorf("Expected X-Old to be renamed to X-New, got %v", got)
+	}
+	if got.Get("X-Api-Version") != "2" {
+		t.Errorf("Expected X-Api-Version 2, got %q", got.Get("X-Api-Version"))
+	}
+	if got.Get("X-Org") != "7" {
+		t.Errorf("Expected X-Org 7, got %q", got.Get("X-Org"))
+	}
+	if got.Get("X-Trace") != traceID.String() {
+		t.Errorf("Expected X-Trace %s, got %q", traceID, got.Get("X-Trace"))
+	}
+	if via := got.Values("X-Via"); len(via) != 2 || via[1] != "backend1/42" {
+		t.Errorf("Expected X-Via [client backend1/42], got %v", via)
+	}
+}
+
+func TestRequestRulesSkipEmptyValues(t *testing.T) {
+	transformer := NewComponent(&Opts{Cfg: testConfig()})
+
+	var got http.Header
+	transformer.Next(&composer.Dummy{
+		CustomHandler: func(_ composer.FlowComponent, w http.ResponseWriter, req *http.Request) {
+			got = req.Header.Clone()
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	serve(t, transformer, "backend1", httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if _, ok := got["X-Org"]; ok {
+		t.Errorf("Expected X-Org to be skipped for an unauthenticated request, got %q", got.Get("X-Org"))
+	}
+	if _, ok := got["X-Trace"]; ok {
+		t.Errorf("Expected X-Trace to be skipped without a trace, got %q", got.Get("X-Trace"))
+	}
+}
+
+func TestResponseRules(t *testing.T) {
+	transformer := NewComponent(&Opts{Cfg: testConfig()})
+	transformer.Next(&composer.Dummy{
+		CustomHandler: func(_ composer.FlowComponent, w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("X-Debug-Query", "select 1")
+			w.Header().Set("x-debug-time", "10ms")
+			w.Header().Set("X-Debugger", "kept")
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte("hi"))
+		},
+	})
+
+	recorder := serve(t, transformer, "backend1", httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if recorder.Header().Get("X-Debug-Query") != "" || recorder.Header().Get("X-Debug-Time") != "" {
+		t.Errorf("Expected X-Debug-* headers to be removed, got %v", recorder.Header())
+	}
+	if recorder.Header().Get("X-Debugger") != "kept" {
+		t.Errorf("Expected X-Debugger to be kept, got %q", recorder.Header().Get("X-Debugger"))
+	}
+	if recorder.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Expected Cache-Control no-store, got %q", recorder.Header().Get("Cache-Control"))
+	}
+	if recorder.Body.String() != "hi" {
+		t.Errorf("Expected body hi, got %q", recorder.Body.String())
+	}
+}
+
+func TestUnmappedBackend(t *testing.T) {
+	transformer := NewComponent(&Opts{Cfg: testConfig()})
+	transformer.Next(&composer.Dummy{
+		CustomHandler: func(_ composer.FlowComponent, w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("X-Internal") != "secret" {
+				t.Errorf("Expected X-Internal to be kept, got %q", req.Header.Get("X-Internal"))
+			}
+			w.Header().Set("X-Debug-Query", "select 1")
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Internal", "secret")
+	recorder := serve(t, transformer, "backend2", req)
+
+	if recorder.Header().Get("X-Debug-Query") == "" {
+		t.Error("Expected X-Debug-Query to be kept")
+	}
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected an invalid template to panic")
+		}
+	}()
+
+	NewComponent(&Opts{Cfg: &config.HeadersConfig{
+		Mappings: []*config.HeadersBackendMapping{
+			{
+				Backend: "backend1",
+				Request: &config.HeaderRules{Set: map[string]string{"X-Org": "{{ .OrgID"}},
+			},
+		},
+	}})
+}
+
+func TestGetMeta(t *testing.T) {
+	transformer := NewComponent(&Opts{Cfg: testConfig()})
+	transformer.Next(&terminal{})
+
+	meta := transformer.GetMeta()
+	if meta[0].Name != componentName {
+		t.Fatalf("Expected name %s, got %s", componentName, meta[0].Name)
+	}
+
+	data, err := meta[0].Data.AsFlowMetaDataHeaders()
+	if err != nil {
+		t.Fatalf("Failed to read meta data: %v", err)
+	}
+	if len(*data.Mappings) != 1 {
+		t.Fatalf("Expected 1 mapping, got %d", len(*data.Mappings))
+	}
+
+	m := (*data.Mappings)[0]
+	if (*m.Request.Set)["X-Org"] != "{{ .OrgID }}" {
+		t.Errorf("Expected the unrendered X-Org template, got %q", (*m.Request.Set)["X-Org"])
+	}
+	if (*m.Response.Remove)[0] != "X-Debug-*" {
+		t.Errorf("Expected response remove X-Debug-*, got %v", *m.Response.Remove)
+	}
+}
@@ -0,0 +1,165 @@
+package headers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/go-logr/logr"
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+)
+
+type (
+	// rules is the compiled form of a config.HeaderRules.
+	rules struct {
+		remove []string
+		rename map[string]string
+		set    []*value
+		add    []*value
+	}
+	// value is a header value, either static or rendered from a template.
+	value struct {
+		name     string
+		static   string
+		template *template.Template
+	}
+	// templateData holds the request context available to header value templates.
+	templateData struct {
+		Backend string
+		OrgID   string
+		UserID  string
+		TraceID string
+	}
+)
+
+func newRules(cfg *config.HeaderRules) (*rules, error) {
+	if cfg == nil {
+		return nil, nil //nolint:nilnil // No rules configured.
+	}
+
+	r := &rules{
+		remove: cfg.Remove,
+		rename: make(map[string]string, len(cfg.Rename)),
+	}
+	for from, to := range cfg.Rename {
+		r.rename[http.CanonicalHeaderKey(from)] = http.CanonicalHeaderKey(to)
+	}
+
+	var err error
+	if r.set, err = newValues(cfg.Set); err != nil {
+		return nil, err
+	}
+	if r.add, err = newValues(cfg.Add); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func newValues(cfg map[string]string) ([]*value, error) {
+	values := make([]*value, 0, len(cfg))
+	for name, raw := range cfg {
+		v := &value{name: http.CanonicalHeaderKey(name), static: raw}
+		if strings.Contains(raw, "{{") {
+			tmpl, err := template.New(name).Option("missingkey=error").Parse(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template for header %s: %w", name, err)
+			}
+			v.template = tmpl
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// apply transforms the header in the order remove, rename, set, add. Values that render empty are
+// skipped, so that for example an unauthenticated request does not get an empty org header.
+func (r *rules) apply(logger logr.Logger, header http.Header, data *templateData) {
+	for _, pattern := range r.remove {
+		remove(header, pattern)
+	}
+
+	for from, to := range r.rename {
+		if values, ok := header[from]; ok {
+			delete(header, from)
+			header[to] = values
+		}
+	}
+
+	for _, v := range r.set {
+		if rendered := v.render(logger, data); rendered != "" {
+			header.Set(v.name, rendered)
+		}
+	}
+
+	for _, v := range r.add {
+		if rendered := v.render(logger, data); rendered != "" {
+			header.Add(v.name, rendered)
+		}
+	}
+}
+
+func (v *value) render(logger logr.Logger, data *templateData) string {
+	if v.template == nil {
+		return v.static
+	}
+
+	buf := &bytes.Buffer{}
+	if err := v.template.Execute(buf, data); err != nil {
+		logger.Error(err, "Failed to render header template", "header", v.name)
+		return ""
+	}
+
+	return buf.String()
+}
+
+// remove deletes the header matching the pattern. A trailing "*" in the pattern matches any header
+// starting with the rest of the pattern.
+func remove(header http.Header, pattern string) {
+	prefix, ok := strings.CutSuffix(pattern, "*")
+	if !ok {
+		header.Del(pattern)
+		return
+	}
+
+	for name := range header {
+		if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			delete(header, name)
+		}
+	}
+}
+
+// meta returns the admin API representation of the rules, templates are shown unrendered.
+func (r *rules) meta() *adminapi.FlowMetaDataHeadersRules {
+	if r == nil {
+		return nil
+	}
+
+	values := func(vs []*value) *map[string]string {
+		if len(vs) == 0 {
+			return nil
+		}
+		m := make(map[string]string, len(vs))
+		for _, v := range vs {
+			m[v.name] = v.static
+		}
+		return &m
+	}
+
+	meta := &adminapi.FlowMetaDataHeadersRules{
+		Set: values(r.set),
+		Add: values(r.add),
+	}
+	if len(r.remove) > 0 {
+		meta.Remove = &r.remove
+	}
+	if len(r.rename) > 0 {
+		meta.Rename = &r.rename
+	}
+
+	return meta
+}
@@ -63,6 +63,12 @@ type APIErrorResponse struct {
 	Errors []string `json:"errors"`
 }
 
+// CachePurge defines model for CachePurge.
+type CachePurge struct {
+	// Purged Number of purged cache entries.
+	Purged int `json:"purged"`
+}
+
 // DebugSession defines model for DebugSession.
 type DebugSession struct {
 	// Backend The backend that the call was made to.
@@ -125,12 +131,95 @@ type FlowMetaDataAuth struct {
 	Scheme  *FlowMetaDataAuthScheme  `json:"scheme,omitempty"`
 }
 
+// FlowMetaDataAuthMTLSIdentity defines model for FlowMetaDataAuthMTLSIdentity.
+type FlowMetaDataAuthMTLSIdentity struct {
+	// Dns The pattern matching the DNS SANs of certificates.
+	Dns *string `json:"dns,omitempty"`
+
+	// Groups The groups of the identity.
+	Groups *[]string `json:"groups,omitempty"`
+
+	// Organisation The organisation of the identity.
+	Organisation *string `json:"organisation,omitempty"`
+
+	// Subject The pattern matching the subject of certificates.
+	Subject *string `json:"subject,omitempty"`
+
+	// Uri The pattern matching the URI SANs of certificates.
+	Uri *string `json:"uri,omitempty"`
+
+	// User The user certificates are authenticated as, if not the matched SAN or subject.
+	User *string `json:"user,omitempty"`
+}
+
 // FlowMetaDataAuthMethodBasic defines model for FlowMetaDataAuthMethodBasic.
-type FlowMetaDataAuthMethodBasic = map[string]interface{}
+type FlowMetaDataAuthMethodBasic struct {
+	Quotas *[]FlowMetaDataAuthQuota `json:"quotas,omitempty"`
+}
+
+// FlowMetaDataAuthMethodHMAC defines model for FlowMetaDataAuthMethodHMAC.
+type FlowMetaDataAuthMethodHMAC struct {
+	// Header The header carrying the signature of requests.
+	Header string `json:"header"`
+
+	// MaxClockSkew The largest clock skew in milliseconds accepted for signed requests.
+	MaxClockSkew int `json:"maxClockSkew"`
+
+	// Organisations The organisations holding shared secrets, the secrets are not shown.
+	Organisations []string `json:"organisations"`
+
+	// SignedHeaders The headers signed in addition to the method, path, timestamp, nonce and body.
+	SignedHeaders *[]string `json:"signedHeaders,omitempty"`
+}
+
+// FlowMetaDataAuthMethodJWT defines model for FlowMetaDataAuthMethodJWT.
+type FlowMetaDataAuthMethodJWT struct {
+	// Algorithms The accepted signature algorithms.
+	Algorithms []string `json:"algorithms"`
+
+	// Audiences The accepted audiences of tokens.
+	Audiences []string `json:"audiences"`
+
+	// Issuer The required issuer of tokens.
+	Issuer string `json:"issuer"`
+}
+
+// FlowMetaDataAuthMethodMTLS defines model for FlowMetaDataAuthMethodMTLS.
+type FlowMetaDataAuthMethodMTLS struct {
+	// Identities The identities client certificates are mapped onto, in matching order.
+	Identities []FlowMetaDataAuthMTLSIdentity `json:"identities"`
+}
+
+// FlowMetaDataAuthMethodOIDC defines model for FlowMetaDataAuthMethodOIDC.
+type FlowMetaDataAuthMethodOIDC struct {
+	// ClientId The client ID of Kerberos at the provider.
+	ClientId string `json:"clientId"`
+
+	// Issuer The issuer of the OpenID Connect provider.
+	Issuer string `json:"issuer"`
+
+	// Scopes The scopes requested when logging in.
+	Scopes []string `json:"scopes"`
+}
 
 // FlowMetaDataAuthMethods defines model for FlowMetaDataAuthMethods.
 type FlowMetaDataAuthMethods struct {
 	Basic *FlowMetaDataAuthMethodBasic `json:"basic,omitempty"`
+	Hmac  *FlowMetaDataAuthMethodHMAC  `json:"hmac,omitempty"`
+	Jwt   *FlowMetaDataAuthMethodJWT   `json:"jwt,omitempty"`
+	Mtls  *FlowMetaDataAuthMethodMTLS  `json:"mtls,omitempty"`
+	Oidc  *FlowMetaDataAuthMethodOIDC  `json:"oidc,omitempty"`
+}
+
+// FlowMetaDataAuthQuota defines model for FlowMetaDataAuthQuota.
+type FlowMetaDataAuthQuota struct {
+	// Backend The backend whose requests are counted, absent when all backends are.
+	Backend *string `json:"backend,omitempty"`
+
+	// Organisation The organisation the quota applies to, absent for the default quota.
+	Organisation     *string `json:"organisation,omitempty"`
+	RequestsPerDay   *int64  `json:"requestsPerDay,omitempty"`
+	RequestsPerMonth *int64  `json:"requestsPerMonth,omitempty"`
 }
 
 // FlowMetaDataAuthScheme defines model for FlowMetaDataAuthScheme.
@@ -152,6 +241,99 @@ type FlowMetaDataAuthSchemeMappingAuthorization struct {
 	Paths  *map[string][]string `json:"paths,omitempty"`
 }
 
+// FlowMetaDataBodyLimit defines model for FlowMetaDataBodyLimit.
+type FlowMetaDataBodyLimit struct {
+	Mappings *[]FlowMetaDataBodyLimitMapping `json:"mappings,omitempty"`
+
+	// MaxRequestBytes Request body limit of backends without a mapping, 0 is unlimited.
+	MaxRequestBytes int64 `json:"maxRequestBytes"`
+
+	// MaxResponseBytes Response body limit of backends without a mapping, 0 is unlimited.
+	MaxResponseBytes int64 `json:"maxResponseBytes"`
+}
+
+// FlowMetaDataBodyLimitMapping defines model for FlowMetaDataBodyLimitMapping.
+type FlowMetaDataBodyLimitMapping struct {
+	Backend string `json:"backend"`
+
+	// MaxRequestBytes Effective request body limit of the backend, 0 is unlimited.
+	MaxRequestBytes int64 `json:"maxRequestBytes"`
+
+	// MaxResponseBytes Effective response body limit of the backend, 0 is unlimited.
+	MaxResponseBytes int64                        `json:"maxResponseBytes"`
+	Paths            *[]FlowMetaDataBodyLimitPath `json:"paths,omitempty"`
+}
+
+// FlowMetaDataBodyLimitPath defines model for FlowMetaDataBodyLimitPath.
+type FlowMetaDataBodyLimitPath struct {
+	// MaxRequestBytes Effective request body limit of the path, 0 is unlimited.
+	MaxRequestBytes int64 `json:"maxRequestBytes"`
+
+	// MaxResponseBytes Effective response body limit of the path, 0 is unlimited.
+	MaxResponseBytes int64  `json:"maxResponseBytes"`
+	Path             string `json:"path"`
+}
+
+// FlowMetaDataCache defines model for FlowMetaDataCache.
+type FlowMetaDataCache struct {
+	// Bytes Total size of the response bodies currently cached in memory.
+	Bytes int64 `json:"bytes"`
+
+	// Entries Number of responses currently cached in memory.
+	Entries    int                         `json:"entries"`
+	Mappings   *[]FlowMetaDataCacheMapping `json:"mappings,omitempty"`
+	MaxBytes   int64                       `json:"maxBytes"`
+	MaxEntries int                         `json:"maxEntries"`
+
+	// Persistent Whether cached responses are also kept in the database.
+	Persistent bool `json:"persistent"`
+}
+
+// FlowMetaDataCacheMapping defines model for FlowMetaDataCacheMapping.
+type FlowMetaDataCacheMapping struct {
+	Backend string `json:"backend"`
+
+	// DefaultTtl Milliseconds that responses without explicit freshness are cached, 0 does not cache them.
+	DefaultTtl int `json:"defaultTtl"`
+}
+
+// FlowMetaDataCompression defines model for FlowMetaDataCompression.
+type FlowMetaDataCompression struct {
+	ContentTypes []string `json:"contentTypes"`
+
+	// Encodings Supported content codings in order of preference.
+	Encodings []string                          `json:"encodings"`
+	Mappings  *[]FlowMetaDataCompressionMapping `json:"mappings,omitempty"`
+	MinSize   int64                             `json:"minSize"`
+}
+
+// FlowMetaDataCompressionMapping defines model for FlowMetaDataCompressionMapping.
+type FlowMetaDataCompressionMapping struct {
+	Backend      string   `json:"backend"`
+	ContentTypes []string `json:"contentTypes"`
+	MinSize      int64    `json:"minSize"`
+}
+
+// FlowMetaDataHeaders defines model for FlowMetaDataHeaders.
+type FlowMetaDataHeaders struct {
+	Mappings *[]FlowMetaDataHeadersMapping `json:"mappings,omitempty"`
+}
+
+// FlowMetaDataHeadersMapping defines model for FlowMetaDataHeadersMapping.
+type FlowMetaDataHeadersMapping struct {
+	Backend  string                    `json:"backend"`
+	Request  *FlowMetaDataHeadersRules `json:"request,omitempty"`
+	Response *FlowMetaDataHeadersRules `json:"response,omitempty"`
+}
+
+// FlowMetaDataHeadersRules defines model for FlowMetaDataHeadersRules.
+type FlowMetaDataHeadersRules struct {
+	Add    *map[string]string `json:"add,omitempty"`
+	Remove *[]string          `json:"remove,omitempty"`
+	Rename *map[string]string `json:"rename,omitempty"`
+	Set    *map[string]string `json:"set,omitempty"`
+}
+
 // FlowMetaDataOAS defines model for FlowMetaDataOAS.
 type FlowMetaDataOAS struct {
 	Backends *[]string `json:"backends,omitempty"`
@@ -162,21 +344,85 @@ type FlowMetaDataObservability struct {
 	Enabled bool `json:"enabled"`
 }
 
+// FlowMetaDataRateLimit defines model for FlowMetaDataRateLimit.
+type FlowMetaDataRateLimit struct {
+	Policies *[]FlowMetaDataRateLimitPolicy `json:"policies,omitempty"`
+}
+
+// FlowMetaDataRateLimitPolicy defines model for FlowMetaDataRateLimitPolicy.
+type FlowMetaDataRateLimitPolicy struct {
+	Backend string  `json:"backend"`
+	Burst   int     `json:"burst"`
+	Header  *string `json:"header,omitempty"`
+	Key     string  `json:"key"`
+
+	// Period Refill period in milliseconds.
+	Period   int `json:"period"`
+	Requests int `json:"requests"`
+}
+
 // FlowMetaDataRouter defines model for FlowMetaDataRouter.
 type FlowMetaDataRouter struct {
 	Backends *[]FlowMetaDataRouterBackend `json:"backends,omitempty"`
+
+	// Routes Configured routes, in order of precedence.
+	Routes *[]FlowMetaDataRouterRoute `json:"routes,omitempty"`
 }
 
 // FlowMetaDataRouterBackend defines model for FlowMetaDataRouterBackend.
 type FlowMetaDataRouterBackend struct {
-	Host string `json:"host"`
-	Name string `json:"name"`
-	Port int    `json:"port"`
+	// Discovery Target discovery of a backend, its targets are the ones resolved by the last successful refresh.
+	Discovery *FlowMetaDataRouterDiscovery `json:"discovery,omitempty"`
+	Host      string                       `json:"host"`
+
+	// LoadBalancing The load balancing strategy used to pick among the targets.
+	LoadBalancing *string                     `json:"loadBalancing,omitempty"`
+	Name          string                      `json:"name"`
+	Port          int                         `json:"port"`
+	Targets       *[]FlowMetaDataRouterTarget `json:"targets,omitempty"`
+}
+
+// FlowMetaDataRouterDiscovery Target discovery of a backend, its targets are the ones resolved by the last successful refresh.
+type FlowMetaDataRouterDiscovery struct {
+	// Error The error of the last refresh, absent when it succeeded.
+	Error *string `json:"error,omitempty"`
+
+	// Interval Milliseconds between refreshes.
+	Interval int `json:"interval"`
+
+	// LastRefresh When the targets were last refreshed successfully, absent before the first.
+	LastRefresh *time.Time `json:"lastRefresh,omitempty"`
+
+	// Source The resolved DNS name or the path of the target file.
+	Source string `json:"source"`
+
+	// Type How the targets are discovered, dns or file.
+	Type string `json:"type"`
+}
+
+// FlowMetaDataRouterRoute defines model for FlowMetaDataRouterRoute.
+type FlowMetaDataRouterRoute struct {
+	Backend    string    `json:"backend"`
+	Host       *string   `json:"host,omitempty"`
+	Methods    *[]string `json:"methods,omitempty"`
+	PathPrefix *string   `json:"pathPrefix,omitempty"`
+}
+
+// FlowMetaDataRouterTarget defines model for FlowMetaDataRouterTarget.
+type FlowMetaDataRouterTarget struct {
+	// Healthy Whether the target currently receives requests, omitted when health is not tracked.
+	Healthy *bool  `json:"healthy,omitempty"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
 }
 
 // FlowTransition A flow transition that occurred during a debug session call. A flow transition represents
 // a flow component's handling of a request, in both directions.
 type FlowTransition struct {
+	// Attributes Details of the flow transition recorded by the flow component, such as the upstream
+	// target chosen by the forwarder.
+	Attributes *map[string]string `json:"attributes,omitempty"`
+
 	// Component The flow component that the transition corresponds to.
 	Component string `json:"component"`
 
@@ -232,6 +478,28 @@ type Permission struct {
 	Name string `json:"name"`
 }
 
+// TrafficSplit Routes the requests of a backend to its variants. Requests matching the headers and cookies of a variant are routed to it, a weighted share of the remaining requests is routed to each variant and the rest stay on the backend.
+type TrafficSplit struct {
+	// Backend Name of the backend whose requests are split.
+	Backend  string                `json:"backend"`
+	Variants []TrafficSplitVariant `json:"variants"`
+}
+
+// TrafficSplitVariant defines model for TrafficSplitVariant.
+type TrafficSplitVariant struct {
+	// Backend Name of the backend requests are routed to.
+	Backend string `json:"backend"`
+
+	// Cookies Requests with all of these cookie values are routed to the variant.
+	Cookies *map[string]string `json:"cookies,omitempty"`
+
+	// Headers Requests with all of these header values are routed to the variant.
+	Headers *map[string]string `json:"headers,omitempty"`
+
+	// Weight Percentage of the requests not matched by any variant that are routed to the variant. The weights of a split may not exceed 100 in total.
+	Weight float64 `json:"weight"`
+}
+
 // User defines model for User.
 type User struct {
 	Groups   *[]Group `json:"groups,omitempty"`
@@ -263,6 +531,11 @@ type LoginUserRequest struct {
 	Username string `json:"username"`
 }
 
+// SetTrafficSplitRequest defines model for SetTrafficSplitRequest.
+type SetTrafficSplitRequest struct {
+	Variants []TrafficSplitVariant `json:"variants"`
+}
+
 // StartDebugSessionRequest defines model for StartDebugSessionRequest.
 type StartDebugSessionRequest struct {
 	// DurationSeconds Duration in seconds to keep the backend in debug mode. If not provided, the backend will be kept in debug mode until debug is disabled, or for a maximum of 1 hour. Minimum is 1 minute, defaults to 5 minutes.
@@ -285,6 +558,12 @@ type UpdateUserRequest struct {
 	Username string `json:"username"`
 }
 
+// PurgeCacheParams defines parameters for PurgeCache.
+type PurgeCacheParams struct {
+	Backend    *string `form:"backend,omitempty" json:"backend,omitempty"`
+	PathPrefix *string `form:"pathPrefix,omitempty" json:"pathPrefix,omitempty"`
+}
+
 // StartDebugSessionJSONBody defines parameters for StartDebugSession.
 type StartDebugSessionJSONBody struct {
 	// DurationSeconds Duration in seconds to keep the backend in debug mode. If not provided, the backend will be kept in debug mode until debug is disabled, or for a maximum of 1 hour. Minimum is 1 minute, defaults to 5 minutes.
@@ -320,6 +599,11 @@ type LoginJSONBody struct {
 	Username string `json:"username"`
 }
 
+// SetTrafficSplitJSONBody defines parameters for SetTrafficSplit.
+type SetTrafficSplitJSONBody struct {
+	Variants []TrafficSplitVariant `json:"variants"`
+}
+
 // LoginSuperuserJSONBody defines parameters for LoginSuperuser.
 type LoginSuperuserJSONBody struct {
 	ClientId     string `json:"clientId"`
@@ -369,6 +653,9 @@ type UpdateGroupJSONRequestBody UpdateGroupJSONBody
 // LoginJSONRequestBody defines body for Login for application/json ContentType.
 type LoginJSONRequestBody LoginJSONBody
 
+// SetTrafficSplitJSONRequestBody defines body for SetTrafficSplit for application/json ContentType.
+type SetTrafficSplitJSONRequestBody SetTrafficSplitJSONBody
+
 // LoginSuperuserJSONRequestBody defines body for LoginSuperuser for application/json ContentType.
 type LoginSuperuserJSONRequestBody LoginSuperuserJSONBody
 
@@ -491,6 +778,136 @@ func (t *FlowMeta_Data) MergeFlowMetaDataOAS(v FlowMetaDataOAS) error {
 	return err
 }
 
+// AsFlowMetaDataHeaders returns the union data inside the FlowMeta_Data as a FlowMetaDataHeaders
+func (t FlowMeta_Data) AsFlowMetaDataHeaders() (FlowMetaDataHeaders, error) {
+	var body FlowMetaDataHeaders
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataHeaders overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataHeaders
+func (t *FlowMeta_Data) FromFlowMetaDataHeaders(v FlowMetaDataHeaders) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataHeaders performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataHeaders
+func (t *FlowMeta_Data) MergeFlowMetaDataHeaders(v FlowMetaDataHeaders) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsFlowMetaDataRateLimit returns the union data inside the FlowMeta_Data as a FlowMetaDataRateLimit
+func (t FlowMeta_Data) AsFlowMetaDataRateLimit() (FlowMetaDataRateLimit, error) {
+	var body FlowMetaDataRateLimit
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataRateLimit overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataRateLimit
+func (t *FlowMeta_Data) FromFlowMetaDataRateLimit(v FlowMetaDataRateLimit) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataRateLimit performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataRateLimit
+func (t *FlowMeta_Data) MergeFlowMetaDataRateLimit(v FlowMetaDataRateLimit) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsFlowMetaDataBodyLimit returns the union data inside the FlowMeta_Data as a FlowMetaDataBodyLimit
+func (t FlowMeta_Data) AsFlowMetaDataBodyLimit() (FlowMetaDataBodyLimit, error) {
+	var body FlowMetaDataBodyLimit
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataBodyLimit overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataBodyLimit
+func (t *FlowMeta_Data) FromFlowMetaDataBodyLimit(v FlowMetaDataBodyLimit) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataBodyLimit performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataBodyLimit
+func (t *FlowMeta_Data) MergeFlowMetaDataBodyLimit(v FlowMetaDataBodyLimit) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsFlowMetaDataCache returns the union data inside the FlowMeta_Data as a FlowMetaDataCache
+func (t FlowMeta_Data) AsFlowMetaDataCache() (FlowMetaDataCache, error) {
+	var body FlowMetaDataCache
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataCache overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataCache
+func (t *FlowMeta_Data) FromFlowMetaDataCache(v FlowMetaDataCache) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataCache performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataCache
+func (t *FlowMeta_Data) MergeFlowMetaDataCache(v FlowMetaDataCache) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsFlowMetaDataCompression returns the union data inside the FlowMeta_Data as a FlowMetaDataCompression
+func (t FlowMeta_Data) AsFlowMetaDataCompression() (FlowMetaDataCompression, error) {
+	var body FlowMetaDataCompression
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataCompression overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataCompression
+func (t *FlowMeta_Data) FromFlowMetaDataCompression(v FlowMetaDataCompression) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataCompression performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataCompression
+func (t *FlowMeta_Data) MergeFlowMetaDataCompression(v FlowMetaDataCompression) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
 // AsNoFlowMetaData returns the union data inside the FlowMeta_Data as a NoFlowMetaData
 func (t FlowMeta_Data) AsNoFlowMetaData() (NoFlowMetaData, error) {
 	var body NoFlowMetaData
@@ -530,6 +947,9 @@ func (t *FlowMeta_Data) UnmarshalJSON(b []byte) error {
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
 
+	// (DELETE /api/admin/cache)
+	PurgeCache(w http.ResponseWriter, r *http.Request, params PurgeCacheParams)
+
 	// (GET /api/admin/debug/{backend}/sessions)
 	ListDebugSessions(w http.ResponseWriter, r *http.Request, backend string)
 
@@ -590,6 +1010,15 @@ type ServerInterface interface {
 	// (POST /api/admin/refresh)
 	RefreshUserSession(w http.ResponseWriter, r *http.Request)
 
+	// (GET /api/admin/splits)
+	ListTrafficSplits(w http.ResponseWriter, r *http.Request)
+
+	// (DELETE /api/admin/splits/{backend})
+	DeleteTrafficSplit(w http.ResponseWriter, r *http.Request, backend string)
+
+	// (PUT /api/admin/splits/{backend})
+	SetTrafficSplit(w http.ResponseWriter, r *http.Request, backend string)
+
 	// (POST /api/admin/superuser/login)
 	LoginSuperuser(w http.ResponseWriter, r *http.Request)
 
@@ -633,6 +1062,47 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// PurgeCache operation middleware
+func (siw *ServerInterfaceWrapper) PurgeCache(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PurgeCacheParams
+
+	// ------------- Optional query parameter "backend" -------------
+
+	err = runtime.BindQueryParameterWithOptions("form", true, false, "backend", r.URL.Query(), &params.Backend, runtime.BindQueryParameterOptions{Type: "string", Format: ""})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "backend", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "pathPrefix" -------------
+
+	err = runtime.BindQueryParameterWithOptions("form", true, false, "pathPrefix", r.URL.Query(), &params.PathPrefix, runtime.BindQueryParameterOptions{Type: "string", Format: ""})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "pathPrefix", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PurgeCache(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // ListDebugSessions operation middleware
 func (siw *ServerInterfaceWrapper) ListDebugSessions(w http.ResponseWriter, r *http.Request) {
 
@@ -1200,8 +1670,79 @@ func (siw *ServerInterfaceWrapper) GetBackendOAS(w http.ResponseWriter, r *http.
 	handler.ServeHTTP(w, r)
 }
 
-// GetPermissions operation middleware
-func (siw *ServerInterfaceWrapper) GetPermissions(w http.ResponseWriter, r *http.Request) {
+// GetPermissions operation middleware
+func (siw *ServerInterfaceWrapper) GetPermissions(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetPermissions(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RefreshUserSession operation middleware
+func (siw *ServerInterfaceWrapper) RefreshUserSession(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RefreshUserSession(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListTrafficSplits operation middleware
+func (siw *ServerInterfaceWrapper) ListTrafficSplits(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListTrafficSplits(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteTrafficSplit operation middleware
+func (siw *ServerInterfaceWrapper) DeleteTrafficSplit(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "backend" -------------
+	var backend string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "backend", r.PathValue("backend"), &backend, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true, Type: "string", Format: ""})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "backend", Err: err})
+		return
+	}
 
 	ctx := r.Context()
 
@@ -1210,7 +1751,7 @@ func (siw *ServerInterfaceWrapper) GetPermissions(w http.ResponseWriter, r *http
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetPermissions(w, r)
+		siw.Handler.DeleteTrafficSplit(w, r, backend)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1220,8 +1761,19 @@ func (siw *ServerInterfaceWrapper) GetPermissions(w http.ResponseWriter, r *http
 	handler.ServeHTTP(w, r)
 }
 
-// RefreshUserSession operation middleware
-func (siw *ServerInterfaceWrapper) RefreshUserSession(w http.ResponseWriter, r *http.Request) {
+// SetTrafficSplit operation middleware
+func (siw *ServerInterfaceWrapper) SetTrafficSplit(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "backend" -------------
+	var backend string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "backend", r.PathValue("backend"), &backend, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true, Type: "string", Format: ""})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "backend", Err: err})
+		return
+	}
 
 	ctx := r.Context()
 
@@ -1230,7 +1782,7 @@ func (siw *ServerInterfaceWrapper) RefreshUserSession(w http.ResponseWriter, r *
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.RefreshUserSession(w, r)
+		siw.Handler.SetTrafficSplit(w, r, backend)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1629,6 +2181,7 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	m.HandleFunc("DELETE "+options.BaseURL+"/api/admin/cache", wrapper.PurgeCache)
 	m.HandleFunc("GET "+options.BaseURL+"/api/admin/debug/{backend}/sessions", wrapper.ListDebugSessions)
 	m.HandleFunc("POST "+options.BaseURL+"/api/admin/debug/{backend}/sessions", wrapper.StartDebugSession)
 	m.HandleFunc("DELETE "+options.BaseURL+"/api/admin/debug/{backend}/sessions/{sessionId}", wrapper.DeleteDebugSession)
@@ -1649,6 +2202,9 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 	m.HandleFunc("GET "+options.BaseURL+"/api/admin/oas/{backend}", wrapper.GetBackendOAS)
 	m.HandleFunc("GET "+options.BaseURL+"/api/admin/permissions", wrapper.GetPermissions)
 	m.HandleFunc("POST "+options.BaseURL+"/api/admin/refresh", wrapper.RefreshUserSession)
+	m.HandleFunc("GET "+options.BaseURL+"/api/admin/splits", wrapper.ListTrafficSplits)
+	m.HandleFunc("DELETE "+options.BaseURL+"/api/admin/splits/{backend}", wrapper.DeleteTrafficSplit)
+	m.HandleFunc("PUT "+options.BaseURL+"/api/admin/splits/{backend}", wrapper.SetTrafficSplit)
 	m.HandleFunc("POST "+options.BaseURL+"/api/admin/superuser/login", wrapper.LoginSuperuser)
 	m.HandleFunc("POST "+options.BaseURL+"/api/admin/superuser/logout", wrapper.LogoutSuperuser)
 	m.HandleFunc("PUT "+options.BaseURL+"/api/admin/superuser/password", wrapper.ChangeSuperuserPassword)
@@ -1664,6 +2220,50 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 	return m
 }
 
+type PurgeCacheRequestObject struct {
+	Params PurgeCacheParams
+}
+
+type PurgeCacheResponseObject interface {
+	VisitPurgeCacheResponse(w http.ResponseWriter) error
+}
+
+type PurgeCache200JSONResponse CachePurge
+
+func (response PurgeCache200JSONResponse) VisitPurgeCacheResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PurgeCache401JSONResponse APIErrorResponse
+
+func (response PurgeCache401JSONResponse) VisitPurgeCacheResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PurgeCache403JSONResponse APIErrorResponse
+
+func (response PurgeCache403JSONResponse) VisitPurgeCacheResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PurgeCache500JSONResponse APIErrorResponse
+
+func (response PurgeCache500JSONResponse) VisitPurgeCacheResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type ListDebugSessionsRequestObject struct {
 	Backend string `json:"backend"`
 }
@@ -2768,6 +3368,164 @@ func (response RefreshUserSession500JSONResponse) VisitRefreshUserSessionRespons
 	return json.NewEncoder(w).Encode(response)
 }
 
+type ListTrafficSplitsRequestObject struct {
+}
+
+type ListTrafficSplitsResponseObject interface {
+	VisitListTrafficSplitsResponse(w http.ResponseWriter) error
+}
+
+type ListTrafficSplits200JSONResponse []TrafficSplit
+
+func (response ListTrafficSplits200JSONResponse) VisitListTrafficSplitsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListTrafficSplits401JSONResponse APIErrorResponse
+
+func (response ListTrafficSplits401JSONResponse) VisitListTrafficSplitsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListTrafficSplits403JSONResponse APIErrorResponse
+
+func (response ListTrafficSplits403JSONResponse) VisitListTrafficSplitsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListTrafficSplits500JSONResponse APIErrorResponse
+
+func (response ListTrafficSplits500JSONResponse) VisitListTrafficSplitsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteTrafficSplitRequestObject struct {
+	Backend string `json:"backend"`
+}
+
+type DeleteTrafficSplitResponseObject interface {
+	VisitDeleteTrafficSplitResponse(w http.ResponseWriter) error
+}
+
+type DeleteTrafficSplit204Response struct {
+}
+
+func (response DeleteTrafficSplit204Response) VisitDeleteTrafficSplitResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteTrafficSplit401JSONResponse APIErrorResponse
+
+func (response DeleteTrafficSplit401JSONResponse) VisitDeleteTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteTrafficSplit403JSONResponse APIErrorResponse
+
+func (response DeleteTrafficSplit403JSONResponse) VisitDeleteTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteTrafficSplit404JSONResponse APIErrorResponse
+
+func (response DeleteTrafficSplit404JSONResponse) VisitDeleteTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteTrafficSplit500JSONResponse APIErrorResponse
+
+func (response DeleteTrafficSplit500JSONResponse) VisitDeleteTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetTrafficSplitRequestObject struct {
+	Backend string `json:"backend"`
+	Body    *SetTrafficSplitJSONRequestBody
+}
+
+type SetTrafficSplitResponseObject interface {
+	VisitSetTrafficSplitResponse(w http.ResponseWriter) error
+}
+
+type SetTrafficSplit200JSONResponse TrafficSplit
+
+func (response SetTrafficSplit200JSONResponse) VisitSetTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetTrafficSplit400JSONResponse APIErrorResponse
+
+func (response SetTrafficSplit400JSONResponse) VisitSetTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetTrafficSplit401JSONResponse APIErrorResponse
+
+func (response SetTrafficSplit401JSONResponse) VisitSetTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetTrafficSplit403JSONResponse APIErrorResponse
+
+func (response SetTrafficSplit403JSONResponse) VisitSetTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetTrafficSplit404JSONResponse APIErrorResponse
+
+func (response SetTrafficSplit404JSONResponse) VisitSetTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetTrafficSplit500JSONResponse APIErrorResponse
+
+func (response SetTrafficSplit500JSONResponse) VisitSetTrafficSplitResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type LoginSuperuserRequestObject struct {
 	Body *LoginSuperuserJSONRequestBody
 }
@@ -3391,6 +4149,9 @@ func (response ChangeUserPassword500JSONResponse) VisitChangeUserPasswordRespons
 // StrictServerInterface represents all server handlers.
 type StrictServerInterface interface {
 
+	// (DELETE /api/admin/cache)
+	PurgeCache(ctx context.Context, request PurgeCacheRequestObject) (PurgeCacheResponseObject, error)
+
 	// (GET /api/admin/debug/{backend}/sessions)
 	ListDebugSessions(ctx context.Context, request ListDebugSessionsRequestObject) (ListDebugSessionsResponseObject, error)
 
@@ -3451,6 +4212,15 @@ type StrictServerInterface interface {
 	// (POST /api/admin/refresh)
 	RefreshUserSession(ctx context.Context, request RefreshUserSessionRequestObject) (RefreshUserSessionResponseObject, error)
 
+	// (GET /api/admin/splits)
+	ListTrafficSplits(ctx context.Context, request ListTrafficSplitsRequestObject) (ListTrafficSplitsResponseObject, error)
+
+	// (DELETE /api/admin/splits/{backend})
+	DeleteTrafficSplit(ctx context.Context, request DeleteTrafficSplitRequestObject) (DeleteTrafficSplitResponseObject, error)
+
+	// (PUT /api/admin/splits/{backend})
+	SetTrafficSplit(ctx context.Context, request SetTrafficSplitRequestObject) (SetTrafficSplitResponseObject, error)
+
 	// (POST /api/admin/superuser/login)
 	LoginSuperuser(ctx context.Context, request LoginSuperuserRequestObject) (LoginSuperuserResponseObject, error)
 
@@ -3514,6 +4284,32 @@ type strictHandler struct {
 	options     StrictHTTPServerOptions
 }
 
+// PurgeCache operation middleware
+func (sh *strictHandler) PurgeCache(w http.ResponseWriter, r *http.Request, params PurgeCacheParams) {
+	var request PurgeCacheRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PurgeCache(ctx, request.(PurgeCacheRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PurgeCache")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PurgeCacheResponseObject); ok {
+		if err := validResponse.VisitPurgeCacheResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // ListDebugSessions operation middleware
 func (sh *strictHandler) ListDebugSessions(w http.ResponseWriter, r *http.Request, backend string) {
 	var request ListDebugSessionsRequestObject
@@ -4064,6 +4860,89 @@ func (sh *strictHandler) RefreshUserSession(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// ListTrafficSplits operation middleware
+func (sh *strictHandler) ListTrafficSplits(w http.ResponseWriter, r *http.Request) {
+	var request ListTrafficSplitsRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListTrafficSplits(ctx, request.(ListTrafficSplitsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListTrafficSplits")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListTrafficSplitsResponseObject); ok {
+		if err := validResponse.VisitListTrafficSplitsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteTrafficSplit operation middleware
+func (sh *strictHandler) DeleteTrafficSplit(w http.ResponseWriter, r *http.Request, backend string) {
+	var request DeleteTrafficSplitRequestObject
+
+	request.Backend = backend
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteTrafficSplit(ctx, request.(DeleteTrafficSplitRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteTrafficSplit")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteTrafficSplitResponseObject); ok {
+		if err := validResponse.VisitDeleteTrafficSplitResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// SetTrafficSplit operation middleware
+func (sh *strictHandler) SetTrafficSplit(w http.ResponseWriter, r *http.Request, backend string) {
+	var request SetTrafficSplitRequestObject
+
+	request.Backend = backend
+
+	var body SetTrafficSplitJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.SetTrafficSplit(ctx, request.(SetTrafficSplitRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "SetTrafficSplit")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(SetTrafficSplitResponseObject); ok {
+		if err := validResponse.VisitSetTrafficSplitResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // LoginSuperuser operation middleware
 func (sh *strictHandler) LoginSuperuser(w http.ResponseWriter, r *http.Request) {
 	var request LoginSuperuserRequestObject
@@ -10,9 +10,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/oapi-codegen/runtime"
 	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
+	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
 const (
@@ -24,6 +26,19 @@ type APIErrorResponse struct {
 	Errors []string `json:"errors"`
 }
 
+// APIKey An API key of an organisation. The key itself is only returned when it is created, the
+// prefix identifies it afterwards.
+type APIKey struct {
+	Created time.Time  `json:"created"`
+	Expires *time.Time `json:"expires,omitempty"`
+	Groups  UserGroups `json:"groups"`
+	Id      int64      `json:"id"`
+	Name    string     `json:"name"`
+
+	// Prefix The first characters of the key.
+	Prefix string `json:"prefix"`
+}
+
 // Group defines model for Group.
 type Group struct {
 	Id   int64  `json:"id"`
@@ -36,6 +51,25 @@ type Organisation struct {
 	Name string `json:"name"`
 }
 
+// QuotaUsage A quota applying to an organisation and the requests counted against it.
+type QuotaUsage struct {
+	// Backend The backend whose requests are counted, absent when all backends are.
+	Backend           *string `json:"backend,omitempty"`
+	RequestsPerDay    *int64  `json:"requestsPerDay,omitempty"`
+	RequestsPerMonth  *int64  `json:"requestsPerMonth,omitempty"`
+	RequestsThisMonth int64   `json:"requestsThisMonth"`
+	RequestsToday     int64   `json:"requestsToday"`
+}
+
+// UsageRecord The usage of a backend by an organisation during a UTC day.
+type UsageRecord struct {
+	Backend       string             `json:"backend"`
+	Day           openapi_types.Date `json:"day"`
+	RequestBytes  int64              `json:"requestBytes"`
+	Requests      int64              `json:"requests"`
+	ResponseBytes int64              `json:"responseBytes"`
+}
+
 // User defines model for User.
 type User struct {
 	Groups *UserGroups `json:"groups,omitempty"`
@@ -46,6 +80,9 @@ type User struct {
 // UserGroups defines model for UserGroups.
 type UserGroups = []Group
 
+// Apikeyid defines model for apikeyid.
+type Apikeyid = int64
+
 // Groupid defines model for groupid.
 type Groupid = int64
 
@@ -55,6 +92,16 @@ type Orgid = int64
 // Userid defines model for userid.
 type Userid = int64
 
+// CreateAPIKeyRequest defines model for CreateAPIKeyRequest.
+type CreateAPIKeyRequest struct {
+	// Expires When the key stops being accepted, omit for a key that does not expire.
+	Expires *time.Time `json:"expires,omitempty"`
+
+	// Groups IDs of groups of the organisation the key is a member of.
+	Groups *[]int64 `json:"groups,omitempty"`
+	Name   string   `json:"name"`
+}
+
 // CreateGroupRequest defines model for CreateGroupRequest.
 type CreateGroupRequest struct {
 	Name string `json:"name"`
@@ -82,6 +129,16 @@ type CreateOrganisationJSONBody struct {
 	Name string `json:"name"`
 }
 
+// CreateAPIKeyJSONBody defines parameters for CreateAPIKey.
+type CreateAPIKeyJSONBody struct {
+	// Expires When the key stops being accepted, omit for a key that does not expire.
+	Expires *time.Time `json:"expires,omitempty"`
+
+	// Groups IDs of groups of the organisation the key is a member of.
+	Groups *[]int64 `json:"groups,omitempty"`
+	Name   string   `json:"name"`
+}
+
 // CreateGroupJSONBody defines parameters for CreateGroup.
 type CreateGroupJSONBody struct {
 	Name string `json:"name"`
@@ -93,6 +150,18 @@ type LoginJSONBody struct {
 	Username string `json:"username"`
 }
 
+// GetUsageParams defines parameters for GetUsage.
+type GetUsageParams struct {
+	// From The first UTC day to include, defaults to the first day of the current month.
+	From *openapi_types.Date `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The last UTC day to include, defaults to the current day.
+	To *openapi_types.Date `form:"to,omitempty" json:"to,omitempty"`
+
+	// Backend Only include the usage of this backend.
+	Backend *string `form:"backend,omitempty" json:"backend,omitempty"`
+}
+
 // CreateUserJSONBody defines parameters for CreateUser.
 type CreateUserJSONBody struct {
 	Name     string `json:"name"`
@@ -111,6 +180,9 @@ type CreateOrganisationJSONRequestBody CreateOrganisationJSONBody
 // UpdateOrganisationJSONRequestBody defines body for UpdateOrganisation for application/json ContentType.
 type UpdateOrganisationJSONRequestBody = Organisation
 
+// CreateAPIKeyJSONRequestBody defines body for CreateAPIKey for application/json ContentType.
+type CreateAPIKeyJSONRequestBody CreateAPIKeyJSONBody
+
 // CreateGroupJSONRequestBody defines body for CreateGroup for application/json ContentType.
 type CreateGroupJSONRequestBody CreateGroupJSONBody
 
@@ -150,6 +222,15 @@ type ServerInterface interface {
 	// (PUT /api/auth/basic/organisations/{orgID})
 	UpdateOrganisation(w http.ResponseWriter, r *http.Request, orgID Orgid)
 
+	// (GET /api/auth/basic/organisations/{orgID}/apikeys)
+	ListAPIKeys(w http.ResponseWriter, r *http.Request, orgID Orgid)
+
+	// (POST /api/auth/basic/organisations/{orgID}/apikeys)
+	CreateAPIKey(w http.ResponseWriter, r *http.Request, orgID Orgid)
+
+	// (DELETE /api/auth/basic/organisations/{orgID}/apikeys/{apiKeyID})
+	DeleteAPIKey(w http.ResponseWriter, r *http.Request, orgID Orgid, apiKeyID Apikeyid)
+
 	// (GET /api/auth/basic/organisations/{orgID}/groups)
 	ListGroups(w http.ResponseWriter, r *http.Request, orgID Orgid)
 
@@ -174,6 +255,12 @@ type ServerInterface interface {
 	// (POST /api/auth/basic/organisations/{orgID}/refresh)
 	Refresh(w http.ResponseWriter, r *http.Request, orgID Orgid)
 
+	// (GET /api/auth/basic/organisations/{orgID}/usage)
+	GetUsage(w http.ResponseWriter, r *http.Request, orgID Orgid, params GetUsageParams)
+
+	// (GET /api/auth/basic/organisations/{orgID}/usage/quotas)
+	GetQuotaUsage(w http.ResponseWriter, r *http.Request, orgID Orgid)
+
 	// (GET /api/auth/basic/organisations/{orgID}/users)
 	ListUsers(w http.ResponseWriter, r *http.Request, orgID Orgid)
 
@@ -341,6 +428,108 @@ func (siw *ServerInterfaceWrapper) UpdateOrganisation(w http.ResponseWriter, r *
 	handler.ServeHTTP(w, r)
 }
 
+// ListAPIKeys operation middleware
+func (siw *ServerInterfaceWrapper) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "orgID" -------------
+	var orgID Orgid
+
+	err = runtime.BindStyledParameterWithOptions("simple", "orgID", r.PathValue("orgID"), &orgID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true, Type: "integer", Format: "int64"})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "orgID", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListAPIKeys(w, r, orgID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateAPIKey operation middleware
+func (siw *ServerInterfaceWrapper) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "orgID" -------------
+	var orgID Orgid
+
+	err = runtime.BindStyledParameterWithOptions("simple", "orgID", r.PathValue("orgID"), &orgID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true, Type: "integer", Format: "int64"})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "orgID", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateAPIKey(w, r, orgID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteAPIKey operation middleware
+func (siw *ServerInterfaceWrapper) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "orgID" -------------
+	var orgID Orgid
+
+	err = runtime.BindStyledParameterWithOptions("simple", "orgID", r.PathValue("orgID"), &orgID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true, Type: "integer", Format: "int64"})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "orgID", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "apiKeyID" -------------
+	var apiKeyID Apikeyid
+
+	err = runtime.BindStyledParameterWithOptions("simple", "apiKeyID", r.PathValue("apiKeyID"), &apiKeyID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true, Type: "integer", Format: "int64"})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "apiKeyID", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteAPIKey(w, r, orgID, apiKeyID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // ListGroups operation middleware
 func (siw *ServerInterfaceWrapper) ListGroups(w http.ResponseWriter, r *http.Request) {
 
@@ -610,6 +799,95 @@ func (siw *ServerInterfaceWrapper) Refresh(w http.ResponseWriter, r *http.Reques
 	handler.ServeHTTP(w, r)
 }
 
+// GetUsage operation middleware
+func (siw *ServerInterfaceWrapper) GetUsage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "orgID" -------------
+	var orgID Orgid
+
+	err = runtime.BindStyledParameterWithOptions("simple", "orgID", r.PathValue("orgID"), &orgID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true, Type: "integer", Format: "int64"})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "orgID", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetUsageParams
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameterWithOptions("form", true, false, "from", r.URL.Query(), &params.From, runtime.BindQueryParameterOptions{Type: "string", Format: "date"})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameterWithOptions("form", true, false, "to", r.URL.Query(), &params.To, runtime.BindQueryParameterOptions{Type: "string", Format: "date"})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "backend" -------------
+
+	err = runtime.BindQueryParameterWithOptions("form", true, false, "backend", r.URL.Query(), &params.Backend, runtime.BindQueryParameterOptions{Type: "string", Format: ""})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "backend", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetUsage(w, r, orgID, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetQuotaUsage operation middleware
+func (siw *ServerInterfaceWrapper) GetQuotaUsage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "orgID" -------------
+	var orgID Orgid
+
+	err = runtime.BindStyledParameterWithOptions("simple", "orgID", r.PathValue("orgID"), &orgID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true, Type: "integer", Format: "int64"})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "orgID", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetQuotaUsage(w, r, orgID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // ListUsers operation middleware
 func (siw *ServerInterfaceWrapper) ListUsers(w http.ResponseWriter, r *http.Request) {
 
@@ -1037,6 +1315,9 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 	m.HandleFunc("DELETE "+options.BaseURL+"/api/auth/basic/organisations/{orgID}", wrapper.DeleteOrganisation)
 	m.HandleFunc("GET "+options.BaseURL+"/api/auth/basic/organisations/{orgID}", wrapper.GetOrganisation)
 	m.HandleFunc("PUT "+options.BaseURL+"/api/auth/basic/organisations/{orgID}", wrapper.UpdateOrganisation)
+	m.HandleFunc("GET "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/apikeys", wrapper.ListAPIKeys)
+	m.HandleFunc("POST "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/apikeys", wrapper.CreateAPIKey)
+	m.HandleFunc("DELETE "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/apikeys/{apiKeyID}", wrapper.DeleteAPIKey)
 	m.HandleFunc("GET "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/groups", wrapper.ListGroups)
 	m.HandleFunc("POST "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/groups", wrapper.CreateGroup)
 	m.HandleFunc("DELETE "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/groups/{groupID}", wrapper.DeleteGroup)
@@ -1045,6 +1326,8 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 	m.HandleFunc("POST "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/login", wrapper.Login)
 	m.HandleFunc("POST "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/logout", wrapper.Logout)
 	m.HandleFunc("POST "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/refresh", wrapper.Refresh)
+	m.HandleFunc("GET "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/usage", wrapper.GetUsage)
+	m.HandleFunc("GET "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/usage/quotas", wrapper.GetQuotaUsage)
 	m.HandleFunc("GET "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/users", wrapper.ListUsers)
 	m.HandleFunc("POST "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/users", wrapper.CreateUser)
 	m.HandleFunc("DELETE "+options.BaseURL+"/api/auth/basic/organisations/{orgID}/users/{userID}", wrapper.DeleteUser)
@@ -1334,6 +1617,177 @@ func (response UpdateOrganisation500JSONResponse) VisitUpdateOrganisationRespons
 	return json.NewEncoder(w).Encode(response)
 }
 
+type ListAPIKeysRequestObject struct {
+	OrgID Orgid `json:"orgID"`
+}
+
+type ListAPIKeysResponseObject interface {
+	VisitListAPIKeysResponse(w http.ResponseWriter) error
+}
+
+type ListAPIKeys200JSONResponse []APIKey
+
+func (response ListAPIKeys200JSONResponse) VisitListAPIKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListAPIKeys401JSONResponse APIErrorResponse
+
+func (response ListAPIKeys401JSONResponse) VisitListAPIKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListAPIKeys403JSONResponse APIErrorResponse
+
+func (response ListAPIKeys403JSONResponse) VisitListAPIKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListAPIKeys500JSONResponse APIErrorResponse
+
+func (response ListAPIKeys500JSONResponse) VisitListAPIKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKeyRequestObject struct {
+	OrgID Orgid `json:"orgID"`
+	Body  *CreateAPIKeyJSONRequestBody
+}
+
+type CreateAPIKeyResponseObject interface {
+	VisitCreateAPIKeyResponse(w http.ResponseWriter) error
+}
+
+type CreateAPIKey201JSONResponse struct {
+	Created time.Time  `json:"created"`
+	Expires *time.Time `json:"expires,omitempty"`
+	Groups  UserGroups `json:"groups"`
+	Id      int64      `json:"id"`
+
+	// Key The API key, it cannot be retrieved again.
+	Key  string `json:"key"`
+	Name string `json:"name"`
+
+	// Prefix The first characters of the key.
+	Prefix string `json:"prefix"`
+}
+
+func (response CreateAPIKey201JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKey400JSONResponse APIErrorResponse
+
+func (response CreateAPIKey400JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKey401JSONResponse APIErrorResponse
+
+func (response CreateAPIKey401JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKey403JSONResponse APIErrorResponse
+
+func (response CreateAPIKey403JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKey409JSONResponse APIErrorResponse
+
+func (response CreateAPIKey409JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKey500JSONResponse APIErrorResponse
+
+func (response CreateAPIKey500JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteAPIKeyRequestObject struct {
+	OrgID    Orgid    `json:"orgID"`
+	ApiKeyID Apikeyid `json:"apiKeyID"`
+}
+
+type DeleteAPIKeyResponseObject interface {
+	VisitDeleteAPIKeyResponse(w http.ResponseWriter) error
+}
+
+type DeleteAPIKey204Response struct {
+}
+
+func (response DeleteAPIKey204Response) VisitDeleteAPIKeyResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteAPIKey401JSONResponse APIErrorResponse
+
+func (response DeleteAPIKey401JSONResponse) VisitDeleteAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteAPIKey403JSONResponse APIErrorResponse
+
+func (response DeleteAPIKey403JSONResponse) VisitDeleteAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteAPIKey404Response struct {
+}
+
+func (response DeleteAPIKey404Response) VisitDeleteAPIKeyResponse(w http.ResponseWriter) error {
+	w.WriteHeader(404)
+	return nil
+}
+
+type DeleteAPIKey500JSONResponse APIErrorResponse
+
+func (response DeleteAPIKey500JSONResponse) VisitDeleteAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type ListGroupsRequestObject struct {
 	OrgID Orgid `json:"orgID"`
 }
@@ -1749,6 +2203,113 @@ func (response Refresh500JSONResponse) VisitRefreshResponse(w http.ResponseWrite
 	return json.NewEncoder(w).Encode(response)
 }
 
+type GetUsageRequestObject struct {
+	OrgID  Orgid `json:"orgID"`
+	Params GetUsageParams
+}
+
+type GetUsageResponseObject interface {
+	VisitGetUsageResponse(w http.ResponseWriter) error
+}
+
+type GetUsage200JSONResponse []UsageRecord
+
+func (response GetUsage200JSONResponse) VisitGetUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetUsage400JSONResponse APIErrorResponse
+
+func (response GetUsage400JSONResponse) VisitGetUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetUsage401JSONResponse APIErrorResponse
+
+func (response GetUsage401JSONResponse) VisitGetUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetUsage403JSONResponse APIErrorResponse
+
+func (response GetUsage403JSONResponse) VisitGetUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetUsage500JSONResponse APIErrorResponse
+
+func (response GetUsage500JSONResponse) VisitGetUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetQuotaUsageRequestObject struct {
+	OrgID Orgid `json:"orgID"`
+}
+
+type GetQuotaUsageResponseObject interface {
+	VisitGetQuotaUsageResponse(w http.ResponseWriter) error
+}
+
+type GetQuotaUsage200JSONResponse []QuotaUsage
+
+func (response GetQuotaUsage200JSONResponse) VisitGetQuotaUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetQuotaUsage401JSONResponse APIErrorResponse
+
+func (response GetQuotaUsage401JSONResponse) VisitGetQuotaUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetQuotaUsage403JSONResponse APIErrorResponse
+
+func (response GetQuotaUsage403JSONResponse) VisitGetQuotaUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetQuotaUsage404JSONResponse APIErrorResponse
+
+func (response GetQuotaUsage404JSONResponse) VisitGetQuotaUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetQuotaUsage500JSONResponse APIErrorResponse
+
+func (response GetQuotaUsage500JSONResponse) VisitGetQuotaUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type ListUsersRequestObject struct {
 	OrgID Orgid `json:"orgID"`
 }
@@ -2188,6 +2749,15 @@ type StrictServerInterface interface {
 	// (PUT /api/auth/basic/organisations/{orgID})
 	UpdateOrganisation(ctx context.Context, request UpdateOrganisationRequestObject) (UpdateOrganisationResponseObject, error)
 
+	// (GET /api/auth/basic/organisations/{orgID}/apikeys)
+	ListAPIKeys(ctx context.Context, request ListAPIKeysRequestObject) (ListAPIKeysResponseObject, error)
+
+	// (POST /api/auth/basic/organisations/{orgID}/apikeys)
+	CreateAPIKey(ctx context.Context, request CreateAPIKeyRequestObject) (CreateAPIKeyResponseObject, error)
+
+	// (DELETE /api/auth/basic/organisations/{orgID}/apikeys/{apiKeyID})
+	DeleteAPIKey(ctx context.Context, request DeleteAPIKeyRequestObject) (DeleteAPIKeyResponseObject, error)
+
 	// (GET /api/auth/basic/organisations/{orgID}/groups)
 	ListGroups(ctx context.Context, request ListGroupsRequestObject) (ListGroupsResponseObject, error)
 
@@ -2212,6 +2782,12 @@ type StrictServerInterface interface {
 	// (POST /api/auth/basic/organisations/{orgID}/refresh)
 	Refresh(ctx context.Context, request RefreshRequestObject) (RefreshResponseObject, error)
 
+	// (GET /api/auth/basic/organisations/{orgID}/usage)
+	GetUsage(ctx context.Context, request GetUsageRequestObject) (GetUsageResponseObject, error)
+
+	// (GET /api/auth/basic/organisations/{orgID}/usage/quotas)
+	GetQuotaUsage(ctx context.Context, request GetQuotaUsageRequestObject) (GetQuotaUsageResponseObject, error)
+
 	// (GET /api/auth/basic/organisations/{orgID}/users)
 	ListUsers(ctx context.Context, request ListUsersRequestObject) (ListUsersResponseObject, error)
 
@@ -2406,6 +2982,92 @@ func (sh *strictHandler) UpdateOrganisation(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// ListAPIKeys operation middleware
+func (sh *strictHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request, orgID Orgid) {
+	var request ListAPIKeysRequestObject
+
+	request.OrgID = orgID
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListAPIKeys(ctx, request.(ListAPIKeysRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListAPIKeys")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListAPIKeysResponseObject); ok {
+		if err := validResponse.VisitListAPIKeysResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// CreateAPIKey operation middleware
+func (sh *strictHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request, orgID Orgid) {
+	var request CreateAPIKeyRequestObject
+
+	request.OrgID = orgID
+
+	var body CreateAPIKeyJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CreateAPIKey(ctx, request.(CreateAPIKeyRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CreateAPIKey")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CreateAPIKeyResponseObject); ok {
+		if err := validResponse.VisitCreateAPIKeyResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteAPIKey operation middleware
+func (sh *strictHandler) DeleteAPIKey(w http.ResponseWriter, r *http.Request, orgID Orgid, apiKeyID Apikeyid) {
+	var request DeleteAPIKeyRequestObject
+
+	request.OrgID = orgID
+	request.ApiKeyID = apiKeyID
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteAPIKey(ctx, request.(DeleteAPIKeyRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteAPIKey")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteAPIKeyResponseObject); ok {
+		if err := validResponse.VisitDeleteAPIKeyResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // ListGroups operation middleware
 func (sh *strictHandler) ListGroups(w http.ResponseWriter, r *http.Request, orgID Orgid) {
 	var request ListGroupsRequestObject
@@ -2638,6 +3300,59 @@ func (sh *strictHandler) Refresh(w http.ResponseWriter, r *http.Request, orgID O
 	}
 }
 
+// GetUsage operation middleware
+func (sh *strictHandler) GetUsage(w http.ResponseWriter, r *http.Request, orgID Orgid, params GetUsageParams) {
+	var request GetUsageRequestObject
+
+	request.OrgID = orgID
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetUsage(ctx, request.(GetUsageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetUsage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetUsageResponseObject); ok {
+		if err := validResponse.VisitGetUsageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetQuotaUsage operation middleware
+func (sh *strictHandler) GetQuotaUsage(w http.ResponseWriter, r *http.Request, orgID Orgid) {
+	var request GetQuotaUsageRequestObject
+
+	request.OrgID = orgID
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetQuotaUsage(ctx, request.(GetQuotaUsageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetQuotaUsage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetQuotaUsageResponseObject); ok {
+		if err := validResponse.VisitGetQuotaUsageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // ListUsers operation middleware
 func (sh *strictHandler) ListUsers(w http.ResponseWriter, r *http.Request, orgID Orgid) {
 	var request ListUsersRequestObject
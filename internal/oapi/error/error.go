@@ -46,6 +46,16 @@ var (
 		StatusCode: http.StatusForbidden,
 	}
 	//nolint:errname // This is intentional to separate pure error types from wrapper API Errors.
+	ErrTooManyRequests = &Error{
+		Errors:     []string{http.StatusText(http.StatusTooManyRequests)},
+		StatusCode: http.StatusTooManyRequests,
+	}
+	//nolint:errname // This is intentional to separate pure error types from wrapper API Errors.
+	ErrRequestEntityTooLarge = &Error{
+		Errors:     []string{http.StatusText(http.StatusRequestEntityTooLarge)},
+		StatusCode: http.StatusRequestEntityTooLarge,
+	}
+	//nolint:errname // This is intentional to separate pure error types from wrapper API Errors.
 	ErrUnimplemented = &Error{
 		Errors:     []string{http.StatusText(http.StatusNotImplemented)},
 		StatusCode: http.StatusNotImplemented,
@@ -6,5 +6,6 @@
 package oapi
 
 //go:generate oapi-codegen -config auth_basic_config.yaml ../../openapi/auth_basic.yaml
+//go:generate oapi-codegen -config auth_oidc_config.yaml ../../openapi/auth_oidc.yaml
 //go:generate oapi-codegen -config admin_config.yaml ../../openapi/admin.yaml
 //go:generate oapi-codegen -config gateway_config.yaml ../../openapi/gateway.yaml
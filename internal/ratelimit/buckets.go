@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type (
+	// buckets holds the token buckets of a policy, one per key. Buckets idle long enough to be
+	// full again are dropped, since a new bucket behaves the same.
+	buckets struct {
+		mu      sync.Mutex
+		limit   rate.Limit
+		burst   int
+		refill  time.Duration
+		entries map[string]*bucket
+		swept   time.Time
+	}
+	bucket struct {
+		limiter  *rate.Limiter
+		lastSeen time.Time
+	}
+)
+
+func newBuckets(requests int, period time.Duration, burst int) *buckets {
+	limit := rate.Limit(float64(requests) / period.Seconds())
+	return &buckets{
+		limit:   limit,
+		burst:   burst,
+		refill:  time.Duration(float64(burst) / float64(limit) * float64(time.Second)),
+		entries: make(map[string]*bucket),
+	}
+}
+
+// reserve takes a token from the bucket of the key. The reservation must be cancelled if the
+// request is not let through.
+func (b *buckets) reserve(key string, now time.Time) (*rate.Reservation, *rate.Limiter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sweep(now)
+
+	e, ok := b.entries[key]
+	if !ok {
+		e = &bucket{limiter: rate.NewLimiter(b.limit, b.burst)}
+		b.entries[key] = e
+	}
+	e.lastSeen = now
+
+	return e.limiter.ReserveN(now, 1), e.limiter
+}
+
+// sweep drops the buckets that have been full for a while, at most once per refill duration.
+func (b *buckets) sweep(now time.Time) {
+	if now.Sub(b.swept) < b.refill {
+		return
+	}
+	b.swept = now
+
+	for key, e := range b.entries {
+		if now.Sub(e.lastSeen) > b.refill {
+			delete(b.entries, key)
+		}
+	}
+}
+
+// len returns the number of buckets currently kept.
+func (b *buckets) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.entries)
+}
@@ -0,0 +1,217 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/composer/custom"
+	"github.com/trebent/kerberos/internal/composer/debug"
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	apierror "github.com/trebent/kerberos/internal/oapi/error"
+	utilhttp "github.com/trebent/kerberos/internal/util/http"
+	"github.com/trebent/zerologr"
+	"golang.org/x/time/rate"
+)
+
+type (
+	Limiter interface {
+		composer.FlowComponent
+		custom.Ordered
+	}
+	limiter struct {
+		next     composer.FlowComponent
+		cfg      *config.RateLimitConfig
+		policies map[string][]*policy
+	}
+	Opts struct {
+		Cfg *config.RateLimitConfig
+		// TrustedProxies resolve the client address of the ip key, nil trusts no proxies.
+		TrustedProxies *utilhttp.TrustedProxies
+	}
+
+	policy struct {
+		cfg     *config.RateLimitPolicy
+		buckets *buckets
+		trusted *utilhttp.TrustedProxies
+	}
+	// state is the bucket state of a policy after a request, reported in the RateLimit-* headers.
+	state struct {
+		limit     int
+		remaining int
+		reset     time.Duration
+	}
+)
+
+const (
+	componentName = "rate-limiter"
+
+	orgHeader  = "X-Krb-Org"
+	userHeader = "X-Krb-User"
+)
+
+var _ Limiter = (*limiter)(nil)
+
+func NewComponent(opts *Opts) Limiter {
+	l := &limiter{
+		cfg:      opts.Cfg,
+		policies: make(map[string][]*policy),
+	}
+	for _, p := range l.cfg.Policies {
+		zerologr.Info("Preparing rate limit policy", "backend", p.Backend, "key", p.Key)
+		l.policies[p.Backend] = append(l.policies[p.Backend], &policy{
+			cfg:     p,
+			trusted: opts.TrustedProxies,
+			buckets: newBuckets(
+				p.Requests,
+				time.Duration(p.PeriodMs)*time.Millisecond,
+				p.Burst,
+			),
+		})
+	}
+
+	return l
+}
+
+func (l *limiter) Order() int {
+	return l.cfg.Order
+}
+
+// Next implements [composer.FlowComponent].
+func (l *limiter) Next(next composer.FlowComponent) {
+	l.next = next
+}
+
+// GetMeta implements [composer.FlowComponent].
+func (l *limiter) GetMeta() []adminapi.FlowMeta {
+	policies := make([]adminapi.FlowMetaDataRateLimitPolicy, 0, len(l.cfg.Policies))
+	for _, p := range l.cfg.Policies {
+		policy := adminapi.FlowMetaDataRateLimitPolicy{
+			Backend:  p.Backend,
+			Key:      p.Key,
+			Requests: p.Requests,
+			Period:   p.PeriodMs,
+			Burst:    p.Burst,
+		}
+		if p.Header != "" {
+			policy.Header = &p.Header
+		}
+		policies = append(policies, policy)
+	}
+
+	fmd := adminapi.FlowMeta_Data{}
+	if err := fmd.FromFlowMetaDataRateLimit(adminapi.FlowMetaDataRateLimit{
+		Policies: &policies,
+	}); err != nil {
+		panic(err)
+	}
+
+	return append([]adminapi.FlowMeta{
+		{
+			Name: componentName,
+			Data: fmd,
+		},
+	}, l.next.GetMeta()...)
+}
+
+// ServeHTTP implements [composer.FlowComponent].
+func (l *limiter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	backend, _ := req.Context().Value(composer.BackendContextKey).(string)
+	policies, ok := l.policies[backend]
+	if !ok {
+		l.next.ServeHTTP(w, req)
+		return
+	}
+
+	debugStart := time.Now()
+	debugCall := composer.DebugFromContext(req.Context())
+	logger, _ := logr.FromContext(req.Context())
+	logger = logger.WithName(componentName)
+
+	now := time.Now()
+	reservations := make([]*rate.Reservation, 0, len(policies))
+	var reported *state
+	for _, p := range policies {
+		reservation, bucket := p.buckets.reserve(p.key(req), now)
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			// The request is not let through, so it must not use up the tokens of any policy.
+			reservation.CancelAt(now)
+			for _, r := range reservations {
+				r.CancelAt(now)
+			}
+
+			logger.Info("Rate limited request", "backend", backend, "key", p.cfg.Key)
+			setHeaders(w, p.state(bucket, now))
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			apierror.ErrorHandler(w, req, apierror.ErrTooManyRequests)
+			debugCall.AddTransition(
+				componentName,
+				debug.CallDirectionInbound,
+				debugStart,
+				time.Now(),
+				debug.CallResultFailure,
+				"rate limited",
+				debug.Attr("key", p.cfg.Key),
+			)
+			return
+		}
+		reservations = append(reservations, reservation)
+
+		// Report the policy closest to throttling.
+		if s := p.state(bucket, now); reported == nil || s.remaining < reported.remaining {
+			reported = s
+		}
+	}
+
+	setHeaders(w, reported)
+	debugCall.AddTransition(
+		componentName,
+		debug.CallDirectionInbound,
+		debugStart,
+		time.Now(),
+		debug.CallResultSuccess,
+		"",
+	)
+
+	l.next.ServeHTTP(w, req)
+}
+
+// key returns the bucket key of the request. Requests without a value for the key share a bucket.
+func (p *policy) key(req *http.Request) string {
+	switch p.cfg.Key {
+	case config.RateLimitKeyIP:
+		return p.trusted.ClientIP(req.Header, utilhttp.RemoteIP(req))
+	case config.RateLimitKeyOrg:
+		return req.Header.Get(orgHeader)
+	case config.RateLimitKeyUser:
+		return req.Header.Get(userHeader)
+	case config.RateLimitKeyHeader:
+		return req.Header.Get(p.cfg.Header)
+	default:
+		return ""
+	}
+}
+
+// state returns the state of a bucket of the policy.
+func (p *policy) state(bucket *rate.Limiter, now time.Time) *state {
+	tokens := max(bucket.TokensAt(now), 0)
+	missing := float64(p.cfg.Burst) - tokens
+
+	return &state{
+		limit:     p.cfg.Burst,
+		remaining: int(math.Floor(tokens)),
+		reset:     time.Duration(missing / float64(bucket.Limit()) * float64(time.Second)),
+	}
+}
+
+// setHeaders sets the RateLimit-* headers, see draft-ietf-httpapi-ratelimit-headers. The reset is
+// the number of seconds until the bucket is full again.
+func setHeaders(w http.ResponseWriter, s *state) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(s.limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(s.remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(s.reset.Seconds()))))
+}
@@ -0,0 +1,251 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/trebent/kerberos/internal/composer"
+	"github.com/trebent/kerberos/internal/config"
+	adminapi "github.com/trebent/kerberos/internal/oapi/admin"
+	utilhttp "github.com/trebent/kerberos/internal/util/http"
+)
+
+// terminal ends the flow in tests.
+type terminal struct {
+	composer.Dummy
+}
+
+func (*terminal) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (*terminal) GetMeta() []adminapi.FlowMeta {
+	return nil
+}
+
+func newLimiter(policies ...*config.RateLimitPolicy) Limiter {
+	cfg := &config.RateLimitConfig{Order: 1, Policies: policies}
+	for _, p := range policies {
+		if p.PeriodMs == 0 {
+			p.PeriodMs = 60000
+		}
+		if p.Burst == 0 {
+			p.Burst = p.Requests
+		}
+	}
+
+	l := NewComponent(&Opts{Cfg: cfg})
+	l.Next(&terminal{})
+	return l
+}
+
+func serve(l Limiter, backend string, modify func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if modify != nil {
+		modify(req)
+	}
+
+	recorder := httptest.NewRecorder()
+	l.ServeHTTP(
+		recorder,
+		req.WithContext(context.WithValue(req.Context(), composer.BackendContextKey, backend)),
+	)
+	return recorder
+}
+
+func TestRateLimitIP(t *testing.T) {
+	l := newLimiter(&config.RateLimitPolicy{
+		Backend:  "backend1",
+		Key:      config.RateLimitKeyIP,
+		Requests: 2,
+	})
+
+	for i, expected := range []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests} {
+		recorder := serve(l, "backend1", nil)
+		if recorder.Code != expected {
+			t.Fatalf("Request %d: expected status code %d, got %d", i, expected, recorder.Code)
+		}
+	}
+
+	recorder := serve(l, "backend1", nil)
+	if recorder.Header().Get("RateLimit-Limit") != "2" {
+		t.Errorf("Expected RateLimit-Limit 2, got %q", recorder.Header().Get("RateLimit-Limit"))
+	}
+	if recorder.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("Expected RateLimit-Remaining 0, got %q", recorder.Header().Get("RateLimit-Remaining"))
+	}
+	if recorder.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", recorder.Header().Get("Retry-After"))
+	}
+	if recorder.Header().Get("RateLimit-Reset") != "60" {
+		t.Errorf("Expected RateLimit-Reset 60, got %q", recorder.Header().Get("RateLimit-Reset"))
+	}
+
+	recorder = serve(l, "backend1", func(req *http.Request) { req.RemoteAddr = "192.0.2.2:1234" })
+	if recorder.Code != http.StatusNoContent {
+		t.Errorf("Expected another client IP to have its own bucket, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("Expected RateLimit-Remaining 1, got %q", recorder.Header().Get("RateLimit-Remaining"))
+	}
+
+	if recorder := serve(l, "backend2", nil); recorder.Code != http.StatusNoContent {
+		t.Errorf("Expected backends without policies to pass, got %d", recorder.Code)
+	}
+}
+
+func TestRateLimitTrustedProxies(t *testing.T) {
+	trusted, err := utilhttp.NewTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	l := NewComponent(&Opts{
+		Cfg: &config.RateLimitConfig{Order: 1, Policies: []*config.RateLimitPolicy{{
+			Backend:  "backend1",
+			Key:      config.RateLimitKeyIP,
+			Requests: 1,
+			PeriodMs: 60000,
+			Burst:    1,
+		}}},
+		TrustedProxies: trusted,
+	})
+	l.Next(&terminal{})
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expected     int
+	}{
+		// Both clients come through the same trusted load balancer.
+		{"first client", "10.0.0.1:1234", "198.51.100.1", http.StatusNoContent},
+		{"second client", "10.0.0.1:1234", "198.51.100.2, 10.0.0.2", http.StatusNoContent},
+		{"first client again", "10.0.0.1:1234", "198.51.100.1", http.StatusTooManyRequests},
+		// Untrusted peers cannot pick their bucket with X-Forwarded-For.
+		{"untrusted peer", "203.0.113.1:1234", "198.51.100.3", http.StatusNoContent},
+		{"untrusted peer again", "203.0.113.1:1234", "198.51.100.4", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		recorder := serve(l, "backend1", func(req *http.Request) {
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+		})
+		if recorder.Code != tt.expected {
+			t.Fatalf("%s: expected status code %d, got %d", tt.name, tt.expected, recorder.Code)
+		}
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	tests := []struct {
+		policy *config.RateLimitPolicy
+		modify func(*http.Request, string)
+	}{
+		{
+			policy: &config.RateLimitPolicy{Key: config.RateLimitKeyOrg},
+			modify: func(req *http.Request, value string) { req.Header.Set(orgHeader, value) },
+		},
+		{
+			policy: &config.RateLimitPolicy{Key: config.RateLimitKeyUser},
+			modify: func(req *http.Request, value string) { req.Header.Set(userHeader, value) },
+		},
+		{
+			policy: &config.RateLimitPolicy{Key: config.RateLimitKeyHeader, Header: "X-Api-Key"},
+			modify: func(req *http.Request, value string) { req.Header.Set("X-Api-Key", value) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.Key, func(t *testing.T) {
+			tt.policy.Backend = "backend1"
+			tt.policy.Requests = 1
+			l := newLimiter(tt.policy)
+
+			one := func(req *http.Request) { tt.modify(req, "1") }
+			two := func(req *http.Request) { tt.modify(req, "2") }
+
+			if recorder := serve(l, "backend1", one); recorder.Code != http.StatusNoContent {
+				t.Fatalf("Expected the first request to pass, got %d", recorder.Code)
+			}
+			if recorder := serve(l, "backend1", one); recorder.Code != http.StatusTooManyRequests {
+				t.Fatalf("Expected the second request to be limited, got %d", recorder.Code)
+			}
+			if recorder := serve(l, "backend1", two); recorder.Code != http.StatusNoContent {
+				t.Fatalf("Expected another key to have its own bucket, got %d", recorder.Code)
+			}
+		})
+	}
+}
+
+func TestRateLimitPolicies(t *testing.T) {
+	l := newLimiter(
+		&config.RateLimitPolicy{Backend: "backend1", Key: config.RateLimitKeyBackend, Requests: 3},
+		&config.RateLimitPolicy{Backend: "backend1", Key: config.RateLimitKeyOrg, Requests: 1},
+	)
+
+	org := func(value string) func(*http.Request) {
+		return func(req *http.Request) { req.Header.Set(orgHeader, value) }
+	}
+
+	if recorder := serve(l, "backend1", org("1")); recorder.Code != http.StatusNoContent {
+		t.Fatalf("Expected the first request to pass, got %d", recorder.Code)
+	}
+	// Throttled requests must not use up the tokens of the backend policy.
+	for range 3 {
+		if recorder := serve(l, "backend1", org("1")); recorder.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected org 1 to be limited, got %d", recorder.Code)
+		}
+	}
+	for _, value := range []string{"2", "3"} {
+		if recorder := serve(l, "backend1", org(value)); recorder.Code != http.StatusNoContent {
+			t.Fatalf("Expected org %s to pass, got %d", value, recorder.Code)
+		}
+	}
+	if recorder := serve(l, "backend1", org("4")); recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the backend policy to limit, got %d", recorder.Code)
+	}
+}
+
+func TestBucketsSweep(t *testing.T) {
+	b := newBuckets(10, time.Second, 10)
+	now := time.Now()
+
+	b.reserve("one", now)
+	b.reserve("two", now.Add(500*time.Millisecond))
+	if b.len() != 2 {
+		t.Fatalf("Expected 2 buckets, got %d", b.len())
+	}
+
+	b.reserve("three", now.Add(1200*time.Millisecond))
+	if b.len() != 2 {
+		t.Errorf("Expected the full bucket to be dropped, got %d buckets", b.len())
+	}
+}
+
+func TestGetMeta(t *testing.T) {
+	l := newLimiter(&config.RateLimitPolicy{
+		Backend:  "backend1",
+		Key:      config.RateLimitKeyHeader,
+		Header:   "X-Api-Key",
+		Requests: 5,
+	})
+
+	meta := l.GetMeta()
+	if meta[0].Name != componentName {
+		t.Fatalf("Expected name %s, got %s", componentName, meta[0].Name)
+	}
+
+	data, err := meta[0].Data.AsFlowMetaDataRateLimit()
+	if err != nil {
+		t.Fatalf("Failed to read meta data: %v", err)
+	}
+
+	p := (*data.Policies)[0]
+	if p.Backend != "backend1" || p.Key != config.RateLimitKeyHeader || *p.Header != "X-Api-Key" ||
+		p.Requests != 5 || p.Period != 60000 || p.Burst != 5 {
+		t.Errorf("Unexpected policy meta: %+v", p)
+	}
+}
@@ -2,9 +2,12 @@
 package response
 
 import (
+	"bufio"
 	"context"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/trebent/zerologr"
 	"go.opentelemetry.io/otel/codes"
@@ -26,9 +29,12 @@ type (
 	}
 )
 
+const grpcStatusHeader = "Grpc-Status"
+
 var (
 	_ http.ResponseWriter = &Wrapper{}
 	_ http.Flusher        = &Wrapper{}
+	_ http.Hijacker       = &Wrapper{}
 
 	_ io.ReadCloser = &BodyWrapper{}
 )
@@ -126,6 +132,33 @@ func (r *Wrapper) Flush() {
 	}
 }
 
+// Hijack lets the caller take over the connection, if the underlying http.ResponseWriter supports
+// it. The gateway only hijacks connections to tunnel protocol upgrades, so a hijacked response is
+// recorded with status code 101 Switching Protocols.
+func (r *Wrapper) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	zerologr.V(100).Info("Hijack connection")
+
+	hijacker, ok := r.responseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.wroteHeader = true
+	r.statusCode = http.StatusSwitchingProtocols
+	return conn, brw, nil
+}
+
+// Unwrap returns the underlying http.ResponseWriter, allowing http.ResponseController to reach
+// features of it the Wrapper does not implement, such as write deadlines.
+func (r *Wrapper) Unwrap() http.ResponseWriter {
+	return r.responseWriter
+}
+
 // NumBytes returns the total number of bytes written to the response.
 func (r *Wrapper) NumBytes() int64 {
 	return r.bytes
@@ -149,5 +182,29 @@ func (r *Wrapper) SpanStatus() (codes.Code, string) {
 		return codes.Error, http.StatusText(r.statusCode)
 	}
 
+	if status, ok := r.GRPCStatus(); ok && status != 0 {
+		return codes.Error, "grpc-status " + strconv.Itoa(status)
+	}
+
 	return codes.Ok, http.StatusText(r.statusCode)
 }
+
+// GRPCStatus returns the status of a gRPC call, read from the grpc-status trailer or, for responses
+// without messages, header. It returns false if the response carries no gRPC status.
+func (r *Wrapper) GRPCStatus() (int, bool) {
+	header := r.Header()
+	value := header.Get(http.TrailerPrefix + grpcStatusHeader)
+	if value == "" {
+		value = header.Get(grpcStatusHeader)
+	}
+	if value == "" {
+		return 0, false
+	}
+
+	status, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return status, true
+}
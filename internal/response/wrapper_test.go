@@ -2,10 +2,13 @@ package response
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"go.opentelemetry.io/otel/codes"
 )
 
 func TestWriteHeader(t *testing.T) {
@@ -52,3 +55,79 @@ func TestBodyWrapper(t *testing.T) {
 		t.Errorf("Expected byte count to be 5, got %d", bwrapper.NumBytes())
 	}
 }
+
+func TestHijack(t *testing.T) {
+	wrapper := &Wrapper{
+		responseWriter: httptest.NewRecorder(),
+	}
+
+	if _, _, err := wrapper.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Fatalf("Expected %v for a recorder, got %v", http.ErrNotSupported, err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		wrapper := NewResponseWrapper(w).(*Wrapper)
+		conn, _, err := wrapper.Hijack()
+		if err != nil {
+			t.Errorf("Failed to hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		if wrapper.StatusCode() != http.StatusSwitchingProtocols {
+			t.Errorf("Expected status code %d, got %d", http.StatusSwitchingProtocols, wrapper.StatusCode())
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 204 No Content\r\n\r\n"))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to get: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, resp.StatusCode)
+	}
+}
+
+func TestGRPCStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     http.Header
+		status     int
+		ok         bool
+		spanStatus codes.Code
+	}{
+		{name: "no gRPC", header: http.Header{}, ok: false, spanStatus: codes.Ok},
+		{name: "trailer", header: http.Header{"Grpc-Status": {"0"}}, status: 0, ok: true, spanStatus: codes.Ok},
+		{
+			name:       "prefixed trailer",
+			header:     http.Header{http.TrailerPrefix + "Grpc-Status": {"14"}},
+			status:     14,
+			ok:         true,
+			spanStatus: codes.Error,
+		},
+		{name: "invalid", header: http.Header{"Grpc-Status": {"x"}}, ok: false, spanStatus: codes.Ok},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			for key, values := range tt.header {
+				recorder.Header()[key] = values
+			}
+			wrapper := &Wrapper{responseWriter: recorder}
+			wrapper.WriteHeader(http.StatusOK)
+
+			status, ok := wrapper.GRPCStatus()
+			if status != tt.status || ok != tt.ok {
+				t.Errorf("Expected gRPC status %d (%t), got %d (%t)", tt.status, tt.ok, status, ok)
+			}
+			if code, _ := wrapper.SpanStatus(); code != tt.spanStatus {
+				t.Errorf("Expected span status %v, got %v", tt.spanStatus, code)
+			}
+		})
+	}
+}
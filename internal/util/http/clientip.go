@@ -0,0 +1,90 @@
+package utilhttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// TrustedProxies resolves the address of clients behind trusted proxies. The zero value trusts
+// no proxies.
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// NewTrustedProxies parses the trusted proxies, given as IP addresses or CIDR ranges.
+func NewTrustedProxies(proxies []string) (*TrustedProxies, error) {
+	tp := &TrustedProxies{}
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("parsing trusted proxy %q: %w", proxy, err)
+			}
+			tp.prefixes = append(tp.prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parsing trusted proxy %q: %w", proxy, err)
+		}
+		tp.prefixes = append(tp.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	return tp, nil
+}
+
+// IsTrusted returns true if the IP address belongs to a trusted proxy.
+func (tp *TrustedProxies) IsTrusted(ip string) bool {
+	if tp == nil {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	return slices.ContainsFunc(tp.prefixes, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	})
+}
+
+// ClientIP returns the address of the client, given the remote address of a request and its
+// header. Behind trusted proxies this is the right-most untrusted address of the X-Forwarded-For
+// header, otherwise the remote address.
+func (tp *TrustedProxies) ClientIP(header http.Header, remote string) string {
+	if !tp.IsTrusted(remote) {
+		return remote
+	}
+
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for hop := range strings.SplitSeq(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	for _, hop := range slices.Backward(hops) {
+		if !tp.IsTrusted(hop) {
+			return hop
+		}
+	}
+
+	return remote
+}
+
+// RemoteIP returns the IP address of the immediate peer of the request.
+func RemoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"flag"
 	"fmt"
@@ -16,17 +18,23 @@ import (
 	"github.com/trebent/envparser"
 	"github.com/trebent/kerberos/internal/admin"
 	"github.com/trebent/kerberos/internal/auth"
+	"github.com/trebent/kerberos/internal/bodylimit"
+	"github.com/trebent/kerberos/internal/cache"
 	"github.com/trebent/kerberos/internal/composer"
 	"github.com/trebent/kerberos/internal/composer/custom"
 	"github.com/trebent/kerberos/internal/composer/forwarder"
 	obs "github.com/trebent/kerberos/internal/composer/observability"
 	"github.com/trebent/kerberos/internal/composer/router"
+	"github.com/trebent/kerberos/internal/compression"
 	"github.com/trebent/kerberos/internal/config"
 	"github.com/trebent/kerberos/internal/db"
 	"github.com/trebent/kerberos/internal/db/postgres"
 	"github.com/trebent/kerberos/internal/db/sqlite"
+	"github.com/trebent/kerberos/internal/headers"
 	"github.com/trebent/kerberos/internal/oas"
+	"github.com/trebent/kerberos/internal/ratelimit"
 	"github.com/trebent/kerberos/internal/response"
+	utilhttp "github.com/trebent/kerberos/internal/util/http"
 	"github.com/trebent/zerologr"
 	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
 )
@@ -206,16 +214,35 @@ func startServer(ctx context.Context, cfg *config.RootConfig) error {
 		return fmt.Errorf("failed to initialize admin: %w", err)
 	}
 
+	zerologr.Info("Loading forwarder")
+	forwarder, err := forwarder.NewComponent(&forwarder.Opts{
+		Backends:     cfg.GatewayConfig.Router.Backends,
+		ProxyHeaders: cfg.GatewayConfig.ProxyHeaders,
+		Splits:       cfg.GatewayConfig.Router.Splits,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize forwarder: %w", err)
+	}
+
+	// Register the forwarder with the admin component so that the admin API can adjust its traffic
+	// splits.
+	adm.SetTrafficSplitter(forwarder)
+
+	zerologr.Info("Loading router")
+	router := router.NewComponent(&router.Opts{
+		Cfg:       cfg.GatewayConfig.Router,
+		Health:    forwarder,
+		Discovery: forwarder,
+	})
+
 	zerologr.Info("Loading observability")
 	observability := obs.NewComponent(&obs.Opts{
 		Cfg:      cfg.ObservabilityConfig,
 		Version:  Version.Value(),
 		Debugger: adm.GetDebugger(),
+		Resolver: router,
 	})
 
-	zerologr.Info("Loading router")
-	router := router.NewComponent(&router.Opts{Cfg: cfg.GatewayConfig.Router})
-
 	zerologr.Info("Loading custom")
 	customFlowComponents := make([]composer.FlowComponent, 0)
 
@@ -230,6 +257,7 @@ func startServer(ctx context.Context, cfg *config.RootConfig) error {
 			return fmt.Errorf("failed to initialize auth: %w", err)
 		}
 		customFlowComponents = append(customFlowComponents, authorizer)
+		authorizer.StartKeyReload(ctx)
 
 		// Register the authorizer with the admin component so that it can serve auth paths via the admin server mux.
 		if err := adm.RegisterAPIProvider(authorizer); err != nil {
@@ -248,16 +276,62 @@ func startServer(ctx context.Context, cfg *config.RootConfig) error {
 		adm.SetOASBackend(oasValidator)
 	}
 
-	custom := custom.NewComponent(customFlowComponents...)
+	if cfg.HeadersEnabled() {
+		zerologr.Info("Loading header transformer")
+		customFlowComponents = append(customFlowComponents, headers.NewComponent(&headers.Opts{
+			Cfg: cfg.HeadersConfig,
+		}))
+	}
 
-	zerologr.Info("Loading forwarder")
-	forwarder, err := forwarder.NewComponent(&forwarder.Opts{
-		Backends: cfg.GatewayConfig.Router.Backends,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to initialize forwarder: %w", err)
+	if cfg.RateLimitEnabled() {
+		zerologr.Info("Loading rate limiter")
+		// Rate limits by IP resolve the client behind the proxies trusted for the proxy headers.
+		var trustedProxies *utilhttp.TrustedProxies
+		if cfg.GatewayConfig.ProxyHeaders != nil {
+			trustedProxies, err = utilhttp.NewTrustedProxies(
+				cfg.GatewayConfig.ProxyHeaders.TrustedProxies,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to initialize rate limiter: %w", err)
+			}
+		}
+		customFlowComponents = append(customFlowComponents, ratelimit.NewComponent(&ratelimit.Opts{
+			Cfg:            cfg.RateLimitConfig,
+			TrustedProxies: trustedProxies,
+		}))
+	}
+
+	if cfg.BodyLimitEnabled() {
+		zerologr.Info("Loading body limiter")
+		customFlowComponents = append(customFlowComponents, bodylimit.NewComponent(&bodylimit.Opts{
+			Cfg: cfg.BodyLimitConfig,
+		}))
 	}
 
+	if cfg.CacheEnabled() {
+		zerologr.Info("Loading response cache")
+		responseCache, err := cache.NewComponent(&cache.Opts{
+			Cfg:       cfg.CacheConfig,
+			SQLClient: db,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize cache: %w", err)
+		}
+		customFlowComponents = append(customFlowComponents, responseCache)
+
+		// Register the cache with the admin component so that the admin API can purge it.
+		adm.SetCachePurger(responseCache)
+	}
+
+	if cfg.CompressionEnabled() {
+		zerologr.Info("Loading response compression")
+		customFlowComponents = append(customFlowComponents, compression.NewComponent(&compression.Opts{
+			Cfg: cfg.CompressionConfig,
+		}))
+	}
+
+	custom := custom.NewComponent(customFlowComponents...)
+
 	zerologr.Info("Loading composer")
 	composer := composer.New(&composer.Opts{
 		Observability: observability,
@@ -269,16 +343,34 @@ func startServer(ctx context.Context, cfg *config.RootConfig) error {
 	// Register the flow fetcher with the admin component so that it can serve flow metadata to the admin API.
 	adm.SetFlowFetcher(composer)
 
+	forwarder.StartDiscovery(ctx)
+	forwarder.StartHealthChecks(ctx)
+
 	zerologr.Info("Starting server")
-	gwMux.Handle("/gw/", composer)
+	// All paths are handled by the composer since routes may match on any host or path, not only the
+	// legacy /gw/backend/ prefix.
+	gwMux.Handle("/", composer)
 	gwMux.Handle("/gw/health", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	}))
+	// HTTP/2 is negotiated over TLS, h2c lets gRPC clients use HTTP/2 without it.
+	gwProtocols := &http.Protocols{}
+	gwProtocols.SetHTTP1(true)
+	gwProtocols.SetHTTP2(true)
+	gwProtocols.SetUnencryptedHTTP2(cfg.GatewayConfig.H2C)
 	gwServer := http.Server{
 		Addr:         fmt.Sprintf(":%d", Port.Value()),
 		ReadTimeout:  readTimeout,
 		WriteTimeout: writeTimeout,
 		Handler:      gwMux,
+		Protocols:    gwProtocols,
+	}
+	if tlsCfg := cfg.GatewayConfig.TLS; tlsCfg != nil && tlsCfg.ClientCAFile != "" {
+		gwTLSConfig, err := gatewayTLSConfig(tlsCfg)
+		if err != nil {
+			return fmt.Errorf("failed to load gateway TLS config: %w", err)
+		}
+		gwServer.TLSConfig = gwTLSConfig
 	}
 
 	loggingMiddleware := func(next http.Handler) http.Handler {
@@ -352,3 +444,29 @@ func startServer(ctx context.Context, cfg *config.RootConfig) error {
 
 	return errors.Join(adminSrvErr, gwSrvErr, shutdownErr)
 }
+
+// gatewayTLSConfig returns the TLS config of the gateway listener, verifying client certificates
+// against the client CA bundle. Clients without a certificate are only rejected if required, else
+// the auth methods decide.
+func gatewayTLSConfig(cfg *config.GatewayTLS) (*tls.Config, error) {
+	zerologr.Info("Loading gateway client CA bundle", "path", cfg.ClientCAFile)
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading client CA bundle %q: %w", cfg.ClientCAFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid PEM certificates found in %q", cfg.ClientCAFile)
+	}
+
+	clientAuth := tls.VerifyClientCertIfGiven
+	if cfg.RequireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: clientAuth,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
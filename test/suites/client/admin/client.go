@@ -64,6 +64,12 @@ type APIErrorResponse struct {
 	Errors []string `json:"errors"`
 }
 
+// CachePurge defines model for CachePurge.
+type CachePurge struct {
+	// Purged Number of purged cache entries.
+	Purged int `json:"purged"`
+}
+
 // DebugSession defines model for DebugSession.
 type DebugSession struct {
 	// Backend The backend that the call was made to.
@@ -126,12 +132,95 @@ type FlowMetaDataAuth struct {
 	Scheme  *FlowMetaDataAuthScheme  `json:"scheme,omitempty"`
 }
 
+// FlowMetaDataAuthMTLSIdentity defines model for FlowMetaDataAuthMTLSIdentity.
+type FlowMetaDataAuthMTLSIdentity struct {
+	// Dns The pattern matching the DNS SANs of certificates.
+	Dns *string `json:"dns,omitempty"`
+
+	// Groups The groups of the identity.
+	Groups *[]string `json:"groups,omitempty"`
+
+	// Organisation The organisation of the identity.
+	Organisation *string `json:"organisation,omitempty"`
+
+	// Subject The pattern matching the subject of certificates.
+	Subject *string `json:"subject,omitempty"`
+
+	// Uri The pattern matching the URI SANs of certificates.
+	Uri *string `json:"uri,omitempty"`
+
+	// User The user certificates are authenticated as, if not the matched SAN or subject.
+	User *string `json:"user,omitempty"`
+}
+
 // FlowMetaDataAuthMethodBasic defines model for FlowMetaDataAuthMethodBasic.
-type FlowMetaDataAuthMethodBasic = map[string]interface{}
+type FlowMetaDataAuthMethodBasic struct {
+	Quotas *[]FlowMetaDataAuthQuota `json:"quotas,omitempty"`
+}
+
+// FlowMetaDataAuthMethodHMAC defines model for FlowMetaDataAuthMethodHMAC.
+type FlowMetaDataAuthMethodHMAC struct {
+	// Header The header carrying the signature of requests.
+	Header string `json:"header"`
+
+	// MaxClockSkew The largest clock skew in milliseconds accepted for signed requests.
+	MaxClockSkew int `json:"maxClockSkew"`
+
+	// Organisations The organisations holding shared secrets, the secrets are not shown.
+	Organisations []string `json:"organisations"`
+
+	// SignedHeaders The headers signed in addition to the method, path, timestamp, nonce and body.
+	SignedHeaders *[]string `json:"signedHeaders,omitempty"`
+}
+
+// FlowMetaDataAuthMethodJWT defines model for FlowMetaDataAuthMethodJWT.
+type FlowMetaDataAuthMethodJWT struct {
+	// Algorithms The accepted signature algorithms.
+	Algorithms []string `json:"algorithms"`
+
+	// Audiences The accepted audiences of tokens.
+	Audiences []string `json:"audiences"`
+
+	// Issuer The required issuer of tokens.
+	Issuer string `json:"issuer"`
+}
+
+// FlowMetaDataAuthMethodMTLS defines model for FlowMetaDataAuthMethodMTLS.
+type FlowMetaDataAuthMethodMTLS struct {
+	// Identities The identities client certificates are mapped onto, in matching order.
+	Identities []FlowMetaDataAuthMTLSIdentity `json:"identities"`
+}
+
+// FlowMetaDataAuthMethodOIDC defines model for FlowMetaDataAuthMethodOIDC.
+type FlowMetaDataAuthMethodOIDC struct {
+	// ClientId The client ID of Kerberos at the provider.
+	ClientId string `json:"clientId"`
+
+	// Issuer The issuer of the OpenID Connect provider.
+	Issuer string `json:"issuer"`
+
+	// Scopes The scopes requested when logging in.
+	Scopes []string `json:"scopes"`
+}
 
 // FlowMetaDataAuthMethods defines model for FlowMetaDataAuthMethods.
 type FlowMetaDataAuthMethods struct {
 	Basic *FlowMetaDataAuthMethodBasic `json:"basic,omitempty"`
+	Hmac  *FlowMetaDataAuthMethodHMAC  `json:"hmac,omitempty"`
+	Jwt   *FlowMetaDataAuthMethodJWT   `json:"jwt,omitempty"`
+	Mtls  *FlowMetaDataAuthMethodMTLS  `json:"mtls,omitempty"`
+	Oidc  *FlowMetaDataAuthMethodOIDC  `json:"oidc,omitempty"`
+}
+
+// FlowMetaDataAuthQuota defines model for FlowMetaDataAuthQuota.
+type FlowMetaDataAuthQuota struct {
+	// Backend The backend whose requests are counted, absent when all backends are.
+	Backend *string `json:"backend,omitempty"`
+
+	// Organisation The organisation the quota applies to, absent for the default quota.
+	Organisation     *string `json:"organisation,omitempty"`
+	RequestsPerDay   *int64  `json:"requestsPerDay,omitempty"`
+	RequestsPerMonth *int64  `json:"requestsPerMonth,omitempty"`
 }
 
 // FlowMetaDataAuthScheme defines model for FlowMetaDataAuthScheme.
@@ -153,6 +242,99 @@ type FlowMetaDataAuthSchemeMappingAuthorization struct {
 	Paths  *map[string][]string `json:"paths,omitempty"`
 }
 
+// FlowMetaDataBodyLimit defines model for FlowMetaDataBodyLimit.
+type FlowMetaDataBodyLimit struct {
+	Mappings *[]FlowMetaDataBodyLimitMapping `json:"mappings,omitempty"`
+
+	// MaxRequestBytes Request body limit of backends without a mapping, 0 is unlimited.
+	MaxRequestBytes int64 `json:"maxRequestBytes"`
+
+	// MaxResponseBytes Response body limit of backends without a mapping, 0 is unlimited.
+	MaxResponseBytes int64 `json:"maxResponseBytes"`
+}
+
+// FlowMetaDataBodyLimitMapping defines model for FlowMetaDataBodyLimitMapping.
+type FlowMetaDataBodyLimitMapping struct {
+	Backend string `json:"backend"`
+
+	// MaxRequestBytes Effective request body limit of the backend, 0 is unlimited.
+	MaxRequestBytes int64 `json:"maxRequestBytes"`
+
+	// MaxResponseBytes Effective response body limit of the backend, 0 is unlimited.
+	MaxResponseBytes int64                        `json:"maxResponseBytes"`
+	Paths            *[]FlowMetaDataBodyLimitPath `json:"paths,omitempty"`
+}
+
+// FlowMetaDataBodyLimitPath defines model for FlowMetaDataBodyLimitPath.
+type FlowMetaDataBodyLimitPath struct {
+	// MaxRequestBytes Effective request body limit of the path, 0 is unlimited.
+	MaxRequestBytes int64 `json:"maxRequestBytes"`
+
+	// MaxResponseBytes Effective response body limit of the path, 0 is unlimited.
+	MaxResponseBytes int64  `json:"maxResponseBytes"`
+	Path             string `json:"path"`
+}
+
+// FlowMetaDataCache defines model for FlowMetaDataCache.
+type FlowMetaDataCache struct {
+	// Bytes Total size of the response bodies currently cached in memory.
+	Bytes int64 `json:"bytes"`
+
+	// Entries Number of responses currently cached in memory.
+	Entries    int                         `json:"entries"`
+	Mappings   *[]FlowMetaDataCacheMapping `json:"mappings,omitempty"`
+	MaxBytes   int64                       `json:"maxBytes"`
+	MaxEntries int                         `json:"maxEntries"`
+
+	// Persistent Whether cached responses are also kept in the database.
+	Persistent bool `json:"persistent"`
+}
+
+// FlowMetaDataCacheMapping defines model for FlowMetaDataCacheMapping.
+type FlowMetaDataCacheMapping struct {
+	Backend string `json:"backend"`
+
+	// DefaultTtl Milliseconds that responses without explicit freshness are cached, 0 does not cache them.
+	DefaultTtl int `json:"defaultTtl"`
+}
+
+// FlowMetaDataCompression defines model for FlowMetaDataCompression.
+type FlowMetaDataCompression struct {
+	ContentTypes []string `json:"contentTypes"`
+
+	// Encodings Supported content codings in order of preference.
+	Encodings []string                          `json:"encodings"`
+	Mappings  *[]FlowMetaDataCompressionMapping `json:"mappings,omitempty"`
+	MinSize   int64                             `json:"minSize"`
+}
+
+// FlowMetaDataCompressionMapping defines model for FlowMetaDataCompressionMapping.
+type FlowMetaDataCompressionMapping struct {
+	Backend      string   `json:"backend"`
+	ContentTypes []string `json:"contentTypes"`
+	MinSize      int64    `json:"minSize"`
+}
+
+// FlowMetaDataHeaders defines model for FlowMetaDataHeaders.
+type FlowMetaDataHeaders struct {
+	Mappings *[]FlowMetaDataHeadersMapping `json:"mappings,omitempty"`
+}
+
+// FlowMetaDataHeadersMapping defines model for FlowMetaDataHeadersMapping.
+type FlowMetaDataHeadersMapping struct {
+	Backend  string                    `json:"backend"`
+	Request  *FlowMetaDataHeadersRules `json:"request,omitempty"`
+	Response *FlowMetaDataHeadersRules `json:"response,omitempty"`
+}
+
+// FlowMetaDataHeadersRules defines model for FlowMetaDataHeadersRules.
+type FlowMetaDataHeadersRules struct {
+	Add    *map[string]string `json:"add,omitempty"`
+	Remove *[]string          `json:"remove,omitempty"`
+	Rename *map[string]string `json:"rename,omitempty"`
+	Set    *map[string]string `json:"set,omitempty"`
+}
+
 // FlowMetaDataOAS defines model for FlowMetaDataOAS.
 type FlowMetaDataOAS struct {
 	Backends *[]string `json:"backends,omitempty"`
@@ -163,21 +345,85 @@ type FlowMetaDataObservability struct {
 	Enabled bool `json:"enabled"`
 }
 
+// FlowMetaDataRateLimit defines model for FlowMetaDataRateLimit.
+type FlowMetaDataRateLimit struct {
+	Policies *[]FlowMetaDataRateLimitPolicy `json:"policies,omitempty"`
+}
+
+// FlowMetaDataRateLimitPolicy defines model for FlowMetaDataRateLimitPolicy.
+type FlowMetaDataRateLimitPolicy struct {
+	Backend string  `json:"backend"`
+	Burst   int     `json:"burst"`
+	Header  *string `json:"header,omitempty"`
+	Key     string  `json:"key"`
+
+	// Period Refill period in milliseconds.
+	Period   int `json:"period"`
+	Requests int `json:"requests"`
+}
+
 // FlowMetaDataRouter defines model for FlowMetaDataRouter.
 type FlowMetaDataRouter struct {
 	Backends *[]FlowMetaDataRouterBackend `json:"backends,omitempty"`
+
+	// Routes Configured routes, in order of precedence.
+	Routes *[]FlowMetaDataRouterRoute `json:"routes,omitempty"`
 }
 
 // FlowMetaDataRouterBackend defines model for FlowMetaDataRouterBackend.
 type FlowMetaDataRouterBackend struct {
-	Host string `json:"host"`
-	Name string `json:"name"`
-	Port int    `json:"port"`
+	// Discovery Target discovery of a backend, its targets are the ones resolved by the last successful refresh.
+	Discovery *FlowMetaDataRouterDiscovery `json:"discovery,omitempty"`
+	Host      string                       `json:"host"`
+
+	// LoadBalancing The load balancing strategy used to pick among the targets.
+	LoadBalancing *string                     `json:"loadBalancing,omitempty"`
+	Name          string                      `json:"name"`
+	Port          int                         `json:"port"`
+	Targets       *[]FlowMetaDataRouterTarget `json:"targets,omitempty"`
+}
+
+// FlowMetaDataRouterDiscovery Target discovery of a backend, its targets are the ones resolved by the last successful refresh.
+type FlowMetaDataRouterDiscovery struct {
+	// Error The error of the last refresh, absent when it succeeded.
+	Error *string `json:"error,omitempty"`
+
+	// Interval Milliseconds between refreshes.
+	Interval int `json:"interval"`
+
+	// LastRefresh When the targets were last refreshed successfully, absent before the first.
+	LastRefresh *time.Time `json:"lastRefresh,omitempty"`
+
+	// Source The resolved DNS name or the path of the target file.
+	Source string `json:"source"`
+
+	// Type How the targets are discovered, dns or file.
+	Type string `json:"type"`
+}
+
+// FlowMetaDataRouterRoute defines model for FlowMetaDataRouterRoute.
+type FlowMetaDataRouterRoute struct {
+	Backend    string    `json:"backend"`
+	Host       *string   `json:"host,omitempty"`
+	Methods    *[]string `json:"methods,omitempty"`
+	PathPrefix *string   `json:"pathPrefix,omitempty"`
+}
+
+// FlowMetaDataRouterTarget defines model for FlowMetaDataRouterTarget.
+type FlowMetaDataRouterTarget struct {
+	// Healthy Whether the target currently receives requests, omitted when health is not tracked.
+	Healthy *bool  `json:"healthy,omitempty"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
 }
 
 // FlowTransition A flow transition that occurred during a debug session call. A flow transition represents
 // a flow component's handling of a request, in both directions.
 type FlowTransition struct {
+	// Attributes Details of the flow transition recorded by the flow component, such as the upstream
+	// target chosen by the forwarder.
+	Attributes *map[string]string `json:"attributes,omitempty"`
+
 	// Component The flow component that the transition corresponds to.
 	Component string `json:"component"`
 
@@ -233,6 +479,28 @@ type Permission struct {
 	Name string `json:"name"`
 }
 
+// TrafficSplit Routes the requests of a backend to its variants. Requests matching the headers and cookies of a variant are routed to it, a weighted share of the remaining requests is routed to each variant and the rest stay on the backend.
+type TrafficSplit struct {
+	// Backend Name of the backend whose requests are split.
+	Backend  string                `json:"backend"`
+	Variants []TrafficSplitVariant `json:"variants"`
+}
+
+// TrafficSplitVariant defines model for TrafficSplitVariant.
+type TrafficSplitVariant struct {
+	// Backend Name of the backend requests are routed to.
+	Backend string `json:"backend"`
+
+	// Cookies Requests with all of these cookie values are routed to the variant.
+	Cookies *map[string]string `json:"cookies,omitempty"`
+
+	// Headers Requests with all of these header values are routed to the variant.
+	Headers *map[string]string `json:"headers,omitempty"`
+
+	// Weight Percentage of the requests not matched by any variant that are routed to the variant. The weights of a split may not exceed 100 in total.
+	Weight float64 `json:"weight"`
+}
+
 // User defines model for User.
 type User struct {
 	Groups   *[]Group `json:"groups,omitempty"`
@@ -264,6 +532,11 @@ type LoginUserRequest struct {
 	Username string `json:"username"`
 }
 
+// SetTrafficSplitRequest defines model for SetTrafficSplitRequest.
+type SetTrafficSplitRequest struct {
+	Variants []TrafficSplitVariant `json:"variants"`
+}
+
 // StartDebugSessionRequest defines model for StartDebugSessionRequest.
 type StartDebugSessionRequest struct {
 	// DurationSeconds Duration in seconds to keep the backend in debug mode. If not provided, the backend will be kept in debug mode until debug is disabled, or for a maximum of 1 hour. Minimum is 1 minute, defaults to 5 minutes.
@@ -286,6 +559,12 @@ type UpdateUserRequest struct {
 	Username string `json:"username"`
 }
 
+// PurgeCacheParams defines parameters for PurgeCache.
+type PurgeCacheParams struct {
+	Backend    *string `form:"backend,omitempty" json:"backend,omitempty"`
+	PathPrefix *string `form:"pathPrefix,omitempty" json:"pathPrefix,omitempty"`
+}
+
 // StartDebugSessionJSONBody defines parameters for StartDebugSession.
 type StartDebugSessionJSONBody struct {
 	// DurationSeconds Duration in seconds to keep the backend in debug mode. If not provided, the backend will be kept in debug mode until debug is disabled, or for a maximum of 1 hour. Minimum is 1 minute, defaults to 5 minutes.
@@ -321,6 +600,11 @@ type LoginJSONBody struct {
 	Username string `json:"username"`
 }
 
+// SetTrafficSplitJSONBody defines parameters for SetTrafficSplit.
+type SetTrafficSplitJSONBody struct {
+	Variants []TrafficSplitVariant `json:"variants"`
+}
+
 // LoginSuperuserJSONBody defines parameters for LoginSuperuser.
 type LoginSuperuserJSONBody struct {
 	ClientId     string `json:"clientId"`
@@ -370,6 +654,9 @@ type UpdateGroupJSONRequestBody UpdateGroupJSONBody
 // LoginJSONRequestBody defines body for Login for application/json ContentType.
 type LoginJSONRequestBody LoginJSONBody
 
+// SetTrafficSplitJSONRequestBody defines body for SetTrafficSplit for application/json ContentType.
+type SetTrafficSplitJSONRequestBody SetTrafficSplitJSONBody
+
 // LoginSuperuserJSONRequestBody defines body for LoginSuperuser for application/json ContentType.
 type LoginSuperuserJSONRequestBody LoginSuperuserJSONBody
 
@@ -492,6 +779,136 @@ func (t *FlowMeta_Data) MergeFlowMetaDataOAS(v FlowMetaDataOAS) error {
 	return err
 }
 
+// AsFlowMetaDataHeaders returns the union data inside the FlowMeta_Data as a FlowMetaDataHeaders
+func (t FlowMeta_Data) AsFlowMetaDataHeaders() (FlowMetaDataHeaders, error) {
+	var body FlowMetaDataHeaders
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataHeaders overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataHeaders
+func (t *FlowMeta_Data) FromFlowMetaDataHeaders(v FlowMetaDataHeaders) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataHeaders performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataHeaders
+func (t *FlowMeta_Data) MergeFlowMetaDataHeaders(v FlowMetaDataHeaders) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsFlowMetaDataRateLimit returns the union data inside the FlowMeta_Data as a FlowMetaDataRateLimit
+func (t FlowMeta_Data) AsFlowMetaDataRateLimit() (FlowMetaDataRateLimit, error) {
+	var body FlowMetaDataRateLimit
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataRateLimit overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataRateLimit
+func (t *FlowMeta_Data) FromFlowMetaDataRateLimit(v FlowMetaDataRateLimit) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataRateLimit performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataRateLimit
+func (t *FlowMeta_Data) MergeFlowMetaDataRateLimit(v FlowMetaDataRateLimit) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsFlowMetaDataBodyLimit returns the union data inside the FlowMeta_Data as a FlowMetaDataBodyLimit
+func (t FlowMeta_Data) AsFlowMetaDataBodyLimit() (FlowMetaDataBodyLimit, error) {
+	var body FlowMetaDataBodyLimit
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataBodyLimit overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataBodyLimit
+func (t *FlowMeta_Data) FromFlowMetaDataBodyLimit(v FlowMetaDataBodyLimit) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataBodyLimit performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataBodyLimit
+func (t *FlowMeta_Data) MergeFlowMetaDataBodyLimit(v FlowMetaDataBodyLimit) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsFlowMetaDataCache returns the union data inside the FlowMeta_Data as a FlowMetaDataCache
+func (t FlowMeta_Data) AsFlowMetaDataCache() (FlowMetaDataCache, error) {
+	var body FlowMetaDataCache
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataCache overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataCache
+func (t *FlowMeta_Data) FromFlowMetaDataCache(v FlowMetaDataCache) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataCache performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataCache
+func (t *FlowMeta_Data) MergeFlowMetaDataCache(v FlowMetaDataCache) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsFlowMetaDataCompression returns the union data inside the FlowMeta_Data as a FlowMetaDataCompression
+func (t FlowMeta_Data) AsFlowMetaDataCompression() (FlowMetaDataCompression, error) {
+	var body FlowMetaDataCompression
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromFlowMetaDataCompression overwrites any union data inside the FlowMeta_Data as the provided FlowMetaDataCompression
+func (t *FlowMeta_Data) FromFlowMetaDataCompression(v FlowMetaDataCompression) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeFlowMetaDataCompression performs a merge with any union data inside the FlowMeta_Data, using the provided FlowMetaDataCompression
+func (t *FlowMeta_Data) MergeFlowMetaDataCompression(v FlowMetaDataCompression) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
 // AsNoFlowMetaData returns the union data inside the FlowMeta_Data as a NoFlowMetaData
 func (t FlowMeta_Data) AsNoFlowMetaData() (NoFlowMetaData, error) {
 	var body NoFlowMetaData
@@ -601,6 +1018,9 @@ func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
 
 // The interface specification for the client above.
 type ClientInterface interface {
+	// PurgeCache request
+	PurgeCache(ctx context.Context, params *PurgeCacheParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// ListDebugSessions request
 	ListDebugSessions(ctx context.Context, backend string, reqEditors ...RequestEditorFn) (*http.Response, error)
 
@@ -671,6 +1091,17 @@ type ClientInterface interface {
 	// RefreshUserSession request
 	RefreshUserSession(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// ListTrafficSplits request
+	ListTrafficSplits(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// DeleteTrafficSplit request
+	DeleteTrafficSplit(ctx context.Context, backend string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// SetTrafficSplitWithBody request with any body
+	SetTrafficSplitWithBody(ctx context.Context, backend string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	SetTrafficSplit(ctx context.Context, backend string, body SetTrafficSplitJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// LoginSuperuserWithBody request with any body
 	LoginSuperuserWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
 
@@ -717,6 +1148,18 @@ type ClientInterface interface {
 	ChangeUserPassword(ctx context.Context, userID int, body ChangeUserPasswordJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
 
+func (c *Client) PurgeCache(ctx context.Context, params *PurgeCacheParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPurgeCacheRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) ListDebugSessions(ctx context.Context, backend string, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewListDebugSessionsRequest(c.Server, backend)
 	if err != nil {
@@ -1017,6 +1460,54 @@ func (c *Client) RefreshUserSession(ctx context.Context, reqEditors ...RequestEd
 	return c.Client.Do(req)
 }
 
+func (c *Client) ListTrafficSplits(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListTrafficSplitsRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) DeleteTrafficSplit(ctx context.Context, backend string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDeleteTrafficSplitRequest(c.Server, backend)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) SetTrafficSplitWithBody(ctx context.Context, backend string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewSetTrafficSplitRequestWithBody(c.Server, backend, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) SetTrafficSplit(ctx context.Context, backend string, body SetTrafficSplitJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewSetTrafficSplitRequest(c.Server, backend, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) LoginSuperuserWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewLoginSuperuserRequestWithBody(c.Server, contentType, body)
 	if err != nil {
@@ -1221,6 +1712,71 @@ func (c *Client) ChangeUserPassword(ctx context.Context, userID int, body Change
 	return c.Client.Do(req)
 }
 
+// NewPurgeCacheRequest generates requests for PurgeCache
+func NewPurgeCacheRequest(server string, params *PurgeCacheParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/admin/cache")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Backend != nil {
+
+			if queryFrag, err := runtime.StyleParamWithOptions("form", true, "backend", *params.Backend, runtime.StyleParamOptions{ParamLocation: runtime.ParamLocationQuery, Type: "string", Format: ""}); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.PathPrefix != nil {
+
+			if queryFrag, err := runtime.StyleParamWithOptions("form", true, "pathPrefix", *params.PathPrefix, runtime.StyleParamOptions{ParamLocation: runtime.ParamLocationQuery, Type: "string", Format: ""}); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 // NewListDebugSessionsRequest generates requests for ListDebugSessions
 func NewListDebugSessionsRequest(server string, backend string) (*http.Request, error) {
 	var err error
@@ -1977,6 +2533,114 @@ func NewRefreshUserSessionRequest(server string) (*http.Request, error) {
 	return req, nil
 }
 
+// NewListTrafficSplitsRequest generates requests for ListTrafficSplits
+func NewListTrafficSplitsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/admin/splits")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewDeleteTrafficSplitRequest generates requests for DeleteTrafficSplit
+func NewDeleteTrafficSplitRequest(server string, backend string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithOptions("simple", false, "backend", backend, runtime.StyleParamOptions{ParamLocation: runtime.ParamLocationPath, Type: "string", Format: ""})
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/admin/splits/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewSetTrafficSplitRequest calls the generic SetTrafficSplit builder with application/json body
+func NewSetTrafficSplitRequest(server string, backend string, body SetTrafficSplitJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewSetTrafficSplitRequestWithBody(server, backend, "application/json", bodyReader)
+}
+
+// NewSetTrafficSplitRequestWithBody generates requests for SetTrafficSplit with any type of body
+func NewSetTrafficSplitRequestWithBody(server string, backend string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithOptions("simple", false, "backend", backend, runtime.StyleParamOptions{ParamLocation: runtime.ParamLocationPath, Type: "string", Format: ""})
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/admin/splits/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
 // NewLoginSuperuserRequest calls the generic LoginSuperuser builder with application/json body
 func NewLoginSuperuserRequest(server string, body LoginSuperuserJSONRequestBody) (*http.Request, error) {
 	var bodyReader io.Reader
@@ -2430,6 +3094,9 @@ func WithBaseURL(baseURL string) ClientOption {
 
 // ClientWithResponsesInterface is the interface specification for the client with responses above.
 type ClientWithResponsesInterface interface {
+	// PurgeCacheWithResponse request
+	PurgeCacheWithResponse(ctx context.Context, params *PurgeCacheParams, reqEditors ...RequestEditorFn) (*PurgeCacheResponse, error)
+
 	// ListDebugSessionsWithResponse request
 	ListDebugSessionsWithResponse(ctx context.Context, backend string, reqEditors ...RequestEditorFn) (*ListDebugSessionsResponse, error)
 
@@ -2500,6 +3167,17 @@ type ClientWithResponsesInterface interface {
 	// RefreshUserSessionWithResponse request
 	RefreshUserSessionWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*RefreshUserSessionResponse, error)
 
+	// ListTrafficSplitsWithResponse request
+	ListTrafficSplitsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListTrafficSplitsResponse, error)
+
+	// DeleteTrafficSplitWithResponse request
+	DeleteTrafficSplitWithResponse(ctx context.Context, backend string, reqEditors ...RequestEditorFn) (*DeleteTrafficSplitResponse, error)
+
+	// SetTrafficSplitWithBodyWithResponse request with any body
+	SetTrafficSplitWithBodyWithResponse(ctx context.Context, backend string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*SetTrafficSplitResponse, error)
+
+	SetTrafficSplitWithResponse(ctx context.Context, backend string, body SetTrafficSplitJSONRequestBody, reqEditors ...RequestEditorFn) (*SetTrafficSplitResponse, error)
+
 	// LoginSuperuserWithBodyWithResponse request with any body
 	LoginSuperuserWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*LoginSuperuserResponse, error)
 
@@ -2546,6 +3224,31 @@ type ClientWithResponsesInterface interface {
 	ChangeUserPasswordWithResponse(ctx context.Context, userID int, body ChangeUserPasswordJSONRequestBody, reqEditors ...RequestEditorFn) (*ChangeUserPasswordResponse, error)
 }
 
+type PurgeCacheResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *CachePurge
+	JSON401      *APIErrorResponse
+	JSON403      *APIErrorResponse
+	JSON500      *APIErrorResponse
+}
+
+// Status returns HTTPResponse.Status
+func (r PurgeCacheResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PurgeCacheResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 type ListDebugSessionsResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
@@ -2910,23 +3613,96 @@ func (r UpdateGroupResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r UpdateGroupResponse) StatusCode() int {
+func (r UpdateGroupResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type LoginResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON400      *APIErrorResponse
+	JSON401      *APIErrorResponse
+	JSON500      *APIErrorResponse
+}
+
+// Status returns HTTPResponse.Status
+func (r LoginResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r LoginResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type LogoutResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON401      *APIErrorResponse
+	JSON500      *APIErrorResponse
+}
+
+// Status returns HTTPResponse.Status
+func (r LogoutResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r LogoutResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetMeResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *MeResponse
+	JSON401      *APIErrorResponse
+	JSON500      *APIErrorResponse
+}
+
+// Status returns HTTPResponse.Status
+func (r GetMeResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetMeResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type LoginResponse struct {
+type GetBackendOASResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
-	JSON400      *APIErrorResponse
+	YAML200      *map[string]interface{}
 	JSON401      *APIErrorResponse
+	JSON403      *APIErrorResponse
+	JSON404      *APIErrorResponse
 	JSON500      *APIErrorResponse
 }
 
 // Status returns HTTPResponse.Status
-func (r LoginResponse) Status() string {
+func (r GetBackendOASResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -2934,22 +3710,23 @@ func (r LoginResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r LoginResponse) StatusCode() int {
+func (r GetBackendOASResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type LogoutResponse struct {
+type GetPermissionsResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
+	JSON200      *[]Permission
 	JSON401      *APIErrorResponse
 	JSON500      *APIErrorResponse
 }
 
 // Status returns HTTPResponse.Status
-func (r LogoutResponse) Status() string {
+func (r GetPermissionsResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -2957,23 +3734,22 @@ func (r LogoutResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r LogoutResponse) StatusCode() int {
+func (r GetPermissionsResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type GetMeResponse struct {
+type RefreshUserSessionResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
-	JSON200      *MeResponse
 	JSON401      *APIErrorResponse
 	JSON500      *APIErrorResponse
 }
 
 // Status returns HTTPResponse.Status
-func (r GetMeResponse) Status() string {
+func (r RefreshUserSessionResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -2981,25 +3757,24 @@ func (r GetMeResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r GetMeResponse) StatusCode() int {
+func (r RefreshUserSessionResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type GetBackendOASResponse struct {
+type ListTrafficSplitsResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
-	YAML200      *map[string]interface{}
+	JSON200      *[]TrafficSplit
 	JSON401      *APIErrorResponse
 	JSON403      *APIErrorResponse
-	JSON404      *APIErrorResponse
 	JSON500      *APIErrorResponse
 }
 
 // Status returns HTTPResponse.Status
-func (r GetBackendOASResponse) Status() string {
+func (r ListTrafficSplitsResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -3007,23 +3782,24 @@ func (r GetBackendOASResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r GetBackendOASResponse) StatusCode() int {
+func (r ListTrafficSplitsResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type GetPermissionsResponse struct {
+type DeleteTrafficSplitResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
-	JSON200      *[]Permission
 	JSON401      *APIErrorResponse
+	JSON403      *APIErrorResponse
+	JSON404      *APIErrorResponse
 	JSON500      *APIErrorResponse
 }
 
 // Status returns HTTPResponse.Status
-func (r GetPermissionsResponse) Status() string {
+func (r DeleteTrafficSplitResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -3031,22 +3807,26 @@ func (r GetPermissionsResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r GetPermissionsResponse) StatusCode() int {
+func (r DeleteTrafficSplitResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
 	return 0
 }
 
-type RefreshUserSessionResponse struct {
+type SetTrafficSplitResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
+	JSON200      *TrafficSplit
+	JSON400      *APIErrorResponse
 	JSON401      *APIErrorResponse
+	JSON403      *APIErrorResponse
+	JSON404      *APIErrorResponse
 	JSON500      *APIErrorResponse
 }
 
 // Status returns HTTPResponse.Status
-func (r RefreshUserSessionResponse) Status() string {
+func (r SetTrafficSplitResponse) Status() string {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.Status
 	}
@@ -3054,7 +3834,7 @@ func (r RefreshUserSessionResponse) Status() string {
 }
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r RefreshUserSessionResponse) StatusCode() int {
+func (r SetTrafficSplitResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
@@ -3342,6 +4122,15 @@ func (r ChangeUserPasswordResponse) StatusCode() int {
 	return 0
 }
 
+// PurgeCacheWithResponse request returning *PurgeCacheResponse
+func (c *ClientWithResponses) PurgeCacheWithResponse(ctx context.Context, params *PurgeCacheParams, reqEditors ...RequestEditorFn) (*PurgeCacheResponse, error) {
+	rsp, err := c.PurgeCache(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePurgeCacheResponse(rsp)
+}
+
 // ListDebugSessionsWithResponse request returning *ListDebugSessionsResponse
 func (c *ClientWithResponses) ListDebugSessionsWithResponse(ctx context.Context, backend string, reqEditors ...RequestEditorFn) (*ListDebugSessionsResponse, error) {
 	rsp, err := c.ListDebugSessions(ctx, backend, reqEditors...)
@@ -3562,6 +4351,41 @@ func (c *ClientWithResponses) RefreshUserSessionWithResponse(ctx context.Context
 	return ParseRefreshUserSessionResponse(rsp)
 }
 
+// ListTrafficSplitsWithResponse request returning *ListTrafficSplitsResponse
+func (c *ClientWithResponses) ListTrafficSplitsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListTrafficSplitsResponse, error) {
+	rsp, err := c.ListTrafficSplits(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListTrafficSplitsResponse(rsp)
+}
+
+// DeleteTrafficSplitWithResponse request returning *DeleteTrafficSplitResponse
+func (c *ClientWithResponses) DeleteTrafficSplitWithResponse(ctx context.Context, backend string, reqEditors ...RequestEditorFn) (*DeleteTrafficSplitResponse, error) {
+	rsp, err := c.DeleteTrafficSplit(ctx, backend, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeleteTrafficSplitResponse(rsp)
+}
+
+// SetTrafficSplitWithBodyWithResponse request with arbitrary body returning *SetTrafficSplitResponse
+func (c *ClientWithResponses) SetTrafficSplitWithBodyWithResponse(ctx context.Context, backend string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*SetTrafficSplitResponse, error) {
+	rsp, err := c.SetTrafficSplitWithBody(ctx, backend, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSetTrafficSplitResponse(rsp)
+}
+
+func (c *ClientWithResponses) SetTrafficSplitWithResponse(ctx context.Context, backend string, body SetTrafficSplitJSONRequestBody, reqEditors ...RequestEditorFn) (*SetTrafficSplitResponse, error) {
+	rsp, err := c.SetTrafficSplit(ctx, backend, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSetTrafficSplitResponse(rsp)
+}
+
 // LoginSuperuserWithBodyWithResponse request with arbitrary body returning *LoginSuperuserResponse
 func (c *ClientWithResponses) LoginSuperuserWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*LoginSuperuserResponse, error) {
 	rsp, err := c.LoginSuperuserWithBody(ctx, contentType, body, reqEditors...)
@@ -3709,6 +4533,53 @@ func (c *ClientWithResponses) ChangeUserPasswordWithResponse(ctx context.Context
 	return ParseChangeUserPasswordResponse(rsp)
 }
 
+// ParsePurgeCacheResponse parses an HTTP response from a PurgeCacheWithResponse call
+func ParsePurgeCacheResponse(rsp *http.Response) (*PurgeCacheResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PurgeCacheResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest CachePurge
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseListDebugSessionsResponse parses an HTTP response from a ListDebugSessionsWithResponse call
 func ParseListDebugSessionsResponse(rsp *http.Response) (*ListDebugSessionsResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -4754,6 +5625,161 @@ func ParseRefreshUserSessionResponse(rsp *http.Response) (*RefreshUserSessionRes
 	return response, nil
 }
 
+// ParseListTrafficSplitsResponse parses an HTTP response from a ListTrafficSplitsWithResponse call
+func ParseListTrafficSplitsResponse(rsp *http.Response) (*ListTrafficSplitsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListTrafficSplitsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []TrafficSplit
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseDeleteTrafficSplitResponse parses an HTTP response from a DeleteTrafficSplitWithResponse call
+func ParseDeleteTrafficSplitResponse(rsp *http.Response) (*DeleteTrafficSplitResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DeleteTrafficSplitResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseSetTrafficSplitResponse parses an HTTP response from a SetTrafficSplitWithResponse call
+func ParseSetTrafficSplitResponse(rsp *http.Response) (*SetTrafficSplitResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SetTrafficSplitResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest TrafficSplit
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest APIErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseLoginSuperuserResponse parses an HTTP response from a LoginSuperuserWithResponse call
 func ParseLoginSuperuserResponse(rsp *http.Response) (*LoginSuperuserResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -17,6 +17,8 @@ const (
 	PermissionIDAdminUserMgmtAdmin  = 5
 	PermissionIDAdminUserMgmtViewer = 6
 	PermissionIDDebugger            = 7
+	PermissionIDCacheAdmin          = 8
+	PermissionIDTrafficAdmin        = 9
 
 	// Permission names.
 
@@ -27,6 +29,8 @@ const (
 	PermissionNameAdminUserMgmtAdmin  = "admin-user-mgmt-admin"
 	PermissionNameAdminUserMgmtViewer = "admin-user-mgmt-viewer"
 	PermissionNameDebugger            = "debugger"
+	PermissionNameCacheAdmin          = "cache-admin"
+	PermissionNameTrafficAdmin        = "traffic-admin"
 )
 
 // --- GetPermissions ---
@@ -62,6 +66,8 @@ func TestPermissionsGetPermissions(t *testing.T) {
 		PermissionIDAdminUserMgmtViewer: PermissionNameAdminUserMgmtViewer,
 		PermissionIDAdminUserMgmtAdmin:  PermissionNameAdminUserMgmtAdmin,
 		PermissionIDDebugger:            PermissionNameDebugger,
+		PermissionIDCacheAdmin:          PermissionNameCacheAdmin,
+		PermissionIDTrafficAdmin:        PermissionNameTrafficAdmin,
 	}
 	for id, name := range expected {
 		if nameByID[id] != name {